@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -14,54 +15,199 @@ import (
 	"github.com/dgallion1/docgest/internal/extract"
 	"github.com/dgallion1/docgest/internal/pathstore"
 	"github.com/dgallion1/docgest/internal/pipeline"
+	"github.com/dgallion1/docgest/internal/storage"
 )
 
 func main() {
-	log := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-
 	cfg := config.Load()
+	log, logLevel := newLogger(cfg)
 	if err := cfg.Validate(); err != nil {
 		log.Error("invalid configuration", "error", err)
 		os.Exit(1)
 	}
 
+	categories, err := extract.DefaultCategories().WithMergeModes(cfg.CategoryMergeModes)
+	if err != nil {
+		log.Error("invalid CATEGORY_MERGE_MODES", "error", err)
+		os.Exit(1)
+	}
+	categories, err = categories.WithSalience(cfg.CategorySalience())
+	if err != nil {
+		log.Error("invalid SALIENCE_* setting", "error", err)
+		os.Exit(1)
+	}
+	categories, err = categories.WithPathTemplates(cfg.CategoryPathTemplates())
+	if err != nil {
+		log.Error("invalid CATEGORY_PATH_* setting", "error", err)
+		os.Exit(1)
+	}
+
+	if cfg.ValidationRulesFile != "" {
+		rules, err := extract.LoadValidationRules(cfg.ValidationRulesFile)
+		if err != nil {
+			log.Error("failed to load validation rules", "error", err)
+			os.Exit(1)
+		}
+		validator, err := extract.NewValidator(rules)
+		if err != nil {
+			log.Error("invalid validation rules", "error", err)
+			os.Exit(1)
+		}
+		// A category the rules accept but storage does not know would pass
+		// validation and then fail every store.
+		if err := categories.Require(rules.Categories); err != nil {
+			log.Error("invalid validation rules", "error", err)
+			os.Exit(1)
+		}
+		extract.SetDefaultValidator(validator)
+		log.Info("loaded validation rules", "file", cfg.ValidationRulesFile)
+	}
+
+	prompts := extract.PromptSet{A: extract.DefaultPrompt()}
+	if cfg.PromptVersion != "" {
+		prompt, err := extract.LoadPrompt(cfg.PromptDir, cfg.PromptVersion)
+		if err != nil {
+			log.Warn("failed to load prompt, using embedded default", "version", cfg.PromptVersion, "error", err)
+		} else {
+			prompts.A = prompt
+		}
+		if cfg.PromptABTestRatio > 0 {
+			prompts.B, err = extract.LoadPrompt(cfg.PromptDir, cfg.PromptVersion+"_b")
+			if err != nil {
+				log.Error("failed to load A/B test prompt", "error", err)
+				os.Exit(1)
+			}
+			prompts.BRatio = cfg.PromptABTestRatio
+		}
+		log.Info("loaded extraction prompts", "a", prompts.A.Version, "b", prompts.B.Version, "b_ratio", prompts.BRatio)
+	}
+	if cfg.PromptDomainContext != "" {
+		prompts = prompts.WithDomainContext(cfg.PromptDomainContext)
+		log.Info("extraction prompt domain context set", "chars", len(cfg.PromptDomainContext))
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Initialize clients.
-	ps := pathstore.NewClient(cfg.PathstoreURL, cfg.PathstoreAPIKey)
+	var ps pathstore.Store
+	var closeStore func()
+	switch cfg.StorageBackend {
+	case "redis":
+		rb, err := storage.NewRedisBackend(cfg.RedisURL, cfg.RedisKeyPrefix)
+		if err != nil {
+			log.Error("invalid REDIS_URL", "error", err)
+			os.Exit(1)
+		}
+		if err := rb.Ping(ctx); err != nil {
+			log.Error("redis unreachable", "error", err)
+			os.Exit(1)
+		}
+		// Listing reads the key index, so keys stored before it existed
+		// must be added once.
+		n, err := rb.Reindex(ctx)
+		if err != nil {
+			log.Error("redis key index rebuild failed", "error", err)
+			os.Exit(1)
+		}
+		log.Info("redis key index rebuilt", "keys", n)
+		ps = storage.NewStore(rb)
+		closeStore = func() { rb.Close() }
+		log.Info("using redis storage backend", "prefix", cfg.RedisKeyPrefix)
+	default:
+		client := pathstore.NewClientWithOptions(cfg.PathstoreURL, cfg.PathstoreAPIKey, pathstore.Options{
+			MaxRetries:       cfg.MaxStoreRetries,
+			RetryBackoffBase: cfg.StoreRetryBackoffBase,
+			PutNodeTimeout:   cfg.PathstorePutTimeout,
+			ReadTimeout:      cfg.PathstoreReadTimeout,
+		})
+		// Workers start only once pathstore answers, so no job spends Claude
+		// tokens on facts that cannot be stored.
+		log.Info("connecting to pathstore", "url", cfg.PathstoreURL, "max_attempts", pathstoreConnectAttempts)
+		if err := client.ConnectWithRetry(ctx, pathstoreConnectAttempts); err != nil {
+			log.Error("pathstore unreachable", "url", cfg.PathstoreURL, "error", err)
+			os.Exit(1)
+		}
+		ps = client
+		closeStore = client.Close
+	}
+	if cfg.PathstoreCacheEnabled {
+		ps = pathstore.NewCachingClient(ps, cfg.PathstoreCacheTTL)
+		log.Info("store read cache enabled", "ttl", cfg.PathstoreCacheTTL)
+	}
 	claude := extract.NewClaudeClient(cfg.AnthropicAPIKey, cfg.AnthropicModel)
+	claude.MaxJSONRecoveryAttempts = cfg.MaxJSONRecoveryAttempts
+	claude.Temperature = cfg.LLMTemperature
+	claude.TopP = cfg.LLMTopP
+	claude.MaxTokens = cfg.LLMMaxTokens
+	claude.StopSequences = cfg.LLMStopSequences
+	if cfg.AuditExtractions {
+		audit, err := extract.OpenAuditLog(cfg.ExtractionAuditFile)
+		if err != nil {
+			log.Error("failed to open extraction audit log", "error", err)
+			os.Exit(1)
+		}
+		claude.Audit = audit
+		log.Info("extraction audit enabled", "file", cfg.ExtractionAuditFile)
+	}
 
 	// Initialize pipeline.
 	orch := pipeline.NewOrchestrator(cfg, claude, ps, log)
+	orch.SetCategories(categories)
+	orch.SetPrompts(prompts)
+	// Workers log every failed job; alert once per model so a deprecated
+	// model stands out from the per-job noise.
+	var deprecatedMu sync.Mutex
+	deprecatedModels := make(map[string]bool)
+	orch.SetOnModelDeprecated(func(model string) {
+		deprecatedMu.Lock()
+		defer deprecatedMu.Unlock()
+		if deprecatedModels[model] {
+			return
+		}
+		deprecatedModels[model] = true
+		log.Error("ALERT: extraction model deprecated; jobs using it will fail until the model is changed", "model", model)
+	})
 	orch.Start(ctx)
 
 	// Initialize HTTP server.
 	srv := api.NewServer(orch, claude, log, cfg)
+	srv.SetLogLevel(logLevel)
 
 	httpServer := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      srv,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 120 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:           ":" + cfg.Port,
+		Handler:        srv,
+		ReadTimeout:    time.Duration(cfg.HTTPReadTimeoutSecs) * time.Second,
+		WriteTimeout:   time.Duration(cfg.HTTPWriteTimeoutSecs) * time.Second,
+		IdleTimeout:    time.Duration(cfg.HTTPIdleTimeoutSecs) * time.Second,
+		MaxHeaderBytes: cfg.HTTPMaxHeaderBytes,
 	}
 
-	// Graceful shutdown.
+	// Graceful shutdown. ListenAndServe returns as soon as Shutdown starts,
+	// so main waits on shutdownDone for the rest.
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 		<-sigCh
 		log.Info("shutting down...")
 
-		orch.Stop()
-
+		// Stop taking requests before the orchestrator, so no handler is
+		// mid-Submit when the pipeline shuts down.
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer shutdownCancel()
 		httpServer.Shutdown(shutdownCtx)
 
+		orch.Stop()
+		stats := orch.FinalStats()
+		log.Info("shutdown summary", slog.Any("stats", stats))
+
 		claude.Close()
-		ps.Close()
+		if claude.Audit != nil {
+			claude.Audit.Close()
+		}
+		closeStore()
 	}()
 
 	log.Info("starting docgest", "port", cfg.Port)
@@ -69,4 +215,25 @@ func main() {
 		log.Error("server error", "error", err)
 		os.Exit(1)
 	}
+	<-shutdownDone
+}
+
+// pathstoreConnectAttempts is how many times startup checks pathstore
+// before giving up. With ConnectWithRetry's doubling waits this rides out
+// roughly a minute of pathstore downtime, e.g. a rolling restart.
+const pathstoreConnectAttempts = 7
+
+// newLogger builds the process logger from LOG_FORMAT and LOG_LEVEL. The
+// returned LevelVar changes the level at runtime. Unknown values fall back
+// to JSON at info so Validate's error can still be logged.
+func newLogger(cfg config.Config) (*slog.Logger, *slog.LevelVar) {
+	level := new(slog.LevelVar)
+	if l, err := cfg.SlogLevel(); err == nil {
+		level.Set(l)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.LogFormat == "text" {
+		return slog.New(slog.NewTextHandler(os.Stdout, opts)), level
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts)), level
 }
@@ -6,8 +6,14 @@ require (
 	github.com/fumiama/go-docx v0.0.0-20250506085032-0c30fd09304b
 	github.com/go-chi/chi/v5 v5.2.5
 	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
+	github.com/redis/go-redis/v9 v9.7.3
 	github.com/yuin/goldmark v1.7.12
 	golang.org/x/net v0.49.0
+	golang.org/x/text v0.33.0
 )
 
-require github.com/fumiama/imgsz v0.0.2 // indirect
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/fumiama/imgsz v0.0.2 // indirect
+)
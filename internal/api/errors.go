@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Machine-readable error codes returned in the "code" field of every error
+// response. Clients should switch on these rather than the message text.
+const (
+	ErrCodeInvalidRequest  = "invalid_request"
+	ErrCodeMissingUserID   = "missing_user_id"
+	ErrCodeMissingFile     = "missing_file"
+	ErrCodeFileTooLarge    = "file_too_large"
+	ErrCodeUnsupportedType = "unsupported_type"
+	ErrCodeQueueFull       = "queue_full"
+	ErrCodeNotFound        = "not_found"
+	ErrCodeConflict        = "conflict"
+	ErrCodeGone            = "gone"
+	ErrCodeJobExpired      = "job_expired"
+	ErrCodeRateLimited     = "rate_limited"
+	ErrCodeUnavailable     = "unavailable"
+	ErrCodeUnauthorized    = "unauthorized"
+	ErrCodeStorage         = "storage_error"
+	ErrCodeInternal        = "internal_error"
+)
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// jsonErrorWithCode writes an ErrorResponse with the given HTTP status.
+func jsonErrorWithCode(w http.ResponseWriter, code, msg string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: msg, Code: code})
+}
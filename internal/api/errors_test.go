@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONErrorWithCode(t *testing.T) {
+	rec := httptest.NewRecorder()
+	jsonErrorWithCode(rec, ErrCodeFileTooLarge, "file exceeds max size", http.StatusRequestEntityTooLarge)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %d, got %d", http.StatusRequestEntityTooLarge, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON content type, got %q", ct)
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Code != ErrCodeFileTooLarge || body.Error != "file exceeds max size" {
+		t.Errorf("unexpected body: %+v", body)
+	}
+}
+
+func TestAuthMiddleware_ErrorCode(t *testing.T) {
+	h := AuthMiddleware("secret", slog.New(slog.NewTextHandler(io.Discard, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body ErrorResponse
+	json.NewDecoder(rec.Body).Decode(&body)
+	if rec.Code != http.StatusUnauthorized || body.Code != ErrCodeUnauthorized {
+		t.Errorf("expected 401 %q, got %d %q", ErrCodeUnauthorized, rec.Code, body.Code)
+	}
+}
@@ -4,25 +4,52 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dgallion1/docgest/internal/pathstore"
+	"github.com/dgallion1/docgest/internal/pipeline"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
-// handleListDocuments lists all documents for a user.
+// handleListDocuments lists all documents for a user. A tag filter is
+// applied to the user's full listing before paging, so each page holds up
+// to limit matches; its cursor is an offset into the matches and is only
+// valid with the same tag.
 func (s *Server) handleListDocuments(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		jsonError(w, "user_id query parameter is required", http.StatusBadRequest)
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id query parameter is required", http.StatusBadRequest)
 		return
 	}
 
+	limit := 200
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	cursor := r.URL.Query().Get("cursor")
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
+	tagFilter := r.URL.Query().Get("tag")
+
 	prefix := fmt.Sprintf("memory/users/%s/documents", userID)
-	children, err := s.orchestrator.PathstoreClient().ListChildren(r.Context(), prefix, 200)
+	var children []pathstore.ListChildrenResponse
+	var nextCursor string
+	var err error
+	if tagFilter != "" {
+		children, err = s.orchestrator.PathstoreClient().ListAll(r.Context(), prefix)
+	} else {
+		children, nextCursor, err = s.orchestrator.PathstoreClient().ListChildrenWithCursor(r.Context(), prefix, limit, cursor)
+	}
 	if err != nil {
-		jsonError(w, "failed to list documents: "+err.Error(), http.StatusInternalServerError)
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to list documents: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -30,95 +57,532 @@ func (s *Server) handleListDocuments(w http.ResponseWriter, r *http.Request) {
 	var docs []map[string]any
 	for _, child := range children {
 		if strings.HasSuffix(child.Key, ".meta") || strings.Contains(child.Key, ".meta") {
+			if !includeDeleted && isSoftDeleted(child.Value) {
+				continue
+			}
+			if tagFilter != "" && !slices.Contains(metaTags(child.Value), tagFilter) {
+				continue
+			}
 			docs = append(docs, map[string]any{
 				"key":   child.Key,
 				"value": child.Value,
 			})
 		}
 	}
+	if tagFilter != "" {
+		offset, _ := strconv.Atoi(cursor)
+		offset = min(max(offset, 0), len(docs))
+		docs = docs[offset:]
+		if len(docs) > limit {
+			docs = docs[:limit]
+			nextCursor = strconv.Itoa(offset + limit)
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{"documents": docs})
+	json.NewEncoder(w).Encode(map[string]any{
+		"documents":   docs,
+		"next_cursor": nextCursor,
+	})
 }
 
-// handleDeleteDocument deletes a document and all its stored facts.
+// handleDeleteDocument queues deletion of a document and all its stored
+// facts; poll the returned job like an ingest. With soft=true the facts are
+// archived synchronously instead and can be restored until SOFT_DELETE_TTL
+// elapses.
 func (s *Server) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
 	docID := chi.URLParam(r, "docID")
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		jsonError(w, "user_id query parameter is required", http.StatusBadRequest)
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("soft") == "true" {
+		s.softDeleteDocument(w, r, userID, docID)
 		return
 	}
 
+	now := time.Now()
+	dj := &pipeline.DeleteJob{
+		ID:        pipeline.ContentHashHex([]byte(fmt.Sprintf("delete-%s-%s-%d", userID, docID, now.UnixNano())))[:20],
+		DocID:     docID,
+		UserID:    userID,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	// Facts missing from an incomplete manifest are swept at most once per
+	// orphanLimiter interval per user.
+	dj.SweepOrphans, _ = s.orphanLimiter.Allow(userID)
+
+	if err := s.orchestrator.SubmitDelete(dj); err != nil {
+		code, status := submitError(err)
+		jsonErrorWithCode(w, code, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{
+		"deletion_job_id": dj.ID,
+		"status":          pipeline.StatusQueued,
+		"poll_url":        fmt.Sprintf("/api/ingest/%s/status", dj.ID),
+	})
+}
+
+// softDeleteDocument moves a document's facts to docPrefix/deleted_facts and
+// marks its meta node with deleted_at. Archived copies expire in pathstore
+// after SoftDeleteTTL, and the orchestrator's sweep purges the rest of the
+// document then.
+func (s *Server) softDeleteDocument(w http.ResponseWriter, r *http.Request, userID, docID string) {
 	ctx := r.Context()
 	ps := s.orchestrator.PathstoreClient()
 	docPrefix := fmt.Sprintf("memory/users/%s/documents/%s", userID, docID)
 
-	// 1. Read manifest entries.
-	manifestPrefix := docPrefix + "/facts"
-	manifestEntries, err := ps.ListChildren(ctx, manifestPrefix, 10000)
+	meta, metaMap, err := readMeta(ctx, ps, docPrefix)
 	if err != nil {
-		jsonError(w, "failed to read manifest: "+err.Error(), http.StatusInternalServerError)
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read document meta: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if meta == nil {
+		jsonErrorWithCode(w, ErrCodeNotFound, "document not found", http.StatusNotFound)
+		return
+	}
+	if isSoftDeleted(metaMap) {
+		jsonErrorWithCode(w, ErrCodeConflict, "document is already deleted", http.StatusConflict)
 		return
 	}
 
-	factsDeleted := 0
+	manifestEntries, err := ps.ListAll(ctx, docPrefix+"/facts")
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read manifest: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	purgeAfter := now.Add(s.cfg.SoftDeleteTTL)
 	missingPaths := 0
+	failed := 0
 
-	// 2. Delete each referenced fact.
-	for _, entry := range manifestEntries {
-		factPath := extractFactPath(entry.Value)
-		if factPath == "" {
+	factPaths := manifestFactPaths(manifestEntries)
+	// Read failures leave a path out of nodes and are counted below.
+	nodes, _ := pathstore.BatchGetNodes(ctx, ps, factPaths)
+	var archived []string
+	for _, factPath := range factPaths {
+		node, ok := nodes[factPath]
+		if !ok {
+			failed++
 			continue
 		}
-		err := ps.DeleteNode(ctx, factPath, false)
-		if err != nil {
+		if node == nil {
 			missingPaths++
-		} else {
-			factsDeleted++
+			continue
 		}
+		archiveErr := ps.PutNode(ctx, archivePath(docPrefix, factPath), pathstore.NodeRequest{
+			Value: map[string]any{
+				"path":        factPath,
+				"value":       node.Value,
+				"memory_type": node.MemoryType,
+				"salience":    node.Salience,
+			},
+			MemoryType: "metacognitive",
+			Salience:   0.1,
+			Source:     "docgest:" + docID,
+			ExpiresAt:  purgeAfter.Format(time.RFC3339),
+		})
+		if archiveErr != nil {
+			failed++
+			continue
+		}
+		archived = append(archived, factPath)
 	}
+	factsArchived, deleteErrs := pathstore.BatchDeleteNodes(ctx, ps, archived, false)
+	failed += len(deleteErrs)
 
-	// 3. Delete document meta and manifest.
-	manifestDeleted := 0
-	if err := ps.DeleteNode(ctx, docPrefix, true); err == nil {
-		manifestDeleted = 1
-	}
+	// Drop the hash index so re-uploading the content is not treated as a duplicate.
+	pipeline.DeleteHashIndex(ctx, ps, userID, docID, docPrefix)
 
-	// 4. Delete hash index entry.
-	deleteHashIndex(ctx, ps, userID, docID, docPrefix)
+	metaMap["deleted_at"] = now.Format(time.RFC3339)
+	if err := writeMeta(ctx, ps, docPrefix, docID, meta, metaMap); err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to mark document deleted: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := pipeline.MarkSoftDeleted(ctx, ps, userID, docID, purgeAfter); err != nil {
+		s.log.Warn("soft-delete index write failed, document will not be swept", "user_id", userID, "doc_id", docID, "error", err)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
-		"facts_deleted":      factsDeleted,
+		"facts_archived":     factsArchived,
 		"missing_fact_paths": missingPaths,
-		"manifest_deleted":   manifestDeleted,
+		"failed":             failed,
+		"deleted_at":         metaMap["deleted_at"],
+		"purge_after":        purgeAfter.Format(time.RFC3339),
 	})
 }
 
-func extractFactPath(value any) string {
-	m, ok := value.(map[string]any)
-	if !ok {
-		return ""
+// handleRestoreDocument moves a soft-deleted document's facts back to their
+// active paths. Documents past SoftDeleteTTL are purged and reported as gone.
+// If any fact fails to restore the document stays deleted with status
+// "partial"; restored facts leave the archive, so a retry handles the rest.
+func (s *Server) handleRestoreDocument(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	ps := s.orchestrator.PathstoreClient()
+	docPrefix := fmt.Sprintf("memory/users/%s/documents/%s", userID, docID)
+
+	meta, metaMap, err := readMeta(ctx, ps, docPrefix)
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read document meta: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
-	path, _ := m["path"].(string)
-	return path
+	if meta == nil {
+		jsonErrorWithCode(w, ErrCodeNotFound, "document not found", http.StatusNotFound)
+		return
+	}
+	if !isSoftDeleted(metaMap) {
+		jsonErrorWithCode(w, ErrCodeConflict, "document is not deleted", http.StatusConflict)
+		return
+	}
+	deletedAt, _ := time.Parse(time.RFC3339, metaMap["deleted_at"].(string))
+	if time.Since(deletedAt) > s.cfg.SoftDeleteTTL {
+		pipeline.PurgeDocument(ctx, ps, userID, docID)
+		jsonErrorWithCode(w, ErrCodeGone, "document was permanently deleted", http.StatusGone)
+		return
+	}
+
+	archived, err := ps.ListAll(ctx, docPrefix+"/deleted_facts")
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read deleted facts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	factsRestored := 0
+	failed := 0
+	for _, entry := range archived {
+		m, ok := entry.Value.(map[string]any)
+		if !ok {
+			continue
+		}
+		factPath, _ := m["path"].(string)
+		if factPath == "" {
+			continue
+		}
+		memoryType, _ := m["memory_type"].(string)
+		salience, _ := m["salience"].(float64)
+		if err := ps.PutNode(ctx, factPath, pathstore.NodeRequest{
+			Value:      m["value"],
+			MemoryType: memoryType,
+			Salience:   salience,
+			Source:     "docgest:" + docID,
+		}); err != nil {
+			failed++
+			continue
+		}
+		ps.DeleteNode(ctx, archivePath(docPrefix, factPath), false)
+		factsRestored++
+	}
+
+	status := "partial"
+	if failed == 0 {
+		status = "restored"
+		delete(metaMap, "deleted_at")
+		if err := writeMeta(ctx, ps, docPrefix, docID, meta, metaMap); err != nil {
+			jsonErrorWithCode(w, ErrCodeStorage, "failed to update document meta: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pipeline.UnmarkSoftDeleted(ctx, ps, userID, docID)
+
+		// Re-create the hash index entries removed by the soft delete.
+		contentHash, _ := metaMap["content_hash"].(string)
+		uploadHash, _ := metaMap["upload_hash"].(string)
+		pipeline.WriteHashIndex(ctx, ps, userID, docID, contentHash, uploadHash, map[string]any{
+			"filename":   metaMap["filename"],
+			"created_at": metaMap["created_at"],
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":         status,
+		"facts_restored": factsRestored,
+		"failed":         failed,
+	})
 }
 
-func deleteHashIndex(ctx context.Context, ps *pathstore.Client, userID, docID, docPrefix string) {
-	// Read the meta to get the content hash.
+// handleVerifyDocument checks that every manifest entry still points at a
+// stored fact. It costs one pathstore read per fact, so it is limited to one
+// request per user per minute.
+func (s *Server) handleVerifyDocument(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+	if ok, wait := s.verifyLimiter.Allow(userID); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		jsonErrorWithCode(w, ErrCodeRateLimited, "verify is limited to one request per minute", http.StatusTooManyRequests)
+		return
+	}
+
+	ctx := r.Context()
+	ps := s.orchestrator.PathstoreClient()
+	docPrefix := fmt.Sprintf("memory/users/%s/documents/%s", userID, docID)
+
+	manifestEntries, err := ps.ListAll(ctx, docPrefix+"/facts")
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read manifest: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	factPaths := manifestFactPaths(manifestEntries)
+	nodes, err := pathstore.BatchGetNodes(ctx, ps, factPaths)
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read fact: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	present := 0
+	missingPaths := []string{}
+	for _, factPath := range factPaths {
+		if nodes[factPath] == nil {
+			missingPaths = append(missingPaths, factPath)
+		} else {
+			present++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"total_manifest_entries": len(manifestEntries),
+		"present":                present,
+		"missing":                len(missingPaths),
+		"missing_paths":          missingPaths,
+	})
+}
+
+// handleDocumentPaths lists the fact paths recorded in a document's manifest
+// without reading the facts. With validate=true each path is also fetched and
+// those no longer stored are reported under "missing".
+func (s *Server) handleDocumentPaths(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	ps := s.orchestrator.PathstoreClient()
+	docPrefix := fmt.Sprintf("memory/users/%s/documents/%s", userID, docID)
+
+	manifestEntries, err := ps.ListAll(ctx, docPrefix+"/facts")
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read manifest: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	paths := manifestFactPaths(manifestEntries)
+
+	resp := map[string]any{"paths": paths}
+	if r.URL.Query().Get("validate") == "true" {
+		nodes, err := pathstore.BatchGetNodes(ctx, ps, paths)
+		if err != nil {
+			jsonErrorWithCode(w, ErrCodeStorage, "failed to read fact: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		missing := []string{}
+		for _, factPath := range paths {
+			if nodes[factPath] == nil {
+				missing = append(missing, factPath)
+			}
+		}
+		resp["missing"] = missing
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// manifestFactPaths returns the fact paths recorded in manifest entries,
+// skipping entries without one.
+func manifestFactPaths(entries []pathstore.ListChildrenResponse) []string {
+	paths := []string{}
+	for _, entry := range entries {
+		if factPath := pipeline.ExtractFactPath(entry.Value); factPath != "" {
+			paths = append(paths, factPath)
+		}
+	}
+	return paths
+}
+
+// handleDocumentPreview returns the chunk preview stored by a dry-run ingest.
+func (s *Server) handleDocumentPreview(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	previewPath := fmt.Sprintf("memory/users/%s/documents/%s/dry_run_preview", userID, docID)
+	node, err := s.orchestrator.PathstoreClient().GetNode(r.Context(), previewPath)
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read preview: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if node == nil {
+		jsonErrorWithCode(w, ErrCodeNotFound, "no dry-run preview for document", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"doc_id":       docID,
+		"preview_path": previewPath,
+		"preview":      node.Value,
+	})
+}
+
+// readMeta fetches a document's meta node and its value as a map. Both are
+// nil if the document does not exist.
+func readMeta(ctx context.Context, ps pathstore.Store, docPrefix string) (*pathstore.NodeResponse, map[string]any, error) {
 	meta, err := ps.GetNode(ctx, docPrefix+"/meta")
 	if err != nil || meta == nil {
-		return
+		return nil, nil, err
 	}
 	metaMap, ok := meta.Value.(map[string]any)
 	if !ok {
+		metaMap = map[string]any{}
+	}
+	return meta, metaMap, nil
+}
+
+// writeMeta stores an updated meta value, keeping the node's memory type and salience.
+func writeMeta(ctx context.Context, ps pathstore.Store, docPrefix, docID string, meta *pathstore.NodeResponse, metaMap map[string]any) error {
+	return ps.PutNode(ctx, docPrefix+"/meta", pathstore.NodeRequest{
+		Value:      metaMap,
+		MemoryType: meta.MemoryType,
+		Salience:   meta.Salience,
+		Source:     "docgest:" + docID,
+	})
+}
+
+// handleAddDocumentTags merges tags into a document's meta node.
+func (s *Server) handleAddDocumentTags(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+	var req struct {
+		UserID string   `json:"user_id"`
+		Tags   []string `json:"tags"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&req); err != nil {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "invalid json body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Tags) == 0 {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "at least one tag is required", http.StatusBadRequest)
+		return
+	}
+
+	s.updateDocumentTags(w, r, req.UserID, docID, func(tags []string) []string {
+		for _, t := range req.Tags {
+			t = strings.TrimSpace(t)
+			if t != "" && !slices.Contains(tags, t) {
+				tags = append(tags, t)
+			}
+		}
+		return tags
+	})
+}
+
+// handleRemoveDocumentTag removes a single tag from a document's meta node.
+func (s *Server) handleRemoveDocumentTag(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "docID")
+	tag := chi.URLParam(r, "tag")
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	s.updateDocumentTags(w, r, userID, docID, func(tags []string) []string {
+		return slices.DeleteFunc(tags, func(t string) bool { return t == tag })
+	})
+}
+
+// updateDocumentTags applies fn to a document's tags and writes the meta back.
+func (s *Server) updateDocumentTags(w http.ResponseWriter, r *http.Request, userID, docID string, fn func([]string) []string) {
+	ctx := r.Context()
+	ps := s.orchestrator.PathstoreClient()
+	docPrefix := fmt.Sprintf("memory/users/%s/documents/%s", userID, docID)
+
+	meta, metaMap, err := readMeta(ctx, ps, docPrefix)
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read document meta: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	hash, _ := metaMap["content_hash"].(string)
-	if hash == "" {
+	if meta == nil {
+		jsonErrorWithCode(w, ErrCodeNotFound, "document not found", http.StatusNotFound)
+		return
+	}
+
+	tags := fn(metaTags(metaMap))
+	if tags == nil {
+		tags = []string{}
+	}
+	metaMap["tags"] = tags
+	if err := writeMeta(ctx, ps, docPrefix, docID, meta, metaMap); err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to update document meta: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	hashPath := fmt.Sprintf("memory/users/%s/documents/by_hash/%s/%s", userID, hash, docID)
-	ps.DeleteNode(ctx, hashPath, false)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"doc_id": docID,
+		"tags":   tags,
+	})
+}
+
+// metaTags reads the tags list from a meta value.
+func metaTags(value any) []string {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	// A store that keeps values in memory hands back the []string written.
+	if tags, ok := m["tags"].([]string); ok {
+		return slices.Clone(tags)
+	}
+	raw, _ := m["tags"].([]any)
+	tags := make([]string, 0, len(raw))
+	for _, t := range raw {
+		if s, ok := t.(string); ok {
+			tags = append(tags, s)
+		}
+	}
+	return tags
+}
+
+// isSoftDeleted reports whether a meta value carries a deleted_at marker.
+func isSoftDeleted(value any) bool {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, deleted := m["deleted_at"].(string)
+	return deleted
+}
+
+// archivePath is where a soft-deleted fact is kept until restore or expiry.
+func archivePath(docPrefix, factPath string) string {
+	return docPrefix + "/deleted_facts/" + path.Base(factPath)
 }
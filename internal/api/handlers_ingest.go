@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,208 +11,533 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dgallion1/docgest/internal/extract"
 	"github.com/dgallion1/docgest/internal/parser"
 	"github.com/dgallion1/docgest/internal/pipeline"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
-	// Limit total request size.
-	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxUploadBytes+1024*1024) // extra 1MB for form overhead
+	// The filename, and so the per-extension limit, is only known once the
+	// form is parsed; until then the body is held to the largest limit.
+	maxUpload := s.cfg.MaxUploadLimit()
+	maxBody := maxUpload + 1024*1024 // extra 1MB for form overhead
+
+	// Reject oversized uploads from the declared length before reading any of
+	// the body. Chunked requests (ContentLength -1) are caught by the
+	// MaxBytesReader below instead.
+	if r.ContentLength > maxBody {
+		s.log.Warn("upload rejected before read", "content_length", r.ContentLength, "limit", maxBody)
+		jsonErrorWithCode(w, ErrCodeFileTooLarge, fmt.Sprintf("file exceeds max size (%d bytes)", maxUpload), http.StatusRequestEntityTooLarge)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
 
 	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		jsonError(w, "invalid multipart form: "+err.Error(), http.StatusBadRequest)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.log.Warn("upload rejected while reading", "limit", maxBody)
+			jsonErrorWithCode(w, ErrCodeFileTooLarge, fmt.Sprintf("file exceeds max size (%d bytes)", maxUpload), http.StatusRequestEntityTooLarge)
+			return
+		}
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "invalid multipart form: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 	defer r.MultipartForm.RemoveAll()
 
 	userID := r.FormValue("user_id")
 	if userID == "" {
-		jsonError(w, "user_id is required", http.StatusBadRequest)
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id is required", http.StatusBadRequest)
 		return
 	}
 
+	// A client-supplied job ID makes retries idempotent: resubmitting it
+	// returns the existing job unless that job failed.
+	jobID := r.FormValue("job_id")
+	if jobID == "" {
+		jobID = r.FormValue("idempotency_key")
+	}
+	if jobID != "" {
+		if len(jobID) > 128 || strings.ContainsAny(jobID, "/?#") {
+			jsonErrorWithCode(w, ErrCodeInvalidRequest, "invalid job_id", http.StatusBadRequest)
+			return
+		}
+		// Answer a retry before reading its file. The check is repeated
+		// atomically when the job is submitted.
+		if existing := s.orchestrator.GetJob(jobID); existing != nil {
+			snap := existing.Snapshot()
+			if snap.UserID != userID || snap.Type != pipeline.JobTypeIngest || snap.Status != pipeline.StatusFailed {
+				writeExistingJob(w, snap, userID)
+				return
+			}
+		}
+	}
+
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		jsonError(w, "file is required: "+err.Error(), http.StatusBadRequest)
+		jsonErrorWithCode(w, ErrCodeMissingFile, "file is required: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
 
 	filename := sanitizeFilename(header.Filename)
-	if !parser.IsSupportedExtension(filename) {
-		jsonError(w, fmt.Sprintf("unsupported file type: %s", filepath.Ext(filename)), http.StatusBadRequest)
-		return
-	}
-
+	contentType := header.Header.Get("Content-Type")
 	// Read file data.
-	data, err := io.ReadAll(io.LimitReader(file, s.cfg.MaxUploadBytes+1))
+	limit := s.cfg.UploadLimit(filename)
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
 	if err != nil {
-		jsonError(w, "failed to read file", http.StatusInternalServerError)
+		jsonErrorWithCode(w, ErrCodeInternal, "failed to read file", http.StatusInternalServerError)
+		return
+	}
+	if int64(len(data)) > limit {
+		s.log.Warn("upload rejected after read", "filename", filename, "limit", limit)
+		jsonErrorWithCode(w, ErrCodeFileTooLarge, fmt.Sprintf("file exceeds max size (%d bytes)", limit), http.StatusRequestEntityTooLarge)
 		return
 	}
-	if int64(len(data)) > s.cfg.MaxUploadBytes {
-		jsonError(w, fmt.Sprintf("file exceeds max size (%d bytes)", s.cfg.MaxUploadBytes), http.StatusRequestEntityTooLarge)
+	// Files without a usable extension or content type are still accepted
+	// when their leading bytes identify a supported format.
+	if _, err := parser.Select(filename, contentType, data, parser.Options{}); err != nil {
+		jsonErrorWithCode(w, ErrCodeUnsupportedType, fmt.Sprintf("unsupported file type: %s", filepath.Ext(filename)), http.StatusBadRequest)
 		return
 	}
 
+	uploadHash := pipeline.ContentHashHex(data)
 	docID := r.FormValue("doc_id")
 	if docID == "" {
-		docID = pipeline.ContentHashHex(data)[:16]
+		docID = uploadHash[:16]
 	}
 	title := r.FormValue("title")
+	sourceType, ok := pipeline.ParseSourceType(r.FormValue("source_type"))
+	if !ok {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, fmt.Sprintf("invalid source_type: %s", r.FormValue("source_type")), http.StatusBadRequest)
+		return
+	}
+	docType, ok := parseDocType(r.FormValue("doc_type"))
+	if !ok {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, fmt.Sprintf("invalid doc_type: %s", r.FormValue("doc_type")), http.StatusBadRequest)
+		return
+	}
+	minTrustFloor, ok := parseMinTrustFloor(r.FormValue("min_trust_floor"))
+	if !ok {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, fmt.Sprintf("invalid min_trust_floor: %s", r.FormValue("min_trust_floor")), http.StatusBadRequest)
+		return
+	}
 
-	// Parse optional chunk config overrides.
-	chunkSize := s.cfg.DefaultChunkSize
+	// Parse optional chunk config overrides; they take precedence over the
+	// user's stored config.
+	var overrides pipeline.UserConfig
 	if v := r.FormValue("chunk_size"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			chunkSize = n
+			overrides.ChunkSize = n
 		}
 	}
-	chunkOverlap := s.cfg.DefaultChunkOverlap
 	if v := r.FormValue("overlap"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			chunkOverlap = n
+			overrides.ChunkOverlap = n
 		}
 	}
 
+	callbackURL := r.FormValue("callback_url")
+	if callbackURL != "" {
+		if err := pipeline.ValidateCallbackURL(r.Context(), callbackURL); err != nil {
+			jsonErrorWithCode(w, ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	// A registered webhook supplies the callback URL and signs deliveries.
+	var callbackSecret string
+	if webhookID := r.FormValue("webhook_id"); webhookID != "" {
+		if callbackURL != "" {
+			jsonErrorWithCode(w, ErrCodeInvalidRequest, "set callback_url or webhook_id, not both", http.StatusBadRequest)
+			return
+		}
+		reg, ok := s.activeWebhook(w, r, webhookID)
+		if !ok {
+			return
+		}
+		callbackURL, callbackSecret = reg.URL, reg.Secret
+	}
+
 	force := r.FormValue("force") == "true"
+	dryRun := r.FormValue("dry_run") == "true"
+	parseOnly := r.FormValue("parse_only") == "true"
+	incremental := r.FormValue("incremental") == "true"
+	tags := parseTags(r.MultipartForm.Value)
 
 	now := time.Now()
+	clientJobID := jobID != ""
+	if !clientJobID {
+		jobID = pipeline.ContentHashHex([]byte(fmt.Sprintf("%s-%s-%d", userID, filename, now.UnixNano())))[:20]
+	}
 	job := &pipeline.Job{
-		ID:        pipeline.ContentHashHex([]byte(fmt.Sprintf("%s-%s-%d", userID, filename, now.UnixNano())))[:20],
-		DocID:     docID,
-		UserID:    userID,
-		Status:    pipeline.StatusQueued,
-		Phase:     "queued",
-		Filename:  filename,
-		Title:     title,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-	// Set internal fields via exported setter or direct. Since fileData is unexported,
-	// we need a method on Job. Let's use the Submit method which passes data.
-
-	_ = chunkSize
-	_ = chunkOverlap
+		ID:             jobID,
+		Type:           pipeline.JobTypeIngest,
+		DocID:          docID,
+		UserID:         userID,
+		Status:         pipeline.StatusQueued,
+		Phase:          "queued",
+		Filename:       filename,
+		Title:          title,
+		ContentType:    contentType,
+		SourceType:     sourceType,
+		DocType:        docType,
+		Tags:           tags,
+		DryRun:         dryRun,
+		ParseOnly:      parseOnly,
+		Incremental:    incremental,
+		MinTrustFloor:  minTrustFloor,
+		CallbackURL:    callbackURL,
+		CallbackSecret: callbackSecret,
+		Overrides:      overrides,
+		RequestID:      middleware.GetReqID(r.Context()),
+		UploadHash:     uploadHash,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
 	_ = force
 
+	// Look the upload up before queueing so the response can name a
+	// document that already has this content. The job still runs; the
+	// worker's own dedup check decides whether it is skipped.
+	existingDocID, err := pipeline.LookupHashIndex(r.Context(), s.orchestrator.PathstoreClient(), userID, uploadHash)
+	if err != nil {
+		s.log.Warn("hash index lookup failed", "user_id", userID, "error", err)
+	}
+
 	// We need to set fileData on the job. Since it's unexported, add a setter.
 	job.SetFileData(data)
 
-	if err := s.orchestrator.Submit(job); err != nil {
-		jsonError(w, err.Error(), http.StatusServiceUnavailable)
+	var existing *pipeline.Job
+	if clientJobID {
+		existing, err = s.orchestrator.SubmitIfAbsent(r.Context(), job)
+	} else {
+		err = s.orchestrator.Submit(r.Context(), job)
+	}
+	if err != nil {
+		code, status := submitError(err)
+		jsonErrorWithCode(w, code, err.Error(), status)
+		return
+	}
+	if existing != nil {
+		// A concurrent retry with the same job_id was submitted first.
+		writeExistingJob(w, existing.Snapshot(), userID)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(ContentHashHeader, uploadHash)
+	if existingDocID != "" {
+		w.Header().Set(ExistingDocIDHeader, existingDocID)
+	}
 	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(map[string]any{
 		"job_id":   job.ID,
 		"doc_id":   job.DocID,
-		"status":   job.Status,
+		"status":   pipeline.StatusQueued,
 		"poll_url": fmt.Sprintf("/api/ingest/%s/status", job.ID),
 	})
 }
 
+// ContentHashHeader carries the SHA-256 of the uploaded bytes on an
+// accepted ingest, so clients can key their own cache on it.
+const ContentHashHeader = "X-Docgest-Content-Hash"
+
+// ExistingDocIDHeader names a document of the user's already indexed under
+// the upload's hash. It is set even though a new job was queued.
+const ExistingDocIDHeader = "X-Docgest-Existing-Doc-ID"
+
+// writeExistingJob answers an ingest whose job_id is already held: with the
+// job's snapshot when it is the caller's ingest, else with a conflict.
+func writeExistingJob(w http.ResponseWriter, snap pipeline.JobSnapshot, userID string) {
+	if snap.UserID != userID || snap.Type != pipeline.JobTypeIngest {
+		jsonErrorWithCode(w, ErrCodeConflict, "job_id is already in use", http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snap)
+}
+
+// submitError maps an orchestrator submit error to an error code and HTTP
+// status: a full queue or shutdown is retryable (503), anything else is
+// internal.
+func submitError(err error) (string, int) {
+	if errors.Is(err, pipeline.ErrQueueFull) {
+		return ErrCodeQueueFull, http.StatusServiceUnavailable
+	}
+	if errors.Is(err, pipeline.ErrStopped) {
+		return ErrCodeUnavailable, http.StatusServiceUnavailable
+	}
+	return ErrCodeInternal, http.StatusInternalServerError
+}
+
+// parseDocType validates a doc_type form value. Empty and "auto" leave the
+// type to be detected after parsing.
+func parseDocType(v string) (string, bool) {
+	if v == "" || v == "auto" {
+		return "", true
+	}
+	return v, extract.IsDocType(v)
+}
+
+// parseMinTrustFloor validates a min_trust_floor form value: empty, or an
+// integer from 0 to the validator's maximum min_trust.
+func parseMinTrustFloor(v string) (int, bool) {
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > extract.DefaultValidator().Rules().MaxMinTrust {
+		return 0, false
+	}
+	return n, true
+}
+
+// handleListJobs lists a user's tracked jobs. Optional filters: status,
+// since (YYYY-MM-DD or RFC3339), tag_* fields, limit and offset.
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	userID := q.Get("user_id")
+	if userID == "" {
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	filters := pipeline.JobFilters{
+		Status: pipeline.JobStatus(q.Get("status")),
+		Tags:   parseTags(q),
+		Limit:  50,
+	}
+	if v := q.Get("since"); v != "" {
+		since, err := parseSince(v)
+		if err != nil {
+			jsonErrorWithCode(w, ErrCodeInvalidRequest, "invalid since: expected YYYY-MM-DD or RFC3339", http.StatusBadRequest)
+			return
+		}
+		filters.Since = since
+	}
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			filters.Limit = min(n, 500)
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			filters.Offset = n
+		}
+	}
+
+	jobs := []pipeline.JobSnapshot{}
+	for _, job := range s.orchestrator.ListJobs(userID, filters) {
+		jobs = append(jobs, job.Snapshot())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"jobs":   jobs,
+		"limit":  filters.Limit,
+		"offset": filters.Offset,
+	})
+}
+
+func parseSince(v string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
 func (s *Server) handleIngestStatus(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "jobID")
 	job := s.orchestrator.GetJob(jobID)
 	if job == nil {
-		jsonError(w, "job not found", http.StatusNotFound)
+		if s.orchestrator.JobExpired(jobID) {
+			jsonErrorWithCode(w, ErrCodeJobExpired, "job expired", http.StatusGone)
+			return
+		}
+		jsonErrorWithCode(w, ErrCodeNotFound, "job not found", http.StatusNotFound)
 		return
 	}
 	snap := job.Snapshot()
+	resp := map[string]any{
+		"job_id":           snap.ID,
+		"type":             snap.Type,
+		"doc_id":           snap.DocID,
+		"status":           snap.Status,
+		"phase":            snap.Phase,
+		"progress":         snap.Progress,
+		"description":      snap.Description,
+		"percent_complete": snap.PercentComplete,
+	}
+	if snap.DryRun {
+		resp["dry_run"] = true
+		if snap.PreviewPath != "" {
+			resp["preview_path"] = snap.PreviewPath
+		}
+	}
+	if snap.ParseOnly {
+		resp["parse_only"] = true
+		if snap.ParsedTree != nil {
+			resp["parsed_tree"] = snap.ParsedTree
+		}
+	}
+	if snap.CallbackURL != "" {
+		resp["callback_attempts"] = snap.CallbackAttempts
+		if snap.CallbackLastError != "" {
+			resp["callback_last_error"] = snap.CallbackLastError
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
-		"job_id":   snap.ID,
-		"doc_id":   snap.DocID,
-		"status":   snap.Status,
-		"phase":    snap.Phase,
-		"progress": snap.Progress,
-	})
+	json.NewEncoder(w).Encode(resp)
 }
 
+// handleBatchIngest queues each uploaded file as its own job. Tar archives
+// (.tar.gz, .tgz, .tar.bz2) are expanded and every supported document in
+// them is queued separately.
 func (s *Server) handleBatchIngest(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxUploadBytes*10+10*1024*1024)
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxUploadLimit()*10+10*1024*1024)
 
 	if err := r.ParseMultipartForm(64 << 20); err != nil {
-		jsonError(w, "invalid multipart form: "+err.Error(), http.StatusBadRequest)
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "invalid multipart form: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 	defer r.MultipartForm.RemoveAll()
 
 	userID := r.FormValue("user_id")
 	if userID == "" {
-		jsonError(w, "user_id is required", http.StatusBadRequest)
+		jsonErrorWithCode(w, ErrCodeMissingUserID, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	tags := parseTags(r.MultipartForm.Value)
+	sourceType, ok := pipeline.ParseSourceType(r.FormValue("source_type"))
+	if !ok {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, fmt.Sprintf("invalid source_type: %s", r.FormValue("source_type")), http.StatusBadRequest)
+		return
+	}
+	docType, ok := parseDocType(r.FormValue("doc_type"))
+	if !ok {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, fmt.Sprintf("invalid doc_type: %s", r.FormValue("doc_type")), http.StatusBadRequest)
+		return
+	}
+	minTrustFloor, ok := parseMinTrustFloor(r.FormValue("min_trust_floor"))
+	if !ok {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, fmt.Sprintf("invalid min_trust_floor: %s", r.FormValue("min_trust_floor")), http.StatusBadRequest)
 		return
 	}
 
 	files := r.MultipartForm.File["files"]
 	if len(files) == 0 {
-		jsonError(w, "at least one file is required", http.StatusBadRequest)
+		jsonErrorWithCode(w, ErrCodeMissingFile, "at least one file is required", http.StatusBadRequest)
 		return
 	}
 
-	var results []map[string]any
-	for _, fh := range files {
-		filename := sanitizeFilename(fh.Filename)
-		if !parser.IsSupportedExtension(filename) {
-			results = append(results, map[string]any{
+	// submit queues one document and returns its batch result.
+	submit := func(filename, contentType string, data []byte) map[string]any {
+		if _, err := parser.Select(filename, contentType, data, parser.Options{}); err != nil {
+			return map[string]any{
 				"filename": filename,
 				"error":    fmt.Sprintf("unsupported file type: %s", filepath.Ext(filename)),
-			})
-			continue
+				"code":     ErrCodeUnsupportedType,
+			}
 		}
 
+		now := time.Now()
+		docID := pipeline.ContentHashHex(data)[:16]
+		job := &pipeline.Job{
+			ID:            pipeline.ContentHashHex([]byte(fmt.Sprintf("%s-%s-%d", userID, filename, now.UnixNano())))[:20],
+			Type:          pipeline.JobTypeIngest,
+			DocID:         docID,
+			UserID:        userID,
+			Status:        pipeline.StatusQueued,
+			Phase:         "queued",
+			Filename:      filename,
+			ContentType:   contentType,
+			SourceType:    sourceType,
+			DocType:       docType,
+			Tags:          tags,
+			RequestID:     middleware.GetReqID(r.Context()),
+			MinTrustFloor: minTrustFloor,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		job.SetFileData(data)
+
+		if err := s.orchestrator.Submit(r.Context(), job); err != nil {
+			code, _ := submitError(err)
+			return map[string]any{
+				"filename": filename,
+				"error":    err.Error(),
+				"code":     code,
+			}
+		}
+
+		return map[string]any{
+			"filename": filename,
+			"job_id":   job.ID,
+			"doc_id":   job.DocID,
+			"status":   pipeline.StatusQueued,
+			"poll_url": fmt.Sprintf("/api/ingest/%s/status", job.ID),
+		}
+	}
+
+	var results []map[string]any
+	for _, fh := range files {
+		filename := sanitizeFilename(fh.Filename)
+		contentType := fh.Header.Get("Content-Type")
 		f, err := fh.Open()
 		if err != nil {
 			results = append(results, map[string]any{
 				"filename": filename,
 				"error":    "failed to open file",
+				"code":     ErrCodeInternal,
 			})
 			continue
 		}
 
-		data, err := io.ReadAll(io.LimitReader(f, s.cfg.MaxUploadBytes+1))
+		limit := s.cfg.UploadLimit(filename)
+		data, err := io.ReadAll(io.LimitReader(f, limit+1))
 		f.Close()
-		if err != nil || int64(len(data)) > s.cfg.MaxUploadBytes {
+		if err != nil || int64(len(data)) > limit {
 			results = append(results, map[string]any{
 				"filename": filename,
 				"error":    "file too large or read error",
+				"code":     ErrCodeFileTooLarge,
 			})
 			continue
 		}
-
-		now := time.Now()
-		docID := pipeline.ContentHashHex(data)[:16]
-		job := &pipeline.Job{
-			ID:        pipeline.ContentHashHex([]byte(fmt.Sprintf("%s-%s-%d", userID, filename, now.UnixNano())))[:20],
-			DocID:     docID,
-			UserID:    userID,
-			Status:    pipeline.StatusQueued,
-			Phase:     "queued",
-			Filename:  filename,
-			CreatedAt: now,
-			UpdatedAt: now,
+		if !parser.IsArchive(filename) {
+			results = append(results, submit(filename, contentType, data))
+			continue
 		}
-		job.SetFileData(data)
 
-		if err := s.orchestrator.Submit(job); err != nil {
+		// Each supported document in an archive becomes its own job.
+		entries, err := parser.ExtractArchive(filename, data, s.cfg.MaxUploadLimit()*10)
+		if err != nil {
 			results = append(results, map[string]any{
 				"filename": filename,
-				"error":    err.Error(),
+				"error":    "invalid archive: " + err.Error(),
+				"code":     ErrCodeInvalidRequest,
 			})
 			continue
 		}
-
-		results = append(results, map[string]any{
-			"filename": filename,
-			"job_id":   job.ID,
-			"doc_id":   job.DocID,
-			"status":   job.Status,
-			"poll_url": fmt.Sprintf("/api/ingest/%s/status", job.ID),
-		})
+		for _, entry := range entries {
+			name := sanitizeFilename(entry.Name)
+			var result map[string]any
+			if int64(len(entry.Data)) > s.cfg.UploadLimit(name) {
+				result = map[string]any{
+					"filename": name,
+					"error":    "file too large",
+					"code":     ErrCodeFileTooLarge,
+				}
+			} else {
+				result = submit(name, "", entry.Data)
+			}
+			result["archive"] = filename
+			result["archive_path"] = entry.Name
+			results = append(results, result)
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -219,10 +545,23 @@ func (s *Server) handleBatchIngest(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]any{"jobs": results})
 }
 
-func jsonError(w http.ResponseWriter, msg string, code int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+// tagPrefix marks form/query fields that carry job tags, e.g. tag_project=alpha.
+const tagPrefix = "tag_"
+
+// parseTags collects tag_* fields into a tag map keyed without the prefix.
+func parseTags(values map[string][]string) map[string]string {
+	var tags map[string]string
+	for k, v := range values {
+		name := strings.TrimPrefix(k, tagPrefix)
+		if name == k || name == "" || len(v) == 0 {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[name] = v[0]
+	}
+	return tags
 }
 
 func sanitizeFilename(name string) string {
@@ -2,12 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 )
 
 func (s *Server) handleLLMStats(w http.ResponseWriter, r *http.Request) {
 	if s.claude == nil || s.claude.Stats == nil {
-		jsonError(w, "llm stats unavailable", http.StatusServiceUnavailable)
+		jsonErrorWithCode(w, ErrCodeUnavailable, "llm stats unavailable", http.StatusServiceUnavailable)
 		return
 	}
 
@@ -17,3 +18,64 @@ func (s *Server) handleLLMStats(w http.ResponseWriter, r *http.Request) {
 		"stats": s.claude.Stats.Snapshot(),
 	})
 }
+
+// handleQueueStats returns a snapshot of the queue, workers and the jobs the
+// job store currently holds.
+func (s *Server) handleQueueStats(w http.ResponseWriter, r *http.Request) {
+	byStatus := s.orchestrator.JobsByStatus()
+	active := 0
+	for status, n := range byStatus {
+		if !status.Terminal() {
+			active += n
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"queue_depth":    s.orchestrator.QueueDepth(),
+		"worker_count":   s.orchestrator.WorkerCount(),
+		"jobs_active":    active,
+		"jobs_by_status": byStatus,
+	})
+}
+
+// handleWorkerStats returns per-worker job counts and processing time so
+// operators can see whether the pool is evenly loaded.
+func (s *Server) handleWorkerStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"workers": s.orchestrator.WorkerStats(),
+	})
+}
+
+// handleAuditLookup returns the prompt text recorded for a prompt hash.
+func (s *Server) handleAuditLookup(w http.ResponseWriter, r *http.Request) {
+	if s.claude == nil || s.claude.Audit == nil {
+		jsonErrorWithCode(w, ErrCodeUnavailable, "extraction audit is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		PromptHash string `json:"prompt_hash"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil || req.PromptHash == "" {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "prompt_hash is required", http.StatusBadRequest)
+		return
+	}
+
+	prompt, ok, err := s.claude.Audit.LookupPrompt(req.PromptHash)
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeInternal, "audit lookup failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		jsonErrorWithCode(w, ErrCodeNotFound, "prompt not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"prompt_hash": req.PromptHash,
+		"prompt":      prompt,
+	})
+}
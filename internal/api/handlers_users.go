@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/dgallion1/docgest/internal/extract"
+	"github.com/dgallion1/docgest/internal/pathstore"
+	"github.com/dgallion1/docgest/internal/pipeline"
+	"github.com/go-chi/chi/v5"
+)
+
+// handleGetUserConfig returns a user's stored extraction parameters.
+func (s *Server) handleGetUserConfig(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	cfg, err := pipeline.FetchUserConfig(r.Context(), s.orchestrator.PathstoreClient(), userID)
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read user config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
+
+// handlePutUserConfig stores a user's extraction parameters. Omitted or
+// zero fields fall back to the service defaults.
+func (s *Server) handlePutUserConfig(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+
+	var cfg pipeline.UserConfig
+	dec := json.NewDecoder(io.LimitReader(r.Body, 64*1024))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "invalid json body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if cfg.ChunkSize < 0 || cfg.ChunkOverlap < 0 || cfg.MaxFactsPerChunk < 0 || cfg.MinTrustFloor < 0 {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "numeric parameters must be non-negative", http.StatusBadRequest)
+		return
+	}
+	if maxTrust := extract.DefaultValidator().Rules().MaxMinTrust; cfg.MinTrustFloor > maxTrust {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, fmt.Sprintf("min_trust_floor must be at most %d", maxTrust), http.StatusBadRequest)
+		return
+	}
+	if cfg.ChunkSize > 0 && cfg.ChunkOverlap >= cfg.ChunkSize {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "chunk_overlap must be smaller than chunk_size", http.StatusBadRequest)
+		return
+	}
+
+	err := s.orchestrator.PathstoreClient().PutNode(r.Context(), pipeline.UserConfigPath(userID), pathstore.NodeRequest{
+		Value:      cfg,
+		MemoryType: "metacognitive",
+		Salience:   0.1,
+		Source:     "docgest:config",
+	})
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to store user config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.orchestrator.InvalidateUserConfig(userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
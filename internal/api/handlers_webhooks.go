@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pipeline"
+	"github.com/go-chi/chi/v5"
+)
+
+// handleRegisterWebhook registers a callback URL and returns its ID and
+// signing secret. The secret is only shown here.
+func (s *Server) handleRegisterWebhook(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil || req.URL == "" {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "url is required", http.StatusBadRequest)
+		return
+	}
+	if err := pipeline.ValidateCallbackURL(r.Context(), req.URL); err != nil {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reg := pipeline.NewWebhookRegistration(req.URL, time.Now())
+	if err := pipeline.StoreWebhook(r.Context(), s.orchestrator.PathstoreClient(), reg); err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to store webhook: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reg)
+}
+
+// handleRenewWebhook extends a registration by WebhookTTL from now. Lapsed
+// registrations cannot be renewed.
+func (s *Server) handleRenewWebhook(w http.ResponseWriter, r *http.Request) {
+	reg, ok := s.activeWebhook(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	reg.ExpiresAt = time.Now().Add(pipeline.WebhookTTL)
+	if err := pipeline.StoreWebhook(r.Context(), s.orchestrator.PathstoreClient(), *reg); err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to store webhook: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"id":         reg.ID,
+		"url":        reg.URL,
+		"expires_at": reg.ExpiresAt,
+	})
+}
+
+// handleVerifyWebhook checks a delivery's X-Docgest-Signature against the
+// raw body using the secret of the webhook_id registration.
+func (s *Server) handleVerifyWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID := r.URL.Query().Get("webhook_id")
+	if webhookID == "" {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "webhook_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeFileTooLarge, "body too large or read error", http.StatusRequestEntityTooLarge)
+		return
+	}
+	reg, ok := s.activeWebhook(w, r, webhookID)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"valid": pipeline.VerifySignature(reg.Secret, body, r.Header.Get(pipeline.SignatureHeader)),
+	})
+}
+
+// activeWebhook loads an unexpired registration, writing the error response
+// and returning false when there is none.
+func (s *Server) activeWebhook(w http.ResponseWriter, r *http.Request, id string) (*pipeline.WebhookRegistration, bool) {
+	reg, err := pipeline.FetchWebhook(r.Context(), s.orchestrator.PathstoreClient(), id)
+	if err != nil {
+		jsonErrorWithCode(w, ErrCodeStorage, "failed to read webhook: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if reg == nil {
+		jsonErrorWithCode(w, ErrCodeNotFound, "webhook not found", http.StatusNotFound)
+		return nil, false
+	}
+	if reg.Expired(time.Now()) {
+		jsonErrorWithCode(w, ErrCodeGone, "webhook registration expired", http.StatusGone)
+		return nil, false
+	}
+	return reg, true
+}
@@ -5,7 +5,11 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 // AuthMiddleware validates the docgest API key.
@@ -14,12 +18,12 @@ func AuthMiddleware(apiKey string, log *slog.Logger) func(http.Handler) http.Han
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			auth := r.Header.Get("Authorization")
 			if !strings.HasPrefix(auth, "Bearer ") {
-				http.Error(w, `{"error":"missing authorization"}`, http.StatusUnauthorized)
+				jsonErrorWithCode(w, ErrCodeUnauthorized, "missing authorization", http.StatusUnauthorized)
 				return
 			}
 			token := strings.TrimPrefix(auth, "Bearer ")
 			if subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) != 1 {
-				http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+				jsonErrorWithCode(w, ErrCodeUnauthorized, "invalid api key", http.StatusUnauthorized)
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -27,23 +31,55 @@ func AuthMiddleware(apiKey string, log *slog.Logger) func(http.Handler) http.Han
 	}
 }
 
-// RequestLogger logs incoming requests.
-func RequestLogger(log *slog.Logger) func(http.Handler) http.Handler {
+// PropagateRequestID puts chi's request ID where the pathstore client
+// finds it, so pathstore calls made while handling the request send it as
+// X-Request-ID. It must run after middleware.RequestID.
+func PropagateRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := pathstore.WithRequestID(r.Context(), middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestLogger logs incoming requests. With a zero slowThreshold every
+// request logs at Info. Otherwise requests slower than slowThreshold log at
+// Warn with slow_request=true, ingest submissions and error responses still
+// log at Info, and everything else drops to Debug.
+func RequestLogger(log *slog.Logger, slowThreshold time.Duration) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 			sw := &statusWriter{ResponseWriter: w, status: 200}
 			next.ServeHTTP(sw, r)
-			log.Info("request",
+			duration := time.Since(start)
+			attrs := []any{
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status", sw.status,
-				"duration_ms", time.Since(start).Milliseconds(),
-			)
+				"duration_ms", duration.Milliseconds(),
+			}
+			if id := middleware.GetReqID(r.Context()); id != "" {
+				attrs = append(attrs, "request_id", id)
+			}
+			switch {
+			case slowThreshold <= 0:
+				log.Info("request", attrs...)
+			case duration > slowThreshold:
+				log.Warn("request", append(attrs, "slow_request", true)...)
+			case sw.status >= 400 || isIngestSubmission(r):
+				log.Info("request", attrs...)
+			default:
+				log.Debug("request", attrs...)
+			}
 		})
 	}
 }
 
+// isIngestSubmission reports whether r submits new ingest jobs.
+func isIngestSubmission(r *http.Request) bool {
+	return r.Method == http.MethodPost && (r.URL.Path == "/api/ingest" || r.URL.Path == "/api/ingest/batch")
+}
+
 type statusWriter struct {
 	http.ResponseWriter
 	status int
@@ -53,3 +89,35 @@ func (w *statusWriter) WriteHeader(code int) {
 	w.status = code
 	w.ResponseWriter.WriteHeader(code)
 }
+
+// keyedLimiter allows one event per key per interval.
+type keyedLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+}
+
+func newKeyedLimiter(interval time.Duration) *keyedLimiter {
+	return &keyedLimiter{
+		interval: interval,
+		last:     make(map[string]time.Time),
+	}
+}
+
+// Allow records an event for key and reports whether it is permitted. When
+// denied it also returns how long until the next event is allowed.
+func (l *keyedLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for k, t := range l.last {
+		if now.Sub(t) >= l.interval {
+			delete(l.last, k)
+		}
+	}
+	if t, ok := l.last[key]; ok {
+		return false, l.interval - now.Sub(t)
+	}
+	l.last[key] = now
+	return true, 0
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestKeyedLimiter_OnePerInterval(t *testing.T) {
+	l := newKeyedLimiter(50 * time.Millisecond)
+
+	if ok, _ := l.Allow("u1"); !ok {
+		t.Fatal("expected first request to be allowed")
+	}
+	ok, wait := l.Allow("u1")
+	if ok {
+		t.Error("expected second request within interval to be denied")
+	}
+	if wait <= 0 || wait > 50*time.Millisecond {
+		t.Errorf("expected wait within interval, got %s", wait)
+	}
+	if ok, _ := l.Allow("u2"); !ok {
+		t.Error("expected other key to be allowed")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if ok, _ := l.Allow("u1"); !ok {
+		t.Error("expected request after interval to be allowed")
+	}
+}
+
+func TestRequestLogger_Levels(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		method    string
+		path      string
+		status    int
+		delay     time.Duration
+		wantLevel string
+		wantSlow  bool
+	}{
+		{"threshold off logs all", 0, http.MethodGet, "/api/jobs/x", 200, 0, "INFO", false},
+		{"fast routine request", time.Second, http.MethodGet, "/api/jobs/x", 200, 0, "DEBUG", false},
+		{"ingest submission", time.Second, http.MethodPost, "/api/ingest", 202, 0, "INFO", false},
+		{"error response", time.Second, http.MethodGet, "/api/jobs/x", 404, 0, "INFO", false},
+		{"slow request", 10 * time.Millisecond, http.MethodGet, "/api/jobs/x", 200, 20 * time.Millisecond, "WARN", true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		h := RequestLogger(log, tt.threshold)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(tt.delay)
+			w.WriteHeader(tt.status)
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+		var entry map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("%s: decode log line %q: %v", tt.name, buf.String(), err)
+		}
+		if entry["level"] != tt.wantLevel {
+			t.Errorf("%s: expected level %s, got %v", tt.name, tt.wantLevel, entry["level"])
+		}
+		if slow, _ := entry["slow_request"].(bool); slow != tt.wantSlow {
+			t.Errorf("%s: expected slow_request %v, got %v", tt.name, tt.wantSlow, entry["slow_request"])
+		}
+	}
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	var storeID string
+	h := middleware.RequestID(PropagateRequestID(RequestLogger(log, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storeID = pathstore.RequestIDFromContext(r.Context())
+	}))))
+	req := httptest.NewRequest(http.MethodGet, "/api/documents", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if storeID != "req-123" {
+		t.Errorf("expected pathstore request ID req-123, got %q", storeID)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON log line, got %q", buf.String())
+	}
+	if entry["request_id"] != "req-123" {
+		t.Errorf("expected request log to carry request_id req-123, got %v", entry["request_id"])
+	}
+}
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/dgallion1/docgest/internal/config"
 	"github.com/dgallion1/docgest/internal/extract"
@@ -17,7 +20,11 @@ type Server struct {
 	orchestrator *pipeline.Orchestrator
 	claude       *extract.ClaudeClient
 	log          *slog.Logger
+	logLevel     *slog.LevelVar
 	cfg          config.Config
+
+	verifyLimiter *keyedLimiter
+	orphanLimiter *keyedLimiter
 }
 
 // NewServer creates and configures the HTTP server.
@@ -27,11 +34,19 @@ func NewServer(orch *pipeline.Orchestrator, claude *extract.ClaudeClient, log *s
 		claude:       claude,
 		log:          log,
 		cfg:          cfg,
+
+		verifyLimiter: newKeyedLimiter(time.Minute),
+		orphanLimiter: newKeyedLimiter(5 * time.Second),
 	}
 	s.setupRoutes()
 	return s
 }
 
+// SetLogLevel enables POST /api/admin/log-level, which adjusts level.
+func (s *Server) SetLogLevel(level *slog.LevelVar) {
+	s.logLevel = level
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
 }
@@ -40,7 +55,8 @@ func (s *Server) setupRoutes() {
 	r := chi.NewRouter()
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
-	r.Use(RequestLogger(s.log))
+	r.Use(PropagateRequestID)
+	r.Use(RequestLogger(s.log, time.Duration(s.cfg.SlowRequestThresholdMs)*time.Millisecond))
 
 	// Public endpoints.
 	r.Get("/health", s.handleHealth)
@@ -50,12 +66,30 @@ func (s *Server) setupRoutes() {
 		r.Use(AuthMiddleware(s.cfg.DocgestAPIKey, s.log))
 
 		r.Post("/api/ingest", s.handleIngest)
+		r.Get("/api/ingest", s.handleListJobs)
 		r.Get("/api/ingest/{jobID}/status", s.handleIngestStatus)
 		r.Post("/api/ingest/batch", s.handleBatchIngest)
 		r.Get("/api/stats/llm", s.handleLLMStats)
+		r.Get("/api/stats/queue", s.handleQueueStats)
+		r.Get("/api/stats/workers", s.handleWorkerStats)
+		r.Post("/api/admin/audit/lookup", s.handleAuditLookup)
+		r.Post("/api/admin/log-level", s.handleSetLogLevel)
+
+		r.Post("/api/webhooks", s.handleRegisterWebhook)
+		r.Post("/api/webhooks/verify", s.handleVerifyWebhook)
+		r.Put("/api/webhooks/{id}/renew", s.handleRenewWebhook)
+
+		r.Get("/api/users/{userID}/config", s.handleGetUserConfig)
+		r.Put("/api/users/{userID}/config", s.handlePutUserConfig)
 
 		r.Get("/api/documents", s.handleListDocuments)
 		r.Delete("/api/documents/{docID}", s.handleDeleteDocument)
+		r.Get("/api/documents/{docID}/restore", s.handleRestoreDocument)
+		r.Get("/api/documents/{docID}/verify", s.handleVerifyDocument)
+		r.Get("/api/documents/{docID}/paths", s.handleDocumentPaths)
+		r.Get("/api/documents/{docID}/preview", s.handleDocumentPreview)
+		r.Post("/api/documents/{docID}/tags", s.handleAddDocumentTags)
+		r.Delete("/api/documents/{docID}/tags/{tag}", s.handleRemoveDocumentTag)
 	})
 
 	s.router = r
@@ -65,3 +99,34 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"status":"ok"}`))
 }
+
+// handleSetLogLevel changes the process log level without a restart.
+func (s *Server) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	if s.logLevel == nil {
+		jsonErrorWithCode(w, ErrCodeUnavailable, "log level is not adjustable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil || req.Level == "" {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "level is required", http.StatusBadRequest)
+		return
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		jsonErrorWithCode(w, ErrCodeInvalidRequest, "unknown level "+req.Level+" (want debug, info, warn or error)", http.StatusBadRequest)
+		return
+	}
+
+	previous := s.logLevel.Level()
+	s.logLevel.Set(level)
+	s.log.Info("log level changed", "from", previous.String(), "to", level.String())
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"level":    level.String(),
+		"previous": previous.String(),
+	})
+}
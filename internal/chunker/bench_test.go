@@ -0,0 +1,31 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dgallion1/docgest/internal/doctree"
+)
+
+// benchCorpus is roughly 100KB of prose in paragraphs of a few sentences.
+var benchCorpus = func() string {
+	para := strings.Repeat("The committee reviewed the quarterly figures and approved the budget. ", 4) +
+		"Several members asked for a breakdown by region before the next meeting.\n\n"
+	return strings.Repeat(para, 100*1024/len(para)+1)
+}()
+
+func BenchmarkChunkTree_OverlapStrategies(b *testing.B) {
+	tree := &doctree.DocTree{
+		Title:    "Bench",
+		Children: []*doctree.DocNode{{Title: "Body", Text: benchCorpus}},
+	}
+	for _, strategy := range []string{OverlapNone, OverlapWord, OverlapSentence} {
+		b.Run(strategy, func(b *testing.B) {
+			cfg := Config{ChunkSize: 1500, ChunkOverlap: 200, MinChunk: 100, ChunkOverlapStrategy: strategy}
+			b.SetBytes(int64(len(benchCorpus)))
+			for b.Loop() {
+				ChunkTree(tree, cfg)
+			}
+		})
+	}
+}
@@ -2,23 +2,61 @@ package chunker
 
 import (
 	"strings"
+	"unicode/utf8"
 
 	"github.com/dgallion1/docgest/internal/doctree"
 )
 
+// Overlap strategies: how the start of a chunk repeats the end of the
+// previous one.
+const (
+	OverlapNone     = "none"     // no overlap
+	OverlapWord     = "word"     // the last ChunkOverlap tokens' worth of words
+	OverlapSentence = "sentence" // whole trailing sentences up to ChunkOverlap tokens
+)
+
+// Size units: what ChunkSize, ChunkOverlap and MinChunk count.
+const (
+	UnitTokens = "tokens" // EstimateTokens' word-based approximation
+	UnitChars  = "chars"  // runes; suits text where characters ≈ tokens, e.g. Chinese or Japanese
+	UnitWords  = "words"  // whitespace-separated words
+)
+
 // Config controls chunking behavior.
 type Config struct {
-	ChunkSize    int // Target chunk size in tokens.
-	ChunkOverlap int // Overlap between consecutive chunks in tokens.
-	MinChunk     int // Minimum chunk size to emit.
+	ChunkSize    int // Target chunk size in ChunkSizeUnit.
+	ChunkOverlap int // Overlap between consecutive chunks in ChunkSizeUnit.
+	MinChunk     int // Minimum chunk size to emit, in ChunkSizeUnit.
+
+	// ChunkOverlapStrategy is OverlapNone, OverlapWord or OverlapSentence;
+	// empty means OverlapWord.
+	ChunkOverlapStrategy string
+
+	// ChunkSizeUnit is UnitTokens, UnitChars or UnitWords; empty means
+	// UnitTokens.
+	ChunkSizeUnit string
 }
 
 // DefaultConfig returns sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		ChunkSize:    1500,
-		ChunkOverlap: 200,
-		MinChunk:     100,
+		ChunkSize:            1500,
+		ChunkOverlap:         200,
+		MinChunk:             100,
+		ChunkOverlapStrategy: OverlapWord,
+		ChunkSizeUnit:        UnitTokens,
+	}
+}
+
+// countUnits measures text in unit.
+func countUnits(text, unit string) int {
+	switch unit {
+	case UnitChars:
+		return utf8.RuneCountInString(text)
+	case UnitWords:
+		return len(strings.Fields(text))
+	default:
+		return EstimateTokens(text)
 	}
 }
 
@@ -53,36 +91,36 @@ func walkNode(node *doctree.DocNode, breadcrumb []string, cfg Config, chunks *[]
 		bc = append(bc, node.Title)
 	}
 
-	// If this node has text, chunk it.
+	// If this node has text, chunk it. Form feeds in the text mark page
+	// breaks, so each chunk gets the pages it actually spans.
 	if node.Text != "" {
-		tokens := EstimateTokens(node.Text)
-		if tokens <= cfg.ChunkSize {
+		paras := pagedParagraphs(node.Text, node.Page)
+		size := countUnits(node.Text, cfg.ChunkSizeUnit)
+		var parts []textPart
+		if size <= cfg.ChunkSize {
 			// Fits in one chunk.
-			if tokens >= cfg.MinChunk {
+			if len(paras) > 0 {
+				parts = []textPart{{
+					text:      strings.ReplaceAll(node.Text, "\f", "\n\n"),
+					pageStart: paras[0].page,
+					pageEnd:   paras[len(paras)-1].page,
+				}}
+			}
+		} else {
+			parts = splitParagraphs(paras, cfg.ChunkSize, cfg.ChunkOverlap, cfg.ChunkOverlapStrategy, cfg.ChunkSizeUnit)
+		}
+		for _, part := range parts {
+			text := NormalizeChunkText(part.text)
+			if countUnits(text, cfg.ChunkSizeUnit) >= cfg.MinChunk {
 				*chunks = append(*chunks, doctree.Chunk{
-					Text:       node.Text,
+					Text:       text,
 					Index:      index,
 					Breadcrumb: copyBreadcrumb(bc),
-					PageStart:  node.Page,
-					PageEnd:    node.Page,
+					PageStart:  part.pageStart,
+					PageEnd:    part.pageEnd,
 				})
 				index++
 			}
-		} else {
-			// Split the text.
-			parts := splitText(node.Text, cfg.ChunkSize, cfg.ChunkOverlap)
-			for _, part := range parts {
-				if EstimateTokens(part) >= cfg.MinChunk {
-					*chunks = append(*chunks, doctree.Chunk{
-						Text:       part,
-						Index:      index,
-						Breadcrumb: copyBreadcrumb(bc),
-						PageStart:  node.Page,
-						PageEnd:    node.Page,
-					})
-					index++
-				}
-			}
 		}
 	}
 
@@ -94,55 +132,106 @@ func walkNode(node *doctree.DocNode, breadcrumb []string, cfg Config, chunks *[]
 	return index
 }
 
-// splitText breaks text into chunks of approximately targetTokens, with overlap.
-func splitText(text string, targetTokens, overlapTokens int) []string {
-	// Split by paragraphs first.
-	paragraphs := splitByParagraphs(text)
+// paragraph is one paragraph of node text and the page it is on.
+type paragraph struct {
+	text string
+	page int
+}
+
+// textPart is one chunk's text and the pages it spans.
+type textPart struct {
+	text      string
+	pageStart int
+	pageEnd   int
+}
 
-	var result []string
+// pagedParagraphs splits text into paragraphs, numbering pages from
+// startPage and advancing at each form feed. Text with page breaks but no
+// start page counts from page 1.
+func pagedParagraphs(text string, startPage int) []paragraph {
+	pages := strings.Split(text, "\f")
+	if startPage == 0 && len(pages) > 1 {
+		startPage = 1
+	}
+	var result []paragraph
+	for i, page := range pages {
+		for _, p := range splitByParagraphs(page) {
+			result = append(result, paragraph{text: p, page: startPage + i})
+		}
+	}
+	return result
+}
+
+// splitText breaks text into chunks of approximately target units, with
+// overlap chosen by strategy.
+func splitText(text string, target, overlap int, strategy, unit string) []string {
+	parts := splitParagraphs(pagedParagraphs(text, 0), target, overlap, strategy, unit)
+	result := make([]string, len(parts))
+	for i, p := range parts {
+		result[i] = p.text
+	}
+	return result
+}
+
+// splitParagraphs packs paragraphs into chunks of approximately target
+// units, with overlap, recording the pages each chunk covers. A chunk that
+// opens with overlap starts on the page the overlap came from.
+func splitParagraphs(paragraphs []paragraph, target, overlapSize int, strategy, unit string) []textPart {
+	var result []textPart
 	var current strings.Builder
-	currentTokens := 0
+	currentSize := 0
+	var pageStart, pageEnd int
+
+	flush := func() {
+		result = append(result, textPart{text: current.String(), pageStart: pageStart, pageEnd: pageEnd})
+	}
 
 	for _, para := range paragraphs {
-		paraTokens := EstimateTokens(para)
+		paraSize := countUnits(para.text, unit)
 
 		// If a single paragraph exceeds the target, split it further.
-		if paraTokens > targetTokens {
+		if paraSize > target {
 			// Flush current buffer.
-			if currentTokens > 0 {
-				result = append(result, current.String())
+			if currentSize > 0 {
+				flush()
 				current.Reset()
-				currentTokens = 0
+				currentSize = 0
 			}
 			// Split the large paragraph by sentences.
-			subParts := splitBySentences(para, targetTokens, overlapTokens)
-			result = append(result, subParts...)
+			for _, sub := range splitBySentences(para.text, target, overlapSize, strategy, unit) {
+				result = append(result, textPart{text: sub, pageStart: para.page, pageEnd: para.page})
+			}
 			continue
 		}
 
 		// Would adding this paragraph exceed the target?
-		if currentTokens+paraTokens > targetTokens && currentTokens > 0 {
-			result = append(result, current.String())
+		if currentSize+paraSize > target && currentSize > 0 {
+			flush()
 
 			// Start next chunk with overlap from end of current.
-			overlap := getOverlapText(current.String(), overlapTokens)
+			overlap := overlapText(current.String(), overlapSize, strategy, unit)
 			current.Reset()
-			currentTokens = 0
+			currentSize = 0
 			if overlap != "" {
 				current.WriteString(overlap)
-				currentTokens = EstimateTokens(overlap)
+				currentSize = countUnits(overlap, unit)
+				pageStart = pageEnd
 			}
 		}
 
+		if currentSize == 0 {
+			pageStart = para.page
+		}
 		if current.Len() > 0 {
 			current.WriteString("\n\n")
 		}
-		current.WriteString(para)
-		currentTokens += paraTokens
+		current.WriteString(para.text)
+		currentSize += paraSize
+		pageEnd = para.page
 	}
 
-	if currentTokens > 0 {
-		result = append(result, current.String())
+	if currentSize > 0 {
+		flush()
 	}
 
 	return result
@@ -162,24 +251,24 @@ func splitByParagraphs(text string) []string {
 }
 
 // splitBySentences breaks a large paragraph into sentence-based chunks.
-func splitBySentences(text string, targetTokens, overlapTokens int) []string {
+func splitBySentences(text string, target, overlapSize int, strategy, unit string) []string {
 	sentences := splitSentences(text)
 
 	var result []string
 	var current strings.Builder
-	currentTokens := 0
+	currentSize := 0
 
 	for _, sent := range sentences {
-		sentTokens := EstimateTokens(sent)
+		sentSize := countUnits(sent, unit)
 
-		if currentTokens+sentTokens > targetTokens && currentTokens > 0 {
+		if currentSize+sentSize > target && currentSize > 0 {
 			result = append(result, current.String())
-			overlap := getOverlapText(current.String(), overlapTokens)
+			overlap := overlapText(current.String(), overlapSize, strategy, unit)
 			current.Reset()
-			currentTokens = 0
+			currentSize = 0
 			if overlap != "" {
 				current.WriteString(overlap)
-				currentTokens = EstimateTokens(overlap)
+				currentSize = countUnits(overlap, unit)
 			}
 		}
 
@@ -187,24 +276,26 @@ func splitBySentences(text string, targetTokens, overlapTokens int) []string {
 			current.WriteString(" ")
 		}
 		current.WriteString(sent)
-		currentTokens += sentTokens
+		currentSize += sentSize
 	}
 
-	if currentTokens > 0 {
+	if currentSize > 0 {
 		result = append(result, current.String())
 	}
 
 	return result
 }
 
-// splitSentences does basic sentence splitting.
+// splitSentences does basic sentence splitting. Full-width CJK
+// terminators end a sentence without a following space.
 func splitSentences(text string) []string {
 	var sentences []string
 	var current strings.Builder
 
 	for i, r := range text {
 		current.WriteRune(r)
-		if (r == '.' || r == '!' || r == '?') && i+1 < len(text) && text[i+1] == ' ' {
+		if (r == '.' || r == '!' || r == '?') && i+1 < len(text) && text[i+1] == ' ' ||
+			r == '。' || r == '！' || r == '？' {
 			sentences = append(sentences, strings.TrimSpace(current.String()))
 			current.Reset()
 		}
@@ -216,11 +307,58 @@ func splitSentences(text string) []string {
 	return sentences
 }
 
-// getOverlapText extracts the last N tokens worth of text for overlap.
-func getOverlapText(text string, targetTokens int) string {
+// overlapText returns the text that opens the chunk after text.
+func overlapText(text string, target int, strategy, unit string) string {
+	switch strategy {
+	case OverlapNone:
+		return ""
+	case OverlapSentence:
+		return getSentenceOverlap(text, target, unit)
+	default:
+		return getOverlapText(text, target, unit)
+	}
+}
+
+// getSentenceOverlap returns the trailing whole sentences of text that fit
+// in target units. When even the last sentence is too long it falls back to
+// word overlap, so long sentences still carry some context forward.
+func getSentenceOverlap(text string, target int, unit string) string {
+	sentences := splitSentences(strings.ReplaceAll(text, "\n\n", " "))
+	if len(sentences) < 2 {
+		return getOverlapText(text, target, unit)
+	}
+	start, size := len(sentences), 0
+	for start > 1 {
+		n := countUnits(sentences[start-1], unit)
+		if size+n > target {
+			break
+		}
+		size += n
+		start--
+	}
+	if start == len(sentences) {
+		return getOverlapText(text, target, unit)
+	}
+	return strings.Join(sentences[start:], " ")
+}
+
+// getOverlapText extracts the last target units worth of text for overlap:
+// whole words for UnitTokens and UnitWords, the trailing runes for
+// UnitChars (which may not be space-separated at all).
+func getOverlapText(text string, target int, unit string) string {
+	if unit == UnitChars {
+		runes := []rune(text)
+		if target <= 0 || len(runes) <= target {
+			return ""
+		}
+		return strings.TrimSpace(string(runes[len(runes)-target:]))
+	}
 	words := strings.Fields(text)
-	// Approximate: 1.33 tokens per word.
-	targetWords := int(float64(targetTokens) / 1.33)
+	targetWords := target
+	if unit != UnitWords {
+		// Approximate: 1.33 tokens per word.
+		targetWords = int(float64(target) / 1.33)
+	}
 	if targetWords <= 0 || len(words) <= targetWords {
 		return ""
 	}
@@ -1,6 +1,7 @@
 package chunker
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -240,3 +241,190 @@ func TestChunkTree_NodeWithNoText(t *testing.T) {
 		}
 	}
 }
+
+func TestChunkTree_PageRangesFromFormFeeds(t *testing.T) {
+	page := func(word string) string { return strings.TrimSpace(strings.Repeat(word+" ", 100)) }
+	tree := &doctree.DocTree{
+		Title: "Report",
+		Children: []*doctree.DocNode{
+			{Text: page("alpha") + "\f" + page("bravo") + "\f" + page("charlie"), Page: 5},
+		},
+	}
+
+	chunks := ChunkTree(tree, Config{ChunkSize: 150, ChunkOverlap: 5, MinChunk: 10})
+
+	// Later chunks open with overlap from the previous page.
+	want := [][2]int{{5, 5}, {5, 6}, {6, 7}}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %d", len(want), len(chunks))
+	}
+	for i, c := range chunks {
+		if c.PageStart != want[i][0] || c.PageEnd != want[i][1] {
+			t.Errorf("chunk %d: expected pages %d-%d, got %d-%d", i, want[i][0], want[i][1], c.PageStart, c.PageEnd)
+		}
+		if strings.Contains(c.Text, "\f") {
+			t.Errorf("chunk %d: form feed leaked into text", i)
+		}
+	}
+}
+
+func TestChunkTree_SingleChunkSpansPages(t *testing.T) {
+	tree := &doctree.DocTree{
+		Children: []*doctree.DocNode{
+			{Text: strings.Repeat("word ", 50) + "\f\f" + strings.Repeat("more ", 50), Page: 2},
+		},
+	}
+
+	chunks := ChunkTree(tree, Config{ChunkSize: 1500, MinChunk: 10})
+	if len(chunks) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(chunks))
+	}
+	if chunks[0].PageStart != 2 || chunks[0].PageEnd != 4 {
+		t.Errorf("expected pages 2-4, got %d-%d", chunks[0].PageStart, chunks[0].PageEnd)
+	}
+	if strings.Contains(chunks[0].Text, "\f") {
+		t.Error("form feed leaked into text")
+	}
+}
+
+// numberedSentences returns n distinct sentences, one paragraph each.
+func numberedSentences(n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "Sentence number %d describes a separate point in plain words.\n\n", i)
+	}
+	return b.String()
+}
+
+func TestChunkTree_OverlapStrategies(t *testing.T) {
+	tree := &doctree.DocTree{
+		Title:    "Overlap",
+		Children: []*doctree.DocNode{{Title: "Section", Text: numberedSentences(60)}},
+	}
+
+	for _, strategy := range []string{OverlapNone, OverlapWord, OverlapSentence} {
+		t.Run(strategy, func(t *testing.T) {
+			chunks := ChunkTree(tree, Config{
+				ChunkSize:            200,
+				ChunkOverlap:         40,
+				MinChunk:             10,
+				ChunkOverlapStrategy: strategy,
+			})
+			if len(chunks) < 2 {
+				t.Fatalf("expected at least 2 chunks, got %d", len(chunks))
+			}
+			first, second := chunks[0].Text, chunks[1].Text
+			lastSentence := first[strings.LastIndex(first, "Sentence number"):]
+			shared := strings.Contains(second, lastSentence)
+
+			switch strategy {
+			case OverlapNone:
+				if shared {
+					t.Errorf("expected no overlap, second chunk repeats %q", lastSentence)
+				}
+			case OverlapWord:
+				if !shared {
+					t.Errorf("expected second chunk to repeat %q", lastSentence)
+				}
+			case OverlapSentence:
+				if !shared {
+					t.Errorf("expected second chunk to repeat %q", lastSentence)
+				}
+				if !strings.HasPrefix(second, "Sentence number") {
+					t.Errorf("expected second chunk to start on a sentence, got %q", second[:40])
+				}
+			}
+		})
+	}
+}
+
+func TestGetSentenceOverlap_LongSentenceFallsBackToWords(t *testing.T) {
+	text := "Short one. " + strings.Repeat("long ", 100) + "end."
+	got := getSentenceOverlap(text, 10, UnitTokens)
+	if got == "" {
+		t.Fatal("expected word overlap, got empty string")
+	}
+	if !strings.HasSuffix(got, "end.") {
+		t.Errorf("expected overlap to end with the text's last word, got %q", got)
+	}
+	if strings.Contains(got, "Short") {
+		t.Errorf("expected only trailing words, got %q", got)
+	}
+}
+
+func TestCountUnits(t *testing.T) {
+	tests := []struct {
+		text string
+		unit string
+		want int
+	}{
+		{"hello big world", UnitTokens, 3},
+		{"hello big world", "", 3},
+		{"hello big world", UnitWords, 3},
+		{"hello big world", UnitChars, 15},
+		{"今天天气很好。", UnitChars, 7},
+		{"今天天气很好。", UnitWords, 1},
+		{"", UnitChars, 0},
+	}
+	for _, tc := range tests {
+		if got := countUnits(tc.text, tc.unit); got != tc.want {
+			t.Errorf("countUnits(%q, %q): expected %d, got %d", tc.text, tc.unit, tc.want, got)
+		}
+	}
+}
+
+func TestChunkTree_CharUnitSplitsCJK(t *testing.T) {
+	// One unspaced paragraph: 40 sentences of 15 runes each. As tokens it
+	// counts as a single word.
+	text := strings.Repeat("今天天气很好，我们去公园散步。", 40)
+	tree := &doctree.DocTree{
+		Title:    "日记",
+		Children: []*doctree.DocNode{{Title: "散步", Text: text}},
+	}
+
+	cfg := Config{ChunkSize: 200, ChunkOverlap: 20, MinChunk: 10, ChunkSizeUnit: UnitChars}
+	chunks := ChunkTree(tree, cfg)
+	if len(chunks) < 3 {
+		t.Fatalf("expected at least 3 chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		// Sentences are rejoined with spaces, which the running size
+		// does not count.
+		if n := countUnits(strings.ReplaceAll(c.Text, " ", ""), UnitChars); n > cfg.ChunkSize {
+			t.Errorf("chunk %d: expected at most %d chars, got %d", i, cfg.ChunkSize, n)
+		}
+	}
+	prev := []rune(chunks[0].Text)
+	overlap := strings.TrimSpace(string(prev[len(prev)-cfg.ChunkOverlap:]))
+	if !strings.HasPrefix(chunks[1].Text, overlap) {
+		t.Errorf("expected chunk 1 to open with %q, got %q", overlap, chunks[1].Text)
+	}
+}
+
+func TestChunkTree_WordUnit(t *testing.T) {
+	var paras []string
+	for i := range 15 {
+		paras = append(paras, fmt.Sprintf("Paragraph %d has", i)+strings.Repeat(" word", 17))
+	}
+	tree := &doctree.DocTree{
+		Title:    "Words",
+		Children: []*doctree.DocNode{{Title: "Body", Text: strings.Join(paras, "\n\n")}},
+	}
+
+	cfg := Config{ChunkSize: 100, ChunkOverlap: 10, MinChunk: 10, ChunkSizeUnit: UnitWords}
+	chunks := ChunkTree(tree, cfg)
+	if len(chunks) < 3 {
+		t.Fatalf("expected at least 3 chunks, got %d", len(chunks))
+	}
+	if n := len(strings.Fields(chunks[0].Text)); n != 100 {
+		t.Errorf("expected first chunk to hold 100 words, got %d", n)
+	}
+	for i, c := range chunks[1:] {
+		if n := len(strings.Fields(c.Text)); n > cfg.ChunkSize {
+			t.Errorf("chunk %d: expected at most %d words, got %d", i+1, cfg.ChunkSize, n)
+		}
+		if !strings.HasPrefix(c.Text, strings.Repeat("word ", 9)+"word\n\nParagraph") {
+			t.Errorf("chunk %d: expected 10 words of overlap, got %q", i+1, c.Text[:60])
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package chunker
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeUnicode applies NFC and drops invisible characters (zero-width
+// spaces and joiners, soft hyphens, byte order marks, directional marks).
+// Whitespace is left alone, so it is safe for code.
+func NormalizeUnicode(s string) string {
+	s = norm.NFC.String(s)
+	return strings.Map(func(r rune) rune {
+		if isInvisible(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// NormalizeChunkText cleans chunk text before it is sent for extraction. It
+// applies NormalizeUnicode, collapses runs of spaces within a line and
+// limits blank lines to one. Line indentation and form feeds (page breaks)
+// are kept, and ``` fenced code blocks are copied verbatim.
+func NormalizeChunkText(s string) string {
+	s = NormalizeUnicode(s)
+
+	var out, prose, code []string
+	flushProse := func() {
+		if p := collapseSpace(strings.Join(prose, "\n")); p != "" {
+			out = append(out, p)
+		}
+		prose = prose[:0]
+	}
+	inFence := false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		switch {
+		case inFence:
+			code = append(code, line)
+			if fence {
+				out = append(out, strings.Join(code, "\n"))
+				code, inFence = code[:0], false
+			}
+		case fence:
+			flushProse()
+			code, inFence = append(code, line), true
+		default:
+			prose = append(prose, line)
+		}
+	}
+	if inFence {
+		// An unclosed fence, e.g. a code block split across chunks.
+		out = append(out, strings.TrimRight(strings.Join(code, "\n"), "\n"))
+	}
+	flushProse()
+	return strings.Join(out, "\n\n")
+}
+
+// collapseSpace collapses runs of spaces within a line and limits blank
+// lines to one, keeping indentation and form feeds.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	var indent []rune
+	lineStart := true
+	space := false
+	newlines := 0
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			indent = indent[:0]
+			lineStart, space = true, false
+			newlines++
+			if newlines <= 2 {
+				b.WriteByte('\n')
+			}
+		case r != '\f' && unicode.IsSpace(r):
+			if !lineStart {
+				space = true
+			} else if r == '\t' {
+				indent = append(indent, '\t')
+			} else if r != '\r' {
+				indent = append(indent, ' ')
+			}
+		default:
+			if lineStart {
+				b.WriteString(string(indent))
+				lineStart = false
+			} else if space {
+				b.WriteByte(' ')
+			}
+			space = false
+			newlines = 0
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimLeft(strings.TrimRight(b.String(), "\n"), "\n")
+}
+
+// isInvisible reports whether r renders as nothing and only fragments
+// words: zero-width characters, soft hyphens, BOMs and bidi controls.
+func isInvisible(r rune) bool {
+	switch r {
+	case '\u00AD', // soft hyphen
+		'\u200B', '\u200C', '\u200D', '\u2060', // zero-width space, non-joiner, joiner, word joiner
+		'\uFEFF',           // byte order mark
+		'\u200E', '\u200F': // left-to-right and right-to-left marks
+		return true
+	}
+	return r >= '\u202A' && r <= '\u202E' || r >= '\u2066' && r <= '\u2069' // bidi embeddings and isolates
+}
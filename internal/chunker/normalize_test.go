@@ -0,0 +1,40 @@
+package chunker
+
+import "testing"
+
+func TestNormalizeChunkText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"zero-width space", "Milo\u200Bis a cat", "Milois a cat"},
+		{"soft hyphen", "exam\u00ADple", "example"},
+		{"byte order mark", "\uFEFFHello", "Hello"},
+		{"directional marks", "\u200Eleft\u200F right", "left right"},
+		{"nfc", "cafe\u0301", "caf\u00E9"},
+		{"collapses spaces", "a  b\t\tc   ", "a b c"},
+		{"keeps indentation", "list:\n    indented  line", "list:\n    indented line"},
+		{"limits blank lines", "one\n\n\n\ntwo", "one\n\ntwo"},
+		{"keeps form feeds", "page one\fpage two", "page one\fpage two"},
+		{"crlf", "one\r\ntwo", "one\ntwo"},
+		{"keeps fenced code", "a  b\n\n```go\nx    = 1\n\n\n\nend\n```\n\nc   d", "a b\n\n```go\nx    = 1\n\n\n\nend\n```\n\nc d"},
+		{"unclosed fence", "intro  text\n\n```\nx    = 1\ny    = 2", "intro text\n\n```\nx    = 1\ny    = 2"},
+		{"invisible in code", "```\nx\u200B = 1\n```", "```\nx = 1\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeChunkText(tt.in); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeUnicode_KeepsWhitespace(t *testing.T) {
+	in := "x    = 1\u200B\n\n\n\ncafe\u0301  end"
+	want := "x    = 1\n\n\n\ncaf\u00E9  end"
+	if got := NormalizeUnicode(in); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
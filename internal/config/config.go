@@ -2,18 +2,51 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
 	Port string
 
+	// HTTP server limits
+	HTTPReadTimeoutSecs  int
+	HTTPWriteTimeoutSecs int
+	HTTPIdleTimeoutSecs  int
+	HTTPMaxHeaderBytes   int
+
+	// Log output: "json" (default) or "text", and the starting level
+	// ("debug", "info", "warn" or "error")
+	LogFormat string
+	LogLevel  string
+
+	// Requests slower than this log at Warn and other routine requests at
+	// Debug; 0 logs every request at Info
+	SlowRequestThresholdMs int
+
+	// Storage backend: "pathstore" (default) or "redis"
+	StorageBackend string
+
 	// Pathstore connection
 	PathstoreURL    string
 	PathstoreAPIKey string
 
+	// Redis connection, used when StorageBackend is "redis"
+	RedisURL       string
+	RedisKeyPrefix string
+
+	// Per-attempt pathstore deadlines
+	PathstorePutTimeout  time.Duration
+	PathstoreReadTimeout time.Duration
+
+	// In-process GetNode cache in front of the store
+	PathstoreCacheEnabled bool
+	PathstoreCacheTTL     time.Duration
+
 	// Auth
 	DocgestAPIKey string
 
@@ -21,65 +54,330 @@ type Config struct {
 	AnthropicAPIKey string
 	AnthropicModel  string
 
-	// Worker pool
+	// Follow-up turns asking for JSON when a response is not JSON
+	MaxJSONRecoveryAttempts int
+
+	// Sampling parameters for extraction requests; nil (unset) uses the
+	// API default
+	LLMTemperature *float64
+	LLMTopP        *float64
+
+	// Response length cap and stop sequences for extraction requests
+	LLMMaxTokens     int
+	LLMStopSequences []string
+
+	// Link facts sharing an entity, or a topic within a chunk, after storage
+	CreateCrossFactLinks bool
+
+	// Rescale salience per category within each document
+	NormalizeSalience bool
+
+	// Drop near-duplicate facts within a document (same category and
+	// entity) whose estimated text similarity reaches this threshold;
+	// 0 (the default) disables.
+	FactDedupThreshold float64
+
+	// Store each fact at a path hashed from its text and document, and skip
+	// it if that path is already written
+	DuplicateFactCheck bool
+
+	// Joins the levels of a fact's dotted entity_path when building its
+	// storage path, e.g. entities/acme/engineering/alice/facts
+	EntityPathSeparator string
+
+	// Per-category pathstore merge mode overrides, e.g. "topic_knowledge=merge"
+	CategoryMergeModes map[string]string
+
+	// Per-category default salience overrides; 0 keeps the built-in default
+	SalienceEntityFact     float64
+	SaliencePreference     float64
+	SalienceTopicKnowledge float64
+	SalienceProcedure      float64
+
+	// Salience multiplier per job source type, e.g. "note=0.7,web_page=0.6".
+	// Types left out of SOURCE_TYPE_MULTIPLIERS keep their default.
+	SourceTypeMultiplier map[string]float64
+
+	// Per-category path template overrides; empty keeps the built-in template
+	CategoryPathEntityFact     string
+	CategoryPathPreference     string
+	CategoryPathTopicKnowledge string
+	CategoryPathProcedure      string
+
+	// Extraction prompt versions loaded from PromptDir; empty PromptVersion
+	// uses the embedded prompt. PromptABTestRatio of jobs use version B.
+	PromptVersion     string
+	PromptDir         string
+	PromptABTestRatio float64
+
+	// Domain vocabulary added to every extraction prompt, e.g. which terms
+	// count as entities in medical records
+	PromptDomainContext string
+
+	// Optional JSON file overriding fact validation rules
+	ValidationRulesFile string
+
+	// Extraction audit trail
+	AuditExtractions    bool
+	ExtractionAuditFile string
+
+	// Worker pool. MaxWorkerCount 0 disables autoscaling; unset defaults
+	// to 2*WorkerCount.
 	WorkerCount          int
+	MinWorkerCount       int
+	MaxWorkerCount       int
 	MaxQueueSize         int
 	MaxConcurrentExtract int
 	MaxConcurrentStore   int
 
-	// Upload limits
-	MaxUploadBytes int64
+	// How often each worker writes its heartbeat node to pathstore for
+	// external monitoring; zero disables heartbeats.
+	WorkerHeartbeatInterval time.Duration
+
+	// What Submit does when the queue is full: "reject" (default), "block"
+	// for up to QueueBlockTimeout, or "drop_oldest" to evict the oldest
+	// queued job.
+	QueueOverflowBehavior string
+	QueueBlockTimeout     time.Duration
+
+	// Extraction retries shared by all chunks of one job
+	RetryBudgetPerJob int
+
+	// Per-phase job deadlines; ExtractTimeout applies to each chunk
+	ParseTimeout   time.Duration
+	ChunkTimeout   time.Duration
+	ExtractTimeout time.Duration
+	StoreTimeout   time.Duration
+
+	// Pathstore write retry
+	MaxStoreRetries       int
+	StoreRetryBackoffBase time.Duration
+
+	// Upload limits. MaxUploadBytesByExtension overrides MaxUploadBytes for
+	// the listed extensions (".pdf", ".csv", ".docx").
+	MaxUploadBytes            int64
+	MaxUploadBytesByExtension map[string]int64
 
 	// Chunking defaults
 	DefaultChunkSize    int
 	DefaultChunkOverlap int
 
+	// How chunk overlap is chosen: "none", "word" or "sentence".
+	DefaultChunkOverlapStrategy string
+
+	// What chunk sizes and overlaps count: "tokens", "chars" or "words".
+	DefaultChunkSizeUnit string
+
 	// Job state
 	JobTTL time.Duration
 
+	// Finished jobs beyond this count are evicted oldest-first before JobTTL.
+	MaxJobStoreSize int
+
+	// Soft-deleted documents can be restored until this elapses.
+	SoftDeleteTTL time.Duration
+
 	// PDF
 	PDFFallbackPdftotext bool
+
+	// HTML: parse only the highest-scoring content block
+	HTMLUseReadability bool
+
+	// DOCX tracked changes: "final" (accepted), "original" (rejected) or "both"
+	DocxRevisionMode string
+
+	// CSV: data rows per document node
+	CSVBatchSize int
 }
 
 func Load() Config {
 	cfg := Config{
 		Port: envOr("PORT", "8090"),
 
+		HTTPReadTimeoutSecs:  envInt("HTTP_READ_TIMEOUT_SECS", 30),
+		HTTPWriteTimeoutSecs: envInt("HTTP_WRITE_TIMEOUT_SECS", 120),
+		HTTPIdleTimeoutSecs:  envInt("HTTP_IDLE_TIMEOUT_SECS", 60),
+		HTTPMaxHeaderBytes:   envInt("HTTP_MAX_HEADER_BYTES", 1<<20),
+
+		LogFormat: envOr("LOG_FORMAT", "json"),
+		LogLevel:  envOr("LOG_LEVEL", "info"),
+
+		SlowRequestThresholdMs: envInt("SLOW_REQUEST_THRESHOLD_MS", 0),
+
+		StorageBackend: envOr("STORAGE_BACKEND", "pathstore"),
+
 		PathstoreURL:    envOr("PATHSTORE_URL", "http://localhost:8080"),
 		PathstoreAPIKey: os.Getenv("PATHSTORE_API_KEY"),
 
+		RedisURL:       envOr("REDIS_URL", "redis://localhost:6379/0"),
+		RedisKeyPrefix: envOr("REDIS_KEY_PREFIX", "docgest:"),
+
+		PathstorePutTimeout:  envDuration("PATHSTORE_PUT_TIMEOUT", 10*time.Second),
+		PathstoreReadTimeout: envDuration("PATHSTORE_READ_TIMEOUT", 5*time.Second),
+
+		PathstoreCacheEnabled: envBool("PATHSTORE_CACHE_ENABLED", false),
+		PathstoreCacheTTL:     envDuration("PATHSTORE_CACHE_TTL", 30*time.Second),
+
 		DocgestAPIKey: os.Getenv("DOCGEST_API_KEY"),
 
 		AnthropicAPIKey: os.Getenv("ANTHROPIC_API_KEY"),
 		AnthropicModel:  envOr("ANTHROPIC_MODEL", "claude-sonnet-4-5-20250929"),
 
+		MaxJSONRecoveryAttempts: envInt("MAX_JSON_RECOVERY_ATTEMPTS", 1),
+
+		LLMTemperature: envOptionalFloat("LLM_TEMPERATURE"),
+		LLMTopP:        envOptionalFloat("LLM_TOP_P"),
+
+		LLMMaxTokens:     envInt("LLM_MAX_TOKENS", 4096),
+		LLMStopSequences: envList("LLM_STOP_SEQUENCES", []string{"\n\n---"}),
+
+		CreateCrossFactLinks: envBool("CREATE_CROSS_FACT_LINKS", true),
+		NormalizeSalience:    envBool("NORMALIZE_SALIENCE", false),
+		FactDedupThreshold:   envFloat("FACT_DEDUP_THRESHOLD", 0),
+		DuplicateFactCheck:   envBool("DUPLICATE_FACT_CHECK", false),
+		EntityPathSeparator:  envOr("ENTITY_PATH_SEPARATOR", "/"),
+		CategoryMergeModes:   envMap("CATEGORY_MERGE_MODES"),
+
+		SalienceEntityFact:     envFloat("SALIENCE_ENTITY_FACT", 0),
+		SaliencePreference:     envFloat("SALIENCE_PREFERENCE", 0),
+		SalienceTopicKnowledge: envFloat("SALIENCE_TOPIC_KNOWLEDGE", 0),
+		SalienceProcedure:      envFloat("SALIENCE_PROCEDURE", 0),
+
+		SourceTypeMultiplier: envFloatMap("SOURCE_TYPE_MULTIPLIERS", map[string]float64{
+			"document":  1.0,
+			"note":      0.7,
+			"web_page":  0.6,
+			"feed_item": 0.6,
+		}),
+
+		CategoryPathEntityFact:     os.Getenv("CATEGORY_PATH_ENTITY_FACT"),
+		CategoryPathPreference:     os.Getenv("CATEGORY_PATH_PREFERENCE"),
+		CategoryPathTopicKnowledge: os.Getenv("CATEGORY_PATH_TOPIC_KNOWLEDGE"),
+		CategoryPathProcedure:      os.Getenv("CATEGORY_PATH_PROCEDURE"),
+
+		PromptVersion:     os.Getenv("PROMPT_VERSION"),
+		PromptDir:         envOr("PROMPT_DIR", "/etc/docgest/prompts"),
+		PromptABTestRatio: envFloat("PROMPT_AB_TEST_RATIO", 0),
+
+		PromptDomainContext: strings.TrimSpace(os.Getenv("PROMPT_DOMAIN_CONTEXT")),
+
+		ValidationRulesFile: os.Getenv("VALIDATION_RULES_FILE"),
+
+		AuditExtractions:    envBool("AUDIT_EXTRACTIONS", false),
+		ExtractionAuditFile: envOr("EXTRACTION_AUDIT_FILE", "extraction_audit.jsonl"),
+
 		WorkerCount:          envInt("WORKER_COUNT", 4),
+		MinWorkerCount:       envInt("MIN_WORKER_COUNT", 0),
+		MaxWorkerCount:       envInt("MAX_WORKER_COUNT", -1),
 		MaxQueueSize:         envInt("MAX_QUEUE_SIZE", 100),
 		MaxConcurrentExtract: envInt("MAX_CONCURRENT_EXTRACT", 5),
 		MaxConcurrentStore:   envInt("MAX_CONCURRENT_STORE", 10),
+		RetryBudgetPerJob:    envInt("RETRY_BUDGET_PER_JOB", 10),
+
+		WorkerHeartbeatInterval: envDuration("WORKER_HEARTBEAT_INTERVAL", 30*time.Second),
+
+		QueueOverflowBehavior: envOr("QUEUE_OVERFLOW_BEHAVIOR", "reject"),
+		QueueBlockTimeout:     envDuration("QUEUE_BLOCK_TIMEOUT", 10*time.Second),
+
+		ParseTimeout:   envDuration("PARSE_TIMEOUT", 2*time.Minute),
+		ChunkTimeout:   envDuration("CHUNK_TIMEOUT", 1*time.Minute),
+		ExtractTimeout: envDuration("EXTRACT_TIMEOUT", 5*time.Minute),
+		StoreTimeout:   envDuration("STORE_TIMEOUT", 10*time.Minute),
+
+		MaxStoreRetries:       envInt("MAX_STORE_RETRIES", 3),
+		StoreRetryBackoffBase: envDuration("STORE_RETRY_BACKOFF_BASE", 500*time.Millisecond),
 
 		MaxUploadBytes: envInt64("MAX_UPLOAD_BYTES", 52428800), // 50MB
+		MaxUploadBytesByExtension: envUploadLimits(map[string]string{
+			".pdf":  "MAX_UPLOAD_BYTES_PDF",
+			".csv":  "MAX_UPLOAD_BYTES_CSV",
+			".docx": "MAX_UPLOAD_BYTES_DOCX",
+		}),
 
 		DefaultChunkSize:    envInt("DEFAULT_CHUNK_SIZE", 1500),
 		DefaultChunkOverlap: envInt("DEFAULT_CHUNK_OVERLAP", 200),
 
-		JobTTL: envDuration("JOB_TTL", 1*time.Hour),
+		DefaultChunkOverlapStrategy: envOr("DEFAULT_CHUNK_OVERLAP_STRATEGY", "word"),
+		DefaultChunkSizeUnit:        envOr("DEFAULT_CHUNK_SIZE_UNIT", "tokens"),
+
+		JobTTL:          envDuration("JOB_TTL", 1*time.Hour),
+		MaxJobStoreSize: envInt("MAX_JOB_STORE_SIZE", 10000),
+
+		SoftDeleteTTL: envDuration("SOFT_DELETE_TTL", 30*24*time.Hour),
 
 		PDFFallbackPdftotext: envBool("PDF_FALLBACK_PDFTOTEXT", true),
+		HTMLUseReadability:   envBool("HTML_USE_READABILITY", false),
+		DocxRevisionMode:     envOr("DOCX_REVISION_MODE", "final"),
+		CSVBatchSize:         envInt("CSV_BATCH_SIZE", 20),
 	}
 
+	if cfg.HTTPReadTimeoutSecs <= 0 {
+		cfg.HTTPReadTimeoutSecs = 30
+	}
+	if cfg.HTTPWriteTimeoutSecs <= 0 {
+		cfg.HTTPWriteTimeoutSecs = 120
+	}
+	if cfg.HTTPIdleTimeoutSecs <= 0 {
+		cfg.HTTPIdleTimeoutSecs = 60
+	}
+	if cfg.HTTPMaxHeaderBytes <= 0 {
+		cfg.HTTPMaxHeaderBytes = 1 << 20
+	}
 	if cfg.WorkerCount <= 0 {
 		cfg.WorkerCount = 4
 	}
+	// Autoscaling bounds default to [WorkerCount, 2*WorkerCount];
+	// MAX_WORKER_COUNT=0 pins the pool at WorkerCount.
+	if cfg.MinWorkerCount <= 0 || cfg.MinWorkerCount > cfg.WorkerCount {
+		cfg.MinWorkerCount = cfg.WorkerCount
+	}
+	if cfg.MaxWorkerCount == 0 {
+		cfg.MinWorkerCount, cfg.MaxWorkerCount = cfg.WorkerCount, cfg.WorkerCount
+	} else if cfg.MaxWorkerCount < cfg.WorkerCount {
+		cfg.MaxWorkerCount = cfg.WorkerCount * 2
+	}
 	if cfg.MaxQueueSize <= 0 {
 		cfg.MaxQueueSize = 100
 	}
+	if cfg.MaxJSONRecoveryAttempts < 0 {
+		cfg.MaxJSONRecoveryAttempts = 1
+	}
+	if cfg.QueueBlockTimeout <= 0 {
+		cfg.QueueBlockTimeout = 10 * time.Second
+	}
 	if cfg.MaxConcurrentExtract <= 0 {
 		cfg.MaxConcurrentExtract = 5
 	}
 	if cfg.MaxConcurrentStore <= 0 {
 		cfg.MaxConcurrentStore = 10
 	}
+	if cfg.RetryBudgetPerJob <= 0 {
+		cfg.RetryBudgetPerJob = 10
+	}
+	if cfg.PathstorePutTimeout <= 0 {
+		cfg.PathstorePutTimeout = 10 * time.Second
+	}
+	if cfg.PathstoreReadTimeout <= 0 {
+		cfg.PathstoreReadTimeout = 5 * time.Second
+	}
+	if cfg.ParseTimeout <= 0 {
+		cfg.ParseTimeout = 2 * time.Minute
+	}
+	if cfg.ChunkTimeout <= 0 {
+		cfg.ChunkTimeout = 1 * time.Minute
+	}
+	if cfg.ExtractTimeout <= 0 {
+		cfg.ExtractTimeout = 5 * time.Minute
+	}
+	if cfg.StoreTimeout <= 0 {
+		cfg.StoreTimeout = 10 * time.Minute
+	}
+	if cfg.MaxStoreRetries < 0 {
+		cfg.MaxStoreRetries = 3
+	}
+	if cfg.StoreRetryBackoffBase <= 0 {
+		cfg.StoreRetryBackoffBase = 500 * time.Millisecond
+	}
 	if cfg.MaxUploadBytes <= 0 {
 		cfg.MaxUploadBytes = 52428800
 	}
@@ -92,13 +390,145 @@ func Load() Config {
 	if cfg.JobTTL <= 0 {
 		cfg.JobTTL = 1 * time.Hour
 	}
+	if cfg.MaxJobStoreSize <= 0 {
+		cfg.MaxJobStoreSize = 10000
+	}
+	if cfg.PromptABTestRatio < 0 {
+		cfg.PromptABTestRatio = 0
+	}
+	if cfg.PromptABTestRatio > 1 {
+		cfg.PromptABTestRatio = 1
+	}
+	if cfg.FactDedupThreshold < 0 {
+		cfg.FactDedupThreshold = 0
+	}
+	if cfg.FactDedupThreshold > 1 {
+		cfg.FactDedupThreshold = 1
+	}
+	if cfg.SoftDeleteTTL <= 0 {
+		cfg.SoftDeleteTTL = 30 * 24 * time.Hour
+	}
 
 	return cfg
 }
 
+// UploadLimit returns the maximum upload size for filename: its
+// extension's override if one is set, otherwise MaxUploadBytes.
+func (c Config) UploadLimit(filename string) int64 {
+	if n, ok := c.MaxUploadBytesByExtension[strings.ToLower(filepath.Ext(filename))]; ok {
+		return n
+	}
+	return c.MaxUploadBytes
+}
+
+// MaxUploadLimit returns the largest upload any extension may send, which
+// bounds a request body before its filename is known.
+func (c Config) MaxUploadLimit() int64 {
+	limit := c.MaxUploadBytes
+	for _, n := range c.MaxUploadBytesByExtension {
+		limit = max(limit, n)
+	}
+	return limit
+}
+
+// CategorySalience returns the configured salience overrides keyed by
+// category, omitting categories left at their built-in default.
+func (c Config) CategorySalience() map[string]float64 {
+	m := make(map[string]float64)
+	for cat, v := range map[string]float64{
+		"entity_fact":     c.SalienceEntityFact,
+		"preference":      c.SaliencePreference,
+		"topic_knowledge": c.SalienceTopicKnowledge,
+		"procedure":       c.SalienceProcedure,
+	} {
+		if v != 0 {
+			m[cat] = v
+		}
+	}
+	return m
+}
+
+// CategoryPathTemplates returns the configured path template overrides
+// keyed by category, omitting categories left at their built-in template.
+func (c Config) CategoryPathTemplates() map[string]string {
+	m := make(map[string]string)
+	for cat, v := range map[string]string{
+		"entity_fact":     c.CategoryPathEntityFact,
+		"preference":      c.CategoryPathPreference,
+		"topic_knowledge": c.CategoryPathTopicKnowledge,
+		"procedure":       c.CategoryPathProcedure,
+	} {
+		if v != "" {
+			m[cat] = v
+		}
+	}
+	return m
+}
+
+// SlogLevel parses LogLevel.
+func (c Config) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return 0, fmt.Errorf("unknown LOG_LEVEL %q (want debug, info, warn or error)", c.LogLevel)
+	}
+	return level, nil
+}
+
 func (c Config) Validate() error {
-	if c.PathstoreAPIKey == "" {
-		return fmt.Errorf("PATHSTORE_API_KEY is required")
+	switch c.StorageBackend {
+	case "pathstore":
+		if c.PathstoreAPIKey == "" {
+			return fmt.Errorf("PATHSTORE_API_KEY is required")
+		}
+	case "redis":
+		if c.RedisURL == "" {
+			return fmt.Errorf("REDIS_URL is required")
+		}
+	default:
+		return fmt.Errorf("unknown STORAGE_BACKEND %q (want pathstore or redis)", c.StorageBackend)
+	}
+	switch c.LogFormat {
+	case "json", "text":
+	default:
+		return fmt.Errorf("unknown LOG_FORMAT %q (want json or text)", c.LogFormat)
+	}
+	if _, err := c.SlogLevel(); err != nil {
+		return err
+	}
+	switch c.QueueOverflowBehavior {
+	case "reject", "block", "drop_oldest":
+	default:
+		return fmt.Errorf("unknown QUEUE_OVERFLOW_BEHAVIOR %q (want reject, block or drop_oldest)", c.QueueOverflowBehavior)
+	}
+	switch c.DefaultChunkOverlapStrategy {
+	case "", "none", "word", "sentence":
+	default:
+		return fmt.Errorf("unknown DEFAULT_CHUNK_OVERLAP_STRATEGY %q (want none, word or sentence)", c.DefaultChunkOverlapStrategy)
+	}
+	switch c.DefaultChunkSizeUnit {
+	case "", "tokens", "chars", "words":
+	default:
+		return fmt.Errorf("unknown DEFAULT_CHUNK_SIZE_UNIT %q (want tokens, chars or words)", c.DefaultChunkSizeUnit)
+	}
+	switch c.DocxRevisionMode {
+	case "final", "original", "both":
+	default:
+		return fmt.Errorf("unknown DOCX_REVISION_MODE %q (want final, original or both)", c.DocxRevisionMode)
+	}
+	if c.CSVBatchSize < 1 {
+		return fmt.Errorf("CSV_BATCH_SIZE %d out of range (want at least 1)", c.CSVBatchSize)
+	}
+	if t := c.LLMTemperature; t != nil && (*t < 0 || *t > 1) {
+		return fmt.Errorf("LLM_TEMPERATURE %v out of range (want 0 to 1)", *t)
+	}
+	if p := c.LLMTopP; p != nil && (*p < 0 || *p > 1) {
+		return fmt.Errorf("LLM_TOP_P %v out of range (want 0 to 1)", *p)
+	}
+	if c.EntityPathSeparator == "" {
+		return fmt.Errorf("ENTITY_PATH_SEPARATOR must not be empty")
+	}
+	if c.LLMMaxTokens <= 0 {
+		return fmt.Errorf("LLM_MAX_TOKENS must be positive, got %d", c.LLMMaxTokens)
 	}
 	if c.DocgestAPIKey == "" {
 		return fmt.Errorf("DOCGEST_API_KEY is required")
@@ -134,6 +564,26 @@ func envInt64(key string, fallback int64) int64 {
 	return fallback
 }
 
+func envFloat(key string, fallback float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
+
+// envOptionalFloat returns nil when key is unset or unparseable, so an
+// explicit 0 can be told apart from no value.
+func envOptionalFloat(key string) *float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return &f
+		}
+	}
+	return nil
+}
+
 func envBool(key string, fallback bool) bool {
 	if v := os.Getenv(key); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
@@ -151,3 +601,65 @@ func envDuration(key string, fallback time.Duration) time.Duration {
 	}
 	return fallback
 }
+
+// envFloatMap overlays "k1=0.5,k2=1.2" onto a copy of defaults. Pairs that
+// don't parse as a positive float are skipped.
+func envFloatMap(key string, defaults map[string]float64) map[string]float64 {
+	m := make(map[string]float64, len(defaults))
+	for k, v := range defaults {
+		m[k] = v
+	}
+	for k, v := range envMap(key) {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			m[k] = f
+		}
+	}
+	return m
+}
+
+// envList parses a comma-separated list, turning a literal \n into a
+// newline so values like "\n\n---" can be written in an env file. Empty
+// items are skipped.
+func envList(key string, fallback []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		item = strings.ReplaceAll(strings.TrimSpace(item), `\n`, "\n")
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envUploadLimits reads a per-extension upload limit from each env var in
+// vars (extension -> key). Unset or non-positive values are omitted.
+func envUploadLimits(vars map[string]string) map[string]int64 {
+	limits := make(map[string]int64)
+	for ext, key := range vars {
+		if n := envInt64(key, 0); n > 0 {
+			limits[ext] = n
+		}
+	}
+	return limits
+}
+
+// envMap parses "k1=v1,k2=v2" into a map. Malformed pairs are skipped.
+func envMap(key string) map[string]string {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	m := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		k, val, ok := strings.Cut(pair, "=")
+		k, val = strings.TrimSpace(k), strings.TrimSpace(val)
+		if ok && k != "" && val != "" {
+			m[k] = val
+		}
+	}
+	return m
+}
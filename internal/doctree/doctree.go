@@ -1,5 +1,10 @@
 package doctree
 
+import (
+	"strings"
+	"unicode"
+)
+
 // DocTree is the root of a parsed document.
 type DocTree struct {
 	Title    string     // Document title (from metadata or filename)
@@ -9,9 +14,14 @@ type DocTree struct {
 // DocNode is a recursive section in the document tree.
 type DocNode struct {
 	Title    string     // Section heading (empty for leaf text)
+	Level    int        // Heading level, 1 for a top-level heading (0 if not a heading)
 	Text     string     // Text content of this node (may be empty for container nodes)
 	Page     int        // Source page/line (0 if N/A)
 	Children []*DocNode // Subsections
+
+	// Meta holds source metadata such as a feed item's publication date
+	// (nil if none).
+	Meta map[string]string
 }
 
 // Chunk is a sized text segment with structural context, ready for extraction.
@@ -22,3 +32,127 @@ type Chunk struct {
 	PageStart  int
 	PageEnd    int
 }
+
+// DiffDocTree compares two versions of a document section by section. A
+// section is a node with text, identified by its heading path and its text,
+// so an edited, renamed or moved section is reported as removed from
+// oldTree and added in newTree. Nodes without text only group other nodes
+// and are not compared. added and unchanged hold nodes of newTree; removed
+// holds nodes of oldTree. Each list is in document order.
+func DiffDocTree(oldTree, newTree *DocTree) (added, removed, unchanged []*DocNode) {
+	type section struct {
+		key  string
+		node *DocNode
+	}
+	sections := func(tree *DocTree) []section {
+		var out []section
+		if tree == nil {
+			return out
+		}
+		var walk func(nodes []*DocNode, breadcrumb []string)
+		walk = func(nodes []*DocNode, breadcrumb []string) {
+			for _, n := range nodes {
+				bc := breadcrumb
+				if n.Title != "" {
+					bc = append(bc[:len(bc):len(bc)], n.Title)
+				}
+				if n.Text != "" {
+					out = append(out, section{key: strings.Join(bc, "\x1f") + "\x00" + n.Text, node: n})
+				}
+				walk(n.Children, bc)
+			}
+		}
+		walk(tree.Children, nil)
+		return out
+	}
+
+	// Count old sections so repeated identical sections match one for one.
+	remaining := make(map[string]int)
+	for _, s := range sections(oldTree) {
+		remaining[s.key]++
+	}
+	matched := make(map[string]int)
+	for _, s := range sections(newTree) {
+		if remaining[s.key] > 0 {
+			remaining[s.key]--
+			matched[s.key]++
+			unchanged = append(unchanged, s.node)
+		} else {
+			added = append(added, s.node)
+		}
+	}
+	for _, s := range sections(oldTree) {
+		if matched[s.key] > 0 {
+			matched[s.key]--
+			continue
+		}
+		removed = append(removed, s.node)
+	}
+	return added, removed, unchanged
+}
+
+// maxInferredTitle caps a title taken from body text, in runes.
+const maxInferredTitle = 80
+
+// InferTitle picks a readable title for a document whose title is just its
+// filename: the first level-1 heading, else the first sentence of the first
+// text node (truncated to 80 characters), else tree.Title unchanged.
+func InferTitle(tree *DocTree) string {
+	if h := firstNode(tree.Children, func(n *DocNode) bool {
+		return n.Level == 1 && strings.TrimSpace(n.Title) != ""
+	}); h != nil {
+		return strings.TrimSpace(h.Title)
+	}
+	if n := firstNode(tree.Children, func(n *DocNode) bool {
+		return strings.TrimSpace(n.Text) != ""
+	}); n != nil {
+		return truncateTitle(firstSentence(n.Text))
+	}
+	return tree.Title
+}
+
+// firstNode returns the first node in document order matching match.
+func firstNode(nodes []*DocNode, match func(*DocNode) bool) *DocNode {
+	for _, n := range nodes {
+		if match(n) {
+			return n
+		}
+		if found := firstNode(n.Children, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// firstSentence returns text up to the first sentence-ending punctuation
+// followed by whitespace, or the first line if that comes sooner.
+func firstSentence(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	for i, r := range text {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		rest := text[i+1:]
+		if rest == "" || unicode.IsSpace(rune(rest[0])) {
+			return strings.TrimSpace(text[:i+1])
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// truncateTitle shortens s to maxInferredTitle runes, cutting at the last
+// word boundary when there is one.
+func truncateTitle(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxInferredTitle {
+		return s
+	}
+	cut := string(runes[:maxInferredTitle])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "..."
+}
@@ -0,0 +1,122 @@
+package doctree
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInferTitle(t *testing.T) {
+	long := strings.Repeat("word ", 30) + "end."
+	tests := []struct {
+		name string
+		tree *DocTree
+		want string
+	}{
+		{
+			name: "first h1",
+			tree: &DocTree{Title: "document_12345", Children: []*DocNode{
+				{Title: "Page 1", Text: "Intro text."},
+				{Title: "Quarterly Report", Level: 1, Children: []*DocNode{{Title: "Summary", Level: 1}}},
+			}},
+			want: "Quarterly Report",
+		},
+		{
+			name: "nested h1",
+			tree: &DocTree{Title: "x", Children: []*DocNode{
+				{Children: []*DocNode{{Title: "Deep Title", Level: 1}}},
+			}},
+			want: "Deep Title",
+		},
+		{
+			name: "first sentence",
+			tree: &DocTree{Title: "document_12345", Children: []*DocNode{
+				{Title: "Page 1", Page: 1, Text: "Revenue grew 4.5% in Q3. Costs were flat."},
+			}},
+			want: "Revenue grew 4.5% in Q3.",
+		},
+		{
+			name: "first line",
+			tree: &DocTree{Title: "scan", Children: []*DocNode{{Text: "ACME CORP\nInvoice 42"}}},
+			want: "ACME CORP",
+		},
+		{
+			name: "truncated",
+			tree: &DocTree{Title: "x", Children: []*DocNode{{Text: long}}},
+			want: strings.TrimSpace(strings.Repeat("word ", 16)) + "...",
+		},
+		{
+			name: "no content",
+			tree: &DocTree{Title: "document_12345", Children: []*DocNode{{Title: "Empty", Text: "  "}}},
+			want: "document_12345",
+		},
+	}
+	for _, tt := range tests {
+		if got := InferTitle(tt.tree); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestDiffDocTree(t *testing.T) {
+	old := NewTree("Doc").
+		Section("Intro", "Welcome.").
+		Section("Setup", "Install it.").
+		SubSection("Linux", "Use apt.").
+		Section("FAQ", "None yet.").
+		Build()
+	updated := NewTree("Doc").
+		Section("Intro", "Welcome.").
+		Section("Setup", "Install it.").
+		SubSection("Linux", "Use apt or dnf.").
+		Section("Support", "Email us.").
+		Build()
+
+	added, removed, unchanged := DiffDocTree(old, updated)
+	titles := func(nodes []*DocNode) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.Title)
+		}
+		return out
+	}
+	if got := titles(added); !slices.Equal(got, []string{"Linux", "Support"}) {
+		t.Errorf("expected Linux and Support added, got %v", got)
+	}
+	if got := titles(removed); !slices.Equal(got, []string{"Linux", "FAQ"}) {
+		t.Errorf("expected Linux and FAQ removed, got %v", got)
+	}
+	if got := titles(unchanged); !slices.Equal(got, []string{"Intro", "Setup"}) {
+		t.Errorf("expected Intro and Setup unchanged, got %v", got)
+	}
+	if removed[0].Text != "Use apt." {
+		t.Errorf("expected removed nodes from the old tree, got %q", removed[0].Text)
+	}
+}
+
+func TestDiffDocTree_MovedSectionAndNilOld(t *testing.T) {
+	old := NewTree("Doc").Section("A", "same text").Build()
+	moved := NewTree("Doc").Section("B", "x").SubSection("A", "same text").Build()
+
+	added, removed, unchanged := DiffDocTree(old, moved)
+	if len(added) != 2 || len(removed) != 1 || len(unchanged) != 0 {
+		t.Errorf("expected a moved section to be removed and re-added, got %d added %d removed %d unchanged",
+			len(added), len(removed), len(unchanged))
+	}
+
+	added, removed, _ = DiffDocTree(nil, old)
+	if len(added) != 1 || len(removed) != 0 {
+		t.Errorf("expected everything added against a nil tree, got %d added %d removed", len(added), len(removed))
+	}
+}
+
+func TestDiffDocTree_RepeatedSections(t *testing.T) {
+	old := NewTree("Doc").Section("Note", "Same.").Build()
+	updated := NewTree("Doc").Section("Note", "Same.").Section("Note", "Same.").Build()
+
+	added, removed, unchanged := DiffDocTree(old, updated)
+	if len(added) != 1 || len(removed) != 0 || len(unchanged) != 1 {
+		t.Errorf("expected 1 added and 1 unchanged, got %d added %d removed %d unchanged",
+			len(added), len(removed), len(unchanged))
+	}
+}
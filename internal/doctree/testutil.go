@@ -0,0 +1,125 @@
+package doctree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TreeBuilder assembles DocTree fixtures for tests. Section starts a new
+// top-level node; SubSection descends into the current node; Sibling adds a
+// peer of the current node; Up climbs one level.
+//
+//	tree := doctree.NewTree("Doc").
+//		Section("A", "text A").
+//		SubSection("A1", "text A1").
+//		Sibling("A2", "text A2").
+//		Section("B", "text B").
+//		Build()
+type TreeBuilder struct {
+	tree *DocTree
+	path []*DocNode // current node and its ancestors, root-most first
+}
+
+// NewTree starts a builder for a tree with the given title.
+func NewTree(title string) *TreeBuilder {
+	return &TreeBuilder{tree: &DocTree{Title: title}}
+}
+
+// Section appends a top-level node and makes it current.
+func (b *TreeBuilder) Section(title, text string) *TreeBuilder {
+	n := &DocNode{Title: title, Text: text}
+	b.tree.Children = append(b.tree.Children, n)
+	b.path = []*DocNode{n}
+	return b
+}
+
+// SubSection appends a child of the current node and makes it current.
+// With no current node it behaves like Section.
+func (b *TreeBuilder) SubSection(title, text string) *TreeBuilder {
+	if len(b.path) == 0 {
+		return b.Section(title, text)
+	}
+	n := &DocNode{Title: title, Text: text}
+	parent := b.path[len(b.path)-1]
+	parent.Children = append(parent.Children, n)
+	b.path = append(b.path, n)
+	return b
+}
+
+// Sibling appends a node next to the current one and makes it current.
+func (b *TreeBuilder) Sibling(title, text string) *TreeBuilder {
+	return b.Up().SubSection(title, text)
+}
+
+// Up moves the cursor to the current node's parent. At the top level the
+// cursor is cleared, so the next SubSection starts a new section.
+func (b *TreeBuilder) Up() *TreeBuilder {
+	if len(b.path) > 0 {
+		b.path = b.path[:len(b.path)-1]
+	}
+	return b
+}
+
+// Page sets the source page of the current node.
+func (b *TreeBuilder) Page(page int) *TreeBuilder {
+	if len(b.path) > 0 {
+		b.path[len(b.path)-1].Page = page
+	}
+	return b
+}
+
+// Build returns the assembled tree.
+func (b *TreeBuilder) Build() *DocTree {
+	return b.tree
+}
+
+// TestingT is the subset of testing.TB used by AssertTreeEqual.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertTreeEqual reports every structural difference between got and want,
+// naming each differing node by its index path and titles.
+func AssertTreeEqual(t TestingT, got, want *DocTree) {
+	t.Helper()
+	if got == nil || want == nil {
+		if got != want {
+			t.Errorf("tree mismatch: got %v, want %v", got, want)
+		}
+		return
+	}
+	if got.Title != want.Title {
+		t.Errorf("tree title: got %q, want %q", got.Title, want.Title)
+	}
+	compareNodes(t, "root", got.Children, want.Children)
+}
+
+func compareNodes(t TestingT, where string, got, want []*DocNode) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got %d children %s, want %d %s", where, len(got), titles(got), len(want), titles(want))
+	}
+	for i := range min(len(got), len(want)) {
+		g, w := got[i], want[i]
+		at := fmt.Sprintf("%s > [%d] %q", where, i, w.Title)
+		if g.Title != w.Title {
+			t.Errorf("%s: title got %q, want %q", at, g.Title, w.Title)
+		}
+		if g.Text != w.Text {
+			t.Errorf("%s: text got %q, want %q", at, g.Text, w.Text)
+		}
+		if g.Page != w.Page {
+			t.Errorf("%s: page got %d, want %d", at, g.Page, w.Page)
+		}
+		compareNodes(t, at, g.Children, w.Children)
+	}
+}
+
+func titles(nodes []*DocNode) string {
+	names := make([]string, len(nodes))
+	for i, n := range nodes {
+		names[i] = fmt.Sprintf("%q", n.Title)
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
@@ -0,0 +1,60 @@
+package doctree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTreeBuilder_Nesting(t *testing.T) {
+	got := NewTree("Doc").
+		Section("A", "text A").
+		SubSection("A1", "text A1").
+		SubSection("A1a", "deep").Page(3).
+		Up().
+		Sibling("A2", "text A2").
+		Section("B", "text B").
+		Build()
+
+	want := &DocTree{
+		Title: "Doc",
+		Children: []*DocNode{
+			{Title: "A", Text: "text A", Children: []*DocNode{
+				{Title: "A1", Text: "text A1", Children: []*DocNode{
+					{Title: "A1a", Text: "deep", Page: 3},
+				}},
+				{Title: "A2", Text: "text A2"},
+			}},
+			{Title: "B", Text: "text B"},
+		},
+	}
+	AssertTreeEqual(t, got, want)
+}
+
+type recorder struct {
+	errs []string
+}
+
+func (r *recorder) Helper() {}
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestAssertTreeEqual_ReportsDifferingNode(t *testing.T) {
+	got := NewTree("Doc").Section("A", "").SubSection("A1", "old").Build()
+	want := NewTree("Doc").Section("A", "").SubSection("A1", "new").Sibling("A2", "").Build()
+
+	var r recorder
+	AssertTreeEqual(&r, got, want)
+	if len(r.errs) != 2 {
+		t.Fatalf("expected 2 differences, got %d: %v", len(r.errs), r.errs)
+	}
+	if !strings.Contains(r.errs[0], `"A1"`) && !strings.Contains(r.errs[1], `"A1"`) {
+		t.Errorf("expected a message naming node A1, got %v", r.errs)
+	}
+	for _, e := range r.errs {
+		if !strings.HasPrefix(e, `root > [0] "A"`) {
+			t.Errorf("expected message to locate the node under A, got %q", e)
+		}
+	}
+}
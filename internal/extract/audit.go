@@ -0,0 +1,167 @@
+package extract
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditInfo identifies the job and chunk an extraction request belongs to.
+type AuditInfo struct {
+	JobID      string
+	DocID      string
+	ChunkIndex int
+}
+
+type auditInfoKey struct{}
+
+// WithAuditInfo attaches job/chunk identity to ctx for the audit log.
+func WithAuditInfo(ctx context.Context, info AuditInfo) context.Context {
+	return context.WithValue(ctx, auditInfoKey{}, info)
+}
+
+func auditInfoFrom(ctx context.Context) AuditInfo {
+	info, _ := ctx.Value(auditInfoKey{}).(AuditInfo)
+	return info
+}
+
+// AuditEntry is one line of the extraction audit log.
+type AuditEntry struct {
+	Timestamp    time.Time `json:"timestamp"`
+	JobID        string    `json:"job_id"`
+	DocID        string    `json:"doc_id"`
+	ChunkIndex   int       `json:"chunk_index"`
+	Model        string    `json:"model"`
+	PromptHash   string    `json:"prompt_hash"`
+	ResponseJSON string    `json:"response_json"`
+	LatencyMs    int64     `json:"latency_ms"`
+	Error        string    `json:"error,omitempty"`
+}
+
+type auditPrompt struct {
+	PromptHash string `json:"prompt_hash"`
+	Prompt     string `json:"prompt"`
+}
+
+// AuditLog appends extraction records to a JSONL file. Prompt text is kept
+// out of the main log and written once to a sibling .prompts.jsonl file
+// keyed by its SHA-256 hash.
+type AuditLog struct {
+	mu          sync.Mutex
+	entries     *os.File
+	prompts     *os.File
+	promptsPath string
+	// written holds the hashes already in the prompt file.
+	written map[string]bool
+}
+
+// OpenAuditLog opens (or creates) the audit log at path and its prompt file.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	entries, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	promptsPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".prompts.jsonl"
+	prompts, err := os.OpenFile(promptsPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		entries.Close()
+		return nil, fmt.Errorf("open audit prompts: %w", err)
+	}
+	a := &AuditLog{entries: entries, prompts: prompts, promptsPath: promptsPath, written: make(map[string]bool)}
+	err = a.scanPrompts(func(p auditPrompt) bool {
+		a.written[p.PromptHash] = true
+		return true
+	})
+	if err != nil {
+		a.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// PromptHash returns the hex SHA-256 of a prompt.
+func PromptHash(prompt string) string {
+	h := sha256.Sum256([]byte(prompt))
+	return fmt.Sprintf("%x", h[:])
+}
+
+// Record appends an entry and the prompt it refers to.
+func (a *AuditLog) Record(entry AuditEntry, prompt string) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal audit entry: %w", err)
+	}
+	promptLine, err := json.Marshal(auditPrompt{PromptHash: entry.PromptHash, Prompt: prompt})
+	if err != nil {
+		return fmt.Errorf("marshal audit prompt: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.written[entry.PromptHash] {
+		if _, err := a.prompts.Write(append(promptLine, '\n')); err != nil {
+			return fmt.Errorf("write audit prompt: %w", err)
+		}
+		a.written[entry.PromptHash] = true
+	}
+	if _, err := a.entries.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write audit entry: %w", err)
+	}
+	return nil
+}
+
+// LookupPrompt scans the prompt file for a hash. It returns false if the
+// hash was never recorded. The scan reads through its own file handle, so
+// it does not hold up Record.
+func (a *AuditLog) LookupPrompt(hash string) (string, bool, error) {
+	var prompt string
+	var found bool
+	err := a.scanPrompts(func(p auditPrompt) bool {
+		if p.PromptHash == hash {
+			prompt, found = p.Prompt, true
+		}
+		return !found
+	})
+	return prompt, found, err
+}
+
+// scanPrompts calls fn for each line of the prompt file until it returns
+// false. A line still being appended does not parse and is skipped.
+func (a *AuditLog) scanPrompts(fn func(auditPrompt) bool) error {
+	f, err := os.Open(a.promptsPath)
+	if err != nil {
+		return fmt.Errorf("open audit prompts: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var p auditPrompt
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			continue
+		}
+		if !fn(p) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Close flushes and closes the audit files.
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	err := a.entries.Close()
+	if perr := a.prompts.Close(); err == nil {
+		err = perr
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package extract
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog_RecordAndLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer audit.Close()
+
+	prompt := "Extract facts from: Milo is a dog."
+	hash := PromptHash(prompt)
+	entry := AuditEntry{JobID: "job-1", DocID: "doc-1", ChunkIndex: 2, PromptHash: hash, ResponseJSON: "[]", LatencyMs: 42}
+	if err := audit.Record(entry, prompt); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	got, ok, err := audit.LookupPrompt(hash)
+	if err != nil || !ok {
+		t.Fatalf("expected prompt to be found, ok=%v err=%v", ok, err)
+	}
+	if got != prompt {
+		t.Errorf("expected prompt %q, got %q", prompt, got)
+	}
+
+	if _, ok, _ := audit.LookupPrompt("missing"); ok {
+		t.Error("expected unknown hash to be absent")
+	}
+}
+
+func TestAuditLog_EntryOmitsPromptText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	prompt := "secret prompt body"
+	audit.Record(AuditEntry{JobID: "job-1", PromptHash: PromptHash(prompt)}, prompt)
+	audit.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("expected one audit line")
+	}
+	line := scanner.Text()
+	if strings.Contains(line, prompt) {
+		t.Error("expected audit entry to contain only the prompt hash")
+	}
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("decode entry: %v", err)
+	}
+	if entry.JobID != "job-1" {
+		t.Errorf("expected job_id %q, got %q", "job-1", entry.JobID)
+	}
+}
+
+func TestAuditLog_WritesEachPromptOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	prompt := "Extract facts from: Milo is a dog."
+	entry := AuditEntry{JobID: "job-1", PromptHash: PromptHash(prompt)}
+	for range 2 {
+		// Reopening must not forget the prompts already written.
+		audit, err := OpenAuditLog(path)
+		if err != nil {
+			t.Fatalf("open audit log: %v", err)
+		}
+		audit.Record(entry, prompt)
+		audit.Record(entry, prompt)
+		audit.Close()
+	}
+
+	countLines := func(path string) int {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return strings.Count(string(data), "\n")
+	}
+	if n := countLines(path); n != 4 {
+		t.Errorf("expected 4 audit entries, got %d", n)
+	}
+	if n := countLines(strings.TrimSuffix(path, ".jsonl") + ".prompts.jsonl"); n != 1 {
+		t.Errorf("expected the prompt written once, got %d lines", n)
+	}
+}
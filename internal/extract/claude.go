@@ -4,23 +4,53 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
 )
 
+// Extractor turns a chunk prompt into extracted facts. *ClaudeClient is the
+// production implementation; an empty model selects the client default.
+type Extractor interface {
+	ExtractFactsWithModel(ctx context.Context, prompt, model string) (*ExtractionResult, error)
+}
+
+var _ Extractor = (*ClaudeClient)(nil)
+
 // ClaudeClient calls the Anthropic Messages API for fact extraction.
 type ClaudeClient struct {
 	apiKey     string
 	model      string
 	httpClient *http.Client
+	baseURL    string
 	Stats      *LLMStats
+	Audit      *AuditLog // Optional; records every extraction request when set.
+
+	// MaxJSONRecoveryAttempts is how many follow-up turns ask for JSON when
+	// a response is not JSON; 0 fails the chunk immediately.
+	MaxJSONRecoveryAttempts int
+
+	// Temperature and TopP are sent with every request when set, including
+	// an explicit 0; nil leaves the API default.
+	Temperature *float64
+	TopP        *float64
+
+	// MaxTokens caps each response. StopSequences end a response early so
+	// the model cannot run past the JSON into another prompt section.
+	MaxTokens     int
+	StopSequences []string
 }
 
+// DefaultStopSequences stops generation at the section separator that
+// Prompt.BuildTyped writes between the instructions and the chunk.
+var DefaultStopSequences = []string{"\n\n---"}
+
 func NewClaudeClient(apiKey, model string) *ClaudeClient {
 	return &ClaudeClient{
 		apiKey: apiKey,
@@ -28,7 +58,11 @@ func NewClaudeClient(apiKey, model string) *ClaudeClient {
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second,
 		},
-		Stats: NewLLMStats(1 * time.Hour),
+		baseURL:                 "https://api.anthropic.com",
+		Stats:                   NewLLMStats(1 * time.Hour),
+		MaxJSONRecoveryAttempts: 1,
+		MaxTokens:               4096,
+		StopSequences:           DefaultStopSequences,
 	}
 }
 
@@ -38,10 +72,13 @@ type anthropicMessage struct {
 }
 
 type anthropicRequest struct {
-	Model     string             `json:"model"`
-	MaxTokens int                `json:"max_tokens"`
-	System    string             `json:"system,omitempty"`
-	Messages  []anthropicMessage `json:"messages"`
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
 }
 
 type anthropicResponse struct {
@@ -61,34 +98,87 @@ type ExtractionResult struct {
 }
 
 // ExtractFacts calls Claude to extract facts from a chunk prompt.
-func (c *ClaudeClient) ExtractFacts(ctx context.Context, prompt string) (result *ExtractionResult, err error) {
+func (c *ClaudeClient) ExtractFacts(ctx context.Context, prompt string) (*ExtractionResult, error) {
+	return c.ExtractFactsWithModel(ctx, prompt, "")
+}
+
+// ExtractFactsWithModel is ExtractFacts with a per-call model override.
+// An empty model uses the client's default.
+func (c *ClaudeClient) ExtractFactsWithModel(ctx context.Context, prompt, model string) (result *ExtractionResult, err error) {
+	if model == "" {
+		model = c.model
+	}
 	start := time.Now()
+	var rawText string
 	defer func() {
 		durationMs := time.Since(start).Milliseconds()
 		if c.Stats != nil {
 			c.Stats.Record(durationMs)
 		}
-		slog.Info("claude extraction request", "model", c.model, "duration_ms", durationMs)
+		slog.Info("claude extraction request", "model", model, "duration_ms", durationMs)
 		if err == nil && result != nil {
 			result.DurationMs = durationMs
 		}
+		if c.Audit != nil {
+			c.recordAudit(ctx, prompt, model, rawText, durationMs, err)
+		}
 	}()
 
+	messages := []anthropicMessage{{Role: "user", Content: prompt}}
+	rawText, err = c.send(ctx, model, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	// Models sometimes answer with prose instead of JSON. Ask again in the
+	// same conversation rather than failing the chunk.
+	text := stripCodeBlock(rawText)
+	for attempt := 0; !looksLikeJSON(text); attempt++ {
+		if attempt >= c.MaxJSONRecoveryAttempts {
+			return nil, fmt.Errorf("response is not JSON (raw: %s)", truncate(text, 200))
+		}
+		slog.Warn("claude returned non-JSON response, asking for JSON", "model", model, "attempt", attempt+1)
+		messages = append(messages,
+			anthropicMessage{Role: "assistant", Content: rawText},
+			anthropicMessage{Role: "user", Content: jsonRecoveryPrompt},
+		)
+		rawText, err = c.send(ctx, model, messages)
+		if err != nil {
+			return nil, err
+		}
+		text = stripCodeBlock(rawText)
+	}
+
+	facts, err := parseFacts(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse facts json: %w (raw: %s)", err, truncate(text, 200))
+	}
+
+	return &ExtractionResult{Facts: facts}, nil
+}
+
+// jsonRecoveryPrompt is the follow-up turn sent when a response is not JSON.
+const jsonRecoveryPrompt = "Your previous response was not valid JSON. Please respond with only the JSON array."
+
+// send posts one Messages API request and returns the first content block's
+// text.
+func (c *ClaudeClient) send(ctx context.Context, model string, messages []anthropicMessage) (string, error) {
 	reqBody := anthropicRequest{
-		Model:     c.model,
-		MaxTokens: 4096,
-		Messages: []anthropicMessage{
-			{Role: "user", Content: prompt},
-		},
+		Model:         model,
+		MaxTokens:     c.MaxTokens,
+		Messages:      messages,
+		Temperature:   c.Temperature,
+		TopP:          c.TopP,
+		StopSequences: c.StopSequences,
 	}
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
+		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/messages", bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return "", fmt.Errorf("create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("x-api-key", c.apiKey)
@@ -96,45 +186,173 @@ func (c *ClaudeClient) ExtractFacts(ctx context.Context, prompt string) (result
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("claude api: %w", err)
+		return "", fmt.Errorf("claude api: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return "", fmt.Errorf("read response: %w", err)
 	}
 
 	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
-		return nil, &RetryableError{
+		return "", &RetryableError{
 			StatusCode: resp.StatusCode,
 			Message:    string(respBody),
 		}
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("claude api status %d: %s", resp.StatusCode, string(respBody))
+		if err := modelUnavailableError(model, respBody); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("claude api status %d: %s", resp.StatusCode, string(respBody))
 	}
 
 	var apiResp anthropicResponse
 	if err := json.Unmarshal(respBody, &apiResp); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+		return "", fmt.Errorf("decode response: %w", err)
 	}
 	if apiResp.Error != nil {
-		return nil, fmt.Errorf("claude error: %s: %s", apiResp.Error.Type, apiResp.Error.Message)
+		if err := modelUnavailableError(model, respBody); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("claude error: %s: %s", apiResp.Error.Type, apiResp.Error.Message)
 	}
 	if len(apiResp.Content) == 0 {
-		return nil, fmt.Errorf("empty response from claude")
+		return "", fmt.Errorf("empty response from claude")
 	}
+	return apiResp.Content[0].Text, nil
+}
+
+// looksLikeJSON reports whether text could be a JSON array or object.
+func looksLikeJSON(text string) bool {
+	return strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{")
+}
 
-	text := apiResp.Content[0].Text
-	text = stripCodeBlock(text)
+// parseFacts decodes a JSON array of facts, or an object wrapping the array
+// in a "facts" field. Elements that fail validateRawFact are logged and
+// skipped rather than failing the whole response.
+func parseFacts(text string) ([]Fact, error) {
+	var raws []json.RawMessage
+	if strings.HasPrefix(text, "{") {
+		var wrapped struct {
+			Facts []json.RawMessage `json:"facts"`
+		}
+		if err := json.Unmarshal([]byte(text), &wrapped); err != nil {
+			return nil, err
+		}
+		raws = wrapped.Facts
+	} else if err := json.Unmarshal([]byte(text), &raws); err != nil {
+		return nil, err
+	}
 
-	var facts []Fact
-	if err := json.Unmarshal([]byte(text), &facts); err != nil {
-		return nil, fmt.Errorf("parse facts json: %w (raw: %s)", err, truncate(text, 200))
+	facts := make([]Fact, 0, len(raws))
+	for i, raw := range raws {
+		f, err := validateRawFact(raw)
+		if err != nil {
+			slog.Debug("skipping malformed fact", "index", i, "error", err)
+			continue
+		}
+		facts = append(facts, *f)
 	}
+	return facts, nil
+}
 
-	return &ExtractionResult{Facts: facts}, nil
+// ParseError describes a fact element in an extraction response that is
+// not a well-formed fact. Field is the offending key, or empty when the
+// element itself is not an object.
+type ParseError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	if e.Field == "" {
+		return "malformed fact: " + e.Reason
+	}
+	return fmt.Sprintf("malformed fact field %q: %s", e.Field, e.Reason)
+}
+
+// validateRawFact checks one element of an extraction response before it
+// is decoded: it must be an object with a non-empty string "text" and a
+// string "category", and any other known field present must have the type
+// the prompt asks for. Null optional fields are treated as absent.
+func validateRawFact(raw json.RawMessage) (*Fact, error) {
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil || m == nil {
+		return nil, &ParseError{Reason: "not an object"}
+	}
+
+	for _, key := range []string{"text", "category"} {
+		v, ok := m[key]
+		if !ok {
+			return nil, &ParseError{Field: key, Reason: "missing"}
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, &ParseError{Field: key, Reason: "not a string"}
+		}
+		if strings.TrimSpace(s) == "" {
+			return nil, &ParseError{Field: key, Reason: "empty"}
+		}
+	}
+	for _, key := range []string{"entity", "entity_path"} {
+		if v := m[key]; v != nil {
+			if _, ok := v.(string); !ok {
+				return nil, &ParseError{Field: key, Reason: "not a string"}
+			}
+		}
+	}
+	for _, key := range []string{"topics", "supersedes"} {
+		if v := m[key]; v != nil {
+			list, ok := v.([]any)
+			if !ok {
+				return nil, &ParseError{Field: key, Reason: "not a list"}
+			}
+			for _, item := range list {
+				if _, ok := item.(string); !ok {
+					return nil, &ParseError{Field: key, Reason: "not a list of strings"}
+				}
+			}
+		}
+	}
+	if v := m["salience"]; v != nil {
+		if _, ok := v.(float64); !ok {
+			return nil, &ParseError{Field: "salience", Reason: "not a number"}
+		}
+	}
+	if v := m["min_trust"]; v != nil {
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return nil, &ParseError{Field: "min_trust", Reason: "not an integer"}
+		}
+	}
+
+	var f Fact
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, &ParseError{Reason: err.Error()}
+	}
+	return &f, nil
+}
+
+func (c *ClaudeClient) recordAudit(ctx context.Context, prompt, model, response string, durationMs int64, extractErr error) {
+	info := auditInfoFrom(ctx)
+	entry := AuditEntry{
+		Timestamp:    time.Now().UTC(),
+		JobID:        info.JobID,
+		DocID:        info.DocID,
+		ChunkIndex:   info.ChunkIndex,
+		Model:        model,
+		PromptHash:   PromptHash(prompt),
+		ResponseJSON: response,
+		LatencyMs:    durationMs,
+	}
+	if extractErr != nil {
+		entry.Error = extractErr.Error()
+	}
+	if err := c.Audit.Record(entry, prompt); err != nil {
+		slog.Warn("extraction audit write failed", "error", err)
+	}
 }
 
 var codeBlockRe = regexp.MustCompile("(?s)^```(?:json)?\\s*(.*?)\\s*```$")
@@ -168,6 +386,53 @@ func (e *RetryableError) Error() string {
 	return fmt.Sprintf("retryable error (status %d): %s", e.StatusCode, truncate(e.Message, 200))
 }
 
+// ErrExtractionFailure marks a chunk whose facts could not be extracted in
+// job errors. Callers wrap it alongside the original error.
+var ErrExtractionFailure = errors.New("extraction failure")
+
+// ErrModelDeprecated matches, via errors.Is, every ModelDeprecatedError.
+var ErrModelDeprecated = errors.New("model not available")
+
+// ModelDeprecatedError means the API no longer serves the requested model,
+// typically because it was deprecated. Retrying cannot help; the configured
+// model has to change.
+type ModelDeprecatedError struct {
+	Model   string
+	Message string
+}
+
+func (e *ModelDeprecatedError) Error() string {
+	return fmt.Sprintf("model %s not available: %s", e.Model, truncate(e.Message, 200))
+}
+
+func (e *ModelDeprecatedError) Unwrap() error {
+	return ErrModelDeprecated
+}
+
+// modelUnavailableError returns a ModelDeprecatedError if body is an API
+// error about the model itself, and nil otherwise.
+func modelUnavailableError(model string, body []byte) error {
+	var apiResp anthropicResponse
+	if json.Unmarshal(body, &apiResp) != nil || apiResp.Error == nil {
+		return nil
+	}
+	switch apiResp.Error.Type {
+	case "invalid_request_error", "not_found_error":
+	default:
+		return nil
+	}
+	msg := strings.ToLower(apiResp.Error.Message)
+	if !strings.Contains(msg, "model") {
+		return nil
+	}
+	for _, cue := range []string{"not available", "not found", "deprecated"} {
+		if strings.Contains(msg, cue) {
+			return &ModelDeprecatedError{Model: model, Message: apiResp.Error.Message}
+		}
+	}
+	return nil
+}
+
 // Close releases resources.
 func (c *ClaudeClient) Close() {
 	c.httpClient.CloseIdleConnections()
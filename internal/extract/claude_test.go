@@ -0,0 +1,262 @@
+package extract
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestModelUnavailableError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"not available", `{"type":"error","error":{"type":"invalid_request_error","message":"model not available"}}`, true},
+		{"not found", `{"type":"error","error":{"type":"not_found_error","message":"model claude-2.0 not found"}}`, true},
+		{"deprecated", `{"type":"error","error":{"type":"invalid_request_error","message":"model claude-2.0 is deprecated"}}`, true},
+		{"model field error", `{"type":"error","error":{"type":"invalid_request_error","message":"model: field required"}}`, false},
+		{"other invalid request", `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: must be positive"}}`, false},
+		{"overloaded", `{"type":"error","error":{"type":"overloaded_error","message":"model is overloaded"}}`, false},
+		{"not json", `bad gateway`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := modelUnavailableError("claude-2.0", []byte(tt.body))
+			if got := errors.Is(err, ErrModelDeprecated); got != tt.want {
+				t.Errorf("expected ErrModelDeprecated %v, got %v", tt.want, err)
+			}
+			var mde *ModelDeprecatedError
+			if tt.want && (!errors.As(err, &mde) || mde.Model != "claude-2.0") {
+				t.Errorf("expected ModelDeprecatedError for claude-2.0, got %v", err)
+			}
+		})
+	}
+}
+
+// fakeMessagesAPI answers successive Messages API calls with replies in
+// order and records the message count of each request.
+func fakeMessagesAPI(t *testing.T, replies ...string) (*ClaudeClient, func() []int) {
+	t.Helper()
+	var mu sync.Mutex
+	var turns []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		mu.Lock()
+		turns = append(turns, len(req.Messages))
+		reply := replies[min(len(turns), len(replies))-1]
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{
+			"content": []map[string]string{{"type": "text", "text": reply}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClaudeClient("key", "model")
+	c.baseURL = srv.URL
+	return c, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), turns...)
+	}
+}
+
+func TestSend_SamplingParameters(t *testing.T) {
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		json.NewEncoder(w).Encode(map[string]any{
+			"content": []map[string]string{{"type": "text", "text": "[]"}},
+		})
+	}))
+	defer srv.Close()
+
+	c := NewClaudeClient("key", "model")
+	c.baseURL = srv.URL
+	if _, err := c.ExtractFacts(context.Background(), "prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	temp, topP := 0.2, 0.9
+	c.Temperature = &temp
+	c.TopP = &topP
+	if _, err := c.ExtractFacts(context.Background(), "prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := bodies[0]["temperature"]; ok {
+		t.Errorf("expected no temperature by default, got %v", bodies[0]["temperature"])
+	}
+	if _, ok := bodies[0]["top_p"]; ok {
+		t.Errorf("expected no top_p by default, got %v", bodies[0]["top_p"])
+	}
+	if bodies[1]["temperature"] != 0.2 {
+		t.Errorf("expected temperature 0.2, got %v", bodies[1]["temperature"])
+	}
+	if bodies[1]["top_p"] != 0.9 {
+		t.Errorf("expected top_p 0.9, got %v", bodies[1]["top_p"])
+	}
+}
+
+func TestSend_ExplicitZeroTemperature(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"content": []map[string]string{{"type": "text", "text": "[]"}},
+		})
+	}))
+	defer srv.Close()
+
+	c := NewClaudeClient("key", "model")
+	c.baseURL = srv.URL
+	zero := 0.0
+	c.Temperature = &zero
+	if _, err := c.ExtractFacts(context.Background(), "prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if temp, ok := body["temperature"]; !ok || temp != 0.0 {
+		t.Errorf("expected temperature 0 to be sent, got %v (present %v)", temp, ok)
+	}
+}
+
+func TestSend_MaxTokensAndStopSequences(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"content": []map[string]string{{"type": "text", "text": "[]"}},
+		})
+	}))
+	defer srv.Close()
+
+	c := NewClaudeClient("key", "model")
+	c.baseURL = srv.URL
+	if _, err := c.ExtractFacts(context.Background(), "prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body["max_tokens"] != 4096.0 {
+		t.Errorf("expected max_tokens 4096, got %v", body["max_tokens"])
+	}
+	stops, _ := body["stop_sequences"].([]any)
+	if len(stops) != 1 || stops[0] != "\n\n---" {
+		t.Errorf("expected default stop sequence, got %v", body["stop_sequences"])
+	}
+
+	c.MaxTokens = 1024
+	c.StopSequences = nil
+	body = nil
+	if _, err := c.ExtractFacts(context.Background(), "prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body["max_tokens"] != 1024.0 {
+		t.Errorf("expected max_tokens 1024, got %v", body["max_tokens"])
+	}
+	if _, ok := body["stop_sequences"]; ok {
+		t.Errorf("expected no stop_sequences when unset, got %v", body["stop_sequences"])
+	}
+}
+
+func TestExtractFacts_RecoversFromNonJSON(t *testing.T) {
+	c, turns := fakeMessagesAPI(t,
+		"Here are the facts I found: Milo is a dog.",
+		"```json\n[{\"text\": \"Milo is a dog.\", \"category\": \"entity_fact\"}]\n```",
+	)
+
+	result, err := c.ExtractFacts(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Facts) != 1 || result.Facts[0].Text != "Milo is a dog." {
+		t.Errorf("unexpected facts: %+v", result.Facts)
+	}
+	if got := turns(); len(got) != 2 || got[1] != 3 {
+		t.Errorf("expected a second request with 3 messages, got %v", got)
+	}
+}
+
+func TestExtractFacts_NonJSONWithoutRecovery(t *testing.T) {
+	c, turns := fakeMessagesAPI(t, "I could not find any facts.")
+	c.MaxJSONRecoveryAttempts = 0
+
+	if _, err := c.ExtractFacts(context.Background(), "prompt"); err == nil {
+		t.Error("expected an error for a non-JSON response")
+	}
+	if got := turns(); len(got) != 1 {
+		t.Errorf("expected 1 request, got %d", len(got))
+	}
+}
+
+func TestExtractFacts_WrappedFactsObject(t *testing.T) {
+	c, _ := fakeMessagesAPI(t, `{"facts": [{"text": "Milo is a dog.", "category": "entity_fact"}]}`)
+
+	result, err := c.ExtractFacts(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Facts) != 1 {
+		t.Errorf("expected 1 fact, got %d", len(result.Facts))
+	}
+}
+
+func TestParseFacts_SkipsMalformed(t *testing.T) {
+	facts, err := parseFacts(`[{"text": "Milo is a dog", "category": "entity_fact"}, null, {"text": ""}, {"text": "Milo is 3", "category": "entity_fact", "salience": "high"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(facts) != 1 || facts[0].Text != "Milo is a dog" {
+		t.Errorf("expected only the well-formed fact, got %+v", facts)
+	}
+}
+
+func TestValidateRawFact(t *testing.T) {
+	tests := []struct {
+		raw   string
+		field string // empty for an element that is not an object
+		ok    bool
+	}{
+		{raw: `{"text": "Milo is a dog", "category": "entity_fact", "entity": null, "topics": ["pets"], "salience": 0.5, "min_trust": 2}`, ok: true},
+		{raw: `null`},
+		{raw: `"Milo is a dog"`},
+		{raw: `{"category": "entity_fact"}`, field: "text"},
+		{raw: `{"text": " ", "category": "entity_fact"}`, field: "text"},
+		{raw: `{"text": 5, "category": "entity_fact"}`, field: "text"},
+		{raw: `{"text": "Milo is a dog"}`, field: "category"},
+		{raw: `{"text": "Milo is a dog", "category": "entity_fact", "entity": 1}`, field: "entity"},
+		{raw: `{"text": "Milo is a dog", "category": "entity_fact", "topics": "pets"}`, field: "topics"},
+		{raw: `{"text": "Milo is a dog", "category": "entity_fact", "supersedes": [1]}`, field: "supersedes"},
+		{raw: `{"text": "Milo is a dog", "category": "entity_fact", "salience": "high"}`, field: "salience"},
+		{raw: `{"text": "Milo is a dog", "category": "entity_fact", "min_trust": 2.5}`, field: "min_trust"},
+	}
+	for _, tt := range tests {
+		f, err := validateRawFact(json.RawMessage(tt.raw))
+		if tt.ok {
+			if err != nil || f == nil {
+				t.Errorf("%s: expected a fact, got error %v", tt.raw, err)
+			}
+			continue
+		}
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: expected a ParseError, got %v", tt.raw, err)
+			continue
+		}
+		if pe.Field != tt.field {
+			t.Errorf("%s: expected field %q, got %q", tt.raw, tt.field, pe.Field)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package extract
+
+import "testing"
+
+func TestDetectDocType(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		text     string
+		want     string
+	}{
+		{"csv by extension", "orders.csv", "Whereas the party shall agree", DocTypeTabular},
+		{"feed by extension", "news.atom", "", DocTypeFeed},
+		{"legal", "lease.pdf", "This Agreement is made between the Landlord (hereinafter the Party) and the Tenant. The Tenant shall pay rent. Governing law: Ohio.", DocTypeLegal},
+		{"scientific", "paper.pdf", "Abstract. We test the hypothesis... Methods ... Results ... Conclusion. Smith et al. showed", DocTypeScientific},
+		{"meeting notes", "sync.md", "Attendees: Ann, Bo. Agenda: launch. Action items: Ann to email.", DocTypeMeetingNotes},
+		{"technical", "README.md", "Install the CLI, then configure the token. Usage: run the command below.", DocTypeTechnical},
+		{"general", "pets.md", "Milo is a cat who likes tuna.", DocTypeGeneral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectDocType(tt.filename, tt.text); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsDocType(t *testing.T) {
+	for _, s := range []string{DocTypeGeneral, DocTypeLegal, DocTypeFeed} {
+		if !IsDocType(s) {
+			t.Errorf("expected %q to be a doc type", s)
+		}
+	}
+	if IsDocType("novel") {
+		t.Error("expected unknown type to be rejected")
+	}
+}
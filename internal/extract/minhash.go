@@ -0,0 +1,215 @@
+package extract
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MinHashSize is the number of hash values in a MinHash signature.
+const MinHashSize = 128
+
+// shingleSize is the character n-gram length used to shingle fact text.
+// Facts are a sentence or two, so short character shingles tolerate small
+// wording changes better than word n-grams would.
+const shingleSize = 5
+
+// DefaultLSHBands splits a signature into 16 bands of 8 rows, which makes
+// pairs above roughly 0.7 Jaccard similarity likely to share a bucket.
+const DefaultLSHBands = 16
+
+// Signature is the MinHash of a text: for each of MinHashSize hash
+// functions, the minimum hash over the text's shingles.
+type Signature [MinHashSize]uint32
+
+// minhashSeeds holds the multiply-shift parameters of each hash function.
+// They are derived from a fixed seed so signatures are stable across runs.
+var minhashSeeds = func() (seeds [MinHashSize][2]uint64) {
+	state := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 { // splitmix64
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+	for i := range seeds {
+		seeds[i] = [2]uint64{next() | 1, next()}
+	}
+	return seeds
+}()
+
+// MinHash computes the signature of text. Case and whitespace differences
+// are ignored.
+func MinHash(text string) Signature {
+	var sig Signature
+	for i := range sig {
+		sig[i] = math.MaxUint32
+	}
+	for _, shingle := range shingles(text) {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		x := h.Sum64()
+		for i, s := range minhashSeeds {
+			if v := uint32((s[0]*x + s[1]) >> 32); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// shingles returns the distinct character n-grams of the normalized text.
+func shingles(text string) []string {
+	norm := []rune(strings.Join(strings.Fields(strings.ToLower(text)), " "))
+	if len(norm) == 0 {
+		return nil
+	}
+	if len(norm) <= shingleSize {
+		return []string{string(norm)}
+	}
+	seen := make(map[string]bool, len(norm))
+	out := make([]string, 0, len(norm))
+	for i := 0; i+shingleSize <= len(norm); i++ {
+		s := string(norm[i : i+shingleSize])
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Similarity estimates the Jaccard similarity of the texts behind two
+// signatures as the fraction of matching hash values.
+func (s Signature) Similarity(other Signature) float64 {
+	same := 0
+	for i := range s {
+		if s[i] == other[i] {
+			same++
+		}
+	}
+	return float64(same) / MinHashSize
+}
+
+// LSHIndex buckets signatures by band so likely near-duplicates can be found
+// without comparing every pair.
+type LSHIndex struct {
+	bands   int
+	rows    int
+	sigs    []Signature
+	buckets []map[uint64][]int
+}
+
+// NewLSHIndex returns an index splitting signatures into bands. bands must
+// divide MinHashSize; other values fall back to DefaultLSHBands.
+func NewLSHIndex(bands int) *LSHIndex {
+	if bands <= 0 || MinHashSize%bands != 0 {
+		bands = DefaultLSHBands
+	}
+	idx := &LSHIndex{
+		bands:   bands,
+		rows:    MinHashSize / bands,
+		buckets: make([]map[uint64][]int, bands),
+	}
+	for b := range idx.buckets {
+		idx.buckets[b] = make(map[uint64][]int)
+	}
+	return idx
+}
+
+// Add indexes sig and returns its ID, which is its insertion position.
+func (x *LSHIndex) Add(sig Signature) int {
+	id := len(x.sigs)
+	x.sigs = append(x.sigs, sig)
+	for b := range x.bands {
+		key := x.bandKey(sig, b)
+		x.buckets[b][key] = append(x.buckets[b][key], id)
+	}
+	return id
+}
+
+func (x *LSHIndex) bandKey(sig Signature, band int) uint64 {
+	h := fnv.New64a()
+	var buf [4]byte
+	for _, v := range sig[band*x.rows : (band+1)*x.rows] {
+		binary.LittleEndian.PutUint32(buf[:], v)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// CandidatePairs returns each pair of IDs sharing at least one bucket, with
+// the lower ID first, in ascending order. Candidates are approximate:
+// callers should confirm them with Similarity.
+func (x *LSHIndex) CandidatePairs() [][2]int {
+	seen := make(map[[2]int]bool)
+	var pairs [][2]int
+	for _, band := range x.buckets {
+		for _, ids := range band {
+			for i := 0; i < len(ids); i++ {
+				for j := i + 1; j < len(ids); j++ {
+					p := [2]int{ids[i], ids[j]}
+					if !seen[p] {
+						seen[p] = true
+						pairs = append(pairs, p)
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	return pairs
+}
+
+// DedupFacts drops facts that duplicate a more salient one: same category,
+// entity and entity path, with estimated text similarity of at least
+// threshold. Facts are visited by descending salience (the earliest on
+// ties) and each is compared only with the facts already kept, so a chain
+// of near matches cannot merge facts that are not themselves alike. It
+// returns the indexes of the kept facts in ascending order.
+func DedupFacts(facts []Fact, threshold float64) []int {
+	idx := NewLSHIndex(DefaultLSHBands)
+	for _, f := range facts {
+		idx.Add(MinHash(f.Text))
+	}
+
+	similar := make(map[int][]int)
+	for _, p := range idx.CandidatePairs() {
+		a, b := facts[p[0]], facts[p[1]]
+		if a.Category != b.Category || a.Entity != b.Entity || a.EntityPath != b.EntityPath {
+			continue
+		}
+		if idx.sigs[p[0]].Similarity(idx.sigs[p[1]]) < threshold {
+			continue
+		}
+		similar[p[0]] = append(similar[p[0]], p[1])
+		similar[p[1]] = append(similar[p[1]], p[0])
+	}
+
+	order := make([]int, len(facts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return facts[order[i]].Salience > facts[order[j]].Salience
+	})
+	kept := make([]bool, len(facts))
+	keep := make([]int, 0, len(facts))
+	for _, i := range order {
+		if !slices.ContainsFunc(similar[i], func(j int) bool { return kept[j] }) {
+			kept[i] = true
+			keep = append(keep, i)
+		}
+	}
+	sort.Ints(keep)
+	return keep
+}
@@ -0,0 +1,95 @@
+package extract
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestMinHash_Similarity(t *testing.T) {
+	a := MinHash("Milo the cat prefers tuna over chicken.")
+	if got := a.Similarity(MinHash("milo the cat  prefers TUNA over chicken.")); got != 1 {
+		t.Errorf("expected identical signatures ignoring case and spacing, got %v", got)
+	}
+	if got := a.Similarity(MinHash("Milo the cat prefers tuna over chicken!")); got < 0.8 {
+		t.Errorf("expected near-duplicate similarity >= 0.8, got %v", got)
+	}
+	if got := a.Similarity(MinHash("The deployment pipeline runs nightly at 2am.")); got > 0.2 {
+		t.Errorf("expected unrelated similarity <= 0.2, got %v", got)
+	}
+}
+
+func TestLSHIndex_CandidatePairs(t *testing.T) {
+	idx := NewLSHIndex(DefaultLSHBands)
+	idx.Add(MinHash("Milo the cat prefers tuna over chicken."))
+	idx.Add(MinHash("The deployment pipeline runs nightly at 2am."))
+	idx.Add(MinHash("Milo the cat prefers tuna over chicken!"))
+
+	pairs := idx.CandidatePairs()
+	if !slices.Contains(pairs, [2]int{0, 2}) {
+		t.Errorf("expected near-duplicates 0 and 2 to be candidates, got %v", pairs)
+	}
+	if slices.Contains(pairs, [2]int{0, 1}) || slices.Contains(pairs, [2]int{1, 2}) {
+		t.Errorf("expected unrelated facts not to be candidates, got %v", pairs)
+	}
+}
+
+func TestDedupFacts_KeepsMostSalient(t *testing.T) {
+	facts := []Fact{
+		{Text: "Milo the cat prefers tuna over chicken.", Category: "entity_fact", Salience: 0.5},
+		{Text: "The deployment pipeline runs nightly at 2am.", Category: "procedure", Salience: 0.5},
+		{Text: "Milo the cat prefers tuna over chicken!", Category: "entity_fact", Salience: 0.9},
+		{Text: "Milo the cat prefers tuna over chicken.", Category: "preference", Salience: 0.5},
+	}
+	got := DedupFacts(facts, 0.8)
+	want := []int{1, 2, 3}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected kept facts %v, got %v", want, got)
+	}
+}
+
+func TestDedupFacts_DifferentEntities(t *testing.T) {
+	facts := []Fact{
+		{Text: "Prefers tuna over chicken.", Category: "entity_fact", Entity: "Milo", Salience: 0.5},
+		{Text: "Prefers tuna over chicken.", Category: "entity_fact", Entity: "Otis", Salience: 0.9},
+		{Text: "Prefers tuna over chicken.", Category: "entity_fact", Entity: "Milo", EntityPath: "acme.milo", Salience: 0.5},
+		{Text: "Prefers tuna over chicken.", Category: "entity_fact", Entity: "Milo", Salience: 0.7},
+	}
+	got := DedupFacts(facts, 0.8)
+	want := []int{1, 2, 3}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected kept facts %v, got %v", want, got)
+	}
+}
+
+func TestDedupFacts_NotTransitive(t *testing.T) {
+	// b is close to both a and c, but a and c are not close to each other.
+	a := "The build server in rack four runs the nightly integration tests for the payments service team."
+	b := "The build server in rack four runs the nightly integration tests for the billing service team."
+	c := "The build server in rack four runs the weekly integration tests for the billing service team."
+	const threshold = 0.75
+	if MinHash(a).Similarity(MinHash(b)) < threshold || MinHash(b).Similarity(MinHash(c)) < threshold ||
+		MinHash(a).Similarity(MinHash(c)) >= threshold {
+		t.Fatal("expected a~b and b~c but not a~c")
+	}
+	facts := []Fact{
+		{Text: a, Category: "procedure", Salience: 0.9},
+		{Text: b, Category: "procedure", Salience: 0.5},
+		{Text: c, Category: "procedure", Salience: 0.5},
+	}
+	got := DedupFacts(facts, threshold)
+	want := []int{0, 2}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected kept facts %v, got %v", want, got)
+	}
+}
+
+func TestDedupFacts_ManyDistinctFacts(t *testing.T) {
+	var facts []Fact
+	for i := range 500 {
+		facts = append(facts, Fact{Text: fmt.Sprintf("Server %d in rack %d runs build job %d.", i*7919, i*31, i*104729), Category: "entity_fact"})
+	}
+	if got := DedupFacts(facts, 0.95); len(got) != len(facts) {
+		t.Errorf("expected all %d distinct facts kept, got %d", len(facts), len(got))
+	}
+}
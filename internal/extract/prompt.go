@@ -2,6 +2,9 @@ package extract
 
 import (
 	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -10,6 +13,7 @@ const ExtractionPrompt = `Extract structured facts from the following document s
 - "text": concise statement of the fact (string, max 200 chars)
 - "category": one of "entity_fact", "preference", "topic_knowledge", "procedure"
 - "entity": the person or thing this fact is about (string or null)
+- "entity_path": for an entity inside an organization, its dotted path from the top, e.g. "acme.engineering.alice" (string, omit otherwise)
 - "topics": list of topic slugs relevant to this fact (list of strings, max 3)
 - "salience": importance from 0.1 to 1.0 (float)
 - "supersedes": list of paths of existing memories this fact replaces (list of strings, default [])
@@ -23,17 +27,63 @@ Rules:
 - Entity names should be lowercase, no spaces (use underscores)
 - Topic slugs should be lowercase, hyphenated
 - Salience: personal facts=0.7, topic knowledge=0.5, procedures=0.6
-- Default min_trust to 0. Most facts should be 0.
+- Default min_trust to 0. Most facts should be 0. Raise it for authoritative sources such as peer-reviewed papers or official filings (7 or higher); keep it at 0 for casual sources such as social media posts.
 - Do NOT extract episode-type facts from documents
 - Return an empty array [] if nothing worth remembering
 
 Respond with ONLY the JSON array, no other text.`
 
-// BuildChunkPrompt creates the full prompt for extracting facts from a chunk,
+// DefaultPromptVersion labels the embedded ExtractionPrompt.
+const DefaultPromptVersion = "default"
+
+// Prompt is a versioned set of extraction instructions.
+type Prompt struct {
+	Version string
+	Text    string
+
+	// DomainContext, if set, is added after the instructions as a
+	// "Domain context:" section describing the deployment's vocabulary.
+	DomainContext string
+}
+
+// DefaultPrompt returns the prompt compiled into the binary.
+func DefaultPrompt() Prompt {
+	return Prompt{Version: DefaultPromptVersion, Text: ExtractionPrompt}
+}
+
+// LoadPrompt reads dir/extraction_v{version}.txt. The prompt's Version is
+// the version string as given, e.g. "3" or "3_b".
+func LoadPrompt(dir, version string) (Prompt, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "extraction_v"+version+".txt"))
+	if err != nil {
+		return Prompt{}, fmt.Errorf("read prompt: %w", err)
+	}
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return Prompt{}, fmt.Errorf("prompt version %s is empty", version)
+	}
+	return Prompt{Version: version, Text: text}, nil
+}
+
+// Build creates the full prompt for extracting facts from a chunk,
 // including document title and section breadcrumb context.
-func BuildChunkPrompt(docTitle string, breadcrumb []string, chunkText string) string {
+func (p Prompt) Build(docTitle string, breadcrumb []string, chunkText string) string {
+	return p.BuildTyped(docTitle, DocTypeGeneral, breadcrumb, chunkText)
+}
+
+// BuildTyped is Build with a section emphasizing the categories that matter
+// most for docType. Unknown types and DocTypeGeneral add nothing.
+func (p Prompt) BuildTyped(docTitle, docType string, breadcrumb []string, chunkText string) string {
 	var sb strings.Builder
-	sb.WriteString(ExtractionPrompt)
+	sb.WriteString(p.Text)
+	if emphasis := docTypeEmphasis[docType]; emphasis != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(emphasis)
+	}
+	if p.DomainContext != "" {
+		sb.WriteString("\n\nDomain context: ")
+		sb.WriteString(p.DomainContext)
+	}
 	sb.WriteString("\n\n---\n")
 	sb.WriteString(fmt.Sprintf("Document: %q\n", docTitle))
 	if len(breadcrumb) > 0 {
@@ -45,3 +95,38 @@ func BuildChunkPrompt(docTitle string, breadcrumb []string, chunkText string) st
 	sb.WriteString(chunkText)
 	return sb.String()
 }
+
+// BuildTypedChunkPrompt builds a typed chunk prompt from the embedded
+// extraction prompt.
+func BuildTypedChunkPrompt(docTitle, docType string, breadcrumb []string, text string) string {
+	return DefaultPrompt().BuildTyped(docTitle, docType, breadcrumb, text)
+}
+
+// PromptSet chooses the prompt for each job. A fraction BRatio of picks
+// return B; the rest return A. A zero PromptSet uses DefaultPrompt.
+type PromptSet struct {
+	A      Prompt
+	B      Prompt
+	BRatio float64
+}
+
+// WithDomainContext returns ps with domainContext set on both prompts.
+func (ps PromptSet) WithDomainContext(domainContext string) PromptSet {
+	if ps.A.Text == "" {
+		ps.A = DefaultPrompt()
+	}
+	ps.A.DomainContext = domainContext
+	ps.B.DomainContext = domainContext
+	return ps
+}
+
+// Pick returns the prompt to use for one job.
+func (ps PromptSet) Pick() Prompt {
+	if ps.BRatio > 0 && ps.B.Text != "" && rand.Float64() < ps.BRatio {
+		return ps.B
+	}
+	if ps.A.Text == "" {
+		return DefaultPrompt()
+	}
+	return ps.A
+}
@@ -0,0 +1,102 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPrompt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "extraction_v2.txt"), []byte("Extract facts v2.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadPrompt(dir, "2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Version != "2" || p.Text != "Extract facts v2." {
+		t.Errorf("unexpected prompt: %+v", p)
+	}
+	if got := p.Build("Doc", []string{"A", "B"}, "body"); !strings.HasPrefix(got, "Extract facts v2.") || !strings.Contains(got, "Section: A > B") {
+		t.Errorf("unexpected built prompt: %q", got)
+	}
+
+	if _, err := LoadPrompt(dir, "3"); err == nil {
+		t.Error("expected error for missing prompt file")
+	}
+}
+
+func TestPromptSet_Pick(t *testing.T) {
+	if got := (PromptSet{}).Pick(); got.Version != DefaultPromptVersion {
+		t.Errorf("expected zero PromptSet to pick the default prompt, got %q", got.Version)
+	}
+
+	a := Prompt{Version: "2", Text: "a"}
+	b := Prompt{Version: "2_b", Text: "b"}
+	if got := (PromptSet{A: a, B: b}).Pick(); got.Version != "2" {
+		t.Errorf("expected A with zero ratio, got %q", got.Version)
+	}
+	if got := (PromptSet{A: a, B: b, BRatio: 1}).Pick(); got.Version != "2_b" {
+		t.Errorf("expected B with ratio 1, got %q", got.Version)
+	}
+
+	picksB := 0
+	ps := PromptSet{A: a, B: b, BRatio: 0.5}
+	for range 1000 {
+		if ps.Pick().Version == "2_b" {
+			picksB++
+		}
+	}
+	if picksB < 350 || picksB > 650 {
+		t.Errorf("expected about half of picks to use B, got %d/1000", picksB)
+	}
+}
+
+func TestBuildTypedChunkPrompt(t *testing.T) {
+	legal := BuildTypedChunkPrompt("Lease", DocTypeLegal, nil, "body")
+	if !strings.Contains(legal, "legal text") || !strings.HasSuffix(legal, "---\nbody") {
+		t.Errorf("expected legal emphasis before the chunk, got %q", legal)
+	}
+	if !strings.HasPrefix(legal, ExtractionPrompt) {
+		t.Error("expected typed prompt to start with the extraction prompt")
+	}
+
+	general := BuildTypedChunkPrompt("Lease", DocTypeGeneral, nil, "body")
+	if general != DefaultPrompt().Build("Lease", nil, "body") {
+		t.Errorf("expected general prompt to match the untyped prompt, got %q", general)
+	}
+}
+
+func TestBuildTyped_DomainContext(t *testing.T) {
+	p := DefaultPrompt()
+	p.DomainContext = "This document is from the medical field. ICD-10 codes are entity identifiers."
+	got := p.BuildTyped("Chart", DocTypeLegal, []string{"Assessment"}, "body")
+
+	domain := strings.Index(got, "\n\nDomain context: This document is from the medical field.")
+	if domain < 0 {
+		t.Fatalf("expected domain context section, got %q", got)
+	}
+	if emphasis := strings.Index(got, "legal text"); emphasis < 0 || emphasis > domain {
+		t.Errorf("expected domain context after the doc type emphasis, got %q", got)
+	}
+	if sep := strings.Index(got, "\n\n---\n"); sep < domain {
+		t.Errorf("expected domain context before the --- separator, got %q", got)
+	}
+
+	if plain := DefaultPrompt().Build("Chart", nil, "body"); strings.Contains(plain, "Domain context") {
+		t.Errorf("expected no domain context section by default, got %q", plain)
+	}
+}
+
+func TestPromptSet_WithDomainContext(t *testing.T) {
+	ps := PromptSet{B: Prompt{Version: "2_b", Text: "b"}}.WithDomainContext("clinical")
+	if ps.A.Version != DefaultPromptVersion || ps.A.DomainContext != "clinical" {
+		t.Errorf("expected default A prompt with domain context, got %+v", ps.A)
+	}
+	if ps.B.DomainContext != "clinical" {
+		t.Errorf("expected B prompt with domain context, got %+v", ps.B)
+	}
+}
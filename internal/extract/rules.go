@@ -0,0 +1,170 @@
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// ValidationRules holds every tunable fact validation parameter. The
+// defaults reproduce the original hardcoded checks.
+type ValidationRules struct {
+	MinTextLen        int      `json:"min_text_len"`
+	MaxTextLen        int      `json:"max_text_len"`
+	MinSalience       float64  `json:"min_salience"`
+	MaxSalience       float64  `json:"max_salience"`
+	MaxMinTrust       int      `json:"max_min_trust"`
+	MaxTopics         int      `json:"max_topics"`
+	Categories        []string `json:"categories"`
+	InjectionPatterns []string `json:"injection_patterns"`
+}
+
+// DefaultValidationRules returns the built-in rules.
+func DefaultValidationRules() ValidationRules {
+	return ValidationRules{
+		MinTextLen:  3,
+		MaxTextLen:  300,
+		MinSalience: 0.01,
+		MaxSalience: 1.0,
+		MaxMinTrust: 10,
+		MaxTopics:   3,
+		Categories:  []string{"entity_fact", "preference", "topic_knowledge", "procedure"},
+		InjectionPatterns: []string{
+			`(?i)(ignore\s+(previous|all|above)|system\s*prompt|you\s+are\s+now|` +
+				`act\s+as\s+|pretend\s+|forget\s+(everything|all)|override|` +
+				`new\s+instructions)`,
+		},
+	}
+}
+
+// LoadValidationRules reads a JSON rules file. Fields absent from the file
+// keep their default values.
+func LoadValidationRules(path string) (ValidationRules, error) {
+	rules := DefaultValidationRules()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, fmt.Errorf("read validation rules: %w", err)
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("parse validation rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Validator applies a compiled set of ValidationRules to facts.
+type Validator struct {
+	rules      ValidationRules
+	categories map[string]bool
+	injection  []*regexp.Regexp
+}
+
+// NewValidator compiles rules into a Validator.
+func NewValidator(rules ValidationRules) (*Validator, error) {
+	if rules.MinTextLen < 0 || rules.MaxTextLen < rules.MinTextLen {
+		return nil, fmt.Errorf("invalid text length bounds [%d, %d]", rules.MinTextLen, rules.MaxTextLen)
+	}
+	if rules.MaxSalience < rules.MinSalience {
+		return nil, fmt.Errorf("invalid salience bounds [%g, %g]", rules.MinSalience, rules.MaxSalience)
+	}
+	if rules.MaxTopics < 0 {
+		return nil, fmt.Errorf("invalid max_topics %d", rules.MaxTopics)
+	}
+	if len(rules.Categories) == 0 {
+		return nil, fmt.Errorf("at least one category is required")
+	}
+	v := &Validator{
+		rules:      rules,
+		categories: make(map[string]bool, len(rules.Categories)),
+	}
+	for _, c := range rules.Categories {
+		v.categories[c] = true
+	}
+	for _, p := range rules.InjectionPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("compile injection pattern %q: %w", p, err)
+		}
+		v.injection = append(v.injection, re)
+	}
+	return v, nil
+}
+
+// Rules returns the rules the validator was built from.
+func (v *Validator) Rules() ValidationRules {
+	return v.rules
+}
+
+// Validate checks a fact for validity, clamping min_trust and trimming
+// topics in place. Returns true if valid; otherwise the second result lists
+// every rule the fact broke, e.g. "text too long (350 chars)".
+func (v *Validator) Validate(f *Fact) (bool, []string) {
+	if f == nil {
+		return false, []string{"nil fact"}
+	}
+	var reasons []string
+	text := strings.TrimSpace(f.Text)
+	if len(text) < v.rules.MinTextLen {
+		reasons = append(reasons, fmt.Sprintf("text too short (%d chars)", len(text)))
+	}
+	if len(text) > v.rules.MaxTextLen {
+		reasons = append(reasons, fmt.Sprintf("text too long (%d chars)", len(text)))
+	}
+	if !v.categories[f.Category] {
+		reasons = append(reasons, fmt.Sprintf("invalid category: %s", f.Category))
+	}
+	for _, re := range v.injection {
+		if re.MatchString(text) {
+			reasons = append(reasons, fmt.Sprintf("injection pattern: %s", re))
+			break
+		}
+	}
+	if f.Salience < v.rules.MinSalience || f.Salience > v.rules.MaxSalience {
+		reasons = append(reasons, fmt.Sprintf("salience out of range (%g)", f.Salience))
+	}
+	if len(reasons) > 0 {
+		return false, reasons
+	}
+	// Clamp min_trust.
+	if f.MinTrust < 0 || f.MinTrust > v.rules.MaxMinTrust {
+		f.MinTrust = 0
+	}
+	// Limit topics.
+	if len(f.Topics) > v.rules.MaxTopics {
+		f.Topics = f.Topics[:v.rules.MaxTopics]
+	}
+	return true, nil
+}
+
+// RejectionKind strips the per-fact detail from a rejection reason so
+// reasons can be counted: "text too long (350 chars)" becomes
+// "text too long" and "invalid category: events" becomes "invalid category".
+func RejectionKind(reason string) string {
+	if i := strings.IndexAny(reason, ":("); i > 0 {
+		return strings.TrimSpace(reason[:i])
+	}
+	return reason
+}
+
+var defaultValidator atomic.Pointer[Validator]
+
+func init() {
+	v, err := NewValidator(DefaultValidationRules())
+	if err != nil {
+		panic(err)
+	}
+	defaultValidator.Store(v)
+}
+
+// DefaultValidator returns the validator used by ValidateFact.
+func DefaultValidator() *Validator {
+	return defaultValidator.Load()
+}
+
+// SetDefaultValidator replaces the validator used by ValidateFact. Call it
+// at startup before the pipeline begins processing.
+func SetDefaultValidator(v *Validator) {
+	defaultValidator.Store(v)
+}
@@ -0,0 +1,89 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRules(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write rules: %v", err)
+	}
+	return path
+}
+
+func TestLoadValidationRules_PartialFileKeepsDefaults(t *testing.T) {
+	rules, err := LoadValidationRules(writeRules(t, `{"max_text_len": 500}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	def := DefaultValidationRules()
+	if rules.MaxTextLen != 500 {
+		t.Errorf("expected max_text_len 500, got %d", rules.MaxTextLen)
+	}
+	if rules.MinTextLen != def.MinTextLen || rules.MaxTopics != def.MaxTopics {
+		t.Errorf("expected unset fields to keep defaults, got %+v", rules)
+	}
+	if len(rules.InjectionPatterns) != len(def.InjectionPatterns) {
+		t.Errorf("expected default injection patterns, got %d", len(rules.InjectionPatterns))
+	}
+}
+
+func TestLoadValidationRules_MissingFile(t *testing.T) {
+	if _, err := LoadValidationRules(filepath.Join(t.TempDir(), "nope.json")); err == nil {
+		t.Error("expected error for missing rules file")
+	}
+}
+
+func TestValidator_CustomRules(t *testing.T) {
+	rules := DefaultValidationRules()
+	rules.MaxTextLen = 500
+	rules.Categories = append(rules.Categories, "event")
+	rules.InjectionPatterns = append(rules.InjectionPatterns, `(?i)confidential`)
+	v, err := NewValidator(rules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := validFact()
+	f.Text = strings.Repeat("a", 400)
+	if ok, _ := v.Validate(&f); !ok {
+		t.Error("expected 400-char fact to pass with max_text_len 500")
+	}
+
+	f = validFact()
+	f.Category = "event"
+	if ok, _ := v.Validate(&f); !ok {
+		t.Error("expected added category to pass")
+	}
+
+	f = validFact()
+	f.Text = "This is CONFIDENTIAL information."
+	if ok, _ := v.Validate(&f); ok {
+		t.Error("expected custom injection pattern to reject fact")
+	}
+}
+
+func TestNewValidator_RejectsBadRules(t *testing.T) {
+	rules := DefaultValidationRules()
+	rules.InjectionPatterns = []string{"("}
+	if _, err := NewValidator(rules); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+
+	rules = DefaultValidationRules()
+	rules.MaxTextLen = 1
+	if _, err := NewValidator(rules); err == nil {
+		t.Error("expected error for max_text_len below min_text_len")
+	}
+
+	rules = DefaultValidationRules()
+	rules.MaxTopics = -1
+	if _, err := NewValidator(rules); err == nil {
+		t.Error("expected error for negative max_topics")
+	}
+}
@@ -1,8 +1,12 @@
 package extract
 
 import (
+	"fmt"
+	"math"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Fact represents an extracted fact from a document chunk.
@@ -10,66 +14,243 @@ type Fact struct {
 	Text       string   `json:"text"`
 	Category   string   `json:"category"`
 	Entity     string   `json:"entity"`
+	EntityPath string   `json:"entity_path"` // dotted hierarchy, e.g. "acme.engineering.alice"
 	Topics     []string `json:"topics"`
 	Salience   float64  `json:"salience"`
 	Supersedes []string `json:"supersedes"`
 	MinTrust   int      `json:"min_trust"`
 }
 
-var validCategories = map[string]bool{
-	"entity_fact":     true,
-	"preference":      true,
-	"topic_knowledge": true,
-	"procedure":       true,
-}
-
-// CategoryInfo maps category to (path template, memory type, default salience).
+// CategoryInfo maps category to (path template, memory type, default salience, merge mode).
 type CategoryInfo struct {
 	PathTemplate string
 	MemoryType   string
 	DefaultSal   float64
+	MergeMode    string
 }
 
-var CategoryMap = map[string]CategoryInfo{
-	"entity_fact":     {PathTemplate: "entities/{entity}/facts", MemoryType: "semantic", DefaultSal: 0.7},
-	"preference":      {PathTemplate: "entities/{entity}/preferences", MemoryType: "semantic", DefaultSal: 0.8},
-	"topic_knowledge": {PathTemplate: "topics/{topic}", MemoryType: "semantic", DefaultSal: 0.5},
-	"procedure":       {PathTemplate: "procedures/{topic}", MemoryType: "procedural", DefaultSal: 0.6},
+// Categories maps a fact category to its storage settings. Build one with
+// DefaultCategories and the With* methods at startup and inject it where
+// facts are stored.
+type Categories map[string]CategoryInfo
+
+// DefaultCategories returns a fresh copy of the built-in category settings.
+func DefaultCategories() Categories {
+	return Categories{
+		"entity_fact":     {PathTemplate: "entities/{entity}/facts", MemoryType: "semantic", DefaultSal: 0.7, MergeMode: "replace"},
+		"preference":      {PathTemplate: "entities/{entity}/preferences", MemoryType: "semantic", DefaultSal: 0.8, MergeMode: "replace"},
+		"topic_knowledge": {PathTemplate: "topics/{topic}", MemoryType: "semantic", DefaultSal: 0.5, MergeMode: "replace"},
+		"procedure":       {PathTemplate: "procedures/{topic}", MemoryType: "procedural", DefaultSal: 0.6, MergeMode: "replace"},
+	}
 }
 
-var injectionPattern = regexp.MustCompile(
-	`(?i)(ignore\s+(previous|all|above)|system\s*prompt|you\s+are\s+now|` +
-		`act\s+as\s+|pretend\s+|forget\s+(everything|all)|override|` +
-		`new\s+instructions)`,
-)
+// validMergeModes are the pathstore merge modes a category may use.
+var validMergeModes = map[string]bool{
+	"replace": true,
+	"merge":   true,
+	"append":  true,
+}
+
+func (c Categories) clone() Categories {
+	out := make(Categories, len(c))
+	for k, v := range c {
+		out[k] = v
+	}
+	return out
+}
+
+// WithMergeModes returns a copy of c with merge modes overridden from a
+// category->mode map.
+func (c Categories) WithMergeModes(modes map[string]string) (Categories, error) {
+	out := c.clone()
+	for cat, mode := range modes {
+		info, ok := out[cat]
+		if !ok {
+			return nil, fmt.Errorf("unknown category %q", cat)
+		}
+		if !validMergeModes[mode] {
+			return nil, fmt.Errorf("invalid merge mode %q for category %q", mode, cat)
+		}
+		info.MergeMode = mode
+		out[cat] = info
+	}
+	return out, nil
+}
+
+// categoryPlaceholders is the substitution variable each category's path
+// template must contain.
+var categoryPlaceholders = map[string]string{
+	"entity_fact":     "{entity}",
+	"preference":      "{entity}",
+	"topic_knowledge": "{topic}",
+	"procedure":       "{topic}",
+}
+
+// Require returns an error naming the first of names that c has no storage
+// settings for. Validation rules may only accept categories that can be
+// stored.
+func (c Categories) Require(names []string) error {
+	for _, name := range names {
+		if _, ok := c[name]; !ok {
+			return fmt.Errorf("category %q has no storage settings", name)
+		}
+	}
+	return nil
+}
+
+// WithPathTemplates returns a copy of c with path templates overridden from
+// a category->template map. Each template must keep its category's
+// placeholder ({entity} or {topic}).
+func (c Categories) WithPathTemplates(templates map[string]string) (Categories, error) {
+	out := c.clone()
+	for cat, tmpl := range templates {
+		info, ok := out[cat]
+		if !ok {
+			return nil, fmt.Errorf("unknown category %q", cat)
+		}
+		tmpl = strings.Trim(strings.TrimSpace(tmpl), "/")
+		if ph := categoryPlaceholders[cat]; !strings.Contains(tmpl, ph) {
+			return nil, fmt.Errorf("path template %q for category %q must contain %s", tmpl, cat, ph)
+		}
+		info.PathTemplate = tmpl
+		out[cat] = info
+	}
+	return out, nil
+}
+
+// WithSalience returns a copy of c with default salience overridden from a
+// category->salience map. Values must be in (0, 1].
+func (c Categories) WithSalience(salience map[string]float64) (Categories, error) {
+	out := c.clone()
+	for cat, sal := range salience {
+		info, ok := out[cat]
+		if !ok {
+			return nil, fmt.Errorf("unknown category %q", cat)
+		}
+		if sal <= 0 || sal > 1 {
+			return nil, fmt.Errorf("salience %v for category %q out of range (0, 1]", sal, cat)
+		}
+		info.DefaultSal = sal
+		out[cat] = info
+	}
+	return out, nil
+}
+
+// ValidateFact normalizes a fact and checks it against the default
+// validator. Returns true if valid, or false with the reasons the fact was
+// rejected. A valid fact's min_trust is raised to minTrustFloor, so facts
+// from an authoritative source are never retrievable at lower trust.
+func ValidateFact(f *Fact, minTrustFloor int) (bool, []string) {
+	ok, reasons := DefaultValidator().Validate(NormalizeFact(f))
+	if ok && f.MinTrust < minTrustFloor {
+		f.MinTrust = minTrustFloor
+	}
+	return ok, reasons
+}
 
-// ValidateFact checks a fact for validity. Returns true if valid.
-func ValidateFact(f *Fact) bool {
+// NormalizeFact tidies a fact's formatting in place and returns it. Text is
+// trimmed, its first letter capitalized and trailing periods dropped (an
+// ellipsis is kept). Each word of the entity name is capitalized, treating
+// spaces, underscores and hyphens as word breaks, and topics are slugified
+// with empty results dropped.
+func NormalizeFact(f *Fact) *Fact {
 	if f == nil {
-		return false
+		return nil
 	}
 	text := strings.TrimSpace(f.Text)
-	if len(text) < 3 || len(text) > 300 {
-		return false
+	if !strings.HasSuffix(text, "...") {
+		text = strings.TrimSpace(strings.TrimRight(text, "."))
 	}
-	if !validCategories[f.Category] {
-		return false
+	f.Text = upperFirst(text)
+	f.Entity = titleWords(strings.TrimSpace(f.Entity))
+	f.EntityPath = strings.TrimSpace(f.EntityPath)
+
+	topics := f.Topics[:0]
+	for _, t := range f.Topics {
+		if slug := Slugify(t); slug != "" {
+			topics = append(topics, slug)
+		}
 	}
-	if injectionPattern.MatchString(text) {
-		return false
+	f.Topics = topics
+	return f
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
 	}
-	if f.Salience < 0.01 || f.Salience > 1.0 {
-		return false
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// titleWords capitalizes the first letter of each word, leaving the rest of
+// the word alone so names like "McDonald" survive.
+func titleWords(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	start := true
+	for _, r := range s {
+		if start {
+			r = unicode.ToUpper(r)
+		}
+		start = unicode.IsSpace(r) || r == '_' || r == '-'
+		sb.WriteRune(r)
 	}
-	// Clamp min_trust.
-	if f.MinTrust < 0 || f.MinTrust > 10 {
-		f.MinTrust = 0
+	return sb.String()
+}
+
+// NormalizeSalience rescales salience within each category so a document's
+// facts use the 0.1-0.9 range instead of clustering at the category default.
+// Each value is z-scored against its category's mean and stddev, and
+// [-2σ, +2σ] maps linearly onto [0.1, 0.9]. Categories with fewer than two
+// facts or no spread are left unchanged. The input slice is not modified.
+func NormalizeSalience(facts []Fact) []Fact {
+	out := make([]Fact, len(facts))
+	copy(out, facts)
+
+	byCategory := make(map[string][]int)
+	for i, f := range out {
+		byCategory[f.Category] = append(byCategory[f.Category], i)
 	}
-	// Limit topics to 3.
-	if len(f.Topics) > 3 {
-		f.Topics = f.Topics[:3]
+
+	for _, idxs := range byCategory {
+		if len(idxs) < 2 {
+			continue
+		}
+		var sum float64
+		for _, i := range idxs {
+			sum += out[i].Salience
+		}
+		mean := sum / float64(len(idxs))
+		var variance float64
+		for _, i := range idxs {
+			d := out[i].Salience - mean
+			variance += d * d
+		}
+		stddev := math.Sqrt(variance / float64(len(idxs)))
+		if stddev < 1e-9 {
+			continue
+		}
+		for _, i := range idxs {
+			z := (out[i].Salience - mean) / stddev
+			z = max(-2, min(2, z))
+			out[i].Salience = 0.5 + z*0.2
+		}
+	}
+	return out
+}
+
+// SlugifyEntityPath slugifies each dot-separated level of a hierarchical
+// entity and joins the levels with sep, so "Acme.Engineering.Alice"
+// becomes "acme/engineering/alice" for sep "/". Empty levels are dropped.
+func SlugifyEntityPath(s, sep string) string {
+	var levels []string
+	for _, level := range strings.Split(s, ".") {
+		if slug := Slugify(level); slug != "" {
+			levels = append(levels, slug)
+		}
 	}
-	return true
+	return strings.Join(levels, sep)
 }
 
 // Slugify converts a string to a URL/path-safe slug.
@@ -18,13 +18,13 @@ func validFact() Fact {
 
 func TestValidateFact_ValidPasses(t *testing.T) {
 	f := validFact()
-	if !ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); !ok {
 		t.Error("expected valid fact to pass validation")
 	}
 }
 
 func TestValidateFact_NilFact(t *testing.T) {
-	if ValidateFact(nil) {
+	if ok, _ := ValidateFact(nil, 0); ok {
 		t.Error("expected nil fact to fail validation")
 	}
 }
@@ -32,7 +32,7 @@ func TestValidateFact_NilFact(t *testing.T) {
 func TestValidateFact_TextTooShort(t *testing.T) {
 	f := validFact()
 	f.Text = "Hi"
-	if ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); ok {
 		t.Error("expected fact with text < 3 chars to fail")
 	}
 }
@@ -40,7 +40,7 @@ func TestValidateFact_TextTooShort(t *testing.T) {
 func TestValidateFact_TextTooLong(t *testing.T) {
 	f := validFact()
 	f.Text = strings.Repeat("a", 301)
-	if ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); ok {
 		t.Error("expected fact with text > 300 chars to fail")
 	}
 }
@@ -48,7 +48,7 @@ func TestValidateFact_TextTooLong(t *testing.T) {
 func TestValidateFact_TextExactlyMinLength(t *testing.T) {
 	f := validFact()
 	f.Text = "abc"
-	if !ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); !ok {
 		t.Error("expected fact with exactly 3 chars to pass")
 	}
 }
@@ -56,7 +56,7 @@ func TestValidateFact_TextExactlyMinLength(t *testing.T) {
 func TestValidateFact_TextExactlyMaxLength(t *testing.T) {
 	f := validFact()
 	f.Text = strings.Repeat("a", 300)
-	if !ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); !ok {
 		t.Error("expected fact with exactly 300 chars to pass")
 	}
 }
@@ -66,7 +66,7 @@ func TestValidateFact_InvalidCategory(t *testing.T) {
 	for _, cat := range invalid {
 		f := validFact()
 		f.Category = cat
-		if ValidateFact(&f) {
+		if ok, _ := ValidateFact(&f, 0); ok {
 			t.Errorf("expected category %q to fail validation", cat)
 		}
 	}
@@ -77,7 +77,7 @@ func TestValidateFact_AllValidCategories(t *testing.T) {
 	for _, cat := range categories {
 		f := validFact()
 		f.Category = cat
-		if !ValidateFact(&f) {
+		if ok, _ := ValidateFact(&f, 0); !ok {
 			t.Errorf("expected category %q to pass validation", cat)
 		}
 	}
@@ -104,7 +104,7 @@ func TestValidateFact_PromptInjection(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			f := validFact()
 			f.Text = tc.text
-			if ValidateFact(&f) {
+			if ok, _ := ValidateFact(&f, 0); ok {
 				t.Errorf("expected injection %q to be rejected", tc.text)
 			}
 		})
@@ -114,7 +114,7 @@ func TestValidateFact_PromptInjection(t *testing.T) {
 func TestValidateFact_SalienceTooLow(t *testing.T) {
 	f := validFact()
 	f.Salience = 0.0
-	if ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); ok {
 		t.Error("expected salience 0.0 to fail (below 0.01)")
 	}
 }
@@ -122,7 +122,7 @@ func TestValidateFact_SalienceTooLow(t *testing.T) {
 func TestValidateFact_SalienceTooHigh(t *testing.T) {
 	f := validFact()
 	f.Salience = 1.1
-	if ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); ok {
 		t.Error("expected salience 1.1 to fail (above 1.0)")
 	}
 }
@@ -130,7 +130,7 @@ func TestValidateFact_SalienceTooHigh(t *testing.T) {
 func TestValidateFact_SalienceBoundaryLow(t *testing.T) {
 	f := validFact()
 	f.Salience = 0.01
-	if !ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); !ok {
 		t.Error("expected salience 0.01 to pass")
 	}
 }
@@ -138,7 +138,7 @@ func TestValidateFact_SalienceBoundaryLow(t *testing.T) {
 func TestValidateFact_SalienceBoundaryHigh(t *testing.T) {
 	f := validFact()
 	f.Salience = 1.0
-	if !ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); !ok {
 		t.Error("expected salience 1.0 to pass")
 	}
 }
@@ -160,7 +160,7 @@ func TestValidateFact_MinTrustClamping(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			f := validFact()
 			f.MinTrust = tc.input
-			ok := ValidateFact(&f)
+			ok, _ := ValidateFact(&f, 0)
 			if ok != tc.isValid {
 				t.Errorf("expected valid=%v, got %v", tc.isValid, ok)
 			}
@@ -171,10 +171,47 @@ func TestValidateFact_MinTrustClamping(t *testing.T) {
 	}
 }
 
+func TestValidateFact_MinTrustFloor(t *testing.T) {
+	tests := []struct {
+		name  string
+		input int
+		floor int
+		want  int
+	}{
+		{"raised to floor", 0, 7, 7},
+		{"above floor stays", 9, 7, 9},
+		{"out of range clamped then raised", 11, 7, 7},
+		{"zero floor is a no-op", 3, 0, 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := validFact()
+			f.MinTrust = tc.input
+			if ok, _ := ValidateFact(&f, tc.floor); !ok {
+				t.Fatal("expected fact to be valid")
+			}
+			if f.MinTrust != tc.want {
+				t.Errorf("expected MinTrust=%d after validation, got %d", tc.want, f.MinTrust)
+			}
+		})
+	}
+}
+
+func TestValidateFact_MinTrustFloorSkipsRejected(t *testing.T) {
+	f := validFact()
+	f.Category = "bogus"
+	if ok, _ := ValidateFact(&f, 7); ok {
+		t.Fatal("expected invalid category to fail")
+	}
+	if want := validFact().MinTrust; f.MinTrust != want {
+		t.Errorf("expected rejected fact's MinTrust to stay %d, got %d", want, f.MinTrust)
+	}
+}
+
 func TestValidateFact_TopicsTruncation(t *testing.T) {
 	f := validFact()
 	f.Topics = []string{"a", "b", "c", "d", "e"}
-	ok := ValidateFact(&f)
+	ok, _ := ValidateFact(&f, 0)
 	if !ok {
 		t.Fatal("expected fact with >3 topics to still be valid (truncated)")
 	}
@@ -192,7 +229,242 @@ func TestValidateFact_TopicsTruncation(t *testing.T) {
 func TestValidateFact_WhitespaceOnlyText(t *testing.T) {
 	f := validFact()
 	f.Text = "   "
-	if ValidateFact(&f) {
+	if ok, _ := ValidateFact(&f, 0); ok {
 		t.Error("expected whitespace-only text to fail (trimmed length < 3)")
 	}
 }
+
+func TestValidateFact_RejectionReasons(t *testing.T) {
+	f := validFact()
+	f.Text = strings.Repeat("a", 350)
+	f.Category = "events"
+	ok, reasons := ValidateFact(&f, 0)
+	if ok {
+		t.Fatal("expected fact to be rejected")
+	}
+	want := []string{"text too long (350 chars)", "invalid category: events"}
+	if len(reasons) != len(want) {
+		t.Fatalf("expected reasons %v, got %v", want, reasons)
+	}
+	for i, w := range want {
+		if reasons[i] != w {
+			t.Errorf("reason[%d]: expected %q, got %q", i, w, reasons[i])
+		}
+	}
+
+	f = validFact()
+	if _, reasons := ValidateFact(&f, 0); len(reasons) != 0 {
+		t.Errorf("expected no reasons for valid fact, got %v", reasons)
+	}
+}
+
+func TestNormalizeFact_Text(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"  alice prefers dark mode.  ", "Alice prefers dark mode"},
+		{"Alice prefers dark mode", "Alice prefers dark mode"},
+		{"the build takes a while...", "The build takes a while..."},
+		{"it stopped..", "It stopped"},
+		{"élodie runs the lab.", "Élodie runs the lab"},
+		{"42 is the answer.", "42 is the answer"},
+	}
+	for _, tt := range tests {
+		f := Fact{Text: tt.in}
+		if got := NormalizeFact(&f).Text; got != tt.want {
+			t.Errorf("NormalizeFact(%q): expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestNormalizeFact_Entity(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"alice", "Alice"},
+		{" alice smith ", "Alice Smith"},
+		{"alice_smith", "Alice_Smith"},
+		{"mary-jane", "Mary-Jane"},
+		{"McDonald", "McDonald"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		f := Fact{Entity: tt.in}
+		if got := NormalizeFact(&f).Entity; got != tt.want {
+			t.Errorf("NormalizeFact entity %q: expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestNormalizeFact_Topics(t *testing.T) {
+	f := Fact{Topics: []string{"Dark Mode", "editors", "  ", "C++ Tips"}}
+	NormalizeFact(&f)
+	want := []string{"dark-mode", "editors", "c-tips"}
+	if len(f.Topics) != len(want) {
+		t.Fatalf("expected topics %v, got %v", want, f.Topics)
+	}
+	for i, w := range want {
+		if f.Topics[i] != w {
+			t.Errorf("topic[%d]: expected %q, got %q", i, w, f.Topics[i])
+		}
+	}
+}
+
+func TestNormalizeFact_Nil(t *testing.T) {
+	if NormalizeFact(nil) != nil {
+		t.Error("expected nil for a nil fact")
+	}
+}
+
+func TestValidateFact_NormalizesBeforeValidating(t *testing.T) {
+	f := validFact()
+	f.Text = "darrell prefers dark mode in all editors."
+	f.Entity = "darrell"
+	if ok, reasons := ValidateFact(&f, 0); !ok {
+		t.Fatalf("expected fact to pass, got %v", reasons)
+	}
+	if f.Text != "Darrell prefers dark mode in all editors" {
+		t.Errorf("expected normalized text, got %q", f.Text)
+	}
+	if f.Entity != "Darrell" {
+		t.Errorf("expected normalized entity, got %q", f.Entity)
+	}
+}
+
+func TestRejectionKind(t *testing.T) {
+	tests := map[string]string{
+		"text too long (350 chars)": "text too long",
+		"invalid category: events":  "invalid category",
+		"salience out of range (2)": "salience out of range",
+		"nil fact":                  "nil fact",
+	}
+	for in, want := range tests {
+		if got := RejectionKind(in); got != want {
+			t.Errorf("RejectionKind(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestCategories_WithMergeModes(t *testing.T) {
+	defaults := DefaultCategories()
+	if defaults["preference"].MergeMode != "replace" {
+		t.Errorf("expected default merge mode %q, got %q", "replace", defaults["preference"].MergeMode)
+	}
+	cats, err := defaults.WithMergeModes(map[string]string{"topic_knowledge": "merge"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cats["topic_knowledge"].MergeMode; got != "merge" {
+		t.Errorf("expected merge mode %q, got %q", "merge", got)
+	}
+	if got := defaults["topic_knowledge"].MergeMode; got != "replace" {
+		t.Errorf("expected receiver to be unchanged, got %q", got)
+	}
+	if _, err := defaults.WithMergeModes(map[string]string{"topic_knowledge": "squash"}); err == nil {
+		t.Error("expected error for invalid merge mode")
+	}
+	if _, err := defaults.WithMergeModes(map[string]string{"unknown": "merge"}); err == nil {
+		t.Error("expected error for unknown category")
+	}
+}
+
+func TestCategories_WithSalience(t *testing.T) {
+	cats, err := DefaultCategories().WithSalience(map[string]float64{"preference": 0.9})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cats["preference"].DefaultSal; got != 0.9 {
+		t.Errorf("expected preference salience 0.9, got %v", got)
+	}
+	if got := cats["entity_fact"].DefaultSal; got != 0.7 {
+		t.Errorf("expected entity_fact to keep default 0.7, got %v", got)
+	}
+	for _, bad := range []float64{0, -0.1, 1.5} {
+		if _, err := DefaultCategories().WithSalience(map[string]float64{"procedure": bad}); err == nil {
+			t.Errorf("expected error for salience %v", bad)
+		}
+	}
+	if _, err := DefaultCategories().WithSalience(map[string]float64{"unknown": 0.5}); err == nil {
+		t.Error("expected error for unknown category")
+	}
+}
+
+func TestCategories_WithPathTemplates(t *testing.T) {
+	cats, err := DefaultCategories().WithPathTemplates(map[string]string{
+		"entity_fact": "/people/{entity}/facts/",
+		"procedure":   "howto/{topic}",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cats["entity_fact"].PathTemplate; got != "people/{entity}/facts" {
+		t.Errorf("expected trimmed template %q, got %q", "people/{entity}/facts", got)
+	}
+	if got := cats["procedure"].PathTemplate; got != "howto/{topic}" {
+		t.Errorf("expected template %q, got %q", "howto/{topic}", got)
+	}
+	if got := cats["preference"].PathTemplate; got != "entities/{entity}/preferences" {
+		t.Errorf("expected preference template unchanged, got %q", got)
+	}
+
+	if _, err := DefaultCategories().WithPathTemplates(map[string]string{"preference": "prefs/{topic}"}); err == nil {
+		t.Error("expected error when {entity} placeholder is missing")
+	}
+	if _, err := DefaultCategories().WithPathTemplates(map[string]string{"topic_knowledge": "kb"}); err == nil {
+		t.Error("expected error when {topic} placeholder is missing")
+	}
+	if _, err := DefaultCategories().WithPathTemplates(map[string]string{"unknown": "x/{topic}"}); err == nil {
+		t.Error("expected error for unknown category")
+	}
+}
+
+func TestNormalizeSalience_SpreadsWithinCategory(t *testing.T) {
+	facts := []Fact{
+		{Category: "topic_knowledge", Salience: 0.5},
+		{Category: "topic_knowledge", Salience: 0.5},
+		{Category: "topic_knowledge", Salience: 0.6},
+		{Category: "topic_knowledge", Salience: 0.4},
+		{Category: "preference", Salience: 0.8},
+	}
+	got := NormalizeSalience(facts)
+
+	if facts[2].Salience != 0.6 {
+		t.Error("expected input slice to be left unmodified")
+	}
+	if got[2].Salience <= got[0].Salience || got[3].Salience >= got[0].Salience {
+		t.Errorf("expected ordering to be preserved, got %v %v %v", got[0].Salience, got[2].Salience, got[3].Salience)
+	}
+	for _, f := range got[:4] {
+		if f.Salience < 0.1 || f.Salience > 0.9 {
+			t.Errorf("expected salience within [0.1, 0.9], got %v", f.Salience)
+		}
+	}
+	if got[2].Salience-got[3].Salience < 0.4 {
+		t.Errorf("expected spread to widen, got %v..%v", got[3].Salience, got[2].Salience)
+	}
+	if got[4].Salience != 0.8 {
+		t.Errorf("expected single-fact category unchanged, got %v", got[4].Salience)
+	}
+}
+
+func TestNormalizeSalience_UniformCategoryUnchanged(t *testing.T) {
+	facts := []Fact{
+		{Category: "procedure", Salience: 0.6},
+		{Category: "procedure", Salience: 0.6},
+	}
+	for _, f := range NormalizeSalience(facts) {
+		if f.Salience != 0.6 {
+			t.Errorf("expected uniform salience unchanged, got %v", f.Salience)
+		}
+	}
+}
+
+func TestCategories_Require(t *testing.T) {
+	c := DefaultCategories()
+	if err := c.Require(DefaultValidationRules().Categories); err != nil {
+		t.Errorf("expected default rule categories to be storable, got %v", err)
+	}
+	if err := c.Require([]string{"entity_fact", "events"}); err == nil || !strings.Contains(err.Error(), "events") {
+		t.Errorf("expected an error naming events, got %v", err)
+	}
+}
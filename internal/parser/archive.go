@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// MaxArchiveEntries caps how many supported documents one archive may
+// contain.
+const MaxArchiveEntries = 50
+
+// ArchiveEntry is one supported document read from an archive.
+type ArchiveEntry struct {
+	Name string // path inside the archive
+	Data []byte
+}
+
+// IsArchive reports whether filename names an archive ExtractArchive can
+// expand: .tar.gz, .tgz or .tar.bz2.
+func IsArchive(filename string) bool {
+	return archiveCompression(filename) != ""
+}
+
+func archiveCompression(filename string) string {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "gzip"
+	case strings.HasSuffix(name, ".tar.bz2"):
+		return "bzip2"
+	default:
+		return ""
+	}
+}
+
+// ExtractArchive returns the documents with a supported extension inside
+// the archive filename. Directories, links and unsupported files are
+// skipped. It fails if the archive holds more than MaxArchiveEntries
+// documents, if their combined size exceeds maxTotal bytes, or if any
+// entry name is absolute or climbs out of the archive with "..".
+func ExtractArchive(filename string, data []byte, maxTotal int64) ([]ArchiveEntry, error) {
+	var r io.Reader
+	switch archiveCompression(filename) {
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("open gzip: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case "bzip2":
+		r = bzip2.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported archive: %s", filename)
+	}
+	return extractTar(r, maxTotal)
+}
+
+func extractTar(r io.Reader, maxTotal int64) ([]ArchiveEntry, error) {
+	tr := tar.NewReader(r)
+	var entries []ArchiveEntry
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar: %w", err)
+		}
+		if !safeArchivePath(hdr.Name) {
+			return nil, fmt.Errorf("unsafe path in archive: %s", hdr.Name)
+		}
+		if hdr.Typeflag != tar.TypeReg || !IsSupportedExtension(hdr.Name) {
+			continue
+		}
+		if len(entries) == MaxArchiveEntries {
+			return nil, fmt.Errorf("archive has more than %d documents", MaxArchiveEntries)
+		}
+		remaining := maxTotal - total
+		data, err := io.ReadAll(io.LimitReader(tr, remaining+1))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+		}
+		total += int64(len(data))
+		if total > maxTotal {
+			return nil, fmt.Errorf("archive contents exceed %d bytes", maxTotal)
+		}
+		entries = append(entries, ArchiveEntry{Name: hdr.Name, Data: data})
+	}
+}
+
+// safeArchivePath rejects absolute names and any name with a ".." element,
+// whichever separator it uses.
+func safeArchivePath(name string) bool {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
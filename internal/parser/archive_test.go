@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+type tarFile struct {
+	name string
+	data string
+	dir  bool
+}
+
+func buildTarGz(t *testing.T, files ...tarFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.data)), Typeflag: tar.TypeReg}
+		if f.dir {
+			hdr = &tar.Header{Name: f.name, Mode: 0o755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestIsArchive(t *testing.T) {
+	for name, want := range map[string]bool{
+		"docs.tar.gz":  true,
+		"DOCS.TGZ":     true,
+		"docs.tar.bz2": true,
+		"docs.gz":      false,
+		"docs.zip":     false,
+		"notes.md":     false,
+	} {
+		if got := IsArchive(name); got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestExtractArchive_TarGz(t *testing.T) {
+	data := buildTarGz(t,
+		tarFile{name: "docs/", dir: true},
+		tarFile{name: "docs/notes.md", data: "# Notes"},
+		tarFile{name: "docs/logo.png", data: "png"},
+		tarFile{name: "docs/people.csv", data: "name\nalice"},
+	)
+	entries, err := ExtractArchive("docs.tgz", data, 1<<20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 supported entries, got %d", len(entries))
+	}
+	if entries[0].Name != "docs/notes.md" || string(entries[0].Data) != "# Notes" {
+		t.Errorf("expected docs/notes.md first, got %s %q", entries[0].Name, entries[0].Data)
+	}
+	if entries[1].Name != "docs/people.csv" {
+		t.Errorf("expected docs/people.csv second, got %s", entries[1].Name)
+	}
+}
+
+func TestExtractArchive_TarBz2(t *testing.T) {
+	data, err := os.ReadFile("testdata/notes.tar.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ExtractArchive("notes.tar.bz2", data, 1<<20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "docs/notes.md" {
+		t.Fatalf("expected only docs/notes.md, got %v", entries)
+	}
+	if !strings.Contains(string(entries[0].Data), "Milo") {
+		t.Errorf("expected notes content, got %q", entries[0].Data)
+	}
+}
+
+func TestExtractArchive_RejectsPathTraversal(t *testing.T) {
+	for _, name := range []string{"../escape.md", "docs/../../escape.md", "/etc/notes.md", `docs\..\..\escape.md`} {
+		data := buildTarGz(t, tarFile{name: name, data: "# x"})
+		if _, err := ExtractArchive("docs.tar.gz", data, 1<<20); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+	// Unsupported files cannot sneak a traversal through either.
+	data := buildTarGz(t, tarFile{name: "../escape.bin", data: "x"})
+	if _, err := ExtractArchive("docs.tar.gz", data, 1<<20); err == nil {
+		t.Error("expected traversal in an unsupported entry to be rejected")
+	}
+}
+
+func TestExtractArchive_Limits(t *testing.T) {
+	var files []tarFile
+	for i := range MaxArchiveEntries + 1 {
+		files = append(files, tarFile{name: fmt.Sprintf("doc-%d.txt", i), data: "x"})
+	}
+	if _, err := ExtractArchive("docs.tar.gz", buildTarGz(t, files...), 1<<20); err == nil {
+		t.Errorf("expected more than %d documents to be rejected", MaxArchiveEntries)
+	}
+
+	data := buildTarGz(t, tarFile{name: "a.txt", data: strings.Repeat("a", 600)}, tarFile{name: "b.txt", data: strings.Repeat("b", 600)})
+	if _, err := ExtractArchive("docs.tar.gz", data, 1000); err == nil {
+		t.Error("expected contents over the total size limit to be rejected")
+	}
+	if _, err := ExtractArchive("docs.tar.gz", data, 1200); err != nil {
+		t.Errorf("expected contents at the limit to pass, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/dgallion1/docgest/internal/doctree"
+)
+
+// AsciiDocParser handles AsciiDoc files. Headings ("=" through "======")
+// build the section hierarchy, "----" listing blocks are kept verbatim as
+// ``` fenced blocks, and
+// admonitions are rendered as "NOTE: text".
+type AsciiDocParser struct{}
+
+var (
+	adocHeading    = regexp.MustCompile(`^(={1,6})\s+(.+?)\s*$`)
+	adocAdmonition = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|CAUTION|WARNING):\s*(.*)$`)
+	adocAttrLine   = regexp.MustCompile(`^:[\w-]+!?:`)
+	adocBlockAttr  = regexp.MustCompile(`^\[([^\]]*)\]$`)
+)
+
+func (p *AsciiDocParser) Parse(r io.Reader, filename string) (*doctree.DocTree, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	tree := &doctree.DocTree{
+		Title: strings.TrimSuffix(strings.TrimSuffix(filename, ".adoc"), ".asciidoc"),
+	}
+
+	type stackEntry struct {
+		node  *doctree.DocNode
+		level int
+	}
+	root := &doctree.DocNode{Title: tree.Title}
+	stack := []stackEntry{{node: root, level: 0}}
+
+	appendText := func(t string) {
+		top := stack[len(stack)-1].node
+		if top.Text != "" {
+			top.Text += "\n\n" + t
+		} else {
+			top.Text = t
+		}
+	}
+
+	var para, listing []string
+	inListing, inExample := false, false
+	// pending is the admonition type from a "[NOTE]" style line; it applies
+	// to the next paragraph, or to every paragraph of the example block
+	// ("====") that follows.
+	pending, blockAdmonition := "", ""
+	flushPara := func() {
+		if t := strings.TrimSpace(strings.Join(para, "\n")); t != "" {
+			appendText(t)
+			pending = ""
+		}
+		para = para[:0]
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "----" {
+			flushPara()
+			if inListing {
+				if strings.TrimSpace(strings.Join(listing, "")) != "" {
+					appendText("```\n" + strings.Join(listing, "\n") + "\n```")
+				}
+				listing = listing[:0]
+			}
+			inListing = !inListing
+			continue
+		}
+		if inListing {
+			listing = append(listing, line)
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushPara()
+			continue
+		case strings.HasPrefix(trimmed, "//"), adocAttrLine.MatchString(trimmed):
+			continue
+		case trimmed == "====":
+			flushPara()
+			inExample = !inExample
+			if inExample {
+				blockAdmonition, pending = pending, ""
+			} else {
+				blockAdmonition = ""
+			}
+			continue
+		}
+
+		if m := adocBlockAttr.FindStringSubmatch(trimmed); m != nil {
+			flushPara()
+			style, _, _ := strings.Cut(m[1], ",")
+			if adocAdmonition.MatchString(style + ":") {
+				pending = style
+			}
+			continue
+		}
+
+		if m := adocHeading.FindStringSubmatch(line); m != nil {
+			flushPara()
+			pending = ""
+			level := len(m[1])
+			newNode := &doctree.DocNode{Title: m[2], Level: level}
+			for len(stack) > 1 && stack[len(stack)-1].level >= level {
+				stack = stack[:len(stack)-1]
+			}
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, newNode)
+			stack = append(stack, stackEntry{node: newNode, level: level})
+			continue
+		}
+
+		if len(para) == 0 {
+			if m := adocAdmonition.FindStringSubmatch(trimmed); m != nil {
+				line = m[1] + ": " + m[2]
+			} else if blockAdmonition != "" {
+				line = blockAdmonition + ": " + trimmed
+			} else if pending != "" {
+				line = pending + ": " + trimmed
+			}
+		}
+		para = append(para, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flushPara()
+	if len(listing) > 0 {
+		appendText(strings.Join(listing, "\n"))
+	}
+
+	tree.Children = root.Children
+	if len(tree.Children) == 0 && root.Text != "" {
+		tree.Children = []*doctree.DocNode{{Text: root.Text}}
+	}
+	return normalizeTree(tree), nil
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dgallion1/docgest/internal/doctree"
+)
+
+func TestAsciiDocParser_Headings(t *testing.T) {
+	input := `= Install Guide
+:toc:
+// generated from the wiki
+
+Intro text.
+
+== Requirements
+
+You need Go 1.25.
+
+=== Linux
+
+Use the package manager.
+
+== Setup
+
+Run the installer.
+`
+	tree, err := (&AsciiDocParser{}).Parse(strings.NewReader(input), "install.adoc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := doctree.NewTree("install").
+		Section("Install Guide", "Intro text.").
+		SubSection("Requirements", "You need Go 1.25.").
+		SubSection("Linux", "Use the package manager.").
+		Up().
+		Sibling("Setup", "Run the installer.").
+		Build()
+	doctree.AssertTreeEqual(t, tree, want)
+}
+
+func TestAsciiDocParser_ListingBlockVerbatim(t *testing.T) {
+	input := "== Usage\n\nRun:\n\n[source,yaml]\n----\n== not a heading\n  indented: true\n\nNOTE: not an admonition\n----\n\nDone.\n"
+	tree, err := (&AsciiDocParser{}).Parse(strings.NewReader(input), "usage.asciidoc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := doctree.NewTree("usage").
+		Section("Usage", "Run:\n\n```\n== not a heading\n  indented: true\n\nNOTE: not an admonition\n```\n\nDone.").
+		Build()
+	doctree.AssertTreeEqual(t, tree, want)
+}
+
+func TestAsciiDocParser_Admonitions(t *testing.T) {
+	input := `== Notes
+
+NOTE: Backups run nightly.
+
+TIP:Use the CLI
+for bulk imports.
+
+[WARNING]
+Deleting a user is permanent.
+
+[CAUTION]
+====
+Rotate keys yearly.
+
+Never share them.
+====
+
+Plain paragraph.
+`
+	tree, err := (&AsciiDocParser{}).Parse(strings.NewReader(input), "notes.adoc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := doctree.NewTree("notes").
+		Section("Notes", strings.Join([]string{
+			"NOTE: Backups run nightly.",
+			"TIP: Use the CLI\nfor bulk imports.",
+			"WARNING: Deleting a user is permanent.",
+			"CAUTION: Rotate keys yearly.",
+			"CAUTION: Never share them.",
+			"Plain paragraph.",
+		}, "\n\n")).
+		Build()
+	doctree.AssertTreeEqual(t, tree, want)
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchFixtures holds one representative section per format; the
+// benchmarks repeat it to build a large document.
+//
+//go:embed testdata/bench
+var benchFixtures embed.FS
+
+// benchSections is how many copies of a fixture section make a large document.
+const benchSections = 200
+
+// benchCSVRows is the data row count of the large CSV.
+const benchCSVRows = 10000
+
+func benchFixture(b *testing.B, name string) string {
+	b.Helper()
+	data, err := benchFixtures.ReadFile("testdata/bench/" + name)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return string(data)
+}
+
+func benchmarkParse(b *testing.B, p Parser, filename string, data []byte) {
+	b.Helper()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		if _, err := p.Parse(bytes.NewReader(data), filename); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarkdownParser_Large(b *testing.B) {
+	doc := "# Operations Handbook\n\n" + strings.Repeat(benchFixture(b, "section.md"), benchSections)
+	benchmarkParse(b, &MarkdownParser{}, "handbook.md", []byte(doc))
+}
+
+func BenchmarkHTMLParser_Large(b *testing.B) {
+	doc := "<html><head><title>Operations Handbook</title></head><body><h1>Operations Handbook</h1>\n" +
+		strings.Repeat(benchFixture(b, "section.html"), benchSections) +
+		"</body></html>\n"
+	benchmarkParse(b, &HTMLParser{}, "handbook.html", []byte(doc))
+}
+
+func BenchmarkTextParser_Large(b *testing.B) {
+	doc := strings.Repeat(benchFixture(b, "section.txt"), benchSections)
+	benchmarkParse(b, &TextParser{}, "review.txt", []byte(doc))
+}
+
+func BenchmarkCSVParser_Large(b *testing.B) {
+	lines := strings.Split(strings.TrimSpace(benchFixture(b, "rows.csv")), "\n")
+	header, rows := lines[0], lines[1:]
+
+	// Cycle the fixture rows, renumbering the order ID so every row is unique.
+	var buf strings.Builder
+	buf.WriteString(header + "\n")
+	for i := range benchCSVRows {
+		_, rest, _ := strings.Cut(rows[i%len(rows)], ",")
+		fmt.Fprintf(&buf, "%d,%s\n", 1001+i, rest)
+	}
+	benchmarkParse(b, &CSVParser{}, "orders.csv", []byte(buf.String()))
+}
@@ -2,69 +2,242 @@ package parser
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dgallion1/docgest/internal/doctree"
 )
 
-// CSVParser handles CSV files.
-type CSVParser struct{}
+// DefaultCSVBatchSize is how many data rows go into each node unless a
+// parser is configured otherwise.
+const DefaultCSVBatchSize = 20
+
+// CSVParser handles CSV files. Rows are read one at a time and rendered in
+// batches of BatchSize (DefaultCSVBatchSize when zero), so only a batch of
+// rows, plus the typeSampleRows read ahead for type inference, is held at
+// once.
+type CSVParser struct {
+	BatchSize int
+}
 
 func (p *CSVParser) Parse(r io.Reader, filename string) (*doctree.DocTree, error) {
 	reader := csv.NewReader(r)
 	reader.LazyQuotes = true
 	reader.TrimLeadingSpace = true
 
-	records, err := reader.ReadAll()
+	tree := &doctree.DocTree{
+		Title: strings.TrimSuffix(filename, ".csv"),
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return normalizeTree(tree), nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("parse csv: %w", err)
 	}
 
-	tree := &doctree.DocTree{
-		Title: strings.TrimSuffix(filename, ".csv"),
+	size := p.BatchSize
+	if size <= 0 {
+		size = DefaultCSVBatchSize
 	}
-
-	if len(records) == 0 {
-		return tree, nil
+	var (
+		types   []colType
+		pending [][]string
+		next    = 2 // row number of pending[0], counting the header as row 1
+	)
+	// flush renders full batches from pending, and the remainder too when
+	// final is set. Nothing is rendered until the column types are known.
+	flush := func(final bool) {
+		for len(pending) >= size || final && len(pending) > 0 {
+			n := min(size, len(pending))
+			tree.Children = append(tree.Children, &doctree.DocNode{
+				Title: fmt.Sprintf("Rows %d-%d", next, next+n-1),
+				Text:  renderRows(header, types, pending[:n]),
+			})
+			next += n
+			pending = append(pending[:0], pending[n:]...)
+		}
+	}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse csv: %w", err)
+		}
+		pending = append(pending, row)
+		if types == nil && len(pending) < typeSampleRows {
+			continue
+		}
+		if types == nil {
+			types = inferColumnTypes(header, pending)
+		}
+		flush(false)
+	}
+	if types == nil {
+		types = inferColumnTypes(header, pending)
 	}
+	flush(true)
+	return normalizeTree(tree), nil
+}
 
-	// First row is headers.
-	headers := records[0]
+// csvBatchNodes groups data rows into nodes of DefaultCSVBatchSize rows
+// each, for manageable chunks. Node titles give 1-indexed row numbers
+// counting the header row.
+func csvBatchNodes(header []string, dataRows [][]string) []*doctree.DocNode {
+	types := inferColumnTypes(header, dataRows)
+	var nodes []*doctree.DocNode
+	for i := 0; i < len(dataRows); i += DefaultCSVBatchSize {
+		end := min(i+DefaultCSVBatchSize, len(dataRows))
+		nodes = append(nodes, &doctree.DocNode{
+			Title: fmt.Sprintf("Rows %d-%d", i+2, end+1),
+			Text:  renderRows(header, types, dataRows[i:end]),
+		})
+	}
+	return nodes
+}
 
-	// Group rows into batches of 20 for manageable chunks.
-	const batchSize = 20
-	dataRows := records[1:]
+// renderRows formats rows as "Header (type): value" pairs under a line
+// listing the labelled headers.
+func renderRows(header []string, types []colType, rows [][]string) string {
+	labels := make([]string, len(header))
+	for j, h := range header {
+		labels[j] = columnLabel(h, types[j])
+	}
 
-	for i := 0; i < len(dataRows); i += batchSize {
-		end := i + batchSize
-		if end > len(dataRows) {
-			end = len(dataRows)
+	var text strings.Builder
+	text.WriteString("Headers: " + strings.Join(labels, ", ") + "\n\n")
+	for _, row := range rows {
+		for j, cell := range row {
+			if j < len(labels) {
+				text.WriteString(labels[j] + ": " + formatCell(cell, types[j]))
+			} else {
+				text.WriteString(cell)
+			}
+			if j < len(row)-1 {
+				text.WriteString(", ")
+			}
 		}
-		batch := dataRows[i:end]
-
-		var text strings.Builder
-		text.WriteString("Headers: " + strings.Join(headers, ", ") + "\n\n")
-		for _, row := range batch {
-			for j, cell := range row {
-				if j < len(headers) {
-					text.WriteString(headers[j] + ": " + cell)
-				} else {
-					text.WriteString(cell)
-				}
-				if j < len(row)-1 {
-					text.WriteString(", ")
-				}
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+// colType is the inferred type of a CSV column.
+type colType int
+
+const (
+	colString colType = iota
+	colInteger
+	colFloat
+	colDate
+)
+
+func (t colType) String() string {
+	switch t {
+	case colInteger:
+		return "integer"
+	case colFloat:
+		return "float"
+	case colDate:
+		return "date"
+	}
+	return "string"
+}
+
+// typeSampleRows is how many rows per column inferColumnTypes inspects.
+const typeSampleRows = 20
+
+// dateLayouts are the date formats recognised in CSV cells.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+}
+
+// inferColumnTypes classifies each column from the first typeSampleRows
+// rows. A column takes a non-string type only if every non-empty sampled
+// cell parses as it; integer wins over float, and both over date.
+func inferColumnTypes(headers []string, rows [][]string) []colType {
+	sample := rows[:min(len(rows), typeSampleRows)]
+	types := make([]colType, len(headers))
+	for j := range headers {
+		isInt, isFloat, isDate := true, true, true
+		seen := false
+		for _, row := range sample {
+			if j >= len(row) {
+				continue
+			}
+			cell := strings.TrimSpace(row[j])
+			if cell == "" {
+				continue
+			}
+			seen = true
+			if _, err := strconv.ParseInt(cell, 10, 64); err != nil {
+				isInt = false
+			}
+			if _, err := strconv.ParseFloat(cell, 64); err != nil {
+				isFloat = false
 			}
-			text.WriteString("\n")
+			if _, ok := parseDate(cell); !ok {
+				isDate = false
+			}
+		}
+		switch {
+		case !seen:
+			types[j] = colString
+		case isInt:
+			types[j] = colInteger
+		case isFloat:
+			types[j] = colFloat
+		case isDate:
+			types[j] = colDate
 		}
+	}
+	return types
+}
 
-		tree.Children = append(tree.Children, &doctree.DocNode{
-			Title: fmt.Sprintf("Rows %d-%d", i+2, end+1), // 1-indexed, skip header
-			Text:  text.String(),
-		})
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
 	}
+	return time.Time{}, false
+}
 
-	return tree, nil
+// columnLabel annotates a header with its type, e.g. "Price (float)".
+// String columns are left as-is.
+func columnLabel(header string, t colType) string {
+	if t == colString {
+		return header
+	}
+	return fmt.Sprintf("%s (%s)", header, t)
+}
+
+// formatCell renders a cell for its column type: floats with two decimals,
+// dates as RFC3339. Cells that do not parse are returned unchanged.
+func formatCell(cell string, t colType) string {
+	v := strings.TrimSpace(cell)
+	switch t {
+	case colFloat:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', 2, 64)
+		}
+	case colDate:
+		if d, ok := parseDate(v); ok {
+			return d.Format(time.RFC3339)
+		}
+	}
+	return cell
 }
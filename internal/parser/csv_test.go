@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInferColumnTypes(t *testing.T) {
+	headers := []string{"Name", "Qty", "Price", "Date", "Notes"}
+	rows := [][]string{
+		{"Widget", "3", "9.99", "2024-01-15", "blue"},
+		{"Gadget", "10", "12", "2024-02-01", ""},
+		{"Gizmo", "", "0.5", "2024-03-10", "42"},
+	}
+	got := inferColumnTypes(headers, rows)
+	want := []colType{colString, colInteger, colFloat, colDate, colString}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s: expected %s, got %s", headers[i], want[i], got[i])
+		}
+	}
+}
+
+func TestInferColumnTypes_SamplesFirstRows(t *testing.T) {
+	rows := make([][]string, 0, typeSampleRows+1)
+	for range typeSampleRows {
+		rows = append(rows, []string{"1"})
+	}
+	rows = append(rows, []string{"not a number"})
+	if got := inferColumnTypes([]string{"N"}, rows); got[0] != colInteger {
+		t.Errorf("expected rows past the sample to be ignored, got %s", got[0])
+	}
+}
+
+func TestCSVParser_TypedRendering(t *testing.T) {
+	input := "Item,Price,Sold\nWidget,9.5,2024-01-15\nGadget,12,2024-02-01\n"
+	tree, err := (&CSVParser{}).Parse(strings.NewReader(input), "sales.csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tree.Children) != 1 {
+		t.Fatalf("expected 1 batch node, got %d", len(tree.Children))
+	}
+	text := tree.Children[0].Text
+	for _, want := range []string{
+		"Headers: Item, Price (float), Sold (date)",
+		"Item: Widget, Price (float): 9.50, Sold (date): 2024-01-15T00:00:00Z",
+		"Price (float): 12.00",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected text to contain %q, got %q", want, text)
+		}
+	}
+}
+
+func TestCSVParser_BatchSize(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("ID,Code\n")
+	for i := 1; i <= 7; i++ {
+		fmt.Fprintf(&sb, "%d,%d\n", i, i)
+	}
+	// A non-integer code past the first batch still makes the column a
+	// string, because types come from the full sample.
+	sb.WriteString("8,x8\n")
+
+	tree, err := (&CSVParser{BatchSize: 3}).Parse(strings.NewReader(sb.String()), "codes.csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var titles []string
+	for _, n := range tree.Children {
+		titles = append(titles, n.Title)
+	}
+	want := []string{"Rows 2-4", "Rows 5-7", "Rows 8-9"}
+	if !slices.Equal(titles, want) {
+		t.Fatalf("expected batches %v, got %v", want, titles)
+	}
+	if text := tree.Children[0].Text; !strings.Contains(text, "Headers: ID (integer), Code\n") {
+		t.Errorf("expected Code typed from the whole sample, got %q", text)
+	}
+	if text := tree.Children[2].Text; !strings.Contains(text, "ID (integer): 8, Code: x8") {
+		t.Errorf("expected last row in the final batch, got %q", text)
+	}
+}
+
+func TestCSVParser_HeaderOnly(t *testing.T) {
+	tree, err := (&CSVParser{}).Parse(strings.NewReader("A,B\n"), "empty.csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tree.Children) != 0 {
+		t.Errorf("expected no batches, got %d", len(tree.Children))
+	}
+}
@@ -1,17 +1,31 @@
 package parser
 
 import (
+	"archive/zip"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/dgallion1/docgest/internal/doctree"
 	"github.com/fumiama/go-docx"
 )
 
+// Revision modes for tracked changes in .docx files.
+const (
+	DocxRevisionFinal    = "final"    // text as if all changes were accepted
+	DocxRevisionOriginal = "original" // text as if all changes were rejected
+	DocxRevisionBoth     = "both"     // inserted and deleted text together
+)
+
 // DOCXParser handles .docx files.
-type DOCXParser struct{}
+type DOCXParser struct {
+	// RevisionMode selects which side of tracked changes to read; empty
+	// means DocxRevisionFinal.
+	RevisionMode string
+}
 
 func (p *DOCXParser) Parse(r io.Reader, filename string) (*doctree.DocTree, error) {
 	// go-docx needs a ReadSeeker+size, so write to temp file.
@@ -33,10 +47,21 @@ func (p *DOCXParser) Parse(r io.Reader, filename string) (*doctree.DocTree, erro
 	}
 
 	doc, err := docx.Parse(tmp, int64(size))
-	tmp.Close()
 	if err != nil {
+		tmp.Close()
 		return nil, fmt.Errorf("parse docx: %w", err)
 	}
+	// Alt text is best effort: a document without readable descr attributes
+	// still parses.
+	altText, _ := docxAltTexts(tmp, size)
+	// go-docx drops <w:ins> and <w:del> content, so paragraphs with tracked
+	// changes are re-read from the XML.
+	revised, err := docxRevisedParagraphs(tmp, size, p.RevisionMode, altText)
+	if err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("read tracked changes: %w", err)
+	}
+	tmp.Close()
 
 	tree := &doctree.DocTree{
 		Title: strings.TrimSuffix(filename, ".docx"),
@@ -63,19 +88,24 @@ func (p *DOCXParser) Parse(r io.Reader, filename string) (*doctree.DocTree, erro
 		currentText.Reset()
 	}
 
+	paraIndex := -1
 	for _, item := range doc.Document.Body.Items {
 		para, ok := item.(*docx.Paragraph)
 		if !ok {
 			continue
 		}
+		paraIndex++
 
 		// Check if paragraph has a heading style.
 		level := docxHeadingLevel(para)
-		text := docxParagraphText(para)
+		text, ok := revised[paraIndex]
+		if !ok {
+			text = docxParagraphText(para, altText)
+		}
 
 		if level > 0 && text != "" {
 			flushText()
-			newNode := &doctree.DocNode{Title: text}
+			newNode := &doctree.DocNode{Title: text, Level: level}
 			for len(stack) > 1 && stack[len(stack)-1].level >= level {
 				stack = stack[:len(stack)-1]
 			}
@@ -96,7 +126,7 @@ func (p *DOCXParser) Parse(r io.Reader, filename string) (*doctree.DocTree, erro
 		tree.Children = []*doctree.DocNode{{Text: root.Text}}
 	}
 
-	return tree, nil
+	return normalizeTree(tree), nil
 }
 
 func docxHeadingLevel(para *docx.Paragraph) int {
@@ -121,7 +151,9 @@ func docxHeadingLevel(para *docx.Paragraph) int {
 	return 0
 }
 
-func docxParagraphText(para *docx.Paragraph) string {
+// docxParagraphText joins a paragraph's run text. Drawings with alt text
+// are rendered inline as "[Figure: <alt-text>]".
+func docxParagraphText(para *docx.Paragraph, altText map[int]string) string {
 	var buf strings.Builder
 	for _, child := range para.Children {
 		run, ok := child.(*docx.Run)
@@ -129,10 +161,187 @@ func docxParagraphText(para *docx.Paragraph) string {
 			continue
 		}
 		for _, rc := range run.Children {
-			if t, ok := rc.(*docx.Text); ok {
-				buf.WriteString(t.Text)
+			switch c := rc.(type) {
+			case *docx.Text:
+				buf.WriteString(c.Text)
+			case *docx.Drawing:
+				alt := altText[docxDrawingID(c)]
+				if alt == "" {
+					continue
+				}
+				if buf.Len() > 0 && !strings.HasSuffix(buf.String(), " ") {
+					buf.WriteByte(' ')
+				}
+				fmt.Fprintf(&buf, "[Figure: %s]", alt)
 			}
 		}
 	}
 	return strings.TrimSpace(buf.String())
 }
+
+// docxDrawingID returns the docPr id of an inline or anchored drawing, or
+// -1 if it has none.
+func docxDrawingID(d *docx.Drawing) int {
+	switch {
+	case d.Inline != nil && d.Inline.DocPr != nil:
+		return d.Inline.DocPr.ID
+	case d.Anchor != nil && d.Anchor.DocPr != nil:
+		return d.Anchor.DocPr.ID
+	}
+	return -1
+}
+
+// docxAltTexts maps each drawing's wp:docPr id to its descr attribute.
+// go-docx does not keep descr, so word/document.xml is scanned directly.
+func docxAltTexts(ra io.ReaderAt, size int64) (map[int]string, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	f, err := zr.Open("word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	alt := make(map[int]string)
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return alt, nil
+		}
+		if err != nil {
+			return alt, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "docPr" {
+			continue
+		}
+		id, descr := -1, ""
+		for _, a := range se.Attr {
+			switch a.Name.Local {
+			case "id":
+				if n, err := strconv.Atoi(a.Value); err == nil {
+					id = n
+				}
+			case "descr":
+				descr = strings.Join(strings.Fields(a.Value), " ")
+			}
+		}
+		if id >= 0 && descr != "" {
+			alt[id] = descr
+		}
+	}
+}
+
+// docxIsInsertion reports whether el wraps runs added with track changes.
+func docxIsInsertion(el xml.StartElement) bool {
+	return el.Name.Local == "ins" || el.Name.Local == "moveTo"
+}
+
+// docxIsDeletion reports whether el wraps runs removed with track changes.
+// Formatting-only changes (w:rPrChange, w:pPrChange) are not deletions and
+// leave the text alone.
+func docxIsDeletion(el xml.StartElement) bool {
+	return el.Name.Local == "del" || el.Name.Local == "moveFrom"
+}
+
+// docxRevisedParagraphs returns the text, under mode, of every top-level
+// body paragraph containing tracked insertions or deletions, keyed by the
+// paragraph's position among the body's paragraphs. Paragraphs without
+// tracked changes are left to go-docx.
+func docxRevisedParagraphs(ra io.ReaderAt, size int64, mode string, altText map[int]string) (map[int]string, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	f, err := zr.Open("word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	revised := make(map[int]string)
+	dec := xml.NewDecoder(f)
+	depth, bodyDepth := 0, -1
+	paraIndex, paraDepth := -1, -1
+	var insDepth, delDepth int
+	hasRevision := false
+	var buf strings.Builder
+	keep := func() bool {
+		switch mode {
+		case DocxRevisionOriginal:
+			return insDepth == 0
+		case DocxRevisionBoth:
+			return true
+		default:
+			return delDepth == 0
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return revised, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case t.Name.Local == "body" && bodyDepth < 0:
+				bodyDepth = depth
+			case t.Name.Local == "p" && depth == bodyDepth+1:
+				paraIndex++
+				paraDepth = depth
+				insDepth, delDepth, hasRevision = 0, 0, false
+				buf.Reset()
+			case paraDepth < 0:
+			case docxIsInsertion(t):
+				insDepth++
+				hasRevision = true
+			case docxIsDeletion(t):
+				delDepth++
+				hasRevision = true
+			case t.Name.Local == "t" || t.Name.Local == "delText":
+				var s string
+				if err := dec.DecodeElement(&s, &t); err != nil {
+					return nil, err
+				}
+				depth--
+				if keep() {
+					buf.WriteString(s)
+				}
+			case t.Name.Local == "docPr":
+				id := -1
+				for _, a := range t.Attr {
+					if a.Name.Local == "id" {
+						id, _ = strconv.Atoi(a.Value)
+					}
+				}
+				if alt := altText[id]; alt != "" && keep() {
+					if buf.Len() > 0 && !strings.HasSuffix(buf.String(), " ") {
+						buf.WriteByte(' ')
+					}
+					fmt.Fprintf(&buf, "[Figure: %s]", alt)
+				}
+			}
+		case xml.EndElement:
+			switch {
+			case depth == paraDepth:
+				if hasRevision {
+					revised[paraIndex] = strings.TrimSpace(buf.String())
+				}
+				paraDepth = -1
+			case paraDepth < 0:
+			case t.Name.Local == "ins" || t.Name.Local == "moveTo":
+				insDepth--
+			case t.Name.Local == "del" || t.Name.Local == "moveFrom":
+				delDepth--
+			}
+			depth--
+		}
+	}
+}
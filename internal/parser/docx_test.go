@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// buildDOCX returns a minimal .docx whose body is the given XML.
+func buildDOCX(t *testing.T, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+ xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing">
+<w:body>` + body + `</w:body></w:document>`))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDOCXParser_DrawingAltText(t *testing.T) {
+	body := `<w:p>
+  <w:r><w:t>Revenue grew in Q4.</w:t></w:r>
+  <w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0">
+    <wp:docPr id="7" name="Chart 1" descr="Bar chart of quarterly revenue,
+      rising from 2M to 5M"/>
+  </wp:inline></w:drawing></w:r>
+</w:p>
+<w:p>
+  <w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0">
+    <wp:docPr id="8" name="Logo"/>
+  </wp:inline></w:drawing></w:r>
+  <w:r><w:t>No alt text here.</w:t></w:r>
+</w:p>`
+
+	p := &DOCXParser{}
+	tree, err := p.Parse(bytes.NewReader(buildDOCX(t, body)), "report.docx")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tree.Children) != 1 {
+		t.Fatalf("expected 1 child, got %d", len(tree.Children))
+	}
+
+	text := tree.Children[0].Text
+	want := "Revenue grew in Q4. [Figure: Bar chart of quarterly revenue, rising from 2M to 5M]"
+	if !strings.Contains(text, want) {
+		t.Errorf("expected text to contain %q, got %q", want, text)
+	}
+	if strings.Count(text, "[Figure:") != 1 {
+		t.Errorf("expected drawings without alt text to be skipped, got %q", text)
+	}
+	if !strings.Contains(text, "No alt text here.") {
+		t.Errorf("expected second paragraph text, got %q", text)
+	}
+}
+
+func TestDOCXParser_TrackedChanges(t *testing.T) {
+	body := `<w:p>
+  <w:r><w:t xml:space="preserve">The launch is </w:t></w:r>
+  <w:del w:id="1" w:author="Ann"><w:r><w:delText>Monday</w:delText></w:r></w:del>
+  <w:ins w:id="2" w:author="Ann"><w:r><w:t>Friday</w:t></w:r></w:ins>
+  <w:r><w:t>.</w:t></w:r>
+</w:p>
+<w:p>
+  <w:r><w:rPr><w:b/><w:rPrChange w:id="3" w:author="Ann"><w:rPr/></w:rPrChange></w:rPr><w:t>Budget is fixed.</w:t></w:r>
+</w:p>`
+
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{"", "The launch is Friday."},
+		{DocxRevisionFinal, "The launch is Friday."},
+		{DocxRevisionOriginal, "The launch is Monday."},
+		{DocxRevisionBoth, "The launch is MondayFriday."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			p := &DOCXParser{RevisionMode: tt.mode}
+			tree, err := p.Parse(bytes.NewReader(buildDOCX(t, body)), "plan.docx")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(tree.Children) != 1 {
+				t.Fatalf("expected 1 child, got %d", len(tree.Children))
+			}
+			want := tt.want + "\n\nBudget is fixed."
+			if got := tree.Children[0].Text; got != want {
+				t.Errorf("expected %q, got %q", want, got)
+			}
+		})
+	}
+}
@@ -10,7 +10,11 @@ import (
 )
 
 // HTMLParser handles HTML files.
-type HTMLParser struct{}
+type HTMLParser struct {
+	// UseReadability narrows parsing to the highest-scoring content block,
+	// dropping navigation and boilerplate on pages without semantic markup.
+	UseReadability bool
+}
 
 func (p *HTMLParser) Parse(r io.Reader, filename string) (*doctree.DocTree, error) {
 	doc, err := html.Parse(r)
@@ -57,7 +61,7 @@ func (p *HTMLParser) Parse(r io.Reader, filename string) (*doctree.DocTree, erro
 				flushText()
 				title := textContent(n)
 
-				newNode := &doctree.DocNode{Title: title}
+				newNode := &doctree.DocNode{Title: title, Level: level}
 				for len(stack) > 1 && stack[len(stack)-1].level >= level {
 					stack = stack[:len(stack)-1]
 				}
@@ -71,8 +75,13 @@ func (p *HTMLParser) Parse(r io.Reader, filename string) (*doctree.DocTree, erro
 			switch n.Data {
 			case "script", "style", "nav", "footer", "header":
 				return
-			case "p", "li", "td", "blockquote":
-				t := textContent(n)
+			case "p", "li", "blockquote", "table":
+				var t string
+				if n.Data == "table" {
+					t = renderHTMLTable(n)
+				} else {
+					t = textContent(n)
+				}
 				if t != "" {
 					if currentText.Len() > 0 {
 						currentText.WriteString("\n\n")
@@ -89,12 +98,16 @@ func (p *HTMLParser) Parse(r io.Reader, filename string) (*doctree.DocTree, erro
 	}
 
 	// Find <body> or use whole document.
-	body := findBody(doc)
-	if body != nil {
-		walk(body)
-	} else {
-		walk(doc)
+	content := findBody(doc)
+	if content == nil {
+		content = doc
+	}
+	if p.UseReadability {
+		if article := readableContent(content); article != nil {
+			content = article
+		}
 	}
+	walk(content)
 	flushText()
 
 	tree.Children = root.Children
@@ -102,7 +115,7 @@ func (p *HTMLParser) Parse(r io.Reader, filename string) (*doctree.DocTree, erro
 		tree.Children = []*doctree.DocNode{{Text: root.Text}}
 	}
 
-	return tree, nil
+	return normalizeTree(tree), nil
 }
 
 func headingLevel(tag string) int {
@@ -161,3 +174,107 @@ func findBody(n *html.Node) *html.Node {
 	}
 	return nil
 }
+
+// renderHTMLTable renders a <table> as a Markdown table. A first row made
+// only of <th> cells becomes the header; otherwise the header is left
+// blank so no data row is lost. Tables nested inside cells cannot be
+// expressed in Markdown, so each is rendered as its own table after the
+// one containing it.
+func renderHTMLTable(n *html.Node) string {
+	var rows [][]string
+	var header bool
+	var nested []*html.Node
+
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "table":
+				nested = append(nested, c)
+			case "tr":
+				var row []string
+				allTH := true
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+						continue
+					}
+					allTH = allTH && cell.Data == "th"
+					row = append(row, tableCellText(cell, &nested))
+				}
+				if len(row) == 0 {
+					continue
+				}
+				if len(rows) == 0 {
+					header = allTH
+				}
+				rows = append(rows, row)
+			default: // thead, tbody, tfoot, caption
+				collect(c)
+			}
+		}
+	}
+	collect(n)
+
+	var parts []string
+	if len(rows) > 0 {
+		cols := 0
+		for _, row := range rows {
+			cols = max(cols, len(row))
+		}
+		if !header {
+			rows = append([][]string{make([]string, cols)}, rows...)
+		}
+
+		var buf strings.Builder
+		writeRow := func(row []string) {
+			buf.WriteString("|")
+			for i := range cols {
+				if i < len(row) && row[i] != "" {
+					buf.WriteString(" " + row[i] + " |")
+				} else {
+					buf.WriteString(" |")
+				}
+			}
+			buf.WriteString("\n")
+		}
+		writeRow(rows[0])
+		buf.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+		for _, row := range rows[1:] {
+			writeRow(row)
+		}
+		parts = append(parts, strings.TrimSuffix(buf.String(), "\n"))
+	}
+	for _, t := range nested {
+		if s := renderHTMLTable(t); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// tableCellText returns a cell's text on one line with pipes escaped.
+// Tables inside the cell are skipped and appended to nested.
+func tableCellText(n *html.Node, nested *[]*html.Node) string {
+	var buf strings.Builder
+	var extract func(*html.Node)
+	extract = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "table" {
+			*nested = append(*nested, n)
+			return
+		}
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			extract(c)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		extract(c)
+	}
+	text := strings.Join(strings.Fields(buf.String()), " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dgallion1/docgest/internal/doctree"
+)
+
+func TestHTMLParser_Table(t *testing.T) {
+	input := `<html><body>
+<h1>Pricing</h1>
+<p>Plans as of May.</p>
+<table>
+  <thead><tr><th>Plan</th><th>Price</th></tr></thead>
+  <tbody>
+    <tr><td>Basic</td><td>$5</td></tr>
+    <tr><td>Pro | Team</td><td>
+      $20
+      per seat</td></tr>
+  </tbody>
+</table>
+<p>Prices exclude tax.</p>
+</body></html>`
+	tree, err := (&HTMLParser{}).Parse(strings.NewReader(input), "pricing.html")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := doctree.NewTree("pricing").
+		Section("Pricing", "Plans as of May.\n\n"+
+			"| Plan | Price |\n"+
+			"| --- | --- |\n"+
+			"| Basic | $5 |\n"+
+			"| Pro \\| Team | $20 per seat |\n\n"+
+			"Prices exclude tax.").
+		Build()
+	doctree.AssertTreeEqual(t, tree, want)
+}
+
+func TestRenderHTMLTable_NoHeader(t *testing.T) {
+	got := renderTableFragment(t, `<table><tr><td>a</td><td>b</td></tr><tr><td>c</td></tr></table>`)
+	want := "| | |\n| --- | --- |\n| a | b |\n| c | |"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRenderHTMLTable_Nested(t *testing.T) {
+	got := renderTableFragment(t, `<table>
+<tr><th>Region</th><th>Detail</th></tr>
+<tr><td>EU</td><td>See below<table><tr><th>Country</th></tr><tr><td>FR</td></tr></table></td></tr>
+</table>`)
+	want := "| Region | Detail |\n| --- | --- |\n| EU | See below |\n\n" +
+		"| Country |\n| --- |\n| FR |"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func renderTableFragment(t *testing.T, fragment string) string {
+	t.Helper()
+	tree, err := (&HTMLParser{}).Parse(strings.NewReader("<html><body>"+fragment+"</body></html>"), "t.html")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tree.Children) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(tree.Children))
+	}
+	return tree.Children[0].Text
+}
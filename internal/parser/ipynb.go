@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dgallion1/docgest/internal/doctree"
+)
+
+// JupyterParser handles Jupyter notebooks (nbformat 3 and 4). Markdown
+// cells go through MarkdownParser so their headings build the section
+// hierarchy across cells; code cells are kept as fenced blocks tagged with
+// the kernel language. Outputs and raw cells are skipped.
+type JupyterParser struct{}
+
+// notebook covers both layouts: nbformat 4 lists cells at the top level,
+// nbformat 3 nests them in worksheets.
+type notebook struct {
+	NBFormat int            `json:"nbformat"`
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+		Language string `json:"language"` // nbformat 3
+	} `json:"metadata"`
+	Worksheets []struct {
+		Cells []notebookCell `json:"cells"`
+	} `json:"worksheets"`
+}
+
+type notebookCell struct {
+	CellType string         `json:"cell_type"`
+	Source   notebookSource `json:"source"`
+	Input    notebookSource `json:"input"`    // nbformat 3 code cells
+	Language string         `json:"language"` // nbformat 3 code cells
+	Level    int            `json:"level"`    // nbformat 3 heading cells
+}
+
+// notebookSource is cell text stored either as one string or as a list of
+// lines.
+type notebookSource string
+
+func (s *notebookSource) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = notebookSource(strings.Join(lines, ""))
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("cell source: %w", err)
+	}
+	*s = notebookSource(str)
+	return nil
+}
+
+func (p *JupyterParser) Parse(r io.Reader, filename string) (*doctree.DocTree, error) {
+	var nb notebook
+	if err := json.NewDecoder(r).Decode(&nb); err != nil {
+		return nil, fmt.Errorf("decode notebook: %w", err)
+	}
+
+	var cells []notebookCell
+	language := nb.Metadata.Kernelspec.Language
+	if language == "" {
+		language = nb.Metadata.LanguageInfo.Name
+	}
+	switch {
+	case nb.NBFormat >= 4:
+		cells = nb.Cells
+	case nb.NBFormat == 3:
+		for _, ws := range nb.Worksheets {
+			cells = append(cells, ws.Cells...)
+		}
+		if language == "" {
+			language = nb.Metadata.Language
+		}
+	default:
+		return nil, fmt.Errorf("unsupported nbformat %d", nb.NBFormat)
+	}
+
+	// Markdown is parsed as one document so a heading in one cell scopes
+	// the cells after it. Code cells stand in as placeholder paragraphs and
+	// are swapped for their fenced source once the tree is built.
+	var md strings.Builder
+	var code []string
+	for _, c := range cells {
+		var block string
+		switch c.CellType {
+		case "markdown":
+			block = strings.TrimSpace(string(c.Source))
+		case "heading":
+			block = strings.Repeat("#", max(c.Level, 1)) + " " + strings.TrimSpace(string(c.Source))
+		case "code":
+			src := strings.TrimSpace(string(c.Source) + string(c.Input))
+			if src == "" {
+				continue
+			}
+			lang := c.Language
+			if lang == "" {
+				lang = language
+			}
+			block = fmt.Sprintf("docgestipynbcell%d", len(code))
+			code = append(code, "```"+lang+"\n"+src+"\n```")
+		}
+		if block != "" {
+			md.WriteString(block)
+			md.WriteString("\n\n")
+		}
+	}
+
+	title := strings.TrimSuffix(filename, ".ipynb")
+	tree, err := (&MarkdownParser{}).Parse(strings.NewReader(md.String()), title)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) > 0 {
+		var restore func(nodes []*doctree.DocNode)
+		restore = func(nodes []*doctree.DocNode) {
+			for _, n := range nodes {
+				// Replace from the highest index so cell1 does not match
+				// inside cell10.
+				for i := len(code) - 1; i >= 0; i-- {
+					n.Text = strings.Replace(n.Text, fmt.Sprintf("docgestipynbcell%d", i), code[i], 1)
+				}
+				restore(n.Children)
+			}
+		}
+		restore(tree.Children)
+	}
+	return normalizeTree(tree), nil
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dgallion1/docgest/internal/doctree"
+)
+
+func TestJupyterParser_NBFormat4(t *testing.T) {
+	input := `{
+  "nbformat": 4,
+  "nbformat_minor": 5,
+  "metadata": {"kernelspec": {"name": "python3", "language": "python"}},
+  "cells": [
+    {"cell_type": "markdown", "metadata": {}, "source": ["# Analysis\n", "\n", "Loads the data."]},
+    {"cell_type": "code", "metadata": {}, "execution_count": 1, "source": "import pandas as pd\ndf = pd.read_csv('x.csv')",
+     "outputs": [{"output_type": "stream", "name": "stdout", "text": ["should not appear\n"]}]},
+    {"cell_type": "raw", "metadata": {}, "source": "raw text is skipped"},
+    {"cell_type": "markdown", "metadata": {}, "source": "## Results\n\nLooks good."}
+  ]
+}`
+	tree, err := (&JupyterParser{}).Parse(strings.NewReader(input), "analysis.ipynb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := doctree.NewTree("analysis").
+		Section("Analysis", "Loads the data.\n\n```python\nimport pandas as pd\ndf = pd.read_csv('x.csv')\n```").
+		SubSection("Results", "Looks good.").
+		Build()
+	doctree.AssertTreeEqual(t, tree, want)
+}
+
+func TestJupyterParser_NBFormat3(t *testing.T) {
+	input := `{
+  "nbformat": 3,
+  "metadata": {"language": "julia"},
+  "worksheets": [{"cells": [
+    {"cell_type": "heading", "level": 1, "source": ["Setup"]},
+    {"cell_type": "markdown", "source": ["Install packages."]},
+    {"cell_type": "code", "input": ["using Pkg"], "outputs": []}
+  ]}]
+}`
+	tree, err := (&JupyterParser{}).Parse(strings.NewReader(input), "setup.ipynb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := doctree.NewTree("setup").
+		Section("Setup", "Install packages.\n\n```julia\nusing Pkg\n```").
+		Build()
+	doctree.AssertTreeEqual(t, tree, want)
+}
+
+func TestJupyterParser_UnsupportedFormat(t *testing.T) {
+	_, err := (&JupyterParser{}).Parse(strings.NewReader(`{"nbformat": 2}`), "old.ipynb")
+	if err == nil || !strings.Contains(err.Error(), "unsupported nbformat") {
+		t.Errorf("expected unsupported nbformat error, got %v", err)
+	}
+}
+
+func TestJupyterParser_Registered(t *testing.T) {
+	if !IsSupportedExtension("notebook.ipynb") {
+		t.Errorf("expected .ipynb to be supported")
+	}
+	p, err := ForFile("notebook.ipynb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := p.(*JupyterParser); !ok {
+		t.Errorf("expected *JupyterParser, got %T", p)
+	}
+}
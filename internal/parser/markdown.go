@@ -61,7 +61,7 @@ func (p *MarkdownParser) Parse(r io.Reader, filename string) (*doctree.DocTree,
 			level := node.Level
 			title := string(node.Text(src))
 
-			newNode := &doctree.DocNode{Title: title}
+			newNode := &doctree.DocNode{Title: title, Level: level}
 
 			// Pop stack until we find a parent with lower level.
 			for len(stack) > 1 && stack[len(stack)-1].level >= level {
@@ -73,8 +73,12 @@ func (p *MarkdownParser) Parse(r io.Reader, filename string) (*doctree.DocTree,
 			stack = append(stack, stackEntry{node: newNode, level: level})
 
 		default:
-			// Collect text content from non-heading blocks.
+			// Collect text content from non-heading blocks. Code is
+			// fenced so the chunker keeps its whitespace.
 			t := extractText(n, src)
+			if t != "" {
+				t = fenceCode(n, src, t)
+			}
 			if t != "" {
 				if currentText.Len() > 0 {
 					currentText.WriteString("\n\n")
@@ -91,13 +95,29 @@ func (p *MarkdownParser) Parse(r io.Reader, filename string) (*doctree.DocTree,
 		tree.Children = []*doctree.DocNode{{Text: root.Text}}
 	}
 
-	return tree, nil
+	return normalizeTree(tree), nil
+}
+
+// fenceCode wraps the text of a code block in ``` fences, tagged with its
+// language when the block names one. Other blocks are returned unchanged.
+func fenceCode(n ast.Node, src []byte, t string) string {
+	var lang string
+	switch node := n.(type) {
+	case *ast.FencedCodeBlock:
+		lang = string(node.Language(src))
+	case *ast.CodeBlock:
+	default:
+		return t
+	}
+	return "```" + lang + "\n" + t + "\n```"
 }
 
 // extractText gets the text content of a goldmark AST node.
 func extractText(n ast.Node, src []byte) string {
 	var buf bytes.Buffer
-	if n.Type() == ast.TypeBlock {
+	// Leaf blocks (code) carry raw lines; blocks with inline children carry
+	// the same text in those children, so reading both would duplicate it.
+	if n.Type() == ast.TypeBlock && n.ChildCount() == 0 {
 		lines := n.Lines()
 		for i := 0; i < lines.Len(); i++ {
 			line := lines.At(i)
@@ -3,6 +3,8 @@ package parser
 import (
 	"strings"
 	"testing"
+
+	"github.com/dgallion1/docgest/internal/doctree"
 )
 
 func TestMarkdownParser_HeadingHierarchy(t *testing.T) {
@@ -28,50 +30,19 @@ Section B content.
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if tree.Title != "doc" {
-		t.Errorf("expected title %q, got %q", "doc", tree.Title)
-	}
-
-	// Top-level: one h1 ("Title")
-	if len(tree.Children) != 1 {
-		t.Fatalf("expected 1 top-level child (h1), got %d", len(tree.Children))
-	}
-
-	h1 := tree.Children[0]
-	if h1.Title != "Title" {
-		t.Errorf("expected h1 title %q, got %q", "Title", h1.Title)
-	}
-
-	// h1 should have "Intro text." as its text content
-	if !strings.Contains(h1.Text, "Intro text.") {
-		t.Errorf("expected h1 text to contain %q, got %q", "Intro text.", h1.Text)
-	}
-
-	// h1 has two h2 children: "Section A" and "Section B"
-	if len(h1.Children) != 2 {
-		t.Fatalf("expected 2 h2 children, got %d", len(h1.Children))
-	}
-
-	secA := h1.Children[0]
-	if secA.Title != "Section A" {
-		t.Errorf("expected %q, got %q", "Section A", secA.Title)
-	}
-	if !strings.Contains(secA.Text, "Section A content.") {
-		t.Errorf("expected section A text to contain %q, got %q", "Section A content.", secA.Text)
-	}
+	want := doctree.NewTree("doc").
+		Section("Title", "Intro text.").
+		SubSection("Section A", "Section A content.").
+		SubSection("Subsection A1", "Subsection A1 content.").
+		Up().
+		Sibling("Section B", "Section B content.").
+		Build()
+	doctree.AssertTreeEqual(t, tree, want)
 
-	// Section A has one h3 child
-	if len(secA.Children) != 1 {
-		t.Fatalf("expected 1 h3 child under Section A, got %d", len(secA.Children))
-	}
-	sub := secA.Children[0]
-	if sub.Title != "Subsection A1" {
-		t.Errorf("expected %q, got %q", "Subsection A1", sub.Title)
-	}
-
-	secB := h1.Children[1]
-	if secB.Title != "Section B" {
-		t.Errorf("expected %q, got %q", "Section B", secB.Title)
+	title := tree.Children[0]
+	if title.Level != 1 || title.Children[0].Level != 2 || title.Children[0].Children[0].Level != 3 {
+		t.Errorf("expected heading levels 1/2/3, got %d/%d/%d",
+			title.Level, title.Children[0].Level, title.Children[0].Children[0].Level)
 	}
 }
 
@@ -138,6 +109,27 @@ func TestMarkdownParser_MixedContentWithCodeBlocks(t *testing.T) {
 	}
 }
 
+func TestMarkdownParser_BlockTextOnce(t *testing.T) {
+	// Paragraphs carry their text both as raw lines and as inline
+	// children; code blocks have only raw lines. Each must appear once.
+	input := "# Notes\n\nMilo is a *golden* retriever.\n\n- Luna is a cat.\n\n> Rex is a parrot.\n\n```\nfetch(ball)\n```\n"
+
+	p := &MarkdownParser{}
+	tree, err := p.Parse(strings.NewReader(input), "notes.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tree.Children) != 1 {
+		t.Fatalf("expected 1 top-level child, got %d", len(tree.Children))
+	}
+	text := tree.Children[0].Text
+	for _, want := range []string{"retriever.", "Luna is a cat.", "Rex is a parrot.", "fetch(ball)"} {
+		if n := strings.Count(text, want); n != 1 {
+			t.Errorf("expected %q once, got %d times in %q", want, n, text)
+		}
+	}
+}
+
 func TestMarkdownParser_EmptyInput(t *testing.T) {
 	p := &MarkdownParser{}
 	tree, err := p.Parse(strings.NewReader(""), "empty.md")
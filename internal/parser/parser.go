@@ -1,14 +1,23 @@
 package parser
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"path/filepath"
 	"strings"
 
+	"github.com/dgallion1/docgest/internal/chunker"
 	"github.com/dgallion1/docgest/internal/doctree"
 )
 
+// ErrParseFailure marks a document that could not be parsed, including one
+// in an unsupported format, in job errors. Callers wrap it alongside the
+// original error.
+var ErrParseFailure = errors.New("parse failure")
+
 // Parser converts raw document bytes into a DocTree.
 type Parser interface {
 	Parse(r io.Reader, filename string) (*doctree.DocTree, error)
@@ -18,15 +27,37 @@ type Parser interface {
 var SupportedExtensions = map[string]bool{
 	".txt": true,
 	".md":  true,
+	".markdown": true,
 	".csv": true,
 	".html": true,
 	".htm":  true,
 	".pdf":  true,
 	".docx": true,
+	".xlsx": true,
+	".rss":  true,
+	".atom": true,
+	".xml":  true,
+	".adoc": true,
+	".asciidoc": true,
+	".ipynb": true,
+}
+
+// Options configures format-specific parser behavior.
+type Options struct {
+	PDFFallbackPdftotext bool
+	HTMLUseReadability   bool
+	DocxRevisionMode     string
+	CSVBatchSize         int
 }
 
-// ForFile returns the appropriate parser for a filename.
+// ForFile returns the appropriate parser for a filename with default options.
 func ForFile(filename string) (Parser, error) {
+	return ForFileWithOptions(filename, Options{})
+}
+
+// ForFileWithOptions returns the appropriate parser for a filename,
+// configured from opts.
+func ForFileWithOptions(filename string, opts Options) (Parser, error) {
 	ext := strings.ToLower(filepath.Ext(filename))
 	switch ext {
 	case ".txt":
@@ -34,13 +65,21 @@ func ForFile(filename string) (Parser, error) {
 	case ".md", ".markdown":
 		return &MarkdownParser{}, nil
 	case ".csv":
-		return &CSVParser{}, nil
+		return &CSVParser{BatchSize: opts.CSVBatchSize}, nil
 	case ".html", ".htm":
-		return &HTMLParser{}, nil
+		return &HTMLParser{UseReadability: opts.HTMLUseReadability}, nil
 	case ".pdf":
-		return &PDFParser{}, nil
+		return &PDFParser{FallbackPdftotext: opts.PDFFallbackPdftotext}, nil
 	case ".docx":
-		return &DOCXParser{}, nil
+		return &DOCXParser{RevisionMode: opts.DocxRevisionMode}, nil
+	case ".xlsx":
+		return &XLSXParser{}, nil
+	case ".rss", ".atom", ".xml":
+		return &RSSParser{}, nil
+	case ".adoc", ".asciidoc":
+		return &AsciiDocParser{}, nil
+	case ".ipynb":
+		return &JupyterParser{}, nil
 	default:
 		return nil, fmt.Errorf("unsupported file extension: %s", ext)
 	}
@@ -51,3 +90,135 @@ func IsSupportedExtension(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
 	return SupportedExtensions[ext]
 }
+
+// ForContentType returns the appropriate parser for a MIME type with
+// default options.
+func ForContentType(mimeType string) (Parser, error) {
+	return ForContentTypeWithOptions(mimeType, Options{})
+}
+
+// ForContentTypeWithOptions returns the appropriate parser for a MIME type
+// such as a multipart part's Content-Type, configured from opts. Parameters
+// like charset are ignored.
+func ForContentTypeWithOptions(mimeType string, opts Options) (Parser, error) {
+	mt, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported content type: %q", mimeType)
+	}
+	switch mt {
+	case "text/html":
+		return &HTMLParser{UseReadability: opts.HTMLUseReadability}, nil
+	case "text/plain":
+		return &TextParser{}, nil
+	case "text/markdown":
+		return &MarkdownParser{}, nil
+	case "application/pdf":
+		return &PDFParser{FallbackPdftotext: opts.PDFFallbackPdftotext}, nil
+	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+		return &DOCXParser{RevisionMode: opts.DocxRevisionMode}, nil
+	case "application/x-ipynb+json":
+		return &JupyterParser{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content type: %s", mt)
+	}
+}
+
+// IsSupportedContentType checks if a MIME type has a parser.
+func IsSupportedContentType(mimeType string) bool {
+	_, err := ForContentType(mimeType)
+	return err == nil
+}
+
+// Select picks the parser for an upload. The extension decides unless it is
+// unsupported or the file's magic bytes contradict it, in which case the
+// declared content type is used when it maps to a parser. An upload with
+// neither a supported extension nor a usable content type falls back to
+// sniffing its leading bytes.
+func Select(filename, contentType string, data []byte, opts Options) (Parser, error) {
+	p, err := ForFileWithOptions(filename, opts)
+	if err == nil && magicMatchesExtension(filename, data) {
+		return p, nil
+	}
+	if cp, cerr := ForContentTypeWithOptions(contentType, opts); cerr == nil {
+		return cp, nil
+	}
+	if err != nil {
+		if dp, derr := DetectParserFromContentWithOptions(data, opts); derr == nil {
+			return dp, nil
+		}
+	}
+	return p, err
+}
+
+// sniffLen is how much of a document DetectParserFromContent inspects.
+const sniffLen = 512
+
+// DetectParserFromContent picks a parser from a document's leading bytes
+// with default options, for uploads whose name says nothing useful.
+func DetectParserFromContent(data []byte) (Parser, error) {
+	return DetectParserFromContentWithOptions(data, Options{})
+}
+
+// DetectParserFromContentWithOptions sniffs the first 512 bytes of data:
+// a PDF or zip signature, an HTML doctype or <html> tag, or a leading
+// Markdown heading or front-matter fence. Zip archives are treated as DOCX;
+// an XLSX without its extension fails to parse.
+func DetectParserFromContentWithOptions(data []byte, opts Options) (Parser, error) {
+	head := data
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+	switch {
+	case bytes.HasPrefix(head, pdfMagic):
+		return &PDFParser{FallbackPdftotext: opts.PDFFallbackPdftotext}, nil
+	case bytes.HasPrefix(head, zipMagic):
+		return &DOCXParser{RevisionMode: opts.DocxRevisionMode}, nil
+	}
+
+	text := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n")
+	lower := bytes.ToLower(text)
+	switch {
+	case bytes.HasPrefix(lower, []byte("<!doctype html")), bytes.Contains(lower, []byte("<html")):
+		return &HTMLParser{UseReadability: opts.HTMLUseReadability}, nil
+	case bytes.HasPrefix(text, []byte("#")), bytes.HasPrefix(text, []byte("---")):
+		return &MarkdownParser{}, nil
+	}
+	return nil, fmt.Errorf("unrecognized document content")
+}
+
+var (
+	pdfMagic = []byte("%PDF-")
+	zipMagic = []byte("PK\x03\x04") // DOCX and XLSX are zip archives
+)
+
+// magicMatchesExtension reports whether data's leading bytes agree with the
+// filename's extension. Only PDF and the zip-based Office formats carry a
+// signature; text formats match as long as data has neither.
+func magicMatchesExtension(filename string, data []byte) bool {
+	isPDF := bytes.HasPrefix(data, pdfMagic)
+	isZip := bytes.HasPrefix(data, zipMagic)
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		return isPDF
+	case ".docx", ".xlsx":
+		return isZip
+	default:
+		return !isPDF && !isZip
+	}
+}
+
+// normalizeTree strips invisible characters and applies NFC to every
+// node's title and text so they never reach the chunker or the extractor.
+// Text whitespace is left for the chunker, which keeps code blocks intact.
+func normalizeTree(tree *doctree.DocTree) *doctree.DocTree {
+	var walk func(nodes []*doctree.DocNode)
+	walk = func(nodes []*doctree.DocNode) {
+		for _, n := range nodes {
+			n.Title = chunker.NormalizeChunkText(n.Title)
+			n.Text = chunker.NormalizeUnicode(n.Text)
+			walk(n.Children)
+		}
+	}
+	walk(tree.Children)
+	return tree
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dgallion1/docgest/internal/chunker"
+)
+
+func TestIsSupportedExtension(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     bool
+	}{
+		{"notes.md", true},
+		{"notes.markdown", true},
+		{"NOTES.MARKDOWN", true},
+		{"report.pdf", true},
+		{"page.htm", true},
+		{"binary.exe", false},
+		{"noext", false},
+	}
+	for _, tt := range tests {
+		if got := IsSupportedExtension(tt.filename); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.filename, tt.want, got)
+		}
+	}
+}
+
+func TestSupportedExtensionsHaveParsers(t *testing.T) {
+	for ext := range SupportedExtensions {
+		if _, err := ForFile("file" + ext); err != nil {
+			t.Errorf("%s: supported extension has no parser: %v", ext, err)
+		}
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		want     Parser
+	}{
+		{"text/html; charset=utf-8", &HTMLParser{}},
+		{"text/plain", &TextParser{}},
+		{"text/markdown", &MarkdownParser{}},
+		{"application/pdf", &PDFParser{}},
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", &DOCXParser{}},
+	}
+	for _, tt := range tests {
+		p, err := ForContentType(tt.mimeType)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.mimeType, err)
+			continue
+		}
+		if fmt.Sprintf("%T", p) != fmt.Sprintf("%T", tt.want) {
+			t.Errorf("%s: expected %T, got %T", tt.mimeType, tt.want, p)
+		}
+	}
+
+	for _, mt := range []string{"application/octet-stream", "", "not a type"} {
+		if _, err := ForContentType(mt); err == nil {
+			t.Errorf("%q: expected error", mt)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n")
+	text := []byte("# Title\n\nBody")
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		data        []byte
+		want        Parser
+	}{
+		{"extension wins", "notes.md", "text/plain", text, &MarkdownParser{}},
+		{"unsupported extension", "notes.bin", "text/markdown", text, &MarkdownParser{}},
+		{"no extension", "README", "text/plain", text, &TextParser{}},
+		{"pdf named txt", "report.txt", "application/pdf", pdf, &PDFParser{}},
+		{"mismatch without content type", "report.txt", "application/octet-stream", pdf, &TextParser{}},
+		{"text named pdf", "notes.pdf", "text/markdown", text, &MarkdownParser{}},
+		{"sniffed without extension", "attachment", "application/octet-stream", pdf, &PDFParser{}},
+		{"sniffed without content type", "notes.bin", "", text, &MarkdownParser{}},
+	}
+	for _, tt := range tests {
+		p, err := Select(tt.filename, tt.contentType, tt.data, Options{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if fmt.Sprintf("%T", p) != fmt.Sprintf("%T", tt.want) {
+			t.Errorf("%s: expected %T, got %T", tt.name, tt.want, p)
+		}
+	}
+
+	if _, err := Select("data.bin", "application/octet-stream", []byte("MZ\x90\x00"), Options{}); err == nil {
+		t.Error("expected error for unsupported extension, content type and content")
+	}
+}
+
+func TestDetectParserFromContent(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Parser
+	}{
+		{"pdf", "%PDF-1.4\n", &PDFParser{}},
+		{"docx", "PK\x03\x04\x14\x00", &DOCXParser{}},
+		{"html doctype", "<!DOCTYPE html>\n<html><body>x</body></html>", &HTMLParser{}},
+		{"html tag", "  <HTML lang=\"en\">", &HTMLParser{}},
+		{"markdown heading", "# Title\n\nBody", &MarkdownParser{}},
+		{"front matter", "---\ntitle: x\n---\n", &MarkdownParser{}},
+		{"bom before heading", "\xef\xbb\xbf# Title", &MarkdownParser{}},
+	}
+	for _, tt := range tests {
+		p, err := DetectParserFromContent([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if fmt.Sprintf("%T", p) != fmt.Sprintf("%T", tt.want) {
+			t.Errorf("%s: expected %T, got %T", tt.name, tt.want, p)
+		}
+	}
+
+	for _, data := range []string{"", "MZ\x90\x00", "plain prose"} {
+		if _, err := DetectParserFromContent([]byte(data)); err == nil {
+			t.Errorf("expected error for %q", data)
+		}
+	}
+}
+
+func TestDetectParserFromContent_OnlySniffsHead(t *testing.T) {
+	data := strings.Repeat("x", sniffLen) + "<html>"
+	if _, err := DetectParserFromContent([]byte(data)); err == nil {
+		t.Errorf("expected <html> past the first %d bytes to be ignored", sniffLen)
+	}
+}
+
+func TestCodeBlocksKeepWhitespace(t *testing.T) {
+	// Aligned code with a run of blank lines, as in the report that
+	// normalization turned "x    = 1\n\n\n\nend" into "x = 1\n\nend".
+	const code = "x    = 1\nyy   = 22\n\n\n\nend"
+	tests := []struct {
+		filename string
+		input    string
+	}{
+		{"code.md", "# Code\n\n```go\n" + code + "\n```\n"},
+		{"code.adoc", "== Code\n\n----\n" + code + "\n----\n"},
+		{"code.ipynb", `{"nbformat": 4, "metadata": {"language_info": {"name": "python"}}, "cells": [
+			{"cell_type": "markdown", "metadata": {}, "source": "# Code"},
+			{"cell_type": "code", "metadata": {}, "outputs": [], "source": "x    = 1\nyy   = 22\n\n\n\nend"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			p, err := ForFile(tt.filename)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tree, err := p.Parse(strings.NewReader(tt.input), tt.filename)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			chunks := chunker.ChunkTree(tree, chunker.Config{ChunkSize: 1000, MinChunk: 1})
+			if len(chunks) != 1 {
+				t.Fatalf("expected 1 chunk, got %d", len(chunks))
+			}
+			if !strings.Contains(chunks[0].Text, code) {
+				t.Errorf("expected code verbatim in chunk, got %q", chunks[0].Text)
+			}
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/dgallion1/docgest/internal/doctree"
 	pdflib "github.com/ledongthuc/pdf"
@@ -18,23 +22,18 @@ type PDFParser struct {
 }
 
 func (p *PDFParser) Parse(r io.Reader, filename string) (*doctree.DocTree, error) {
-	// ledongthuc/pdf requires a ReadSeeker+size, so we write to a temp file.
-	tmp, err := os.CreateTemp("", "docgest-pdf-*.pdf")
+	rs, size, cleanup, err := toReadSeeker(r)
 	if err != nil {
-		return nil, fmt.Errorf("create temp file: %w", err)
-	}
-	tmpPath := tmp.Name()
-	defer os.Remove(tmpPath)
-
-	if _, err := io.Copy(tmp, r); err != nil {
-		tmp.Close()
-		return nil, fmt.Errorf("write temp file: %w", err)
+		return nil, err
 	}
-	tmp.Close()
+	defer cleanup()
 
-	text, err := extractPDFText(tmpPath)
+	text, items, err := extractPDFText(rs, size)
 	if err != nil && p.FallbackPdftotext {
-		text, err = extractPdftotext(tmpPath)
+		if _, serr := rs.Seek(0, io.SeekStart); serr != nil {
+			return nil, fmt.Errorf("rewind pdf: %w", serr)
+		}
+		text, err = extractPdftotext(rs)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("extract pdf text: %w", err)
@@ -44,35 +43,106 @@ func (p *PDFParser) Parse(r io.Reader, filename string) (*doctree.DocTree, error
 		Title: strings.TrimSuffix(filename, ".pdf"),
 	}
 
-	// Split into pages (simple heuristic: form feed or large gaps).
-	pages := splitPages(text)
-	for i, page := range pages {
-		page = strings.TrimSpace(page)
-		if page == "" {
-			continue
+	if sections := pdfSections(detectHeadingsFromFontSize(items)); sections != nil {
+		tree.Children = sections
+		return normalizeTree(tree), nil
+	}
+
+	// Keep the document as one node so chunks can run across page breaks;
+	// the form feeds between pages let the chunker attribute each chunk to
+	// the pages it spans.
+	if body, firstPage := joinPages(text); body != "" {
+		tree.Children = []*doctree.DocNode{{Text: body, Page: firstPage}}
+	}
+
+	return normalizeTree(tree), nil
+}
+
+// maxInMemoryPDF is the largest non-seekable PDF buffered in memory; larger
+// inputs spill to a temp file.
+const maxInMemoryPDF = 10 << 20
+
+// toReadSeeker adapts r for ledongthuc/pdf, which needs random access and
+// the total size. Seekable readers are used as-is; otherwise up to
+// maxInMemoryPDF bytes are buffered in memory before falling back to a temp
+// file. The returned cleanup func must always be called.
+func toReadSeeker(r io.Reader) (io.ReadSeeker, int64, func(), error) {
+	noop := func() {}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		size, err := rs.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, noop, fmt.Errorf("seek pdf: %w", err)
 		}
-		tree.Children = append(tree.Children, &doctree.DocNode{
-			Title: fmt.Sprintf("Page %d", i+1),
-			Text:  page,
-			Page:  i + 1,
-		})
+		if _, err := rs.Seek(0, io.SeekStart); err != nil {
+			return nil, 0, noop, fmt.Errorf("seek pdf: %w", err)
+		}
+		return rs, size, noop, nil
 	}
 
-	if len(tree.Children) == 0 && strings.TrimSpace(text) != "" {
-		tree.Children = []*doctree.DocNode{{Text: strings.TrimSpace(text), Page: 1}}
+	head, err := io.ReadAll(io.LimitReader(r, maxInMemoryPDF+1))
+	if err != nil {
+		return nil, 0, noop, fmt.Errorf("read pdf: %w", err)
+	}
+	if len(head) <= maxInMemoryPDF {
+		return bytes.NewReader(head), int64(len(head)), noop, nil
 	}
 
-	return tree, nil
+	tmp, err := os.CreateTemp("", "docgest-pdf-*.pdf")
+	if err != nil {
+		return nil, 0, noop, fmt.Errorf("create temp file: %w", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	size, err := io.Copy(tmp, io.MultiReader(bytes.NewReader(head), r))
+	if err != nil {
+		cleanup()
+		return nil, 0, noop, fmt.Errorf("write temp file: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, 0, noop, fmt.Errorf("seek temp file: %w", err)
+	}
+	return tmp, size, cleanup, nil
+}
+
+// seekReaderAt implements io.ReaderAt over a ReadSeeker that lacks it.
+type seekReaderAt struct {
+	mu sync.Mutex
+	rs io.ReadSeeker
+}
+
+func (s *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.rs, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
 }
 
-func extractPDFText(path string) (string, error) {
-	f, reader, err := pdflib.Open(path)
+// extractPDFText returns the plain text of every page, separated by form
+// feeds. For documents without an outline it also returns the positioned
+// glyphs of every page, with a pdfPageBreak item between pages, for heading
+// detection.
+func extractPDFText(rs io.ReadSeeker, size int64) (string, []pdflib.Text, error) {
+	ra, ok := rs.(io.ReaderAt)
+	if !ok {
+		ra = &seekReaderAt{rs: rs}
+	}
+	reader, err := pdflib.NewReader(ra, size)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	defer f.Close()
+	withItems := len(reader.Outline().Child) == 0
 
 	var buf strings.Builder
+	var items []pdflib.Text
 	numPages := reader.NumPage()
 	for i := 1; i <= numPages; i++ {
 		page := reader.Page(i)
@@ -87,12 +157,239 @@ func extractPDFText(path string) (string, error) {
 			buf.WriteString("\f") // Form feed as page separator.
 		}
 		buf.WriteString(text)
+		if withItems {
+			if i > 1 {
+				items = append(items, pdfPageBreak)
+			}
+			items = append(items, pageContent(page)...)
+		}
+	}
+	return buf.String(), items, nil
+}
+
+// pageContent returns the page's glyphs, or nil if its content stream
+// cannot be interpreted (the library panics on malformed operators).
+func pageContent(page pdflib.Page) (text []pdflib.Text) {
+	defer func() {
+		if recover() != nil {
+			text = nil
+		}
+	}()
+	return page.Content().Text
+}
+
+// pdfPageBreak separates pages in the item stream passed to
+// detectHeadingsFromFontSize.
+var pdfPageBreak = pdflib.Text{S: "\f"}
+
+// headingCandidate is a heading found by font size and the body text that
+// follows it. Text before the first heading has an empty Title.
+type headingCandidate struct {
+	Title string
+	Size  float64 // font size of the heading, in points
+	Page  int     // 1-based page the heading is on
+	Body  string  // following text; pages are separated by form feeds
+}
+
+// pdfLine is one line of text rebuilt from positioned glyphs.
+type pdfLine struct {
+	text string
+	size float64 // font size of the line's first glyph
+	y    float64
+	page int
+}
+
+// maxHeadingRunes bounds how long a line can be and still be a heading.
+const maxHeadingRunes = 200
+
+// detectHeadingsFromFontSize finds headings in a PDF without an outline.
+// Lines that start in a font size within the top 10% of the document's
+// glyphs, and larger than the most common (body) size, are headings;
+// consecutive heading lines of the same size form one heading. It returns
+// nil when the document has no such lines, so callers can keep the flat
+// page text.
+func detectHeadingsFromFontSize(items []pdflib.Text) []headingCandidate {
+	lines := pdfLines(items)
+
+	// Histogram of glyph counts by size, rounded to half a point.
+	hist := make(map[float64]int)
+	total := 0
+	for _, it := range items {
+		if strings.TrimSpace(it.S) == "" {
+			continue
+		}
+		hist[roundFontSize(it.FontSize)]++
+		total++
+	}
+	if total == 0 {
+		return nil
+	}
+	sizes := make([]float64, 0, len(hist))
+	for s := range hist {
+		sizes = append(sizes, s)
+	}
+	slices.Sort(sizes)
+	bodySize, threshold := sizes[0], sizes[len(sizes)-1]
+	for _, s := range sizes {
+		if hist[s] > hist[bodySize] {
+			bodySize = s
+		}
+	}
+	seen := 0
+	for i := len(sizes) - 1; i >= 0; i-- {
+		seen += hist[sizes[i]]
+		threshold = sizes[i]
+		if seen*10 >= total {
+			break
+		}
+	}
+
+	isHeading := func(l pdfLine) bool {
+		size := roundFontSize(l.size)
+		return size >= threshold && size > bodySize && len([]rune(l.text)) <= maxHeadingRunes
+	}
+	if !slices.ContainsFunc(lines, isHeading) {
+		return nil
+	}
+
+	var out []headingCandidate
+	var body strings.Builder
+	cur := headingCandidate{Page: 1}
+	if len(lines) > 0 {
+		cur.Page = lines[0].page
+	}
+	prevHeading := false
+	var prev pdfLine
+	flush := func() {
+		cur.Body = strings.TrimSpace(body.String())
+		if cur.Title != "" || cur.Body != "" {
+			out = append(out, cur)
+		}
+		body.Reset()
+	}
+	for i, l := range lines {
+		if isHeading(l) {
+			if prevHeading && l.page == prev.page && roundFontSize(l.size) == roundFontSize(cur.Size) {
+				cur.Title += " " + l.text
+			} else {
+				flush()
+				cur = headingCandidate{Title: l.text, Size: l.size, Page: l.page}
+			}
+			prevHeading, prev = true, l
+			continue
+		}
+		if i > 0 && !prevHeading {
+			switch {
+			case l.page != prev.page:
+				body.WriteString(strings.Repeat("\f", l.page-prev.page))
+			case prev.y-l.y > 1.8*max(l.size, prev.size):
+				body.WriteString("\n\n") // a wider gap than a line break
+			default:
+				body.WriteString("\n")
+			}
+		} else if prevHeading && l.page != prev.page {
+			body.WriteString(strings.Repeat("\f", l.page-prev.page))
+		}
+		body.WriteString(l.text)
+		prevHeading, prev = false, l
 	}
-	return buf.String(), nil
+	flush()
+	return out
 }
 
-func extractPdftotext(path string) (string, error) {
-	cmd := exec.Command("pdftotext", "-layout", path, "-")
+// pdfLines rebuilds text lines from glyphs in content-stream order. A glyph
+// starts a new line when it moves vertically by more than half its size,
+// and a space is inserted where glyphs on a line are visibly apart.
+func pdfLines(items []pdflib.Text) []pdfLine {
+	var lines []pdfLine
+	var cur strings.Builder
+	var line pdfLine
+	page := 1
+	var prev pdflib.Text
+	started := false
+	flush := func() {
+		if t := strings.Join(strings.Fields(cur.String()), " "); t != "" {
+			line.text = t
+			lines = append(lines, line)
+		}
+		cur.Reset()
+		started = false
+	}
+	for _, it := range items {
+		if it == pdfPageBreak {
+			flush()
+			page++
+			continue
+		}
+		if !started {
+			if strings.TrimSpace(it.S) == "" {
+				continue
+			}
+			line = pdfLine{size: it.FontSize, y: it.Y, page: page}
+			started = true
+		} else if math.Abs(it.Y-prev.Y) > max(it.FontSize, prev.FontSize)/2 {
+			flush()
+			if strings.TrimSpace(it.S) == "" {
+				continue
+			}
+			line = pdfLine{size: it.FontSize, y: it.Y, page: page}
+			started = true
+		} else if it.X-(prev.X+prev.W) > 0.2*it.FontSize {
+			cur.WriteByte(' ')
+		}
+		cur.WriteString(it.S)
+		prev = it
+	}
+	flush()
+	return lines
+}
+
+func roundFontSize(s float64) float64 {
+	return float64(int(s*2+0.5)) / 2
+}
+
+// pdfSections nests heading candidates into document nodes, larger font
+// sizes as higher levels. Body text before the first heading becomes a
+// leading untitled node.
+func pdfSections(cands []headingCandidate) []*doctree.DocNode {
+	if len(cands) == 0 {
+		return nil
+	}
+	var sizes []float64
+	for _, c := range cands {
+		if c.Title != "" && !slices.Contains(sizes, roundFontSize(c.Size)) {
+			sizes = append(sizes, roundFontSize(c.Size))
+		}
+	}
+	slices.Sort(sizes)
+	slices.Reverse(sizes)
+
+	type stackEntry struct {
+		node  *doctree.DocNode
+		level int
+	}
+	root := &doctree.DocNode{}
+	stack := []stackEntry{{node: root, level: 0}}
+	for _, c := range cands {
+		if c.Title == "" {
+			root.Children = append(root.Children, &doctree.DocNode{Text: c.Body, Page: c.Page})
+			continue
+		}
+		level := slices.Index(sizes, roundFontSize(c.Size)) + 1
+		node := &doctree.DocNode{Title: c.Title, Level: level, Text: c.Body, Page: c.Page}
+		for len(stack) > 1 && stack[len(stack)-1].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].node
+		parent.Children = append(parent.Children, node)
+		stack = append(stack, stackEntry{node: node, level: level})
+	}
+	return root.Children
+}
+
+func extractPdftotext(r io.Reader) (string, error) {
+	cmd := exec.Command("pdftotext", "-layout", "-", "-")
+	cmd.Stdin = r
 	out, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("pdftotext: %w", err)
@@ -103,3 +400,25 @@ func extractPdftotext(path string) (string, error) {
 func splitPages(text string) []string {
 	return strings.Split(text, "\f")
 }
+
+// joinPages trims each page of form-feed separated text and drops blank
+// leading and trailing pages. It returns the joined text, still separated by
+// form feeds, and the 1-based number of its first page.
+func joinPages(text string) (string, int) {
+	pages := splitPages(text)
+	first, last := 0, len(pages)-1
+	for first <= last && strings.TrimSpace(pages[first]) == "" {
+		first++
+	}
+	for last >= first && strings.TrimSpace(pages[last]) == "" {
+		last--
+	}
+	if first > last {
+		return "", 0
+	}
+	body := make([]string, 0, last-first+1)
+	for _, page := range pages[first : last+1] {
+		body = append(body, strings.TrimSpace(page))
+	}
+	return strings.Join(body, "\f"), first + 1
+}
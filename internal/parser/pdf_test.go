@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	pdflib "github.com/ledongthuc/pdf"
+)
+
+func TestToReadSeeker_UsesSeekerDirectly(t *testing.T) {
+	src := bytes.NewReader([]byte("%PDF-1.4 data"))
+	src.Seek(3, io.SeekStart)
+
+	rs, size, cleanup, err := toReadSeeker(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cleanup()
+	if rs != io.ReadSeeker(src) {
+		t.Error("expected the original ReadSeeker to be returned")
+	}
+	if size != 13 {
+		t.Errorf("expected size 13, got %d", size)
+	}
+	if pos, _ := src.Seek(0, io.SeekCurrent); pos != 0 {
+		t.Errorf("expected reader rewound to 0, got %d", pos)
+	}
+}
+
+func TestToReadSeeker_SmallInputBufferedInMemory(t *testing.T) {
+	data := "%PDF-1.4 small"
+	rs, size, cleanup, err := toReadSeeker(io.NopCloser(strings.NewReader(data)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cleanup()
+	if _, ok := rs.(*bytes.Reader); !ok {
+		t.Errorf("expected in-memory reader, got %T", rs)
+	}
+	if size != int64(len(data)) {
+		t.Errorf("expected size %d, got %d", len(data), size)
+	}
+}
+
+func TestToReadSeeker_LargeInputSpillsToTempFile(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), maxInMemoryPDF+10)
+	rs, size, cleanup, err := toReadSeeker(io.NopCloser(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, ok := rs.(*os.File)
+	if !ok {
+		cleanup()
+		t.Fatalf("expected temp file, got %T", rs)
+	}
+	if size != int64(len(data)) {
+		t.Errorf("expected size %d, got %d", len(data), size)
+	}
+	got, _ := io.ReadAll(f)
+	if !bytes.Equal(got, data) {
+		t.Error("expected temp file to hold the full input")
+	}
+
+	cleanup()
+	if _, err := os.Stat(f.Name()); !os.IsNotExist(err) {
+		t.Errorf("expected temp file removed after cleanup, got %v", err)
+	}
+}
+
+func TestSeekReaderAt_ShortReadReturnsEOF(t *testing.T) {
+	ra := &seekReaderAt{rs: strings.NewReader("abcdef")}
+	buf := make([]byte, 4)
+	n, err := ra.ReadAt(buf, 4)
+	if n != 2 || err != io.EOF {
+		t.Errorf("expected 2 bytes and io.EOF, got %d and %v", n, err)
+	}
+	if string(buf[:n]) != "ef" {
+		t.Errorf("expected %q, got %q", "ef", buf[:n])
+	}
+}
+
+func TestJoinPages(t *testing.T) {
+	body, first := joinPages("\f  \fIntro page\n\f\fBody page  \f \f")
+	if body != "Intro page\f\fBody page" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if first != 3 {
+		t.Errorf("expected first page 3, got %d", first)
+	}
+
+	if body, _ := joinPages(" \f\n"); body != "" {
+		t.Errorf("expected empty body for blank pages, got %q", body)
+	}
+}
+
+// glyphs lays out s as one line of per-character items, like
+// pdflib.Page.Content returns.
+func glyphs(s string, size, y float64) []pdflib.Text {
+	var out []pdflib.Text
+	x := 72.0
+	for _, r := range s {
+		w := size * 0.5
+		out = append(out, pdflib.Text{FontSize: size, X: x, Y: y, W: w, S: string(r)})
+		x += w
+	}
+	return out
+}
+
+func TestDetectHeadingsFromFontSize(t *testing.T) {
+	body := "Body text set in the regular size for this document."
+	var items []pdflib.Text
+	items = append(items, glyphs("Preface line before any heading.", 11, 760)...)
+	items = append(items, glyphs("Annual Report", 24, 720)...)
+	items = append(items, glyphs(body, 11, 690)...)
+	items = append(items, glyphs(body, 11, 677)...)
+	items = append(items, glyphs("Revenue", 16, 640)...)
+	items = append(items, glyphs(body, 11, 610)...)
+	items = append(items, pdfPageBreak)
+	items = append(items, glyphs(body, 11, 760)...)
+	items = append(items, glyphs(body, 11, 747)...)
+	items = append(items, glyphs(body, 11, 734)...)
+
+	cands := detectHeadingsFromFontSize(items)
+	if len(cands) != 3 {
+		t.Fatalf("expected 3 candidates, got %d: %+v", len(cands), cands)
+	}
+	if cands[0].Title != "" || cands[0].Body != "Preface line before any heading." {
+		t.Errorf("expected untitled preface, got %+v", cands[0])
+	}
+	if cands[1].Title != "Annual Report" || cands[1].Body != body+"\n"+body {
+		t.Errorf("unexpected first heading %+v", cands[1])
+	}
+	if cands[2].Title != "Revenue" || cands[2].Page != 1 {
+		t.Errorf("unexpected second heading %+v", cands[2])
+	}
+	if want := body + "\f" + body + "\n" + body + "\n" + body; cands[2].Body != want {
+		t.Errorf("expected body across the page break, got %q", cands[2].Body)
+	}
+
+	nodes := pdfSections(cands)
+	if len(nodes) != 2 {
+		t.Fatalf("expected preface and one top-level section, got %d", len(nodes))
+	}
+	report := nodes[1]
+	if report.Level != 1 || len(report.Children) != 1 {
+		t.Fatalf("expected level 1 section with 1 child, got level %d with %d", report.Level, len(report.Children))
+	}
+	if child := report.Children[0]; child.Title != "Revenue" || child.Level != 2 {
+		t.Errorf("expected nested Revenue at level 2, got %q at %d", child.Title, child.Level)
+	}
+}
+
+func TestDetectHeadingsFromFontSize_UniformSize(t *testing.T) {
+	var items []pdflib.Text
+	for i := range 5 {
+		items = append(items, glyphs("Every line uses the same font size.", 11, 760-float64(i)*13)...)
+	}
+	if cands := detectHeadingsFromFontSize(items); cands != nil {
+		t.Errorf("expected no headings, got %+v", cands)
+	}
+}
+
+func TestDetectHeadingsFromFontSize_MultiLineHeading(t *testing.T) {
+	var items []pdflib.Text
+	items = append(items, glyphs("A Long Title That", 20, 740)...)
+	items = append(items, glyphs("Wraps Onto Two Lines", 20, 716)...)
+	for i := range 6 {
+		items = append(items, glyphs("Regular paragraph text under the heading.", 11, 690-float64(i)*13)...)
+	}
+	cands := detectHeadingsFromFontSize(items)
+	if len(cands) != 1 || cands[0].Title != "A Long Title That Wraps Onto Two Lines" {
+		t.Errorf("expected one merged heading, got %+v", cands)
+	}
+}
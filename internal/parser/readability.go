@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Class and id tokens that suggest an element does or does not hold the
+// main article text. Tokens match by prefix, so "nav" also matches
+// "navigation"; adTokens must match exactly.
+var (
+	positiveTokens = []string{"content", "article", "main", "post", "entry", "story", "text"}
+	negativeTokens = []string{"nav", "sidebar", "advert", "footer", "comment", "menu", "promo", "sponsor", "social", "share", "related", "widget"}
+	adTokens       = []string{"ad", "ads"}
+)
+
+// minParagraphLen is the shortest paragraph that contributes to a score;
+// shorter ones are usually captions, bylines or buttons.
+const minParagraphLen = 25
+
+// readableContent returns the element most likely to hold the article body,
+// scored Readability-style: each paragraph credits its parent in full and
+// its grandparent by half, by length and comma count; class and id names
+// and tag type adjust the base score; and the total is discounted by the
+// share of text inside links. It returns nil if nothing scores.
+func readableContent(root *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	credit := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = baseScore(n)
+		}
+		scores[n] += score
+	}
+
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript":
+				return
+			case "p", "pre", "td", "blockquote":
+				text := textContent(n)
+				if len(text) >= minParagraphLen {
+					score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+					credit(n.Parent, score)
+					if n.Parent != nil {
+						credit(n.Parent.Parent, score/2)
+					}
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(root)
+
+	var best *html.Node
+	bestScore := 0.0
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
+
+// baseScore is a candidate's starting score from its tag and class/id.
+func baseScore(n *html.Node) float64 {
+	score := classWeight(n)
+	switch n.Data {
+	case "article", "main":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "form", "ol", "ul", "dl", "dd", "dt", "li", "address":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	return score
+}
+
+// classWeight scores an element's class and id attributes: +25 for
+// content-like names, -25 for navigation, sidebar and ad names.
+func classWeight(n *html.Node) float64 {
+	var weight float64
+	for _, a := range n.Attr {
+		if a.Key != "class" && a.Key != "id" {
+			continue
+		}
+		tokens := strings.FieldsFunc(strings.ToLower(a.Val), func(r rune) bool {
+			return r == ' ' || r == '-' || r == '_'
+		})
+		if matchesToken(tokens, negativeTokens, true) || matchesToken(tokens, adTokens, false) {
+			weight -= 25
+		}
+		if matchesToken(tokens, positiveTokens, true) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func matchesToken(tokens, words []string, prefix bool) bool {
+	for _, t := range tokens {
+		for _, w := range words {
+			if t == w || (prefix && strings.HasPrefix(t, w)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// linkDensity is the fraction of n's text that sits inside <a> elements.
+func linkDensity(n *html.Node) float64 {
+	total := len(textContent(n))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var visit func(*html.Node)
+	visit = func(c *html.Node) {
+		if c.Type == html.ElementNode && c.Data == "a" {
+			linked += len(textContent(c))
+			return
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			visit(cc)
+		}
+	}
+	visit(n)
+	return float64(linked) / float64(total)
+}
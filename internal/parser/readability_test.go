@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const noisyPage = `<html><head><title>Milo the Dog</title></head><body>
+<div class="top-menu">
+  <div><a href="/">Home</a> | <a href="/news">News</a> | <a href="/about">About us and our long history</a></div>
+  <p>Sign up for our newsletter, get daily updates, deals and more from us.</p>
+</div>
+<div class="layout">
+  <div class="article-body">
+    <h2>About Milo</h2>
+    <p>Milo is a golden retriever who lives with the Smith family in Portland, Oregon.</p>
+    <p>He loves fetch, long walks by the river, and, above all, swimming in the lake.</p>
+    <p>Milo was adopted from a shelter in 2019, when he was two years old.</p>
+  </div>
+  <div class="sidebar">
+    <p><a href="/a">Ten surprising facts about cats you never knew</a></p>
+    <p><a href="/b">Why golden retrievers are the best, according to science</a></p>
+  </div>
+  <div id="ad-slot"><p>Buy the best dog food now, limited offer, while stocks last.</p></div>
+</div>
+</body></html>`
+
+func TestHTMLParser_Readability(t *testing.T) {
+	tree, err := (&HTMLParser{UseReadability: true}).Parse(strings.NewReader(noisyPage), "milo.html")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tree.Title != "Milo the Dog" {
+		t.Errorf("expected title from <title>, got %q", tree.Title)
+	}
+	if len(tree.Children) != 1 || tree.Children[0].Title != "About Milo" {
+		t.Fatalf("expected a single About Milo section, got %d children", len(tree.Children))
+	}
+	text := tree.Children[0].Text
+	if !strings.Contains(text, "golden retriever who lives with the Smith family") {
+		t.Errorf("expected article text, got %q", text)
+	}
+	for _, noise := range []string{"newsletter", "cats", "dog food"} {
+		if strings.Contains(text, noise) {
+			t.Errorf("expected %q boilerplate to be dropped, got %q", noise, text)
+		}
+	}
+}
+
+func TestHTMLParser_ReadabilityDisabled(t *testing.T) {
+	tree, err := (&HTMLParser{}).Parse(strings.NewReader(noisyPage), "milo.html")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var all strings.Builder
+	for _, c := range tree.Children {
+		all.WriteString(c.Text)
+	}
+	if !strings.Contains(all.String(), "dog food") {
+		t.Error("expected boilerplate to be kept without readability")
+	}
+}
+
+func TestClassWeight(t *testing.T) {
+	tests := []struct {
+		attr string
+		want float64
+	}{
+		{"main-content", 25},
+		{"navigation", -25},
+		{"ad", -25},
+		{"header shadow", 0},
+		{"sidebar article", 0},
+	}
+	for _, tt := range tests {
+		n := &html.Node{Type: html.ElementNode, Data: "div", Attr: []html.Attribute{{Key: "class", Val: tt.attr}}}
+		if got := classWeight(n); got != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.attr, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/doctree"
+	"golang.org/x/net/html"
+)
+
+// RSSParser handles RSS 2.0 and Atom 1.0 feeds. Each item or entry becomes
+// a node whose Page is its 1-based position in the feed; the publication
+// date and link are kept in the node's Meta.
+type RSSParser struct{}
+
+type rssFeed struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type atomFeed struct {
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title     string `xml:"title"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Links     []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+}
+
+// feedItem is an RSS item or Atom entry reduced to what the tree needs.
+type feedItem struct {
+	title, body, published, link string
+}
+
+func (p *RSSParser) Parse(r io.Reader, filename string) (*doctree.DocTree, error) {
+	dec := xml.NewDecoder(r)
+	root, err := feedRoot(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var title string
+	var items []feedItem
+	switch root.Name.Local {
+	case "rss":
+		var feed rssFeed
+		if err := dec.DecodeElement(&feed, &root); err != nil {
+			return nil, fmt.Errorf("parse rss: %w", err)
+		}
+		title = feed.Channel.Title
+		for _, it := range feed.Channel.Items {
+			body := it.Content
+			if strings.TrimSpace(body) == "" {
+				body = it.Description
+			}
+			items = append(items, feedItem{title: it.Title, body: body, published: it.PubDate, link: it.Link})
+		}
+	case "feed":
+		var feed atomFeed
+		if err := dec.DecodeElement(&feed, &root); err != nil {
+			return nil, fmt.Errorf("parse atom: %w", err)
+		}
+		title = feed.Title
+		for _, e := range feed.Entries {
+			body := e.Content
+			if strings.TrimSpace(body) == "" {
+				body = e.Summary
+			}
+			published := e.Published
+			if published == "" {
+				published = e.Updated
+			}
+			items = append(items, feedItem{title: e.Title, body: body, published: published, link: atomLink(e)})
+		}
+	default:
+		return nil, fmt.Errorf("not an RSS or Atom feed: root element <%s>", root.Name.Local)
+	}
+
+	tree := &doctree.DocTree{
+		Title: strings.TrimSpace(title),
+	}
+	if tree.Title == "" {
+		tree.Title = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+
+	for i, it := range items {
+		node := &doctree.DocNode{
+			Title: strings.TrimSpace(it.title),
+			Text:  htmlToText(it.body),
+			Page:  i + 1,
+		}
+		if node.Title == "" && node.Text == "" {
+			continue
+		}
+		meta := make(map[string]string)
+		if published := feedDate(it.published); published != "" {
+			meta["published"] = published
+			// Also in the text, so extraction can date the item's facts.
+			node.Text = strings.TrimSpace("Published: " + published + "\n\n" + node.Text)
+		}
+		if link := strings.TrimSpace(it.link); link != "" {
+			meta["link"] = link
+		}
+		if len(meta) > 0 {
+			node.Meta = meta
+		}
+		tree.Children = append(tree.Children, node)
+	}
+	return normalizeTree(tree), nil
+}
+
+// feedRoot returns the document's root element.
+func feedRoot(dec *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return xml.StartElement{}, fmt.Errorf("parse feed: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se, nil
+		}
+	}
+}
+
+// atomLink prefers an entry's alternate link over its others.
+func atomLink(e atomEntry) string {
+	for _, l := range e.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	if len(e.Links) > 0 {
+		return e.Links[0].Href
+	}
+	return ""
+}
+
+// feedDateLayouts covers RFC 822 dates used by RSS and RFC 3339 used by Atom.
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+}
+
+// feedDate normalizes a feed date to RFC3339, returning it unchanged if no
+// known layout matches.
+func feedDate(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return s
+}
+
+// htmlToText strips markup from feed descriptions, which are usually HTML.
+func htmlToText(s string) string {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	return textContent(doc)
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRSSParser_RSS2(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+  <title>Release Notes</title>
+  <item>
+    <title>v1.2 released</title>
+    <link>https://example.com/v1.2</link>
+    <description>&lt;p&gt;Adds &lt;b&gt;XLSX&lt;/b&gt; support.&lt;/p&gt;</description>
+    <pubDate>Tue, 05 Mar 2024 10:00:00 +0000</pubDate>
+  </item>
+  <item>
+    <title>v1.1 released</title>
+    <description>Short summary.</description>
+    <content:encoded><![CDATA[<p>Full notes for v1.1.</p>]]></content:encoded>
+  </item>
+</channel>
+</rss>`
+
+	tree, err := (&RSSParser{}).Parse(strings.NewReader(input), "notes.rss")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tree.Title != "Release Notes" {
+		t.Errorf("expected channel title, got %q", tree.Title)
+	}
+	if len(tree.Children) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(tree.Children))
+	}
+
+	first := tree.Children[0]
+	if first.Title != "v1.2 released" || first.Page != 1 {
+		t.Errorf("unexpected first item: title %q page %d", first.Title, first.Page)
+	}
+	if want := "Published: 2024-03-05T10:00:00Z\n\nAdds XLSX support."; first.Text != want {
+		t.Errorf("expected text %q, got %q", want, first.Text)
+	}
+	if first.Meta["published"] != "2024-03-05T10:00:00Z" || first.Meta["link"] != "https://example.com/v1.2" {
+		t.Errorf("unexpected meta: %v", first.Meta)
+	}
+
+	second := tree.Children[1]
+	if second.Text != "Full notes for v1.1." || second.Page != 2 {
+		t.Errorf("expected content:encoded to win, got %q page %d", second.Text, second.Page)
+	}
+	if second.Meta != nil {
+		t.Errorf("expected no meta, got %v", second.Meta)
+	}
+}
+
+func TestRSSParser_Atom(t *testing.T) {
+	input := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Dev Blog</title>
+  <entry>
+    <title>Channels in Go</title>
+    <link rel="self" href="https://example.com/api/1"/>
+    <link rel="alternate" href="https://example.com/channels"/>
+    <updated>2024-02-01T08:30:00Z</updated>
+    <summary>Go channels synchronize goroutines.</summary>
+  </entry>
+</feed>`
+
+	tree, err := (&RSSParser{}).Parse(strings.NewReader(input), "blog.atom")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tree.Title != "Dev Blog" || len(tree.Children) != 1 {
+		t.Fatalf("unexpected tree: title %q, %d children", tree.Title, len(tree.Children))
+	}
+	entry := tree.Children[0]
+	if !strings.HasSuffix(entry.Text, "Go channels synchronize goroutines.") {
+		t.Errorf("expected summary text, got %q", entry.Text)
+	}
+	if entry.Meta["published"] != "2024-02-01T08:30:00Z" || entry.Meta["link"] != "https://example.com/channels" {
+		t.Errorf("unexpected meta: %v", entry.Meta)
+	}
+}
+
+func TestRSSParser_NotAFeed(t *testing.T) {
+	_, err := (&RSSParser{}).Parse(strings.NewReader(`<config><a>1</a></config>`), "config.xml")
+	if err == nil {
+		t.Error("expected error for non-feed XML")
+	}
+}
@@ -51,5 +51,5 @@ func (p *TextParser) Parse(r io.Reader, filename string) (*doctree.DocTree, erro
 		})
 	}
 
-	return tree, nil
+	return normalizeTree(tree), nil
 }
@@ -3,6 +3,8 @@ package parser
 import (
 	"strings"
 	"testing"
+
+	"github.com/dgallion1/docgest/internal/doctree"
 )
 
 func TestTextParser_BasicParagraphSplitting(t *testing.T) {
@@ -13,23 +15,28 @@ func TestTextParser_BasicParagraphSplitting(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if tree.Title != "notes" {
-		t.Errorf("expected title %q, got %q", "notes", tree.Title)
-	}
-	if len(tree.Children) != 3 {
-		t.Fatalf("expected 3 children, got %d", len(tree.Children))
-	}
+	want := doctree.NewTree("notes").
+		Section("", "First paragraph line one.\nFirst paragraph line two.").
+		Section("", "Second paragraph.").
+		Section("", "Third paragraph.").
+		Build()
+	doctree.AssertTreeEqual(t, tree, want)
+}
 
-	want := []string{
-		"First paragraph line one.\nFirst paragraph line two.",
-		"Second paragraph.",
-		"Third paragraph.",
-	}
-	for i, w := range want {
-		if tree.Children[i].Text != w {
-			t.Errorf("child[%d]: expected %q, got %q", i, w, tree.Children[i].Text)
-		}
+func TestTextParser_NormalizesText(t *testing.T) {
+	// Invisible characters go; whitespace is left for the chunker.
+	input := "\uFEFFMilo is a cat.\u200B\n\nHe likes  tuna.\u00AD"
+	p := &TextParser{}
+	tree, err := p.Parse(strings.NewReader(input), "notes.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
+
+	want := doctree.NewTree("notes").
+		Section("", "Milo is a cat.").
+		Section("", "He likes  tuna.").
+		Build()
+	doctree.AssertTreeEqual(t, tree, want)
 }
 
 func TestTextParser_EmptyInput(t *testing.T) {
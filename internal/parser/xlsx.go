@@ -0,0 +1,253 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/dgallion1/docgest/internal/doctree"
+)
+
+// XLSXParser handles .xlsx workbooks. Each sheet becomes a node; large
+// sheets are split into row batches like CSV files. Cell values are read as
+// stored, so dates appear as spreadsheet serial numbers.
+type XLSXParser struct{}
+
+func (p *XLSXParser) Parse(r io.Reader, filename string) (*doctree.DocTree, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read xlsx: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open xlsx: %w", err)
+	}
+
+	sheets, err := xlsxSheets(zr)
+	if err != nil {
+		return nil, fmt.Errorf("parse xlsx: %w", err)
+	}
+
+	tree := &doctree.DocTree{
+		Title: strings.TrimSuffix(filename, ".xlsx"),
+	}
+	for _, sh := range sheets {
+		rows := sh.rows
+		node := &doctree.DocNode{Title: sh.name}
+		switch classifySheet(rows) {
+		case sheetSkip:
+			continue
+		case sheetSingle:
+			node.Text = renderRows(rows[0], inferColumnTypes(rows[0], rows[1:]), rows[1:])
+		case sheetBatched:
+			node.Children = csvBatchNodes(rows[0], rows[1:])
+		}
+		tree.Children = append(tree.Children, node)
+	}
+	return normalizeTree(tree), nil
+}
+
+// sheetStrategy is how a sheet's rows are turned into nodes.
+type sheetStrategy int
+
+const (
+	sheetSkip    sheetStrategy = iota // header only or empty
+	sheetSingle                       // one node holding every row
+	sheetBatched                      // DefaultCSVBatchSize rows per child node
+)
+
+// maxSingleNodeRows is the largest sheet, header included, kept as one node.
+const maxSingleNodeRows = 50
+
+// classifySheet picks a strategy from the sheet's row count, header row
+// included.
+func classifySheet(rows [][]string) sheetStrategy {
+	switch {
+	case len(rows) <= 1:
+		return sheetSkip
+	case len(rows) <= maxSingleNodeRows:
+		return sheetSingle
+	default:
+		return sheetBatched
+	}
+}
+
+type xlsxSheet struct {
+	name string
+	rows [][]string
+}
+
+// xlsxSheets reads every worksheet in workbook order. Empty rows are dropped.
+func xlsxSheets(zr *zip.Reader) ([]xlsxSheet, error) {
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xlsxDecode(zr, "xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xlsxDecode(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Rels))
+	for _, rel := range rels.Rels {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+
+	// Workbooks without text cells have no shared strings part.
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := xlsxDecode(zr, "xl/sharedStrings.xml", &sst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	shared := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		shared[i] = si.String()
+	}
+
+	sheets := make([]xlsxSheet, 0, len(wb.Sheets))
+	for _, s := range wb.Sheets {
+		target, ok := targets[s.RID]
+		if !ok {
+			return nil, fmt.Errorf("sheet %q: missing relationship %s", s.Name, s.RID)
+		}
+		var ws struct {
+			Rows []struct {
+				Cells []xlsxCell `xml:"c"`
+			} `xml:"sheetData>row"`
+		}
+		if err := xlsxDecode(zr, target, &ws); err != nil {
+			return nil, fmt.Errorf("sheet %q: %w", s.Name, err)
+		}
+
+		sh := xlsxSheet{name: s.Name}
+		for _, row := range ws.Rows {
+			var cells []string
+			for i, c := range row.Cells {
+				col := xlsxColumn(c.Ref)
+				if col < 0 {
+					col = i
+				}
+				if col > xlsxMaxColumn {
+					// Beyond any column Excel can write; growing the row
+					// to reach it would take memory the upload did not.
+					continue
+				}
+				for len(cells) <= col {
+					cells = append(cells, "")
+				}
+				cells[col] = c.value(shared)
+			}
+			if strings.TrimSpace(strings.Join(cells, "")) != "" {
+				sh.rows = append(sh.rows, cells)
+			}
+		}
+		sheets = append(sheets, sh)
+	}
+	return sheets, nil
+}
+
+// xlsxText is a shared or inline string: plain <t> or rich-text runs.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	V      string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+// value resolves a cell to its display text.
+func (c xlsxCell) value(shared []string) string {
+	switch c.Type {
+	case "s":
+		if i, err := strconv.Atoi(c.V); err == nil && i >= 0 && i < len(shared) {
+			return shared[i]
+		}
+		return ""
+	case "inlineStr":
+		return c.Inline.String()
+	case "b":
+		if c.V == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return c.V
+}
+
+// xlsxMaxColumn is the 0-based index of XFD, Excel's last column.
+const xlsxMaxColumn = 16383
+
+// xlsxColumn converts the letters of a cell reference like "AB12" to a
+// 0-based column index, or -1 if ref has none. References past XFD return
+// xlsxMaxColumn+1 however long they are.
+func xlsxColumn(ref string) int {
+	col := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		if col <= xlsxMaxColumn+1 {
+			col = col*26 + int(ch-'A'+1)
+		}
+		n++
+	}
+	if col > xlsxMaxColumn+1 {
+		col = xlsxMaxColumn + 2
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
+
+func xlsxDecode(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := xml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
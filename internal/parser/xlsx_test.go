@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildXLSX returns a minimal .xlsx with one worksheet per name, in order.
+// Cells in the first column are shared strings; the rest are inline.
+func buildXLSX(t *testing.T, names []string, sheets [][][]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	write := func(name, body string) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + body))
+	}
+
+	var wb, rels, sst strings.Builder
+	var shared []string
+	for i, name := range names {
+		fmt.Fprintf(&wb, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, name, i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+
+		var data strings.Builder
+		for r, row := range sheets[i] {
+			fmt.Fprintf(&data, `<row r="%d">`, r+1)
+			for c, cell := range row {
+				ref := fmt.Sprintf("%c%d", 'A'+c, r+1)
+				if c == 0 {
+					fmt.Fprintf(&data, `<c r="%s" t="s"><v>%d</v></c>`, ref, len(shared))
+					shared = append(shared, cell)
+				} else {
+					fmt.Fprintf(&data, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, cell)
+				}
+			}
+			data.WriteString(`</row>`)
+		}
+		write(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1),
+			`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`+data.String()+`</sheetData></worksheet>`)
+	}
+	for _, s := range shared {
+		fmt.Fprintf(&sst, `<si><t>%s</t></si>`, s)
+	}
+
+	write("xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`+wb.String()+`</sheets></workbook>`)
+	write("xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+rels.String()+`</Relationships>`)
+	write("xl/sharedStrings.xml", `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+sst.String()+`</sst>`)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sheetRows(n int) [][]string {
+	rows := [][]string{{"Region", "Units"}}
+	for i := 1; i < n; i++ {
+		rows = append(rows, []string{fmt.Sprintf("r%d", i), fmt.Sprint(i * 10)})
+	}
+	return rows
+}
+
+func TestClassifySheet(t *testing.T) {
+	tests := []struct {
+		rows int
+		want sheetStrategy
+	}{
+		{0, sheetSkip},
+		{1, sheetSkip},
+		{2, sheetSingle},
+		{maxSingleNodeRows, sheetSingle},
+		{maxSingleNodeRows + 1, sheetBatched},
+	}
+	for _, tt := range tests {
+		if got := classifySheet(make([][]string, tt.rows)); got != tt.want {
+			t.Errorf("%d rows: expected strategy %d, got %d", tt.rows, tt.want, got)
+		}
+	}
+}
+
+func TestXLSXParser_PerSheetStrategy(t *testing.T) {
+	data := buildXLSX(t,
+		[]string{"Summary", "Data", "Headers"},
+		[][][]string{sheetRows(5), sheetRows(61), sheetRows(1)},
+	)
+
+	tree, err := (&XLSXParser{}).Parse(bytes.NewReader(data), "sales.xlsx")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tree.Title != "sales" {
+		t.Errorf("expected title %q, got %q", "sales", tree.Title)
+	}
+	if len(tree.Children) != 2 {
+		t.Fatalf("expected header-only sheet to be skipped, got %d sheets", len(tree.Children))
+	}
+
+	summary := tree.Children[0]
+	if summary.Title != "Summary" || len(summary.Children) != 0 {
+		t.Errorf("expected Summary as a single node, got %q with %d children", summary.Title, len(summary.Children))
+	}
+	if !strings.Contains(summary.Text, "Region: r4, Units (integer): 40") {
+		t.Errorf("expected summary rows in text, got %q", summary.Text)
+	}
+
+	dataSheet := tree.Children[1]
+	if dataSheet.Title != "Data" || len(dataSheet.Children) != 3 {
+		t.Fatalf("expected Data split into 3 batches, got %q with %d children", dataSheet.Title, len(dataSheet.Children))
+	}
+	if got := dataSheet.Children[2].Title; got != "Rows 42-61" {
+		t.Errorf("expected last batch %q, got %q", "Rows 42-61", got)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for ref, want := range map[string]int{
+		"A1": 0, "Z9": 25, "AA3": 26, "AB12": 27, "12": -1,
+		"XFD1": xlsxMaxColumn, "XFE1": xlsxMaxColumn + 1,
+		"ZZZZZZ1": xlsxMaxColumn + 1, strings.Repeat("Z", 40) + "1": xlsxMaxColumn + 1,
+	} {
+		if got := xlsxColumn(ref); got != want {
+			t.Errorf("%s: expected %d, got %d", ref, want, got)
+		}
+	}
+}
+
+func TestXLSXParser_OversizedColumnRef(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Data" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="inlineStr"><is><t>Name</t></is></c><c r="ZZZZZZ1" t="inlineStr"><is><t>huge</t></is></c></row>` +
+			`<row r="2"><c r="A2" t="inlineStr"><is><t>Milo</t></is></c></row>` +
+			`</sheetData></worksheet>`,
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := (&XLSXParser{}).Parse(bytes.NewReader(buf.Bytes()), "big.xlsx")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tree.Children) != 1 {
+		t.Fatalf("expected 1 sheet node, got %d", len(tree.Children))
+	}
+	text := tree.Children[0].Text
+	if strings.Contains(text, "huge") || !strings.Contains(text, "Name: Milo") {
+		t.Errorf("expected the out-of-range cell to be skipped, got %q", text)
+	}
+}
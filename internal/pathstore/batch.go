@@ -0,0 +1,77 @@
+package pathstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// BatchConcurrency bounds how many requests a batch call has in flight.
+const BatchConcurrency = 10
+
+// BatchGetNodes fetches keys from s concurrently. The map holds every key
+// that was read, with a nil node for keys that do not exist; keys whose
+// read failed are absent and their errors are joined into err.
+func BatchGetNodes(ctx context.Context, s Store, keys []string) (map[string]*NodeResponse, error) {
+	nodes := make(map[string]*NodeResponse, len(keys))
+	var mu sync.Mutex
+	var errs []error
+	forEachKey(keys, func(key string) {
+		node, err := s.GetNode(ctx, key)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("get %s: %w", key, err))
+			return
+		}
+		nodes[key] = node
+	})
+	return nodes, errors.Join(errs...)
+}
+
+// BatchDeleteNodes deletes keys from s concurrently and returns how many
+// deletes succeeded along with the error for each one that failed.
+func BatchDeleteNodes(ctx context.Context, s Store, keys []string, recursive bool) (int, []error) {
+	deleted := 0
+	var mu sync.Mutex
+	var errs []error
+	forEachKey(keys, func(key string) {
+		err := s.DeleteNode(ctx, key, recursive)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("delete %s: %w", key, err))
+			return
+		}
+		deleted++
+	})
+	return deleted, errs
+}
+
+// forEachKey runs fn for every key with at most BatchConcurrency running
+// at once, and returns when all have finished.
+func forEachKey(keys []string, fn func(key string)) {
+	sem := make(chan struct{}, BatchConcurrency)
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(key)
+		}(key)
+	}
+	wg.Wait()
+}
+
+// BatchGetNodes is BatchGetNodes with c as the store.
+func (c *Client) BatchGetNodes(ctx context.Context, keys []string) (map[string]*NodeResponse, error) {
+	return BatchGetNodes(ctx, c, keys)
+}
+
+// BatchDeleteNodes is BatchDeleteNodes with c as the store.
+func (c *Client) BatchDeleteNodes(ctx context.Context, keys []string, recursive bool) (int, []error) {
+	return BatchDeleteNodes(ctx, c, keys, recursive)
+}
@@ -0,0 +1,95 @@
+package pathstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyStore fails reads and deletes of keys in fail and records the peak
+// number of calls in flight.
+type flakyStore struct {
+	*countingStore
+	fail     map[string]bool
+	inflight atomic.Int64
+	peak     atomic.Int64
+}
+
+func (s *flakyStore) enter() func() {
+	n := s.inflight.Add(1)
+	for {
+		p := s.peak.Load()
+		if n <= p || s.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return func() { s.inflight.Add(-1) }
+}
+
+func (s *flakyStore) GetNode(ctx context.Context, key string) (*NodeResponse, error) {
+	defer s.enter()()
+	if s.fail[key] {
+		return nil, errors.New("boom")
+	}
+	return s.countingStore.GetNode(ctx, key)
+}
+
+func (s *flakyStore) DeleteNode(ctx context.Context, key string, recursive bool) error {
+	defer s.enter()()
+	if s.fail[key] {
+		return errors.New("boom")
+	}
+	return s.countingStore.DeleteNode(ctx, key, recursive)
+}
+
+func TestBatchGetNodes(t *testing.T) {
+	store := &flakyStore{countingStore: newCountingStore(), fail: map[string]bool{"bad": true}}
+	var keys []string
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("k%d", i)
+		store.nodes[key] = i
+		keys = append(keys, key)
+	}
+	keys = append(keys, "missing", "bad")
+
+	nodes, err := BatchGetNodes(context.Background(), store, keys)
+	if err == nil {
+		t.Error("expected an error for the failed key")
+	}
+	if len(nodes) != 31 {
+		t.Errorf("expected 31 keys read, got %d", len(nodes))
+	}
+	if n := nodes["k7"]; n == nil || n.Value != 7 {
+		t.Errorf("expected k7 = 7, got %+v", n)
+	}
+	if n, ok := nodes["missing"]; !ok || n != nil {
+		t.Errorf("expected missing key present with nil node, got %+v, %v", n, ok)
+	}
+	if _, ok := nodes["bad"]; ok {
+		t.Error("expected failed key to be absent")
+	}
+	if p := store.peak.Load(); p > BatchConcurrency {
+		t.Errorf("expected at most %d requests in flight, got %d", BatchConcurrency, p)
+	}
+}
+
+func TestBatchDeleteNodes(t *testing.T) {
+	store := &flakyStore{countingStore: newCountingStore(), fail: map[string]bool{"bad": true}}
+	store.nodes["a"] = 1
+	store.nodes["b"] = 2
+
+	deleted, errs := BatchDeleteNodes(context.Background(), store, []string{"a", "b", "bad"}, false)
+	if deleted != 2 {
+		t.Errorf("expected 2 deleted, got %d", deleted)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected 1 error, got %v", errs)
+	}
+	if len(store.nodes) != 0 {
+		t.Errorf("expected store to be empty, got %v", store.nodes)
+	}
+}
@@ -0,0 +1,147 @@
+package pathstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// benchStub is a minimal in-process pathstore that returns fixed responses.
+func benchStub(b *testing.B, listSize int) *httptest.Server {
+	b.Helper()
+	nodeBody, _ := json.Marshal(NodeResponse{
+		Key:        "memory.users.u1.entities.milo.facts.01ABC",
+		Value:      map[string]any{"text": "Milo is a dog.", "entity": "milo"},
+		MemoryType: "semantic",
+		Salience:   0.7,
+	})
+	nodes := make([]ListChildrenResponse, listSize)
+	for i := range nodes {
+		nodes[i] = ListChildrenResponse{
+			Key:   fmt.Sprintf("memory.users.u1.documents.d1.facts.%04d", i),
+			Value: map[string]any{"path": "memory/users/u1/topics/go/01ABC", "category": "topic_knowledge"},
+		}
+	}
+	listBody, _ := json.Marshal(map[string]any{"nodes": nodes})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		switch {
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusOK)
+		case strings.HasSuffix(r.URL.Path, "/*"):
+			w.Write(listBody)
+		default:
+			w.Write(nodeBody)
+		}
+	}))
+	b.Cleanup(srv.Close)
+	return srv
+}
+
+// stubBody fetches a stub response so SetBytes reflects the real payload.
+func stubBody(b *testing.B, url string) []byte {
+	b.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return body
+}
+
+func benchNode() NodeRequest {
+	return NodeRequest{
+		Value: map[string]any{
+			"text":      "Milo is a golden retriever who loves fetch.",
+			"entity":    "milo",
+			"topics":    []string{"pets", "dogs"},
+			"min_trust": 0,
+			"source":    map[string]any{"type": "document", "doc_id": "d1"},
+		},
+		MemoryType: "semantic",
+		Salience:   0.7,
+		Source:     "docgest:d1",
+	}
+}
+
+func BenchmarkPutNode(b *testing.B) {
+	srv := benchStub(b, 0)
+	c := NewClient(srv.URL, "bench-key")
+	req := benchNode()
+	body, _ := json.Marshal(req)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for b.Loop() {
+		if err := c.PutNode(ctx, "memory/users/u1/entities/milo/facts/01ABC", req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetNode(b *testing.B) {
+	srv := benchStub(b, 0)
+	c := NewClient(srv.URL, "bench-key")
+	ctx := context.Background()
+	body := stubBody(b, srv.URL+"/kv/x")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for b.Loop() {
+		if _, err := c.GetNode(ctx, "memory/users/u1/entities/milo/facts/01ABC"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkListChildren(b *testing.B, n int) {
+	srv := benchStub(b, n)
+	c := NewClient(srv.URL, "bench-key")
+	ctx := context.Background()
+	body := stubBody(b, srv.URL+"/kv/x/*")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for b.Loop() {
+		nodes, err := c.ListChildren(ctx, "memory/users/u1/documents/d1/facts", n)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(nodes) != n {
+			b.Fatalf("expected %d nodes, got %d", n, len(nodes))
+		}
+	}
+}
+
+func BenchmarkListChildren_10(b *testing.B)  { benchmarkListChildren(b, 10) }
+func BenchmarkListChildren_100(b *testing.B) { benchmarkListChildren(b, 100) }
+
+func BenchmarkBatchPutNodes_10(b *testing.B) {
+	srv := benchStub(b, 0)
+	c := NewClient(srv.URL, "bench-key")
+	req := benchNode()
+	body, _ := json.Marshal(req)
+	ctx := context.Background()
+	keys := make([]string, 10)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("memory/users/u1/entities/milo/facts/%02d", i)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body) * len(keys)))
+	for b.Loop() {
+		for _, k := range keys {
+			if err := c.PutNode(ctx, k, req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
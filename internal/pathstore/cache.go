@@ -0,0 +1,181 @@
+package pathstore
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a cached GetNode result is served without
+// revalidation.
+const DefaultCacheTTL = 30 * time.Second
+
+// CachingClient wraps a Store with an in-process cache for GetNode. A
+// result younger than the TTL is served from memory; one up to twice the
+// TTL old is served stale while a single background fetch refreshes it.
+// Concurrent misses for the same key share one fetch. PutNode and
+// DeleteNode invalidate the keys they touch; every other call passes
+// straight through. Each caller gets its own deep copy of the node's
+// Value, and entries past twice the TTL are swept at most once per TTL.
+//
+// The cache only sees writes made through it, so it suits single-instance
+// deployments or data that tolerates TTL-bounded staleness.
+type CachingClient struct {
+	Store
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]cacheEntry
+	inflight  map[string]*cacheFetch
+	lastSweep time.Time
+}
+
+type cacheEntry struct {
+	node      *NodeResponse // nil records a missing key
+	fetchedAt time.Time
+}
+
+type cacheFetch struct {
+	done chan struct{}
+	node *NodeResponse
+	err  error
+}
+
+var _ Store = (*CachingClient)(nil)
+
+// NewCachingClient wraps store with a GetNode cache. A non-positive ttl
+// uses DefaultCacheTTL.
+func NewCachingClient(store Store, ttl time.Duration) *CachingClient {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &CachingClient{
+		Store:    store,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]cacheEntry),
+		inflight: make(map[string]*cacheFetch),
+	}
+}
+
+// GetNode returns the cached node for key, fetching it when absent or
+// expired.
+func (c *CachingClient) GetNode(ctx context.Context, key string) (*NodeResponse, error) {
+	c.mu.Lock()
+	c.sweepLocked()
+	e, ok := c.entries[key]
+	age := c.now().Sub(e.fetchedAt)
+	if ok && age < 2*c.ttl {
+		if age >= c.ttl {
+			c.fetchLocked(ctx, key)
+		}
+		c.mu.Unlock()
+		return copyNode(e.node), nil
+	}
+	f := c.fetchLocked(ctx, key)
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return copyNode(f.node), f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchLocked starts a fetch of key unless one is already running. The
+// fetch outlives ctx's cancellation so one abandoned caller does not fail
+// the others waiting on it. Caller must hold mu.
+func (c *CachingClient) fetchLocked(ctx context.Context, key string) *cacheFetch {
+	if f, ok := c.inflight[key]; ok {
+		return f
+	}
+	f := &cacheFetch{done: make(chan struct{})}
+	c.inflight[key] = f
+	go func() {
+		node, err := c.Store.GetNode(context.WithoutCancel(ctx), key)
+		c.mu.Lock()
+		// An invalidation while the fetch ran removes it from inflight; its
+		// result may predate the write, so it is not cached.
+		if c.inflight[key] == f {
+			delete(c.inflight, key)
+			if err == nil {
+				c.entries[key] = cacheEntry{node: copyNode(node), fetchedAt: c.now()}
+			}
+		}
+		c.mu.Unlock()
+		f.node, f.err = node, err
+		close(f.done)
+	}()
+	return f
+}
+
+// PutNode writes through and invalidates key.
+func (c *CachingClient) PutNode(ctx context.Context, key string, req NodeRequest) error {
+	err := c.Store.PutNode(ctx, key, req)
+	c.invalidate(key, false)
+	return err
+}
+
+// DeleteNode deletes through and invalidates key, and every key below it
+// when recursive.
+func (c *CachingClient) DeleteNode(ctx context.Context, key string, recursive bool) error {
+	err := c.Store.DeleteNode(ctx, key, recursive)
+	c.invalidate(key, recursive)
+	return err
+}
+
+func (c *CachingClient) invalidate(key string, subtree bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	delete(c.inflight, key)
+	if !subtree {
+		return
+	}
+	prefix := key + "/"
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+		}
+	}
+	for k := range c.inflight {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.inflight, k)
+		}
+	}
+}
+
+// sweepLocked drops entries too old to be served, at most once per TTL, so
+// keys read once do not stay in memory. Caller must hold mu.
+func (c *CachingClient) sweepLocked() {
+	now := c.now()
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for k, e := range c.entries {
+		if now.Sub(e.fetchedAt) >= 2*c.ttl {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// copyNode copies n with a deep copy of its Value, so callers that modify
+// a returned map do not change the cached node or each other's copies.
+func copyNode(n *NodeResponse) *NodeResponse {
+	if n == nil {
+		return nil
+	}
+	cp := *n
+	if raw, err := json.Marshal(n.Value); err == nil {
+		var v any
+		if json.Unmarshal(raw, &v) == nil {
+			cp.Value = v
+		}
+	}
+	return &cp
+}
@@ -0,0 +1,223 @@
+package pathstore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingStore serves GetNode from a map, counting calls and optionally
+// blocking each one until release is closed.
+type countingStore struct {
+	Store
+	mu      sync.Mutex
+	nodes   map[string]any
+	gets    atomic.Int64
+	release chan struct{}
+}
+
+func newCountingStore() *countingStore {
+	return &countingStore{nodes: make(map[string]any)}
+}
+
+func (s *countingStore) set(key string, v any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[key] = v
+}
+
+func (s *countingStore) GetNode(ctx context.Context, key string) (*NodeResponse, error) {
+	s.gets.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.nodes[key]
+	if !ok {
+		return nil, nil
+	}
+	return &NodeResponse{Key: key, Value: v}, nil
+}
+
+func (s *countingStore) PutNode(ctx context.Context, key string, req NodeRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[key] = req.Value
+	return nil
+}
+
+func (s *countingStore) DeleteNode(ctx context.Context, key string, recursive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.nodes, key)
+	return nil
+}
+
+func TestCachingClient_ServesFromCache(t *testing.T) {
+	store := newCountingStore()
+	store.nodes["a"] = "v1"
+	c := NewCachingClient(store, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		node, err := c.GetNode(ctx, "a")
+		if err != nil || node == nil || node.Value != "v1" {
+			t.Fatalf("expected v1, got %+v, %v", node, err)
+		}
+	}
+	if n := store.gets.Load(); n != 1 {
+		t.Errorf("expected 1 store read, got %d", n)
+	}
+}
+
+func TestCachingClient_CachesMissingKey(t *testing.T) {
+	store := newCountingStore()
+	c := NewCachingClient(store, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if node, err := c.GetNode(ctx, "missing"); err != nil || node != nil {
+			t.Fatalf("expected nil node, got %+v, %v", node, err)
+		}
+	}
+	if n := store.gets.Load(); n != 1 {
+		t.Errorf("expected 1 store read, got %d", n)
+	}
+}
+
+func TestCachingClient_InvalidatesOnWrite(t *testing.T) {
+	store := newCountingStore()
+	store.nodes["a"] = "v1"
+	store.nodes["dir/b"] = "v1"
+	c := NewCachingClient(store, time.Minute)
+	ctx := context.Background()
+
+	c.GetNode(ctx, "a")
+	if err := c.PutNode(ctx, "a", NodeRequest{Value: "v2"}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if node, _ := c.GetNode(ctx, "a"); node == nil || node.Value != "v2" {
+		t.Errorf("expected v2 after put, got %+v", node)
+	}
+
+	c.GetNode(ctx, "dir/b")
+	if err := c.DeleteNode(ctx, "dir", true); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	delete(store.nodes, "dir/b") // the fake does not delete recursively
+	if node, _ := c.GetNode(ctx, "dir/b"); node != nil {
+		t.Errorf("expected dir/b to be invalidated by recursive delete, got %+v", node)
+	}
+}
+
+func TestCachingClient_StaleWhileRevalidate(t *testing.T) {
+	store := newCountingStore()
+	store.nodes["a"] = "v1"
+	c := NewCachingClient(store, time.Minute)
+	var now atomic.Int64
+	now.Store(time.Now().UnixNano())
+	c.now = func() time.Time { return time.Unix(0, now.Load()) }
+	advance := func(d time.Duration) { now.Add(int64(d)) }
+	ctx := context.Background()
+
+	c.GetNode(ctx, "a")
+	store.set("a", "v2") // changed behind the cache's back
+
+	advance(90 * time.Second)
+	if node, _ := c.GetNode(ctx, "a"); node == nil || node.Value != "v1" {
+		t.Errorf("expected stale v1 while revalidating, got %+v", node)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if node, _ := c.GetNode(ctx, "a"); node != nil && node.Value == "v2" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := store.gets.Load(); n != 2 {
+		t.Errorf("expected 1 background refresh, got %d store reads", n)
+	}
+	if node, _ := c.GetNode(ctx, "a"); node == nil || node.Value != "v2" {
+		t.Errorf("expected refreshed v2, got %+v", node)
+	}
+
+	advance(3 * time.Minute)
+	store.set("a", "v3")
+	if node, _ := c.GetNode(ctx, "a"); node == nil || node.Value != "v3" {
+		t.Errorf("expected expired entry to be fetched synchronously, got %+v", node)
+	}
+}
+
+func TestCachingClient_CoalescesConcurrentMisses(t *testing.T) {
+	store := newCountingStore()
+	store.nodes["a"] = "v1"
+	store.release = make(chan struct{})
+	c := NewCachingClient(store, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if node, err := c.GetNode(context.Background(), "a"); err != nil || node == nil {
+				t.Errorf("expected node, got %+v, %v", node, err)
+			}
+		}()
+	}
+	// Give the readers time to pile up behind the blocked fetch.
+	for store.gets.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(store.release)
+	wg.Wait()
+
+	if n := store.gets.Load(); n != 1 {
+		t.Errorf("expected 1 store read for 10 concurrent readers, got %d", n)
+	}
+}
+
+func TestCachingClient_CopiesValue(t *testing.T) {
+	store := newCountingStore()
+	store.nodes["meta"] = map[string]any{"title": "Doc"}
+	c := NewCachingClient(store, time.Minute)
+	ctx := context.Background()
+
+	first, _ := c.GetNode(ctx, "meta")
+	first.Value.(map[string]any)["deleted_at"] = "now"
+
+	second, _ := c.GetNode(ctx, "meta")
+	if _, ok := second.Value.(map[string]any)["deleted_at"]; ok {
+		t.Errorf("expected a caller's change not to reach the cache, got %v", second.Value)
+	}
+	if n := store.gets.Load(); n != 1 {
+		t.Errorf("expected the second read from cache, got %d store reads", n)
+	}
+}
+
+func TestCachingClient_SweepsExpiredEntries(t *testing.T) {
+	store := newCountingStore()
+	c := NewCachingClient(store, time.Minute)
+	var now atomic.Int64
+	now.Store(time.Now().UnixNano())
+	c.now = func() time.Time { return time.Unix(0, now.Load()) }
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		store.set(key, key)
+		c.GetNode(ctx, key)
+	}
+	now.Add(int64(3 * time.Minute))
+	store.set("d", "d")
+	c.GetNode(ctx, "d")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) != 1 {
+		t.Errorf("expected only the fresh entry after a sweep, got %d", len(c.entries))
+	}
+}
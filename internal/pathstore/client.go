@@ -16,26 +16,73 @@ type Client struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+
+	maxRetries       int
+	retryBackoffBase time.Duration
+	putNodeTimeout   time.Duration
+	readTimeout      time.Duration
+}
+
+// Options tunes client behavior. Zero values fall back to defaults.
+type Options struct {
+	MaxRetries       int           // Retries after the first attempt for retryable errors.
+	RetryBackoffBase time.Duration // Base delay, doubled per attempt.
+	PutNodeTimeout   time.Duration // Per-attempt deadline for writes (PUT/DELETE).
+	ReadTimeout      time.Duration // Per-attempt deadline for reads (GET/list).
+}
+
+// DefaultOptions returns the options used by NewClient.
+func DefaultOptions() Options {
+	return Options{
+		MaxRetries:       3,
+		RetryBackoffBase: 500 * time.Millisecond,
+		PutNodeTimeout:   10 * time.Second,
+		ReadTimeout:      5 * time.Second,
+	}
 }
 
 func NewClient(baseURL, apiKey string) *Client {
+	return NewClientWithOptions(baseURL, apiKey, DefaultOptions())
+}
+
+// NewClientWithOptions creates a client with explicit retry and timeout settings.
+func NewClientWithOptions(baseURL, apiKey string, opts Options) *Client {
+	defaults := DefaultOptions()
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.RetryBackoffBase <= 0 {
+		opts.RetryBackoffBase = defaults.RetryBackoffBase
+	}
+	if opts.PutNodeTimeout <= 0 {
+		opts.PutNodeTimeout = defaults.PutNodeTimeout
+	}
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = defaults.ReadTimeout
+	}
 	return &Client{
 		baseURL: baseURL,
 		apiKey:  apiKey,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: &requestIDTransport{base: http.DefaultTransport},
 		},
+		maxRetries:       opts.MaxRetries,
+		retryBackoffBase: opts.RetryBackoffBase,
+		putNodeTimeout:   opts.PutNodeTimeout,
+		readTimeout:      opts.ReadTimeout,
 	}
 }
 
 // NodeRequest is the body for PUT /kv/{key}.
 type NodeRequest struct {
-	Value      any     `json:"value"`
-	MergeMode  string  `json:"merge_mode,omitempty"`
-	MemoryType string  `json:"memory_type,omitempty"`
-	Salience   float64 `json:"salience,omitempty"`
-	Source     string  `json:"source,omitempty"`
-	ExpiresAt  string  `json:"expires_at,omitempty"`
+	Value      any      `json:"value"`
+	MergeMode  string   `json:"merge_mode,omitempty"`
+	MemoryType string   `json:"memory_type,omitempty"`
+	Salience   float64  `json:"salience,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	Supersedes []string `json:"supersedes,omitempty"`
 }
 
 // NodeResponse is the response from GET /kv/{key}.
@@ -62,51 +109,66 @@ func (c *Client) PutNode(ctx context.Context, key string, req NodeRequest) error
 	if err != nil {
 		return fmt.Errorf("marshal node: %w", err)
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+"/kv/"+key, bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return c.withRetry(ctx, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.putNodeTimeout)
+		defer cancel()
+		httpReq, err := http.NewRequestWithContext(attemptCtx, http.MethodPut, c.baseURL+"/kv/"+key, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("put node: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("put node %s: status %d: %s", key, resp.StatusCode, string(respBody))
-	}
-	return nil
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return classify(0, fmt.Errorf("put node: %w", err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return classify(resp.StatusCode, fmt.Errorf("put node %s: status %d: %s", key, resp.StatusCode, string(respBody)))
+		}
+		return nil
+	})
 }
 
 // GetNode retrieves a node by key.
 func (c *Client) GetNode(ctx context.Context, key string) (*NodeResponse, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/kv/"+key, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	var node *NodeResponse
+	err := c.withRetry(ctx, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.readTimeout)
+		defer cancel()
+		httpReq, err := http.NewRequestWithContext(attemptCtx, http.MethodGet, c.baseURL+"/kv/"+key, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("get node: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
-	}
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, fmt.Errorf("get node %s: status %d: %s", key, resp.StatusCode, string(respBody))
-	}
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return classify(0, fmt.Errorf("get node: %w", err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			node = nil
+			return nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return classify(resp.StatusCode, fmt.Errorf("get node %s: status %d: %s", key, resp.StatusCode, string(respBody)))
+		}
 
-	var node NodeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&node); err != nil {
-		return nil, fmt.Errorf("decode node: %w", err)
+		var n NodeResponse
+		if err := json.NewDecoder(resp.Body).Decode(&n); err != nil {
+			return fmt.Errorf("decode node: %w", err)
+		}
+		node = &n
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return &node, nil
+	return node, nil
 }
 
 // DeleteNode deletes a node and optionally its children.
@@ -115,22 +177,26 @@ func (c *Client) DeleteNode(ctx context.Context, key string, recursive bool) err
 	if recursive {
 		u += "?children=true"
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return c.withRetry(ctx, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.putNodeTimeout)
+		defer cancel()
+		httpReq, err := http.NewRequestWithContext(attemptCtx, http.MethodDelete, u, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("delete node: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("delete node %s: status %d: %s", key, resp.StatusCode, string(respBody))
-	}
-	return nil
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return classify(0, fmt.Errorf("delete node: %w", err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return classify(resp.StatusCode, fmt.Errorf("delete node %s: status %d: %s", key, resp.StatusCode, string(respBody)))
+		}
+		return nil
+	})
 }
 
 // ListChildrenResponse is a single node from a prefix scan.
@@ -141,33 +207,80 @@ type ListChildrenResponse struct {
 
 // ListChildren does a prefix scan under the given key.
 func (c *Client) ListChildren(ctx context.Context, key string, limit int) ([]ListChildrenResponse, error) {
-	u := c.baseURL + "/kv/" + key + "/*"
+	nodes, _, err := c.ListChildrenWithCursor(ctx, key, limit, "")
+	return nodes, err
+}
+
+// ListChildrenWithCursor returns one page of a prefix scan. Pass the returned
+// nextCursor to fetch the following page; it is empty on the last page.
+func (c *Client) ListChildrenWithCursor(ctx context.Context, key string, limit int, cursor string) ([]ListChildrenResponse, string, error) {
+	q := url.Values{}
 	if limit > 0 {
-		u += "?limit=" + url.QueryEscape(fmt.Sprintf("%d", limit))
+		q.Set("limit", fmt.Sprintf("%d", limit))
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+	if cursor != "" {
+		q.Set("cursor", cursor)
 	}
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	u := c.baseURL + "/kv/" + key + "/*"
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	var nodes []ListChildrenResponse
+	var nextCursor string
+	err := c.withRetry(ctx, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.readTimeout)
+		defer cancel()
+		httpReq, err := http.NewRequestWithContext(attemptCtx, http.MethodGet, u, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-	resp, err := c.httpClient.Do(httpReq)
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return classify(0, fmt.Errorf("list children: %w", err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return classify(resp.StatusCode, fmt.Errorf("list children %s: status %d: %s", key, resp.StatusCode, string(respBody)))
+		}
+
+		var result struct {
+			Nodes      []ListChildrenResponse `json:"nodes"`
+			NextCursor string                 `json:"next_cursor"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("decode children: %w", err)
+		}
+		nodes = result.Nodes
+		nextCursor = result.NextCursor
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("list children: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, fmt.Errorf("list children %s: status %d: %s", key, resp.StatusCode, string(respBody))
+		return nil, "", err
 	}
+	return nodes, nextCursor, nil
+}
 
-	var result struct {
-		Nodes []ListChildrenResponse `json:"nodes"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode children: %w", err)
+// listAllPageSize is the page size ListAll requests from pathstore.
+const listAllPageSize = 500
+
+// ListAll pages through every child under key until no cursor remains.
+func (c *Client) ListAll(ctx context.Context, key string) ([]ListChildrenResponse, error) {
+	var all []ListChildrenResponse
+	cursor := ""
+	for {
+		nodes, next, err := c.ListChildrenWithCursor(ctx, key, listAllPageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, nodes...)
+		if next == "" || next == cursor {
+			return all, nil
+		}
+		cursor = next
 	}
-	return result.Nodes, nil
 }
 
 // PutLink creates or updates an edge between two nodes.
@@ -176,21 +289,48 @@ func (c *Client) PutLink(ctx context.Context, req LinkRequest) error {
 	if err != nil {
 		return fmt.Errorf("marshal link: %w", err)
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+"/links", bytes.NewReader(body))
+	return c.withRetry(ctx, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.putNodeTimeout)
+		defer cancel()
+		httpReq, err := http.NewRequestWithContext(attemptCtx, http.MethodPut, c.baseURL+"/links", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return classify(0, fmt.Errorf("put link: %w", err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return classify(resp.StatusCode, fmt.Errorf("put link: status %d: %s", resp.StatusCode, string(respBody)))
+		}
+		return nil
+	})
+}
+
+// Ping checks that pathstore is reachable with a single GET /health. It
+// does not retry; callers decide how long to wait for the server.
+func (c *Client) Ping(ctx context.Context) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, c.readTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(attemptCtx, http.MethodGet, c.baseURL+"/health", nil)
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("put link: %w", err)
+		return fmt.Errorf("ping: %w", err)
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("put link: status %d: %s", resp.StatusCode, string(respBody))
+		return fmt.Errorf("ping: status %d: %s", resp.StatusCode, string(respBody))
 	}
 	return nil
 }
@@ -0,0 +1,260 @@
+package pathstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testClient(url string, maxRetries int) *Client {
+	return NewClientWithOptions(url, "test-key", Options{
+		MaxRetries:       maxRetries,
+		RetryBackoffBase: time.Millisecond,
+	})
+}
+
+func TestPutNode_RetriesOn5xx(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := testClient(srv.URL, 3)
+	if err := c.PutNode(context.Background(), "a/b", NodeRequest{Value: "x"}); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 calls, got %d", got)
+	}
+}
+
+func TestPutNode_NoRetryOn4xx(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := testClient(srv.URL, 3)
+	err := c.PutNode(context.Background(), "a/b", NodeRequest{Value: "x"})
+	if err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+	if IsRetryable(err) {
+		t.Error("expected 4xx error to be non-retryable")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 call, got %d", got)
+	}
+}
+
+func TestDeleteNode_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := testClient(srv.URL, 2)
+	err := c.DeleteNode(context.Background(), "a/b", false)
+	if !IsRetryable(err) {
+		t.Fatalf("expected retryable error, got %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 calls (1 + 2 retries), got %d", got)
+	}
+}
+
+func TestListChildren_RetriesNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	c := testClient(url, 1)
+	_, err := c.ListChildren(context.Background(), "a", 10)
+	if !IsRetryable(err) {
+		t.Errorf("expected network error to be retryable, got %v", err)
+	}
+}
+
+func TestPutNode_PerAttemptTimeout(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClientWithOptions(srv.URL, "test-key", Options{
+		MaxRetries:       1,
+		RetryBackoffBase: time.Millisecond,
+		PutNodeTimeout:   20 * time.Millisecond,
+	})
+	start := time.Now()
+	err := c.PutNode(context.Background(), "a/b", NodeRequest{Value: "x"})
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected per-attempt timeout to bound the call, took %s", elapsed)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected timed-out attempt to be retried once, got %d calls", got)
+	}
+}
+
+func TestListAll_FollowsCursor(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"nodes":[{"key_path":"a.1"},{"key_path":"a.2"}],"next_cursor":"p2"}`,
+		"p2": `{"nodes":[{"key_path":"a.3"}],"next_cursor":""}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	c := testClient(srv.URL, 0)
+	nodes, err := c.ListAll(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("expected 3 nodes across pages, got %d", len(nodes))
+	}
+	if nodes[2].Key != "a.3" {
+		t.Errorf("expected last key %q, got %q", "a.3", nodes[2].Key)
+	}
+}
+
+func TestListChildrenWithCursor_ReturnsNextCursor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") != "1" {
+			t.Errorf("expected limit=1, got %q", r.URL.Query().Get("limit"))
+		}
+		w.Write([]byte(`{"nodes":[{"key_path":"a.1"}],"next_cursor":"next"}`))
+	}))
+	defer srv.Close()
+
+	c := testClient(srv.URL, 0)
+	nodes, next, err := c.ListChildrenWithCursor(context.Background(), "a", 1, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes) != 1 || next != "next" {
+		t.Errorf("expected 1 node and cursor %q, got %d nodes and %q", "next", len(nodes), next)
+	}
+}
+
+func TestPing(t *testing.T) {
+	var path, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if err := testClient(srv.URL, 3).Ping(context.Background()); err != nil {
+		t.Fatalf("expected ping to succeed, got %v", err)
+	}
+	if path != "/health" || auth != "Bearer test-key" {
+		t.Errorf("expected authenticated GET /health, got %s with %q", path, auth)
+	}
+}
+
+func TestPing_FailsWithoutRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if err := testClient(srv.URL, 3).Ping(context.Background()); err == nil {
+		t.Fatal("expected error for 503 response")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 call, got %d", got)
+	}
+
+	srv.Close()
+	if err := testClient(srv.URL, 3).Ping(context.Background()); err == nil {
+		t.Error("expected error for an unreachable server")
+	}
+}
+
+func TestConnectWithRetry(t *testing.T) {
+	defer func(d time.Duration) { connectBackoffBase = d }(connectBackoffBase)
+	connectBackoffBase = time.Millisecond
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if err := testClient(srv.URL, 0).ConnectWithRetry(context.Background(), 5); err != nil {
+		t.Fatalf("expected connect to succeed once pathstore answers, got %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 pings, got %d", got)
+	}
+}
+
+func TestConnectWithRetry_GivesUp(t *testing.T) {
+	defer func(d time.Duration) { connectBackoffBase = d }(connectBackoffBase)
+	connectBackoffBase = time.Millisecond
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := testClient(srv.URL, 0).ConnectWithRetry(context.Background(), 4)
+	if !errors.Is(err, ErrPathstoreUnreachable) {
+		t.Fatalf("expected ErrPathstoreUnreachable, got %v", err)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("expected 4 pings, got %d", got)
+	}
+}
+
+func TestClient_SendsRequestID(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(RequestIDHeader))
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := testClient(srv.URL, 0)
+	c.GetNode(WithRequestID(context.Background(), "req-123"), "a/b")
+	c.GetNode(context.Background(), "a/b")
+	if len(got) != 2 || got[0] != "req-123" || got[1] != "" {
+		t.Errorf("expected X-Request-ID only on the request with an ID, got %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+package pathstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryableError indicates a transient pathstore failure (5xx or network error).
+type RetryableError struct {
+	StatusCode int // 0 for network errors
+	Err        error
+}
+
+func (e *RetryableError) Error() string {
+	return fmt.Sprintf("retryable error (status %d): %s", e.StatusCode, e.Err)
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable checks if a pathstore error is worth retrying.
+func IsRetryable(err error) bool {
+	var retryErr *RetryableError
+	return errors.As(err, &retryErr)
+}
+
+// classify wraps err as retryable for network errors (status 0) and 5xx responses.
+func classify(status int, err error) error {
+	if status == 0 || status >= 500 {
+		return &RetryableError{StatusCode: status, Err: err}
+	}
+	return err
+}
+
+// backoff returns a duration for attempt n (0-indexed) with jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	base := c.retryBackoffBase * time.Duration(1<<uint(attempt))
+	if base > 30*time.Second {
+		base = 30 * time.Second
+	}
+	if base <= 0 {
+		return 0
+	}
+	jitter := time.Duration(rand.Int64N(int64(base)/2 + 1))
+	return base + jitter
+}
+
+// withRetry runs fn, retrying retryable failures up to c.maxRetries times.
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if err == nil || !IsRetryable(err) || attempt >= c.maxRetries {
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-time.After(c.backoff(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// ErrPathstoreUnreachable is returned by ConnectWithRetry when pathstore
+// never answered.
+var ErrPathstoreUnreachable = errors.New("pathstore unreachable")
+
+// ErrStorageFailure marks a failed pathstore write or read in job errors.
+// Callers wrap it alongside the original error, so errors.Is matches both.
+var ErrStorageFailure = errors.New("storage failure")
+
+// connectBackoffBase is the wait after the first failed connect attempt;
+// it doubles after each further failure, up to 30s.
+var connectBackoffBase = time.Second
+
+// ConnectWithRetry pings pathstore until it answers, waiting 1s, 2s, 4s,
+// 8s... between attempts. After maxAttempts failures, or if ctx ends, it
+// returns an error wrapping ErrPathstoreUnreachable and the last ping
+// error.
+func (c *Client) ConnectWithRetry(ctx context.Context, maxAttempts int) error {
+	maxAttempts = max(maxAttempts, 1)
+	wait := connectBackoffBase
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = c.Ping(ctx); err == nil {
+			return nil
+		}
+		if attempt >= maxAttempts {
+			break
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return fmt.Errorf("%w after %d attempts: %w", ErrPathstoreUnreachable, attempt, err)
+		}
+		wait = min(2*wait, 30*time.Second)
+	}
+	return fmt.Errorf("%w after %d attempts: %w", ErrPathstoreUnreachable, maxAttempts, err)
+}
@@ -0,0 +1,18 @@
+package pathstore
+
+import "context"
+
+// Store is the subset of the pathstore API used by the pipeline and HTTP
+// handlers. *Client is the production implementation; tests substitute an
+// in-memory one.
+type Store interface {
+	PutNode(ctx context.Context, key string, req NodeRequest) error
+	GetNode(ctx context.Context, key string) (*NodeResponse, error)
+	DeleteNode(ctx context.Context, key string, recursive bool) error
+	ListChildren(ctx context.Context, key string, limit int) ([]ListChildrenResponse, error)
+	ListChildrenWithCursor(ctx context.Context, key string, limit int, cursor string) ([]ListChildrenResponse, string, error)
+	ListAll(ctx context.Context, key string) ([]ListChildrenResponse, error)
+	PutLink(ctx context.Context, req LinkRequest) error
+}
+
+var _ Store = (*Client)(nil)
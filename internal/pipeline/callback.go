@@ -0,0 +1,138 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// callbackRetryDelays are the waits before each retry of a failed callback
+// delivery.
+var callbackRetryDelays = []time.Duration{5 * time.Second, 15 * time.Second, 30 * time.Second}
+
+// callbackTimeout bounds one delivery attempt.
+const callbackTimeout = 10 * time.Second
+
+// cgnatRange is the carrier-grade NAT block, which net.IP.IsPrivate omits.
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// ValidateCallbackURL checks that raw is an https URL whose host resolves
+// only to public addresses, so a callback cannot be aimed at the service's
+// own network.
+func ValidateCallbackURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid callback_url: %w", err)
+	}
+	if u.Scheme != "https" {
+		return errors.New("callback_url must use https")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("callback_url has no host")
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("resolve callback_url host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("callback_url resolves to non-public address %s", addr.IP)
+		}
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() &&
+		!cgnatRange.Contains(ip)
+}
+
+// newCallbackClient returns an HTTP client that refuses to connect to
+// non-public addresses, re-checking at dial time so a host that resolved
+// publicly during validation cannot be rebound to a private one. Redirects
+// are not followed.
+func newCallbackClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("refusing callback connection to %s", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   callbackTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// deliverCallback POSTs the finished job's snapshot to its callback URL,
+// retrying after each of delays until a 2xx response. Every attempt is
+// recorded on the job. Jobs with a CallbackSecret have the body signed.
+func deliverCallback(ctx context.Context, client *http.Client, delays []time.Duration, job *Job, log *slog.Logger) {
+	body, err := json.Marshal(job.Snapshot())
+	if err != nil {
+		log.Error("encode callback body failed", "error", err)
+		return
+	}
+	for attempt := 0; ; attempt++ {
+		err := postCallback(ctx, client, job.CallbackURL, job.ID, job.CallbackSecret, body)
+		job.recordCallbackAttempt(err)
+		if err == nil {
+			log.Info("callback delivered", "attempts", attempt+1)
+			return
+		}
+		if attempt >= len(delays) {
+			log.Warn("callback delivery failed", "attempts", attempt+1, "error", err)
+			return
+		}
+		log.Warn("callback attempt failed, retrying", "attempt", attempt+1, "retry_in", delays[attempt], "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delays[attempt]):
+		}
+	}
+}
+
+func postCallback(ctx context.Context, client *http.Client, callbackURL, jobID, secret string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Docgest-Job-Id", jobID)
+	if secret != "" {
+		req.Header.Set(SignatureHeader, SignPayload(secret, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidateCallbackURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://93.184.216.34/hooks/docgest", true},
+		{"http://93.184.216.34/hooks/docgest", false},
+		{"https:///no-host", false},
+		{"https://127.0.0.1/hook", false},
+		{"https://10.1.2.3/hook", false},
+		{"https://192.168.0.10/hook", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://100.64.0.1/hook", false},
+		{"https://[::1]/hook", false},
+		{"https://[fd00::1]/hook", false},
+		{"https://0.0.0.0/hook", false},
+	}
+	for _, tt := range tests {
+		err := ValidateCallbackURL(context.Background(), tt.url)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: expected ok=%v, got error %v", tt.url, tt.ok, err)
+		}
+	}
+}
+
+func TestCallbackClient_RefusesPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	resp, err := newCallbackClient().Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Error("expected the callback client to refuse a loopback address")
+	}
+}
+
+func TestDeliverCallback_RetriesUntilDelivered(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var gotJobID, gotSignature string
+	var gotBody map[string]any
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		gotJobID = r.Header.Get("X-Docgest-Job-Id")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &gotBody)
+		if VerifySignature("s3cret", data, r.Header.Get(SignatureHeader)) {
+			gotSignature = "valid"
+		}
+	}))
+	defer srv.Close()
+
+	job := &Job{ID: "job-1", Status: StatusCompleted, CallbackURL: srv.URL, CallbackSecret: "s3cret", UpdatedAt: time.Now()}
+	delays := []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+	deliverCallback(context.Background(), srv.Client(), delays, job, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	snap := job.Snapshot()
+	if snap.CallbackAttempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", snap.CallbackAttempts)
+	}
+	if snap.CallbackLastError != "" {
+		t.Errorf("expected last error cleared after delivery, got %q", snap.CallbackLastError)
+	}
+	if gotJobID != "job-1" {
+		t.Errorf("expected X-Docgest-Job-Id job-1, got %q", gotJobID)
+	}
+	if gotSignature != "valid" {
+		t.Error("expected delivery signed with the webhook secret")
+	}
+	if gotBody["job_id"] != "job-1" || gotBody["status"] != string(StatusCompleted) {
+		t.Errorf("expected job snapshot body, got %v", gotBody)
+	}
+}
+
+func TestDeliverCallback_GivesUpAfterRetries(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	job := &Job{ID: "job-1", Status: StatusFailed, CallbackURL: srv.URL, UpdatedAt: time.Now()}
+	delays := []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+	deliverCallback(context.Background(), srv.Client(), delays, job, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	snap := job.Snapshot()
+	if snap.CallbackAttempts != 4 {
+		t.Errorf("expected 1 attempt plus 3 retries, got %d", snap.CallbackAttempts)
+	}
+	if snap.CallbackLastError != "callback status 500" {
+		t.Errorf("expected last error to be recorded, got %q", snap.CallbackLastError)
+	}
+}
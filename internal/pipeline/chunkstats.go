@@ -0,0 +1,41 @@
+package pipeline
+
+import "strings"
+
+// ChunkFactStats is the fact yield of one document section, recorded in
+// the document meta under chunk_stats so low-yield sections stand out.
+type ChunkFactStats struct {
+	ChunkIndex           int      `json:"chunk_index"`
+	Breadcrumb           []string `json:"breadcrumb"`
+	FactsExtracted       int      `json:"facts_extracted"`
+	FactsValid           int      `json:"facts_valid"`
+	FactsStored          int      `json:"facts_stored"`
+	ExtractionDurationMs int64    `json:"extraction_duration_ms"`
+}
+
+// breadcrumbKey is the chunk_stats key for a chunk's breadcrumb.
+func breadcrumbKey(breadcrumb []string) string {
+	return strings.Join(breadcrumb, " > ")
+}
+
+// chunkStatsByBreadcrumb keys per-chunk stats by breadcrumb. A section
+// split across several chunks is reported once with the counts and
+// durations summed and ChunkIndex set to its first chunk.
+func chunkStatsByBreadcrumb(chunks []*ChunkFactStats) map[string]ChunkFactStats {
+	out := make(map[string]ChunkFactStats, len(chunks))
+	for _, c := range chunks {
+		key := breadcrumbKey(c.Breadcrumb)
+		s, ok := out[key]
+		if !ok {
+			out[key] = *c
+			continue
+		}
+		s.ChunkIndex = min(s.ChunkIndex, c.ChunkIndex)
+		s.FactsExtracted += c.FactsExtracted
+		s.FactsValid += c.FactsValid
+		s.FactsStored += c.FactsStored
+		s.ExtractionDurationMs += c.ExtractionDurationMs
+		out[key] = s
+	}
+	return out
+}
@@ -0,0 +1,21 @@
+package pipeline
+
+import "testing"
+
+func TestChunkStatsByBreadcrumb(t *testing.T) {
+	stats := chunkStatsByBreadcrumb([]*ChunkFactStats{
+		{ChunkIndex: 0, Breadcrumb: []string{"Guide", "Setup"}, FactsExtracted: 3, FactsValid: 2, FactsStored: 2, ExtractionDurationMs: 40},
+		{ChunkIndex: 1, Breadcrumb: []string{"Guide", "Usage"}, FactsExtracted: 1, FactsValid: 1, FactsStored: 0, ExtractionDurationMs: 10},
+		{ChunkIndex: 2, Breadcrumb: []string{"Guide", "Setup"}, FactsExtracted: 2, FactsValid: 2, FactsStored: 1, ExtractionDurationMs: 25},
+	})
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 sections, got %d", len(stats))
+	}
+	setup := stats["Guide > Setup"]
+	if setup.ChunkIndex != 0 || setup.FactsExtracted != 5 || setup.FactsValid != 4 || setup.FactsStored != 3 || setup.ExtractionDurationMs != 65 {
+		t.Errorf("expected split section summed under its first chunk, got %+v", setup)
+	}
+	if usage := stats["Guide > Usage"]; usage.ChunkIndex != 1 || usage.FactsExtracted != 1 {
+		t.Errorf("expected usage section from chunk 1, got %+v", usage)
+	}
+}
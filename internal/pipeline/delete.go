@@ -0,0 +1,228 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// DeleteJob asks the deletion worker to purge one document.
+type DeleteJob struct {
+	ID     string
+	DocID  string
+	UserID string
+
+	// SweepOrphans also scans the user's entity facts for ones the manifest
+	// missed. The API rate-limits this per user.
+	SweepOrphans bool
+
+	// RequestID is passed on to the job and its pathstore calls.
+	RequestID string
+
+	job *Job
+}
+
+// DeleteResult summarizes a purge.
+type DeleteResult struct {
+	FactsDeleted     int `json:"facts_deleted"`
+	MissingFactPaths int `json:"missing_fact_paths"`
+	ManifestDeleted  int `json:"manifest_deleted"`
+	OrphansDeleted   int `json:"orphans_deleted"`
+}
+
+// deleteJobTimeout bounds one deletion job, including the orphan sweep.
+const deleteJobTimeout = 10 * time.Minute
+
+// DeletionWorker runs queued document deletions.
+type DeletionWorker struct {
+	pathstore pathstore.Store
+	log       *slog.Logger
+}
+
+func NewDeletionWorker(ps pathstore.Store, log *slog.Logger) *DeletionWorker {
+	return &DeletionWorker{pathstore: ps, log: log}
+}
+
+// Process purges the document and records the outcome on the job.
+func (w *DeletionWorker) Process(ctx context.Context, dj *DeleteJob) {
+	job := dj.job
+	log := w.log.With("job_id", dj.ID, "doc_id", dj.DocID, "user_id", dj.UserID, "request_id", dj.RequestID)
+	ctx = pathstore.WithRequestID(ctx, dj.RequestID)
+	ctx, cancel := context.WithTimeout(ctx, deleteJobTimeout)
+	defer cancel()
+
+	job.SetStatus(StatusDeleting, "deleting")
+	result, err := PurgeDocument(ctx, w.pathstore, dj.UserID, dj.DocID)
+	if err != nil {
+		log.Error("document delete failed", "error", err)
+		job.AddError(fmt.Errorf("delete: %w: %w", pathstore.ErrStorageFailure, err))
+		job.SetStatus(StatusFailed, "deleting")
+		return
+	}
+
+	if dj.SweepOrphans {
+		job.SetStatus(StatusDeleting, "sweeping orphans")
+		n, err := CleanupOrphanFacts(ctx, w.pathstore, dj.UserID, dj.DocID)
+		result.OrphansDeleted = n
+		if err != nil {
+			log.Warn("orphan fact cleanup failed", "deleted", n, "error", err)
+			job.AddError(fmt.Errorf("orphan sweep: %w: %w", pathstore.ErrStorageFailure, err))
+		}
+	}
+
+	job.SetDeleteResult(result)
+	log.Info("document deleted", "facts_deleted", result.FactsDeleted, "orphans_deleted", result.OrphansDeleted)
+	job.SetStatus(StatusCompleted, "done")
+}
+
+// PurgeDocument permanently removes a document's facts, manifest and meta.
+func PurgeDocument(ctx context.Context, ps pathstore.Store, userID, docID string) (DeleteResult, error) {
+	var result DeleteResult
+	docPrefix := fmt.Sprintf("memory/users/%s/documents/%s", userID, docID)
+
+	// 1. Read manifest entries.
+	manifestEntries, err := ps.ListAll(ctx, docPrefix+"/facts")
+	if err != nil {
+		return result, err
+	}
+
+	// 2. Delete each referenced fact.
+	var factPaths []string
+	for _, entry := range manifestEntries {
+		if factPath := ExtractFactPath(entry.Value); factPath != "" {
+			factPaths = append(factPaths, factPath)
+		}
+	}
+	deleted, errs := pathstore.BatchDeleteNodes(ctx, ps, factPaths, false)
+	result.FactsDeleted = deleted
+	result.MissingFactPaths = len(errs)
+
+	// 3. Delete hash index entry (reads meta, so must precede step 4).
+	DeleteHashIndex(ctx, ps, userID, docID, docPrefix)
+
+	// 4. Delete document meta and manifest.
+	if err := ps.DeleteNode(ctx, docPrefix, true); err == nil {
+		result.ManifestDeleted = 1
+	}
+
+	// 5. A soft-deleted document no longer needs sweeping.
+	UnmarkSoftDeleted(ctx, ps, userID, docID)
+	return result, nil
+}
+
+// CleanupOrphanFacts deletes entity facts whose source.doc_id is docID. It
+// catches facts the manifest failed to record and returns how many were
+// deleted.
+func CleanupOrphanFacts(ctx context.Context, ps pathstore.Store, userID, docID string) (int, error) {
+	nodes, err := ps.ListAll(ctx, fmt.Sprintf("memory/users/%s/entities", userID))
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, n := range nodes {
+		if !strings.Contains(n.Key, ".facts.") || factDocID(n.Value) != docID {
+			continue
+		}
+		if err := ps.DeleteNode(ctx, keyToPath(n.Key), false); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// ExtractFactPath returns the fact path recorded in a manifest entry.
+func ExtractFactPath(value any) string {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return ""
+	}
+	path, _ := m["path"].(string)
+	return path
+}
+
+// DeleteHashIndex removes the dedup index entries for a document. It reads
+// the content and upload hashes from the document meta, so call it before
+// deleting meta.
+func DeleteHashIndex(ctx context.Context, ps pathstore.Store, userID, docID, docPrefix string) {
+	meta, err := ps.GetNode(ctx, docPrefix+"/meta")
+	if err != nil || meta == nil {
+		return
+	}
+	metaMap, ok := meta.Value.(map[string]any)
+	if !ok {
+		return
+	}
+	contentHash, _ := metaMap["content_hash"].(string)
+	uploadHash, _ := metaMap["upload_hash"].(string)
+	for _, hash := range documentHashes(contentHash, uploadHash) {
+		ps.DeleteNode(ctx, hashIndexPath(userID, hash, docID), false)
+	}
+}
+
+// LookupHashIndex returns the ID of a document of the user's indexed under
+// hash, either its parsed-text hash or its upload hash. It returns "" when
+// none is.
+func LookupHashIndex(ctx context.Context, ps pathstore.Store, userID, hash string) (string, error) {
+	children, err := ps.ListChildren(ctx, fmt.Sprintf("memory/users/%s/documents/by_hash/%s", userID, hash), 1)
+	if err != nil || len(children) == 0 {
+		return "", err
+	}
+	// The doc_id is the last element of the key path.
+	parts := strings.Split(children[0].Key, ".")
+	return parts[len(parts)-1], nil
+}
+
+// WriteHashIndex indexes a document for dedup under both its parsed-text
+// hash and its upload hash, with value (filename and created_at) on each
+// entry. Every entry is attempted; the errors are joined.
+func WriteHashIndex(ctx context.Context, ps pathstore.Store, userID, docID, contentHash, uploadHash string, value map[string]any) error {
+	var errs []error
+	for _, hash := range documentHashes(contentHash, uploadHash) {
+		err := ps.PutNode(ctx, hashIndexPath(userID, hash, docID), pathstore.NodeRequest{
+			Value:      value,
+			MemoryType: "metacognitive",
+			Salience:   0.1,
+			Source:     "docgest:" + docID,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("hash %s: %w", hash, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func hashIndexPath(userID, hash, docID string) string {
+	return fmt.Sprintf("memory/users/%s/documents/by_hash/%s/%s", userID, hash, docID)
+}
+
+// documentHashes returns the non-empty, distinct hashes a document is
+// indexed under.
+func documentHashes(contentHash, uploadHash string) []string {
+	var hashes []string
+	if contentHash != "" {
+		hashes = append(hashes, contentHash)
+	}
+	if uploadHash != "" && uploadHash != contentHash {
+		hashes = append(hashes, uploadHash)
+	}
+	return hashes
+}
+
+// factDocID returns source.doc_id from a stored fact value.
+func factDocID(value any) string {
+	m, _ := value.(map[string]any)
+	src, _ := m["source"].(map[string]any)
+	id, _ := src["doc_id"].(string)
+	return id
+}
+
+// keyToPath converts a dotted key_path from a listing back to a slash path.
+func keyToPath(key string) string {
+	return strings.ReplaceAll(key, ".", "/")
+}
@@ -0,0 +1,149 @@
+package pipeline_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/chunker"
+	"github.com/dgallion1/docgest/internal/extract"
+	"github.com/dgallion1/docgest/internal/parser"
+	"github.com/dgallion1/docgest/internal/pathstore"
+	"github.com/dgallion1/docgest/internal/pipeline"
+	"github.com/dgallion1/docgest/internal/testutil"
+)
+
+// failingPutStore rejects every write to a fact path.
+type failingPutStore struct {
+	pathstore.Store
+	err error
+}
+
+func (s failingPutStore) PutNode(ctx context.Context, key string, req pathstore.NodeRequest) error {
+	if strings.Contains(key, "/entities/") {
+		return s.err
+	}
+	return s.Store.PutNode(ctx, key, req)
+}
+
+// failFirstPutStore rejects only the first write to a fact path.
+type failFirstPutStore struct {
+	pathstore.Store
+	failed *atomic.Bool
+}
+
+func (s failFirstPutStore) PutNode(ctx context.Context, key string, req pathstore.NodeRequest) error {
+	if strings.Contains(key, "/entities/") && s.failed.CompareAndSwap(false, true) {
+		return errors.New("disk full")
+	}
+	return s.Store.PutNode(ctx, key, req)
+}
+
+// processJob runs one ingest job through a worker and returns it.
+func processJob(t *testing.T, ex extract.Extractor, ps pathstore.Store, filename string, data []byte) *pipeline.Job {
+	t.Helper()
+	w := pipeline.NewWorker(ex, ps, slog.New(slog.NewTextHandler(io.Discard, nil)), chunker.DefaultConfig(), 2, 2)
+	now := time.Now()
+	job := &pipeline.Job{
+		ID:        "err-test",
+		Type:      pipeline.JobTypeIngest,
+		DocID:     "doc1",
+		UserID:    "u1",
+		Status:    pipeline.StatusQueued,
+		Filename:  filename,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	job.SetFileData(data)
+	w.Process(context.Background(), job)
+	return job
+}
+
+func TestWorker_ParseFailureChain(t *testing.T) {
+	job := processJob(t, testutil.NewMockExtractor(), testutil.NewMockPathstoreClient(), "broken.ipynb", []byte("{not json"))
+	err := job.Err()
+	if !errors.Is(err, parser.ErrParseFailure) {
+		t.Errorf("expected ErrParseFailure in chain, got %v", err)
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("expected the decoder's *json.SyntaxError in chain, got %v", err)
+	}
+}
+
+func TestWorker_ExtractionFailureChain(t *testing.T) {
+	cause := errors.New("upstream overloaded")
+	ex := testutil.NewMockExtractor()
+	ex.SetError(cause)
+	job := processJob(t, ex, testutil.NewMockPathstoreClient(), "pets.md", []byte(leakTestMarkdown))
+	err := job.Err()
+	if !errors.Is(err, extract.ErrExtractionFailure) || !errors.Is(err, cause) {
+		t.Errorf("expected ErrExtractionFailure wrapping %v, got %v", cause, err)
+	}
+	if errors.Is(err, pathstore.ErrStorageFailure) {
+		t.Errorf("expected no storage failure, got %v", err)
+	}
+}
+
+func TestWorker_StorageFailureChain(t *testing.T) {
+	cause := errors.New("disk full")
+	ps := failingPutStore{Store: testutil.NewMockPathstoreClient(), err: cause}
+	job := processJob(t, testutil.NewMockExtractor(testutil.DefaultFacts...), ps, "pets.md", []byte(leakTestMarkdown))
+	err := job.Err()
+	if !errors.Is(err, pathstore.ErrStorageFailure) || !errors.Is(err, cause) {
+		t.Errorf("expected ErrStorageFailure wrapping %v, got %v", cause, err)
+	}
+	for _, msg := range job.Snapshot().Progress.Errors {
+		if !strings.Contains(msg, "storage failure: disk full") {
+			t.Errorf("expected sentinel and cause in %q", msg)
+		}
+	}
+}
+
+func TestWorker_PartialStoreFailure(t *testing.T) {
+	mock := testutil.NewMockPathstoreClient()
+	ps := failFirstPutStore{Store: mock, failed: new(atomic.Bool)}
+	ex := testutil.NewMockExtractor(testutil.DefaultFacts...)
+	job := processJob(t, ex, ps, "pets.md", []byte(leakTestMarkdown))
+	snap := job.Snapshot()
+	if snap.Status != pipeline.StatusPartial {
+		t.Fatalf("expected partial, got %s", snap.Status)
+	}
+	want := ex.Calls()*len(testutil.DefaultFacts) - 1
+	if snap.Progress.FactsStored != want {
+		t.Errorf("expected facts_stored %d, got %d", want, snap.Progress.FactsStored)
+	}
+	meta, _ := mock.GetNode(context.Background(), "memory/users/u1/documents/doc1/meta")
+	if meta == nil {
+		t.Fatal("expected meta written after a partial store")
+	}
+	if got := meta.Value.(map[string]any)["facts_stored"]; got != want {
+		t.Errorf("expected meta facts_stored %d, got %v", want, got)
+	}
+	if entries := mock.Keys("memory/users/u1/documents/doc1/facts"); len(entries) != want {
+		t.Errorf("expected %d manifest entries kept, got %d", want, len(entries))
+	}
+}
+
+func TestWorker_TotalStoreFailureRollsBack(t *testing.T) {
+	mock := testutil.NewMockPathstoreClient()
+	ps := failingPutStore{Store: mock, err: errors.New("disk full")}
+	// Only the entity fact, so every fact write fails.
+	job := processJob(t, testutil.NewMockExtractor(testutil.DefaultFacts[0]), ps, "pets.md", []byte(leakTestMarkdown))
+	snap := job.Snapshot()
+	if snap.Status != pipeline.StatusFailed {
+		t.Fatalf("expected failed, got %s", snap.Status)
+	}
+	if snap.Progress.FactsStored != 0 {
+		t.Errorf("expected facts_stored 0, got %d", snap.Progress.FactsStored)
+	}
+	if keys := mock.Keys("memory/users/u1"); len(keys) != 0 {
+		t.Errorf("expected nothing left under the user, got %v", keys)
+	}
+}
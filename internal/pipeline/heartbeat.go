@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// Heartbeat statuses.
+const (
+	HeartbeatRunning = "running"
+	HeartbeatStopped = "stopped"
+)
+
+// heartbeatWriteTimeout bounds each heartbeat write, including the final
+// stopped write made after the worker's context is cancelled.
+const heartbeatWriteTimeout = 5 * time.Second
+
+// WorkerHeartbeat is the node a worker writes to WorkerHeartbeatPath. A
+// running worker rewrites it every WorkerHeartbeatInterval, so a monitor
+// can treat one whose LastActive is more than twice the interval old as
+// stalled. A worker that exits cleanly writes Status HeartbeatStopped.
+type WorkerHeartbeat struct {
+	Status        string    `json:"status"`
+	PID           int       `json:"pid"`
+	WorkerIndex   int       `json:"worker_index"`
+	JobsProcessed int       `json:"jobs_processed"`
+	CurrentJobID  string    `json:"current_job_id,omitempty"`
+	LastActive    time.Time `json:"last_active"`
+	Version       string    `json:"version"`
+}
+
+// WorkerHeartbeatPath is the pathstore key holding worker index's
+// heartbeat.
+func WorkerHeartbeatPath(index int) string {
+	return fmt.Sprintf("system/docgest/workers/%d/heartbeat", index)
+}
+
+// startHeartbeat writes worker index's heartbeat now and then every
+// interval until the returned func is called. The func writes a final
+// stopped heartbeat and returns once it is stored.
+func (o *Orchestrator) startHeartbeat(ctx context.Context, index int, counters *workerCounters, interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			o.writeHeartbeat(ctx, index, counters, HeartbeatRunning)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+		o.writeHeartbeat(context.WithoutCancel(ctx), index, counters, HeartbeatStopped)
+	}
+}
+
+func (o *Orchestrator) writeHeartbeat(ctx context.Context, index int, counters *workerCounters, status string) {
+	stats := counters.snapshot()
+	hb := WorkerHeartbeat{
+		Status:        status,
+		PID:           os.Getpid(),
+		WorkerIndex:   index,
+		JobsProcessed: stats.JobsProcessed,
+		CurrentJobID:  stats.CurrentJobID,
+		LastActive:    time.Now().UTC(),
+		Version:       buildVersion(),
+	}
+	putCtx, cancel := context.WithTimeout(ctx, heartbeatWriteTimeout)
+	defer cancel()
+	err := o.ps.PutNode(putCtx, WorkerHeartbeatPath(index), pathstore.NodeRequest{
+		Value:      hb,
+		MemoryType: "metacognitive",
+		Salience:   0,
+		Source:     "docgest:heartbeat",
+	})
+	if err != nil && ctx.Err() == nil {
+		o.log.Warn("worker heartbeat failed", "worker", index, "error", err)
+	}
+}
+
+// buildVersion is the main module's version from the build info, or
+// "unknown" when the binary carries none.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
+}
@@ -0,0 +1,87 @@
+package pipeline_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pipeline"
+	"github.com/dgallion1/docgest/internal/testutil"
+)
+
+func TestOrchestrator_WorkerHeartbeats(t *testing.T) {
+	testutil.AssertNoGoroutineLeak(t, func() {
+		cfg := testutil.TestConfig()
+		cfg.WorkerCount, cfg.MinWorkerCount, cfg.MaxWorkerCount = 2, 2, 2
+		cfg.WorkerHeartbeatInterval = 20 * time.Millisecond
+		ps := testutil.NewMockPathstoreClient()
+		orch := pipeline.NewOrchestrator(cfg, testutil.NewMockExtractor(testutil.DefaultFacts...), ps, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		ctx, cancel := context.WithCancel(context.Background())
+		orch.Start(ctx)
+
+		heartbeat := func(index int) pipeline.WorkerHeartbeat {
+			node, _ := ps.GetNode(context.Background(), pipeline.WorkerHeartbeatPath(index))
+			if node == nil {
+				return pipeline.WorkerHeartbeat{}
+			}
+			hb, _ := node.Value.(pipeline.WorkerHeartbeat)
+			return hb
+		}
+
+		now := time.Now()
+		job := &pipeline.Job{
+			ID:        "heartbeat-test",
+			Type:      pipeline.JobTypeIngest,
+			DocID:     "doc1",
+			UserID:    "u1",
+			Status:    pipeline.StatusQueued,
+			Filename:  "pets.md",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		job.SetFileData([]byte(leakTestMarkdown))
+		if err := orch.Submit(ctx, job); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+
+		// A later beat reports the finished job.
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) && heartbeat(0).JobsProcessed+heartbeat(1).JobsProcessed < 1 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		for i := range 2 {
+			hb := heartbeat(i)
+			if hb.Status != pipeline.HeartbeatRunning {
+				t.Errorf("worker %d: expected status running, got %q", i, hb.Status)
+			}
+			if hb.WorkerIndex != i || hb.PID != os.Getpid() || hb.Version == "" || hb.LastActive.IsZero() {
+				t.Errorf("worker %d: incomplete heartbeat %+v", i, hb)
+			}
+		}
+		if got := heartbeat(0).JobsProcessed + heartbeat(1).JobsProcessed; got != 1 {
+			t.Errorf("expected 1 job processed across heartbeats, got %d", got)
+		}
+
+		cancel()
+		orch.Stop()
+		for i := range 2 {
+			if hb := heartbeat(i); hb.Status != pipeline.HeartbeatStopped {
+				t.Errorf("worker %d: expected status stopped after Stop, got %q", i, hb.Status)
+			}
+		}
+	})
+}
+
+func TestOrchestrator_HeartbeatsDisabled(t *testing.T) {
+	cfg := testutil.TestConfig()
+	ps := testutil.NewMockPathstoreClient()
+	orch := pipeline.NewOrchestrator(cfg, testutil.NewMockExtractor(), ps, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	orch.Start(context.Background())
+	orch.Stop()
+	if keys := ps.Keys("system/docgest/workers"); len(keys) != 0 {
+		t.Errorf("expected no heartbeats with a zero interval, got %v", keys)
+	}
+}
@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dgallion1/docgest/internal/doctree"
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// incrementalState is what an incremental ingest knows about the stored
+// version of a document.
+type incrementalState struct {
+	// done holds the hashes of chunks the stored version extracted.
+	done map[string]bool
+	// retained counts stored facts kept because their chunk is unchanged.
+	retained int
+	// stale are the manifest entries of facts to remove once the new
+	// version is stored.
+	stale []pathstore.ListChildrenResponse
+}
+
+// chunkHash identifies a chunk by its breadcrumb and text.
+func chunkHash(c doctree.Chunk) string {
+	return ContentHashHex([]byte(strings.Join(c.Breadcrumb, "\x1f") + "\x00" + c.Text))[:16]
+}
+
+// loadIncremental compares the new version's chunk hashes with those
+// recorded in the meta of the stored version at docPrefix. A chunk hash
+// covers the chunk's breadcrumb and text, so it already tells changed and
+// moved sections apart and no stored tree is needed. Facts whose chunk is
+// unchanged are retained; facts from changed or removed chunks, and facts
+// recorded without a chunk hash, become stale. A document with nothing
+// stored yields an empty state, so every chunk is extracted.
+func (w *Worker) loadIncremental(ctx context.Context, docPrefix string, chunkHashes []string) (*incrementalState, error) {
+	state := &incrementalState{done: make(map[string]bool)}
+
+	meta, err := w.pathstore.GetNode(ctx, docPrefix+"/meta")
+	if err != nil {
+		return nil, fmt.Errorf("read meta: %w", err)
+	}
+	if meta != nil {
+		metaMap, _ := meta.Value.(map[string]any)
+		for _, h := range stringList(metaMap["chunk_hashes"]) {
+			state.done[h] = true
+		}
+	}
+
+	current := make(map[string]bool, len(chunkHashes))
+	for _, h := range chunkHashes {
+		if state.done[h] {
+			current[h] = true
+		}
+	}
+	entries, err := w.pathstore.ListAll(ctx, docPrefix+"/facts")
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	for _, e := range entries {
+		m, _ := e.Value.(map[string]any)
+		if h, _ := m["chunk_hash"].(string); current[h] {
+			state.retained++
+		} else {
+			state.stale = append(state.stale, e)
+		}
+	}
+	return state, nil
+}
+
+// pending returns the chunks the stored version did not extract.
+func (s *incrementalState) pending(chunks []doctree.Chunk, chunkHashes []string) []doctree.Chunk {
+	var out []doctree.Chunk
+	for i, c := range chunks {
+		if !s.done[chunkHashes[i]] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// removeStaleFacts deletes the facts of stale manifest entries and the
+// entries themselves, skipping facts the new version wrote.
+func (w *Worker) removeStaleFacts(ctx context.Context, log *slog.Logger, entries []pathstore.ListChildrenResponse, written map[string]bool) {
+	removed, failed := 0, 0
+	for _, e := range entries {
+		factPath := ExtractFactPath(e.Value)
+		if written[factPath] {
+			// The new version stored the same fact at the same path and
+			// rewrote its manifest entry; both stay.
+			continue
+		}
+		if factPath != "" {
+			if err := w.pathstore.DeleteNode(ctx, factPath, false); err != nil {
+				log.Warn("stale fact delete failed", "path", factPath, "error", err)
+				failed++
+				continue
+			}
+		}
+		if err := w.pathstore.DeleteNode(ctx, keyToPath(e.Key), false); err != nil {
+			log.Warn("stale manifest entry delete failed", "key", e.Key, "error", err)
+		}
+		removed++
+	}
+	if len(entries) > 0 {
+		log.Info("replaced stale facts", "removed", removed, "failed", failed)
+	}
+}
+
+// stringList converts a decoded JSON array (or a []string) to strings,
+// skipping non-string elements.
+func stringList(v any) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []any:
+		out := make([]string, 0, len(list))
+		for _, x := range list {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -1,12 +1,17 @@
 package pipeline
 
 import (
+	"container/list"
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/dgallion1/docgest/internal/doctree"
+	"github.com/dgallion1/docgest/internal/extract"
 )
 
 // JobStatus represents the state of an ingestion job.
@@ -22,31 +27,131 @@ const (
 	StatusFailed     JobStatus = "failed"
 	StatusPartial    JobStatus = "partial"
 	StatusDupSkipped JobStatus = "duplicate_skipped"
+	StatusDeleting   JobStatus = "deleting"
 )
 
-// Job tracks the state of a single document ingestion.
+// Terminal reports whether a job in this status will not change again.
+func (s JobStatus) Terminal() bool {
+	switch s {
+	case StatusCompleted, StatusFailed, StatusPartial, StatusDupSkipped:
+		return true
+	}
+	return false
+}
+
+// JobType distinguishes ingestion jobs from deletion jobs.
+type JobType string
+
+const (
+	JobTypeIngest JobType = "ingest"
+	JobTypeDelete JobType = "delete"
+)
+
+// SourceType describes where a document came from; it scales the salience
+// of the document's facts.
+type SourceType string
+
+const (
+	SourceDocument SourceType = "document"
+	SourceNote     SourceType = "note"
+	SourceWebPage  SourceType = "web_page"
+	SourceFeedItem SourceType = "feed_item"
+)
+
+// ParseSourceType validates a source type; empty means SourceDocument.
+func ParseSourceType(s string) (SourceType, bool) {
+	switch st := SourceType(s); st {
+	case "":
+		return SourceDocument, true
+	case SourceDocument, SourceNote, SourceWebPage, SourceFeedItem:
+		return st, true
+	default:
+		return "", false
+	}
+}
+
+// Job tracks the state of a single document ingestion or deletion.
 type Job struct {
 	mu sync.Mutex
 
-	ID     string `json:"job_id"`
-	DocID  string `json:"doc_id"`
-	UserID string `json:"user_id"`
+	ID     string  `json:"job_id"`
+	Type   JobType `json:"type"`
+	DocID  string  `json:"doc_id"`
+	UserID string  `json:"user_id"`
 
 	Status   JobStatus `json:"status"`
 	Phase    string    `json:"phase"`
 	Filename string    `json:"filename"`
 	Title    string    `json:"title"`
 
+	// ContentType is the upload's declared MIME type, used to pick a parser
+	// when the extension is missing, unsupported or contradicted by the data.
+	ContentType string `json:"content_type,omitempty"`
+
+	// SourceType scales fact salience; empty is treated as SourceDocument.
+	SourceType SourceType `json:"source_type,omitempty"`
+
+	// DocType selects the extraction emphasis (see extract.DocTypeLegal and
+	// friends). Empty means the worker detects it after parsing.
+	DocType string `json:"doc_type,omitempty"`
+
+	// Tags are caller-supplied labels, set before Submit and never mutated.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// DryRun stops the job after chunking and stores a chunk preview at
+	// PreviewPath instead of extracting facts.
+	DryRun      bool   `json:"dry_run,omitempty"`
+	PreviewPath string `json:"preview_path,omitempty"`
+
+	// ParseOnly stops the job after parsing, keeping the DocTree on the job
+	// for the status response. Nothing is chunked, extracted or stored.
+	ParseOnly bool `json:"parse_only,omitempty"`
+
+	// Incremental re-extracts only the chunks that changed since the
+	// document was last stored under DocID, keeping the other facts.
+	Incremental bool `json:"incremental,omitempty"`
+
+	// MinTrustFloor raises every stored fact's min_trust to at least this
+	// value. Zero falls back to the user's config.
+	MinTrustFloor int `json:"min_trust_floor,omitempty"`
+
+	// RequestID is the ID of the API request that created the job. The
+	// job's pathstore calls send it as X-Request-ID.
+	RequestID string `json:"request_id,omitempty"`
+
+	// CallbackURL, if set, receives the job snapshot once the job finishes.
+	// It must pass ValidateCallbackURL before Submit.
+	CallbackURL string `json:"callback_url,omitempty"`
+
+	// CallbackSecret, if set, signs callback deliveries in SignatureHeader.
+	// It comes from the registered webhook the callback was made through.
+	CallbackSecret string `json:"-"`
+
+	// Overrides are per-request extraction parameters, applied on top of
+	// the user's stored config.
+	Overrides UserConfig `json:"-"`
+
 	Progress Progress `json:"progress"`
 
-	ContentHash string    `json:"content_hash,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ContentHash string `json:"content_hash,omitempty"`
+	// UploadHash is the hash of the uploaded bytes. The dedup index holds
+	// it alongside ContentHash so a client can look a document up by the
+	// file it sent without parsing it.
+	UploadHash string    `json:"upload_hash,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 
 	// Internal: not serialized.
-	fileData []byte
-	chunks   []doctree.Chunk
-	errors   []string
+	store      *JobStore // set by JobStore.Put so terminal jobs join its LRU
+	fileData   []byte
+	parsedTree *doctree.DocTree // set for ParseOnly jobs
+	chunks     []doctree.Chunk
+	errs       []error
+
+	callbackAttempts  int
+	callbackLastError string
+
+	chunkPos map[int]int // chunk index -> position in Progress.Chunks
 }
 
 // Progress tracks processing progress.
@@ -55,27 +160,227 @@ type Progress struct {
 	ChunksProcessed int      `json:"chunks_processed"`
 	FactsValid      int      `json:"facts_valid"`
 	FactsStored     int      `json:"facts_stored"`
+	LinksCreated    int      `json:"links_created"`
 	Errors          []string `json:"errors"`
+
+	// ParsedNodeCount and ParsedTextBytes size the parsed document so
+	// callers can estimate how long the remaining phases will take.
+	ParsedNodeCount int `json:"parsed_node_count"`
+	ParsedTextBytes int `json:"parsed_text_bytes"`
+
+	// RejectionReasons counts facts dropped by validation, keyed by
+	// extract.RejectionKind.
+	RejectionReasons map[string]int `json:"rejection_reasons,omitempty"`
+
+	// Chunks reports each chunk's extraction state in the order
+	// extraction started, for chunk-by-chunk progress displays.
+	Chunks []ChunkProgress `json:"chunks,omitempty"`
+
+	// Delete is set when a deletion job completes.
+	Delete *DeleteResult `json:"delete,omitempty"`
+}
+
+// ChunkStatus is the extraction state of one chunk.
+type ChunkStatus string
+
+const (
+	ChunkStarted   ChunkStatus = "started"
+	ChunkCompleted ChunkStatus = "completed"
+	ChunkFailed    ChunkStatus = "failed"
+)
+
+// ChunkProgress is one chunk's entry in Progress.Chunks.
+type ChunkProgress struct {
+	ChunkIndex int         `json:"chunk_index"`
+	Breadcrumb []string    `json:"breadcrumb"`
+	Status     ChunkStatus `json:"status"`
 }
 
-// JobStore is a thread-safe in-memory job registry with TTL eviction.
+// JobStore is a thread-safe in-memory job registry with TTL eviction. When
+// maxSize is positive it also evicts the least recently finished job once
+// the store is full; jobs still in progress are only removed by TTL.
 type JobStore struct {
-	mu   sync.Mutex
-	jobs map[string]*Job
-	ttl  time.Duration
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	ttl     time.Duration
+	maxSize int
+
+	// done orders terminal jobs by when they finished, oldest at the front.
+	done     *list.List
+	doneElem map[string]*list.Element
+
+	// retired totals finished ingest jobs already evicted, so Stats covers
+	// the store's whole lifetime.
+	retired JobTotals
+
+	// expired records when evicted jobs were removed, so callers can tell
+	// an expired job from one that never existed. Entries are dropped after
+	// expiredTTL.
+	expired map[string]time.Time
+}
+
+// expiredTTL is how long an evicted job's ID is remembered.
+const expiredTTL = 24 * time.Hour
+
+// JobTotals aggregates finished ingest jobs.
+type JobTotals struct {
+	Jobs            int               `json:"jobs"`
+	ByStatus        map[JobStatus]int `json:"by_status"`
+	FactsExtracted  int               `json:"facts_extracted"`
+	FactsStored     int               `json:"facts_stored"`
+	ChunksProcessed int               `json:"chunks_processed"`
+}
+
+// add folds a finished ingest job into t. Other jobs are ignored.
+func (t *JobTotals) add(j *Job) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.jobType() != JobTypeIngest || !j.Status.Terminal() || j.DryRun || j.ParseOnly {
+		return
+	}
+	if t.ByStatus == nil {
+		t.ByStatus = make(map[JobStatus]int)
+	}
+	t.Jobs++
+	t.ByStatus[j.Status]++
+	t.FactsExtracted += j.Progress.FactsValid
+	t.FactsStored += j.Progress.FactsStored
+	t.ChunksProcessed += j.Progress.ChunksProcessed
 }
 
-func NewJobStore(ttl time.Duration) *JobStore {
+func NewJobStore(ttl time.Duration, maxSize int) *JobStore {
 	return &JobStore{
-		jobs: make(map[string]*Job),
-		ttl:  ttl,
+		jobs:     make(map[string]*Job),
+		ttl:      ttl,
+		maxSize:  maxSize,
+		done:     list.New(),
+		doneElem: make(map[string]*list.Element),
+		expired:  make(map[string]time.Time),
 	}
 }
 
 func (s *JobStore) Put(job *Job) {
+	job.mu.Lock()
+	job.store = s
+	terminal := job.Status.Terminal()
+	job.mu.Unlock()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.removeLocked(job.ID)
+	delete(s.expired, job.ID)
 	s.jobs[job.ID] = job
+	if terminal {
+		s.doneElem[job.ID] = s.done.PushBack(job)
+	}
+	s.evictLocked()
+}
+
+// PutIfAbsent stores job unless the store holds another job with its ID,
+// checking and storing under one lock so concurrent callers with the same
+// ID cannot both succeed. A failed job of the same user and type is
+// replaced, so a retry runs again; any other holder is returned with false.
+func (s *JobStore) PutIfAbsent(job *Job) (*Job, bool) {
+	job.mu.Lock()
+	job.store = s
+	job.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing := s.jobs[job.ID]; existing != nil {
+		existing.mu.Lock()
+		replace := existing.Status == StatusFailed && existing.UserID == job.UserID && existing.Type == job.Type
+		existing.mu.Unlock()
+		if !replace {
+			return existing, false
+		}
+	}
+	s.removeLocked(job.ID)
+	delete(s.expired, job.ID)
+	s.jobs[job.ID] = job
+	s.evictLocked()
+	return job, true
+}
+
+// Len returns the number of jobs held.
+func (s *JobStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+// CountByStatus counts the jobs currently held, of every type, by status.
+// Unlike Totals it does not include evicted jobs.
+func (s *JobStore) CountByStatus() map[JobStatus]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[JobStatus]int)
+	for _, job := range s.jobs {
+		counts[job.currentStatus()]++
+	}
+	return counts
+}
+
+// markDone moves a job that just reached a terminal status to the back of
+// the eviction order.
+func (s *JobStore) markDone(job *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobs[job.ID] != job {
+		return
+	}
+	if el, ok := s.doneElem[job.ID]; ok {
+		s.done.MoveToBack(el)
+	} else {
+		s.doneElem[job.ID] = s.done.PushBack(job)
+	}
+	s.evictLocked()
+}
+
+// evictLocked drops the oldest finished jobs until the store fits maxSize.
+// Caller must hold s.mu.
+func (s *JobStore) evictLocked() {
+	if s.maxSize <= 0 {
+		return
+	}
+	for len(s.jobs) > s.maxSize && s.done.Len() > 0 {
+		oldest := s.done.Front().Value.(*Job)
+		s.retired.add(oldest)
+		s.removeLocked(oldest.ID)
+		s.expired[oldest.ID] = time.Now()
+	}
+}
+
+// removeLocked deletes a job and its LRU entry. Caller must hold s.mu.
+func (s *JobStore) removeLocked(id string) {
+	delete(s.jobs, id)
+	if el, ok := s.doneElem[id]; ok {
+		s.done.Remove(el)
+		delete(s.doneElem, id)
+	}
+}
+
+// Totals aggregates every finished ingest job the store has held,
+// including evicted ones, and counts ingest jobs still in progress.
+func (s *JobStore) Totals() (totals JobTotals, inFlight int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	totals = s.retired
+	totals.ByStatus = make(map[JobStatus]int, len(s.retired.ByStatus))
+	for k, v := range s.retired.ByStatus {
+		totals.ByStatus[k] = v
+	}
+	for _, job := range s.jobs {
+		job.mu.Lock()
+		running := job.jobType() == JobTypeIngest && !job.Status.Terminal()
+		job.mu.Unlock()
+		if running {
+			inFlight++
+			continue
+		}
+		totals.add(job)
+	}
+	return totals, inFlight
 }
 
 func (s *JobStore) Get(id string) *Job {
@@ -84,6 +389,70 @@ func (s *JobStore) Get(id string) *Job {
 	return s.jobs[id]
 }
 
+// ListByTags returns jobs whose tags include every given key/value pair,
+// newest first. An empty filter matches all jobs.
+func (s *JobStore) ListByTags(tags map[string]string) []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*Job
+	for _, job := range s.jobs {
+		if matchTags(job.Tags, tags) {
+			out = append(out, job)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// JobFilters narrows ListByUser results. Zero values match everything.
+type JobFilters struct {
+	Status JobStatus
+	Since  time.Time // Only jobs created at or after Since.
+	Tags   map[string]string
+	Limit  int
+	Offset int
+}
+
+// ListByUser returns a user's jobs matching filters, newest first.
+func (s *JobStore) ListByUser(userID string, filters JobFilters) []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*Job
+	for _, job := range s.jobs {
+		if job.UserID != userID || !matchTags(job.Tags, filters.Tags) {
+			continue
+		}
+		if !filters.Since.IsZero() && job.CreatedAt.Before(filters.Since) {
+			continue
+		}
+		if filters.Status != "" && job.currentStatus() != filters.Status {
+			continue
+		}
+		out = append(out, job)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+
+	if filters.Offset > 0 {
+		if filters.Offset >= len(out) {
+			return nil
+		}
+		out = out[filters.Offset:]
+	}
+	if filters.Limit > 0 && len(out) > filters.Limit {
+		out = out[:filters.Limit]
+	}
+	return out
+}
+
+func matchTags(have, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
 // Cleanup removes expired jobs.
 func (s *JobStore) Cleanup() {
 	s.mu.Lock()
@@ -91,29 +460,69 @@ func (s *JobStore) Cleanup() {
 	now := time.Now()
 	for id, job := range s.jobs {
 		if now.Sub(job.UpdatedAt) > s.ttl {
-			delete(s.jobs, id)
+			s.retired.add(job)
+			s.removeLocked(id)
+			s.expired[id] = now
+		}
+	}
+	for id, at := range s.expired {
+		if now.Sub(at) > expiredTTL {
+			delete(s.expired, id)
 		}
 	}
 }
 
+// Expired reports whether a job with this ID was evicted within the last
+// expiredTTL.
+func (s *JobStore) Expired(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.expired[id]
+	return ok
+}
+
 // SetStatus updates job status atomically.
 func (j *Job) SetStatus(status JobStatus, phase string) {
 	j.mu.Lock()
-	defer j.mu.Unlock()
 	j.Status = status
 	j.Phase = phase
 	j.UpdatedAt = time.Now()
+	store := j.store
+	j.mu.Unlock()
+
+	// Notify outside j.mu: the store locks its own mutex before any job's.
+	if store != nil && status.Terminal() {
+		store.markDone(j)
+	}
+}
+
+// currentStatus reads the status under the job lock.
+func (j *Job) currentStatus() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.Status
 }
 
-// AddError records an error.
-func (j *Job) AddError(err string) {
+// AddError records an error. Progress.Errors gets its message; Err keeps
+// the error itself so its chain can be inspected.
+func (j *Job) AddError(err error) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
-	j.errors = append(j.errors, err)
-	j.Progress.Errors = j.errors
+	j.errs = append(j.errs, err)
+	j.Progress.Errors = append(j.Progress.Errors, err.Error())
 	j.UpdatedAt = time.Now()
 }
 
+// Err joins every error recorded with AddError, or returns nil if there
+// were none. Use errors.Is against parser.ErrParseFailure,
+// extract.ErrExtractionFailure or pathstore.ErrStorageFailure to classify
+// a failed job.
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return errors.Join(j.errs...)
+}
+
 // IncrChunksProcessed atomically increments chunks processed.
 func (j *Job) IncrChunksProcessed() {
 	j.mu.Lock()
@@ -131,6 +540,27 @@ func (j *Job) AddFacts(valid, stored int) {
 	j.UpdatedAt = time.Now()
 }
 
+// AddLinks records links written between the job's facts.
+func (j *Job) AddLinks(n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Progress.LinksCreated += n
+	j.UpdatedAt = time.Now()
+}
+
+// AddRejections counts the reasons a fact failed validation.
+func (j *Job) AddRejections(reasons []string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Progress.RejectionReasons == nil {
+		j.Progress.RejectionReasons = make(map[string]int)
+	}
+	for _, r := range reasons {
+		j.Progress.RejectionReasons[extract.RejectionKind(r)]++
+	}
+	j.UpdatedAt = time.Now()
+}
+
 // SetTotalChunks records total chunk count.
 func (j *Job) SetTotalChunks(n int) {
 	j.mu.Lock()
@@ -139,6 +569,65 @@ func (j *Job) SetTotalChunks(n int) {
 	j.UpdatedAt = time.Now()
 }
 
+// SetParsedStats records the size of the parsed document.
+func (j *Job) SetParsedStats(nodes, textBytes int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Progress.ParsedNodeCount = nodes
+	j.Progress.ParsedTextBytes = textBytes
+	j.UpdatedAt = time.Now()
+}
+
+// SetChunkStatus records the extraction state of chunk index. The first
+// report for a chunk fixes its breadcrumb and position.
+func (j *Job) SetChunkStatus(index int, breadcrumb []string, status ChunkStatus) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if pos, ok := j.chunkPos[index]; ok {
+		j.Progress.Chunks[pos].Status = status
+	} else {
+		if j.chunkPos == nil {
+			j.chunkPos = make(map[int]int)
+		}
+		j.chunkPos[index] = len(j.Progress.Chunks)
+		j.Progress.Chunks = append(j.Progress.Chunks, ChunkProgress{ChunkIndex: index, Breadcrumb: breadcrumb, Status: status})
+	}
+	j.UpdatedAt = time.Now()
+}
+
+// recordCallbackAttempt counts a callback delivery attempt and keeps its
+// error, clearing it on success.
+func (j *Job) recordCallbackAttempt(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.callbackAttempts++
+	j.callbackLastError = ""
+	if err != nil {
+		j.callbackLastError = err.Error()
+	}
+}
+
+// SetDocType records the document type chosen for extraction.
+func (j *Job) SetDocType(docType string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.DocType = docType
+}
+
+// SetPreviewPath records where a dry run stored its chunk preview.
+func (j *Job) SetPreviewPath(path string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.PreviewPath = path
+}
+
+// SetParsedTree records the tree a parse-only job produced.
+func (j *Job) SetParsedTree(tree *doctree.DocTree) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.parsedTree = tree
+}
+
 // SetFileData sets the raw file bytes for processing.
 func (j *Job) SetFileData(data []byte) {
 	j.mu.Lock()
@@ -155,14 +644,101 @@ func (j *Job) FileData() []byte {
 
 // JobSnapshot is a read-only, JSON-safe copy of job state.
 type JobSnapshot struct {
-	ID       string    `json:"job_id"`
-	DocID    string    `json:"doc_id"`
-	UserID   string    `json:"user_id"`
-	Status   JobStatus `json:"status"`
-	Phase    string    `json:"phase"`
-	Filename string    `json:"filename"`
-	Title    string    `json:"title"`
-	Progress Progress  `json:"progress"`
+	ID         string            `json:"job_id"`
+	Type       JobType           `json:"type"`
+	DocID      string            `json:"doc_id"`
+	UserID     string            `json:"user_id"`
+	Status     JobStatus         `json:"status"`
+	Phase      string            `json:"phase"`
+	Filename   string            `json:"filename"`
+	Title      string            `json:"title"`
+	SourceType SourceType        `json:"source_type,omitempty"`
+	DocType    string            `json:"doc_type,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Progress   Progress          `json:"progress"`
+
+	DryRun      bool   `json:"dry_run,omitempty"`
+	PreviewPath string `json:"preview_path,omitempty"`
+
+	// ParsedTree is the parse-only result. Only the status endpoint
+	// returns it, keeping job lists small.
+	ParseOnly  bool             `json:"parse_only,omitempty"`
+	ParsedTree *doctree.DocTree `json:"-"`
+
+	CallbackURL       string `json:"callback_url,omitempty"`
+	CallbackAttempts  int    `json:"callback_attempts,omitempty"`
+	CallbackLastError string `json:"callback_last_error,omitempty"`
+
+	// Description summarizes progress for people polling the job, e.g.
+	// "Extracting facts from chunk 2/50".
+	Description string `json:"description"`
+	// PercentComplete is the share of chunks processed (0 before chunking).
+	PercentComplete float64 `json:"percent_complete"`
+}
+
+// describe returns a one-line summary of the job's state. Caller must hold
+// j.mu.
+func (j *Job) describe() string {
+	p := j.Progress
+	switch j.Status {
+	case StatusQueued:
+		return "Waiting for a worker"
+	case StatusParsing:
+		return "Parsing document"
+	case StatusChunking:
+		return "Splitting document into chunks"
+	case StatusExtracting:
+		return fmt.Sprintf("Extracting facts from chunk %d/%d", p.ChunksProcessed, p.TotalChunks)
+	case StatusStoring:
+		return fmt.Sprintf("Storing %d facts", p.FactsValid)
+	case StatusDeleting:
+		return "Deleting document"
+	case StatusCompleted:
+		if j.jobType() == JobTypeDelete {
+			return "Document deleted"
+		}
+		if j.DryRun {
+			return fmt.Sprintf("Previewed %d chunks without extraction", p.TotalChunks)
+		}
+		if j.ParseOnly {
+			return fmt.Sprintf("Parsed %d nodes without chunking or extraction", p.ParsedNodeCount)
+		}
+		return fmt.Sprintf("Stored %d facts from %d chunks", p.FactsStored, p.TotalChunks)
+	case StatusPartial:
+		return fmt.Sprintf("Stored %d facts from %d chunks with %d errors", p.FactsStored, p.TotalChunks, len(p.Errors))
+	case StatusDupSkipped:
+		return "Skipped: document already ingested"
+	case StatusFailed:
+		return fmt.Sprintf("Failed while %s", j.Phase)
+	default:
+		return string(j.Status)
+	}
+}
+
+// percentComplete is ChunksProcessed as a percentage of TotalChunks.
+// Caller must hold j.mu.
+func (j *Job) percentComplete() float64 {
+	if j.Progress.TotalChunks == 0 {
+		return 0
+	}
+	return float64(j.Progress.ChunksProcessed) / float64(j.Progress.TotalChunks) * 100
+}
+
+// jobType reports the job's type; jobs created without one are ingests.
+// Caller must hold j.mu.
+func (j *Job) jobType() JobType {
+	if j.Type == "" {
+		return JobTypeIngest
+	}
+	return j.Type
+}
+
+// SetDeleteResult records the outcome of a deletion job.
+func (j *Job) SetDeleteResult(r DeleteResult) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Progress.Delete = &r
+	j.UpdatedAt = time.Now()
 }
 
 // Snapshot returns a JSON-safe copy of the job state.
@@ -173,21 +749,47 @@ func (j *Job) Snapshot() JobSnapshot {
 	if errs == nil {
 		errs = []string{}
 	}
+	var rejections map[string]int
+	if len(j.Progress.RejectionReasons) > 0 {
+		rejections = make(map[string]int, len(j.Progress.RejectionReasons))
+		for k, v := range j.Progress.RejectionReasons {
+			rejections[k] = v
+		}
+	}
 	return JobSnapshot{
-		ID:       j.ID,
-		DocID:    j.DocID,
-		UserID:   j.UserID,
-		Status:   j.Status,
-		Phase:    j.Phase,
-		Filename: j.Filename,
-		Title:    j.Title,
+		ID:         j.ID,
+		Type:       j.jobType(),
+		DocID:      j.DocID,
+		UserID:     j.UserID,
+		Status:     j.Status,
+		Phase:      j.Phase,
+		Filename:   j.Filename,
+		Title:      j.Title,
+		SourceType: j.SourceType,
+		DocType:    j.DocType,
+		Tags:       j.Tags,
 		Progress: Progress{
-			TotalChunks:     j.Progress.TotalChunks,
-			ChunksProcessed: j.Progress.ChunksProcessed,
-			FactsValid:      j.Progress.FactsValid,
-			FactsStored:     j.Progress.FactsStored,
-			Errors:          errs,
+			TotalChunks:      j.Progress.TotalChunks,
+			ChunksProcessed:  j.Progress.ChunksProcessed,
+			FactsValid:       j.Progress.FactsValid,
+			FactsStored:      j.Progress.FactsStored,
+			LinksCreated:     j.Progress.LinksCreated,
+			Errors:           errs,
+			ParsedNodeCount:  j.Progress.ParsedNodeCount,
+			ParsedTextBytes:  j.Progress.ParsedTextBytes,
+			RejectionReasons: rejections,
+			Chunks:           slices.Clone(j.Progress.Chunks),
+			Delete:           j.Progress.Delete,
 		},
+		DryRun:            j.DryRun,
+		PreviewPath:       j.PreviewPath,
+		ParseOnly:         j.ParseOnly,
+		ParsedTree:        j.parsedTree,
+		CallbackURL:       j.CallbackURL,
+		CallbackAttempts:  j.callbackAttempts,
+		CallbackLastError: j.callbackLastError,
+		Description:       j.describe(),
+		PercentComplete:   j.percentComplete(),
 	}
 }
 
@@ -1,8 +1,15 @@
 package pipeline
 
 import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/dgallion1/docgest/internal/extract"
 )
 
 func TestContentHashHex_Consistency(t *testing.T) {
@@ -86,10 +93,32 @@ func TestJob_SetStatusFailed(t *testing.T) {
 	}
 }
 
+func TestJob_ErrKeepsChain(t *testing.T) {
+	job := &Job{ID: "j1"}
+	if err := job.Err(); err != nil {
+		t.Fatalf("expected nil error before AddError, got %v", err)
+	}
+
+	cause := errors.New("connection reset")
+	job.AddError(fmt.Errorf("chunk 2: %w: %w", extract.ErrExtractionFailure, cause))
+	job.AddError(errors.New("no extractable content"))
+
+	err := job.Err()
+	if !errors.Is(err, extract.ErrExtractionFailure) {
+		t.Errorf("expected ErrExtractionFailure in chain, got %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("expected original cause in chain, got %v", err)
+	}
+	if got := job.Snapshot().Progress.Errors[0]; got != "chunk 2: extraction failure: connection reset" {
+		t.Errorf("expected sentinel and cause in message, got %q", got)
+	}
+}
+
 func TestJob_AddError(t *testing.T) {
 	job := &Job{ID: "err-test", UpdatedAt: time.Now()}
-	job.AddError("chunk 3 failed")
-	job.AddError("chunk 7 failed")
+	job.AddError(errors.New("chunk 3 failed"))
+	job.AddError(errors.New("chunk 7 failed"))
 
 	snap := job.Snapshot()
 	if len(snap.Progress.Errors) != 2 {
@@ -112,6 +141,25 @@ func TestJob_IncrChunksProcessed(t *testing.T) {
 	}
 }
 
+func TestJob_SetChunkStatus(t *testing.T) {
+	job := &Job{ID: "chunk-test", UpdatedAt: time.Now()}
+	job.SetChunkStatus(3, []string{"Intro"}, ChunkStarted)
+	job.SetChunkStatus(1, []string{"Setup", "Install"}, ChunkStarted)
+	job.SetChunkStatus(3, nil, ChunkCompleted)
+	job.SetChunkStatus(1, nil, ChunkFailed)
+
+	chunks := job.Snapshot().Progress.Chunks
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunk entries, got %d", len(chunks))
+	}
+	if chunks[0].ChunkIndex != 3 || chunks[0].Status != ChunkCompleted || chunks[0].Breadcrumb[0] != "Intro" {
+		t.Errorf("expected chunk 3 completed with its breadcrumb kept, got %+v", chunks[0])
+	}
+	if chunks[1].ChunkIndex != 1 || chunks[1].Status != ChunkFailed || len(chunks[1].Breadcrumb) != 2 {
+		t.Errorf("expected chunk 1 failed with its breadcrumb kept, got %+v", chunks[1])
+	}
+}
+
 func TestJob_AddFacts(t *testing.T) {
 	job := &Job{ID: "facts-test", UpdatedAt: time.Now()}
 	job.AddFacts(5, 4)
@@ -126,6 +174,20 @@ func TestJob_AddFacts(t *testing.T) {
 	}
 }
 
+func TestJob_AddRejections(t *testing.T) {
+	job := &Job{ID: "reject-test", UpdatedAt: time.Now()}
+	job.AddRejections([]string{"text too long (350 chars)", "invalid category: events"})
+	job.AddRejections([]string{"text too long (410 chars)"})
+
+	snap := job.Snapshot()
+	if got := snap.Progress.RejectionReasons["text too long"]; got != 2 {
+		t.Errorf("expected 2 text too long rejections, got %d", got)
+	}
+	if got := snap.Progress.RejectionReasons["invalid category"]; got != 1 {
+		t.Errorf("expected 1 invalid category rejection, got %d", got)
+	}
+}
+
 func TestJob_SetTotalChunks(t *testing.T) {
 	job := &Job{ID: "total-test", UpdatedAt: time.Now()}
 	job.SetTotalChunks(42)
@@ -158,8 +220,36 @@ func TestJob_SnapshotErrorsNotNil(t *testing.T) {
 	}
 }
 
+func TestJob_SnapshotDescription(t *testing.T) {
+	job := &Job{ID: "desc-test", Status: StatusExtracting, UpdatedAt: time.Now()}
+	job.SetTotalChunks(8)
+	job.IncrChunksProcessed()
+	job.IncrChunksProcessed()
+
+	snap := job.Snapshot()
+	if snap.Description != "Extracting facts from chunk 2/8" {
+		t.Errorf("expected extracting description, got %q", snap.Description)
+	}
+	if snap.PercentComplete != 25 {
+		t.Errorf("expected 25 percent complete, got %v", snap.PercentComplete)
+	}
+
+	job.SetStatus(StatusStoring, "storing")
+	job.AddFacts(6, 0)
+	if got := job.Snapshot().Description; got != "Storing 6 facts" {
+		t.Errorf("expected storing description, got %q", got)
+	}
+}
+
+func TestJob_PercentCompleteNoChunks(t *testing.T) {
+	job := &Job{ID: "pct-test", Status: StatusParsing, UpdatedAt: time.Now()}
+	if got := job.Snapshot().PercentComplete; got != 0 {
+		t.Errorf("expected 0 percent complete, got %v", got)
+	}
+}
+
 func TestJobStore_PutGet(t *testing.T) {
-	store := NewJobStore(time.Hour)
+	store := NewJobStore(time.Hour, 0)
 	job := &Job{ID: "store-1", UpdatedAt: time.Now()}
 	store.Put(job)
 
@@ -172,15 +262,122 @@ func TestJobStore_PutGet(t *testing.T) {
 	}
 }
 
+func TestJobStore_PutIfAbsent(t *testing.T) {
+	store := NewJobStore(time.Hour, 0)
+	const n = 20
+	var wg sync.WaitGroup
+	var won atomic.Int32
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := store.PutIfAbsent(&Job{ID: "key-1", UserID: "u1", Type: JobTypeIngest, Status: StatusQueued}); ok {
+				won.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if won.Load() != 1 {
+		t.Fatalf("expected exactly one concurrent put to win, got %d", won.Load())
+	}
+
+	store.Get("key-1").SetStatus(StatusFailed, "extracting")
+	if _, ok := store.PutIfAbsent(&Job{ID: "key-1", UserID: "u2", Type: JobTypeIngest}); ok {
+		t.Error("expected another user's failed job not to be replaced")
+	}
+	retry := &Job{ID: "key-1", UserID: "u1", Type: JobTypeIngest}
+	if got, ok := store.PutIfAbsent(retry); !ok || got != retry || store.Get("key-1") != retry {
+		t.Error("expected the same user's failed job to be replaced")
+	}
+}
+
+// TestJobStore_ConcurrentAccess is meant for go test -race: handlers and
+// workers share the store, so Put, Get and Cleanup must not race.
+func TestJobStore_ConcurrentAccess(t *testing.T) {
+	store := NewJobStore(time.Hour, 0)
+	const n = 50
+	for i := range n {
+		store.Put(&Job{ID: fmt.Sprintf("seed-%d", i), UpdatedAt: time.Now()})
+	}
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			job := &Job{ID: fmt.Sprintf("put-%d", i), UpdatedAt: time.Now()}
+			store.Put(job)
+			job.SetStatus(StatusCompleted, "done")
+		}()
+		go func() {
+			defer wg.Done()
+			if job := store.Get(fmt.Sprintf("seed-%d", rand.IntN(n))); job == nil {
+				t.Error("expected seeded job to be found")
+			} else {
+				job.Snapshot()
+			}
+		}()
+	}
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.Cleanup()
+		}()
+	}
+	wg.Wait()
+
+	if got := store.Len(); got != 2*n {
+		t.Errorf("expected %d jobs, got %d", 2*n, got)
+	}
+}
+
+// TestJob_ConcurrentMutations is meant for go test -race: a worker updates
+// a job while status requests snapshot it.
+func TestJob_ConcurrentMutations(t *testing.T) {
+	job := &Job{ID: "mut-test", UpdatedAt: time.Now()}
+	const n = 50
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			job.SetStatus(StatusExtracting, fmt.Sprintf("extracting_%d", i))
+		}()
+		go func() {
+			defer wg.Done()
+			job.AddError(fmt.Errorf("error %d", i))
+		}()
+		go func() {
+			defer wg.Done()
+			job.IncrChunksProcessed()
+		}()
+		go func() {
+			defer wg.Done()
+			job.Snapshot()
+		}()
+	}
+	wg.Wait()
+
+	snap := job.Snapshot()
+	if snap.Progress.ChunksProcessed != n {
+		t.Errorf("expected %d chunks processed, got %d", n, snap.Progress.ChunksProcessed)
+	}
+	if len(snap.Progress.Errors) != n {
+		t.Errorf("expected %d errors, got %d", n, len(snap.Progress.Errors))
+	}
+}
+
 func TestJobStore_GetMissing(t *testing.T) {
-	store := NewJobStore(time.Hour)
+	store := NewJobStore(time.Hour, 0)
 	if store.Get("nonexistent") != nil {
 		t.Error("expected nil for missing job")
 	}
 }
 
 func TestJobStore_TTLCleanup(t *testing.T) {
-	store := NewJobStore(50 * time.Millisecond)
+	store := NewJobStore(50*time.Millisecond, 0)
 
 	expired := &Job{ID: "old", UpdatedAt: time.Now()}
 	store.Put(expired)
@@ -200,10 +397,198 @@ func TestJobStore_TTLCleanup(t *testing.T) {
 	if store.Get("new") == nil {
 		t.Error("expected fresh job to survive cleanup")
 	}
+	if !store.Expired("old") {
+		t.Error("expected cleaned up job to be reported as expired")
+	}
+	if store.Expired("new") || store.Expired("never") {
+		t.Error("expected only evicted jobs to be reported as expired")
+	}
+
+	// Reusing the ID clears the tombstone.
+	store.Put(&Job{ID: "old", UpdatedAt: time.Now()})
+	if store.Expired("old") {
+		t.Error("expected a resubmitted job not to be reported as expired")
+	}
 }
 
 func TestJobStore_CleanupEmpty(t *testing.T) {
-	store := NewJobStore(time.Hour)
+	store := NewJobStore(time.Hour, 0)
 	// Should not panic on empty store.
 	store.Cleanup()
 }
+
+func TestJobStore_ListByTags(t *testing.T) {
+	store := NewJobStore(time.Hour, 0)
+	now := time.Now()
+	store.Put(&Job{ID: "a", CreatedAt: now, Tags: map[string]string{"project": "alpha", "priority": "high"}})
+	store.Put(&Job{ID: "b", CreatedAt: now.Add(time.Second), Tags: map[string]string{"project": "alpha"}})
+	store.Put(&Job{ID: "c", CreatedAt: now, Tags: map[string]string{"project": "beta"}})
+	store.Put(&Job{ID: "d", CreatedAt: now})
+
+	got := store.ListByTags(map[string]string{"project": "alpha"})
+	if len(got) != 2 {
+		t.Fatalf("expected 2 jobs tagged project=alpha, got %d", len(got))
+	}
+	if got[0].ID != "b" {
+		t.Errorf("expected newest job first, got %q", got[0].ID)
+	}
+
+	got = store.ListByTags(map[string]string{"project": "alpha", "priority": "high"})
+	if len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("expected only job %q to match both tags, got %d jobs", "a", len(got))
+	}
+
+	if got := store.ListByTags(nil); len(got) != 4 {
+		t.Errorf("expected empty filter to match all 4 jobs, got %d", len(got))
+	}
+}
+
+func TestJobStore_ListByUser(t *testing.T) {
+	store := NewJobStore(time.Hour, 0)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, st := range []JobStatus{StatusCompleted, StatusFailed, StatusFailed, StatusQueued} {
+		store.Put(&Job{
+			ID:        string(rune('a' + i)),
+			UserID:    "u1",
+			Status:    st,
+			CreatedAt: base.Add(time.Duration(i) * 24 * time.Hour),
+		})
+	}
+	store.Put(&Job{ID: "other", UserID: "u2", Status: StatusFailed, CreatedAt: base})
+
+	if got := store.ListByUser("u1", JobFilters{}); len(got) != 4 {
+		t.Fatalf("expected 4 jobs for u1, got %d", len(got))
+	}
+
+	failed := store.ListByUser("u1", JobFilters{Status: StatusFailed})
+	if len(failed) != 2 {
+		t.Fatalf("expected 2 failed jobs, got %d", len(failed))
+	}
+	if failed[0].ID != "c" {
+		t.Errorf("expected newest failed job %q first, got %q", "c", failed[0].ID)
+	}
+
+	since := store.ListByUser("u1", JobFilters{Since: base.Add(48 * time.Hour)})
+	if len(since) != 2 {
+		t.Errorf("expected 2 jobs since day 3, got %d", len(since))
+	}
+
+	page := store.ListByUser("u1", JobFilters{Limit: 2, Offset: 1})
+	if len(page) != 2 || page[0].ID != "c" || page[1].ID != "b" {
+		t.Errorf("expected page [c b], got %d jobs", len(page))
+	}
+
+	if got := store.ListByUser("u1", JobFilters{Offset: 10}); len(got) != 0 {
+		t.Errorf("expected empty page past end, got %d", len(got))
+	}
+}
+
+func TestJobStore_LRUEvictsOldestFinished(t *testing.T) {
+	store := NewJobStore(time.Hour, 3)
+	running := &Job{ID: "running", Status: StatusExtracting}
+	store.Put(running)
+	a := &Job{ID: "a", Status: StatusQueued}
+	b := &Job{ID: "b", Status: StatusQueued}
+	store.Put(a)
+	store.Put(b)
+
+	// b finishes before a, so it is the older finished job.
+	b.SetStatus(StatusCompleted, "done")
+	a.SetStatus(StatusFailed, "parsing")
+
+	store.Put(&Job{ID: "c", Status: StatusQueued})
+
+	if store.Len() != 3 {
+		t.Errorf("expected 3 jobs, got %d", store.Len())
+	}
+	if store.Get("b") != nil {
+		t.Error("expected least recently finished job to be evicted")
+	}
+	if !store.Expired("b") {
+		t.Error("expected evicted job to be reported as expired")
+	}
+	if store.Get("a") == nil || store.Get("c") == nil {
+		t.Error("expected newer jobs to remain")
+	}
+	if store.Get("running") == nil {
+		t.Error("expected in-progress job never to be evicted by size")
+	}
+}
+
+func TestJobStore_LRUKeepsInProgressJobs(t *testing.T) {
+	store := NewJobStore(time.Hour, 2)
+	for _, id := range []string{"a", "b", "c"} {
+		store.Put(&Job{ID: id, Status: StatusQueued})
+	}
+	if store.Len() != 3 {
+		t.Errorf("expected in-progress jobs to exceed maxSize, got %d", store.Len())
+	}
+
+	// Once one finishes, the store shrinks back to maxSize.
+	store.Get("a").SetStatus(StatusCompleted, "done")
+	if store.Len() != 2 {
+		t.Errorf("expected 2 jobs after a job finished, got %d", store.Len())
+	}
+	if store.Get("a") != nil {
+		t.Error("expected the finished job to be evicted")
+	}
+}
+
+func TestJobStore_CleanupRemovesLRUEntry(t *testing.T) {
+	store := NewJobStore(time.Millisecond, 1)
+	store.Put(&Job{ID: "old", Status: StatusCompleted, UpdatedAt: time.Now().Add(-time.Hour)})
+	store.Cleanup()
+	if store.Len() != 0 {
+		t.Fatalf("expected expired job removed, got %d", store.Len())
+	}
+
+	// A stale LRU entry would evict the fresh job here.
+	store.Put(&Job{ID: "new", Status: StatusCompleted, UpdatedAt: time.Now()})
+	if store.Get("new") == nil {
+		t.Error("expected fresh job to be kept")
+	}
+}
+
+func TestJobStore_TotalsIncludeEvicted(t *testing.T) {
+	store := NewJobStore(time.Hour, 2)
+	finished := func(id string, status JobStatus, facts, stored int) *Job {
+		j := &Job{ID: id, Status: status, UpdatedAt: time.Now()}
+		j.Progress.FactsValid = facts
+		j.Progress.FactsStored = stored
+		j.Progress.ChunksProcessed = 1
+		return j
+	}
+	store.Put(finished("a", StatusCompleted, 4, 4))
+	store.Put(finished("b", StatusFailed, 0, 0))
+	store.Put(finished("c", StatusPartial, 3, 2)) // evicts "a"
+	store.Put(&Job{ID: "d", Type: JobTypeDelete, Status: StatusCompleted, UpdatedAt: time.Now()})
+	store.Put(&Job{ID: "e", Status: StatusExtracting, UpdatedAt: time.Now()})
+	store.Put(&Job{ID: "f", Status: StatusCompleted, DryRun: true, UpdatedAt: time.Now()})
+
+	totals, inFlight := store.Totals()
+	if totals.Jobs != 3 {
+		t.Errorf("expected 3 finished ingest jobs, got %d", totals.Jobs)
+	}
+	if totals.ByStatus[StatusCompleted] != 1 || totals.ByStatus[StatusFailed] != 1 || totals.ByStatus[StatusPartial] != 1 {
+		t.Errorf("unexpected status counts: %v", totals.ByStatus)
+	}
+	if totals.FactsExtracted != 7 || totals.FactsStored != 6 || totals.ChunksProcessed != 3 {
+		t.Errorf("expected 7 extracted, 6 stored, 3 chunks; got %+v", totals)
+	}
+	if inFlight != 1 {
+		t.Errorf("expected 1 job in flight, got %d", inFlight)
+	}
+}
+
+func TestJobStore_CountByStatus(t *testing.T) {
+	store := NewJobStore(time.Hour, 0)
+	store.Put(&Job{ID: "a", Status: StatusQueued, UpdatedAt: time.Now()})
+	store.Put(&Job{ID: "b", Status: StatusCompleted, UpdatedAt: time.Now()})
+	store.Put(&Job{ID: "c", Status: StatusCompleted, UpdatedAt: time.Now()})
+	store.Put(&Job{ID: "d", Type: JobTypeDelete, Status: StatusFailed, UpdatedAt: time.Now()})
+
+	counts := store.CountByStatus()
+	if counts[StatusQueued] != 1 || counts[StatusCompleted] != 2 || counts[StatusFailed] != 1 {
+		t.Errorf("unexpected status counts: %v", counts)
+	}
+}
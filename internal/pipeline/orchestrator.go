@@ -2,14 +2,17 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"sync"
 	"time"
 
 	"github.com/dgallion1/docgest/internal/chunker"
 	"github.com/dgallion1/docgest/internal/config"
 	"github.com/dgallion1/docgest/internal/extract"
+	"github.com/dgallion1/docgest/internal/parser"
 	"github.com/dgallion1/docgest/internal/pathstore"
 )
 
@@ -17,59 +20,143 @@ import (
 type Orchestrator struct {
 	jobs     *JobStore
 	queue    chan *Job
-	claude   *extract.ClaudeClient
-	ps       *pathstore.Client
+	claude   extract.Extractor
+	ps       pathstore.Store
 	log      *slog.Logger
 	cfg      config.Config
 	chunkCfg chunker.Config
 
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
+	categories  extract.Categories
+	userConfigs *userConfigCache
+
+	// prompts selects the extraction prompt per job.
+	prompts extract.PromptSet
+
+	// onModelDeprecated is called when a job fails because its extraction
+	// model is no longer served.
+	onModelDeprecated func(model string)
+
+	// deleteQueue feeds the single deletion worker.
+	deleteQueue chan *DeleteJob
+
+	// callbackClient delivers job callbacks, retrying after each of
+	// callbackDelays.
+	callbackClient *http.Client
+	callbackDelays []time.Duration
+
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	startedAt time.Time
+
+	// stopped is closed by Stop. The queues are never closed, since a
+	// Submit may still be sending on them; senders select on stopped
+	// instead.
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	// Worker pool: one retire channel and counter set per live worker,
+	// newest last.
+	workerMu       sync.RWMutex
+	workerStop     []chan struct{}
+	workerCounters []*workerCounters
+	workerCtx      context.Context
+	// nextWorkerIndex numbers workers for their lifetime, so a worker
+	// spawned after a retirement never reuses the retired one's heartbeat
+	// node while its final stopped write may still be in flight.
+	nextWorkerIndex int
 }
 
+const (
+	// scaleInterval is how often the autoscaler samples queue depth.
+	scaleInterval = 5 * time.Second
+	// scaleDownIdle is how long the queue must stay empty before retiring a worker.
+	scaleDownIdle = 60 * time.Second
+)
+
 // NewOrchestrator creates and starts the pipeline.
-func NewOrchestrator(cfg config.Config, claude *extract.ClaudeClient, ps *pathstore.Client, log *slog.Logger) *Orchestrator {
+func NewOrchestrator(cfg config.Config, claude extract.Extractor, ps pathstore.Store, log *slog.Logger) *Orchestrator {
 	o := &Orchestrator{
-		jobs:  NewJobStore(cfg.JobTTL),
+		jobs:  NewJobStore(cfg.JobTTL, cfg.MaxJobStoreSize),
 		queue: make(chan *Job, cfg.MaxQueueSize),
 		claude: claude,
 		ps:     ps,
 		log:    log,
 		cfg:    cfg,
 		chunkCfg: chunker.Config{
-			ChunkSize:    cfg.DefaultChunkSize,
-			ChunkOverlap: cfg.DefaultChunkOverlap,
-			MinChunk:     100,
+			ChunkSize:            cfg.DefaultChunkSize,
+			ChunkOverlap:         cfg.DefaultChunkOverlap,
+			MinChunk:             100,
+			ChunkOverlapStrategy: cfg.DefaultChunkOverlapStrategy,
+			ChunkSizeUnit:        cfg.DefaultChunkSizeUnit,
 		},
+		categories:  extract.DefaultCategories(),
+		userConfigs: newUserConfigCache(ps),
+		deleteQueue: make(chan *DeleteJob, cfg.MaxQueueSize),
+		stopped:     make(chan struct{}),
+
+		callbackClient: newCallbackClient(),
+		callbackDelays: callbackRetryDelays,
 	}
 	return o
 }
 
-// Start launches worker goroutines.
+// SetCategories replaces the category settings used by workers. Call it
+// before Start.
+func (o *Orchestrator) SetCategories(cats extract.Categories) {
+	o.categories = cats
+}
+
+// SetPrompts replaces the extraction prompts used by workers. Call it
+// before Start.
+func (o *Orchestrator) SetPrompts(prompts extract.PromptSet) {
+	o.prompts = prompts
+}
+
+// SetOnModelDeprecated registers a hook, e.g. for alerting, called each
+// time a job fails because its extraction model is no longer available.
+// Call it before Start.
+func (o *Orchestrator) SetOnModelDeprecated(fn func(model string)) {
+	o.onModelDeprecated = fn
+}
+
+// Start launches worker goroutines and the autoscaler.
 func (o *Orchestrator) Start(ctx context.Context) {
 	workerCtx, cancel := context.WithCancel(ctx)
 	o.cancel = cancel
+	o.startedAt = time.Now()
 
+	o.workerMu.Lock()
+	o.workerCtx = workerCtx
 	for range o.cfg.WorkerCount {
+		o.spawnWorkerLocked()
+	}
+	o.workerMu.Unlock()
+
+	if o.cfg.MaxWorkerCount > o.cfg.MinWorkerCount {
 		o.wg.Add(1)
 		go func() {
 			defer o.wg.Done()
-			w := NewWorker(o.claude, o.ps, o.log, o.chunkCfg, o.cfg.MaxConcurrentExtract, o.cfg.MaxConcurrentStore)
-			for {
-				select {
-				case <-workerCtx.Done():
-					return
-				case job, ok := <-o.queue:
-					if !ok {
-						return
-					}
-					w.Process(workerCtx, job)
-				}
-			}
+			o.autoscale(workerCtx)
 		}()
 	}
 
-	// Start job store cleanup.
+	// A single deletion worker keeps deletes from competing with ingest
+	// for pathstore capacity.
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		dw := NewDeletionWorker(o.ps, o.log)
+		for {
+			select {
+			case <-workerCtx.Done():
+				return
+			case dj := <-o.deleteQueue:
+				dw.Process(workerCtx, dj)
+			}
+		}
+	}()
+
+	// Start job store cleanup and the soft-delete sweep.
 	o.wg.Add(1)
 	go func() {
 		defer o.wg.Done()
@@ -81,29 +168,313 @@ func (o *Orchestrator) Start(ctx context.Context) {
 				return
 			case <-ticker.C:
 				o.jobs.Cleanup()
+				purged, err := SweepSoftDeleted(workerCtx, o.ps, o.log, time.Now())
+				if err != nil {
+					o.log.Warn("soft-delete sweep failed", "error", err)
+				} else if purged > 0 {
+					o.log.Info("purged soft-deleted documents", "count", purged)
+				}
 			}
 		}
 	}()
 }
 
-// Stop gracefully shuts down the pipeline.
+// spawnWorkerLocked starts one worker goroutine. Caller must hold workerMu.
+func (o *Orchestrator) spawnWorkerLocked() {
+	stop := make(chan struct{})
+	index := o.nextWorkerIndex
+	o.nextWorkerIndex++
+	counters := &workerCounters{index: index}
+	o.workerStop = append(o.workerStop, stop)
+	o.workerCounters = append(o.workerCounters, counters)
+	ctx := o.workerCtx
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		if interval := o.cfg.WorkerHeartbeatInterval; interval > 0 {
+			defer o.startHeartbeat(ctx, index, counters, interval)()
+		}
+		w := NewWorker(o.claude, o.ps, o.log, o.chunkCfg, o.cfg.MaxConcurrentExtract, o.cfg.MaxConcurrentStore)
+		w.categories = o.categories
+		w.prompts = o.prompts
+		// PDF_FALLBACK_PDFTOTEXT is not passed on: the PDF parser has
+		// never shelled out to pdftotext in the pipeline.
+		w.parserOpts = parser.Options{
+			HTMLUseReadability: o.cfg.HTMLUseReadability,
+			DocxRevisionMode:   o.cfg.DocxRevisionMode,
+			CSVBatchSize:       o.cfg.CSVBatchSize,
+		}
+		w.userConfigs = o.userConfigs
+		w.createLinks = o.cfg.CreateCrossFactLinks
+		w.normalizeSalience = o.cfg.NormalizeSalience
+		w.dedupThreshold = o.cfg.FactDedupThreshold
+		w.duplicateFactCheck = o.cfg.DuplicateFactCheck
+		w.entityPathSeparator = o.cfg.EntityPathSeparator
+		w.onModelDeprecated = o.onModelDeprecated
+		w.sourceMultipliers = o.cfg.SourceTypeMultiplier
+		w.parseTimeout = o.cfg.ParseTimeout
+		w.chunkTimeout = o.cfg.ChunkTimeout
+		w.extractTimeout = o.cfg.ExtractTimeout
+		w.storeTimeout = o.cfg.StoreTimeout
+		w.retryBudget = o.cfg.RetryBudgetPerJob
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case job := <-o.queue:
+				done := counters.track(job)
+				w.Process(ctx, job)
+				done()
+				o.notifyCallback(job)
+			}
+		}
+	}()
+}
+
+// notifyCallback starts delivering a finished job to its callback URL in
+// the background. Call it wherever a job reaches a terminal status. The
+// delivery is tracked in o.wg and stops when Stop cancels the workers.
+func (o *Orchestrator) notifyCallback(job *Job) {
+	if job.CallbackURL == "" || !job.currentStatus().Terminal() {
+		return
+	}
+	o.workerMu.Lock()
+	ctx := o.workerCtx
+	o.workerMu.Unlock()
+	if ctx == nil {
+		// Not started: nothing will cancel the delivery but its retries.
+		ctx = context.Background()
+	}
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		deliverCallback(ctx, o.callbackClient, o.callbackDelays, job, o.log.With("job_id", job.ID))
+	}()
+}
+
+// retireWorkerLocked signals the newest worker to exit after its current
+// job. Caller must hold workerMu.
+func (o *Orchestrator) retireWorkerLocked() {
+	n := len(o.workerStop)
+	if n == 0 {
+		return
+	}
+	close(o.workerStop[n-1])
+	o.workerStop = o.workerStop[:n-1]
+	o.workerCounters = o.workerCounters[:n-1]
+}
+
+// WorkerCount returns the number of live workers.
+func (o *Orchestrator) WorkerCount() int {
+	o.workerMu.RLock()
+	defer o.workerMu.RUnlock()
+	return len(o.workerStop)
+}
+
+// autoscale grows the pool while the queue backs up and shrinks it after
+// the queue has been idle for scaleDownIdle.
+func (o *Orchestrator) autoscale(ctx context.Context) {
+	ticker := time.NewTicker(scaleInterval)
+	defer ticker.Stop()
+	var idleSince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			depth := o.QueueDepth()
+			if depth > 0 {
+				idleSince = time.Time{}
+			} else if idleSince.IsZero() {
+				idleSince = now
+			}
+			var idleFor time.Duration
+			if !idleSince.IsZero() {
+				idleFor = now.Sub(idleSince)
+			}
+
+			o.workerMu.Lock()
+			workers := len(o.workerStop)
+			switch scaleDecision(depth, workers, o.cfg.MinWorkerCount, o.cfg.MaxWorkerCount, idleFor) {
+			case 1:
+				o.spawnWorkerLocked()
+				o.log.Info("scaled workers up", "workers", workers+1, "queue_depth", depth)
+			case -1:
+				o.retireWorkerLocked()
+				idleSince = now
+				o.log.Info("scaled workers down", "workers", workers-1)
+			}
+			o.workerMu.Unlock()
+		}
+	}
+}
+
+// scaleDecision returns +1 to add a worker, -1 to retire one, or 0.
+func scaleDecision(depth, workers, minWorkers, maxWorkers int, idleFor time.Duration) int {
+	if depth > workers*2 && workers < maxWorkers {
+		return 1
+	}
+	if depth == 0 && idleFor >= scaleDownIdle && workers > minWorkers {
+		return -1
+	}
+	return 0
+}
+
+// Stop gracefully shuts down the pipeline. Submits racing with Stop fail
+// with ErrStopped; jobs still queued are abandoned.
 func (o *Orchestrator) Stop() {
+	o.stopOnce.Do(func() { close(o.stopped) })
 	if o.cancel != nil {
 		o.cancel()
 	}
-	close(o.queue)
 	o.wg.Wait()
 }
 
-// Submit queues a new job for processing.
-func (o *Orchestrator) Submit(job *Job) error {
+// OrchestratorStats summarizes the ingest jobs handled since Start.
+type OrchestratorStats struct {
+	JobTotals
+	JobsInFlight int           `json:"jobs_in_flight"`
+	Uptime       time.Duration `json:"uptime"`
+}
+
+// LogValue renders the stats for the shutdown log with a readable uptime.
+func (s OrchestratorStats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("jobs_processed", s.Jobs),
+		slog.Int("completed", s.ByStatus[StatusCompleted]),
+		slog.Int("failed", s.ByStatus[StatusFailed]),
+		slog.Int("partial", s.ByStatus[StatusPartial]),
+		slog.Int("dup_skipped", s.ByStatus[StatusDupSkipped]),
+		slog.Int("in_flight", s.JobsInFlight),
+		slog.Int("facts_extracted", s.FactsExtracted),
+		slog.Int("facts_stored", s.FactsStored),
+		slog.Int("chunks_processed", s.ChunksProcessed),
+		slog.String("uptime", s.Uptime.Round(time.Second).String()),
+	)
+}
+
+// FinalStats aggregates every ingest job the orchestrator has tracked,
+// including ones already evicted from the job store. Call it after Stop
+// for a shutdown summary.
+func (o *Orchestrator) FinalStats() OrchestratorStats {
+	totals, inFlight := o.jobs.Totals()
+	stats := OrchestratorStats{JobTotals: totals, JobsInFlight: inFlight}
+	if !o.startedAt.IsZero() {
+		stats.Uptime = time.Since(o.startedAt)
+	}
+	return stats
+}
+
+// ErrQueueFull is returned, wrapped, by Submit and SubmitDelete when the
+// job cannot be queued.
+var ErrQueueFull = errors.New("queue is full")
+
+// ErrStopped is returned, wrapped, by Submit and SubmitDelete once Stop has
+// been called.
+var ErrStopped = errors.New("orchestrator is stopped")
+
+// Submit queues a new job for processing. When the queue is full it
+// applies cfg.QueueOverflowBehavior: "block" waits up to QueueBlockTimeout
+// (or until ctx is done), "drop_oldest" fails the oldest queued job to make
+// room, and anything else rejects the new job.
+func (o *Orchestrator) Submit(ctx context.Context, job *Job) error {
 	o.jobs.Put(job)
+	return o.enqueue(ctx, job)
+}
+
+// SubmitIfAbsent is Submit for a client-chosen job ID. If the store already
+// holds a job with that ID, which has not failed or belongs to another
+// user, that job is returned and nothing is queued.
+func (o *Orchestrator) SubmitIfAbsent(ctx context.Context, job *Job) (*Job, error) {
+	if existing, ok := o.jobs.PutIfAbsent(job); !ok {
+		return existing, nil
+	}
+	return nil, o.enqueue(ctx, job)
+}
+
+// enqueue hands a stored job to the workers, applying the queue overflow
+// behavior when the queue is full.
+func (o *Orchestrator) enqueue(ctx context.Context, job *Job) error {
+	select {
+	case <-o.stopped:
+		return o.rejectStopped(job)
+	default:
+	}
 	select {
 	case o.queue <- job:
 		return nil
 	default:
-		job.SetStatus(StatusFailed, "queue_full")
-		return fmt.Errorf("job queue is full (%d)", o.cfg.MaxQueueSize)
+	}
+
+	switch o.cfg.QueueOverflowBehavior {
+	case "block":
+		ctx, cancel := context.WithTimeout(ctx, o.cfg.QueueBlockTimeout)
+		defer cancel()
+		select {
+		case o.queue <- job:
+			return nil
+		case <-o.stopped:
+			return o.rejectStopped(job)
+		case <-ctx.Done():
+		}
+	case "drop_oldest":
+		select {
+		case old, ok := <-o.queue:
+			if ok {
+				old.AddError(errors.New("evicted from a full queue"))
+				old.SetStatus(StatusFailed, "queue_overflow_evicted")
+				o.log.Warn("queue overflow: evicted oldest job", "evicted_job_id", old.ID, "job_id", job.ID)
+				o.notifyCallback(old)
+			}
+		default:
+		}
+		select {
+		case o.queue <- job:
+			return nil
+		default:
+		}
+	}
+	job.SetStatus(StatusFailed, "queue_full")
+	o.notifyCallback(job)
+	return fmt.Errorf("job %w (%d)", ErrQueueFull, o.cfg.MaxQueueSize)
+}
+
+// rejectStopped fails a job submitted after Stop.
+func (o *Orchestrator) rejectStopped(job *Job) error {
+	job.SetStatus(StatusFailed, "shutting_down")
+	return fmt.Errorf("job %s: %w", job.ID, ErrStopped)
+}
+
+// SubmitDelete queues a document deletion. Its progress is tracked as a
+// job of type JobTypeDelete under dj.ID.
+func (o *Orchestrator) SubmitDelete(dj *DeleteJob) error {
+	now := time.Now()
+	dj.job = &Job{
+		ID:        dj.ID,
+		Type:      JobTypeDelete,
+		DocID:     dj.DocID,
+		UserID:    dj.UserID,
+		Status:    StatusQueued,
+		Phase:     "queued",
+		RequestID: dj.RequestID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	o.jobs.Put(dj.job)
+	select {
+	case <-o.stopped:
+		return o.rejectStopped(dj.job)
+	default:
+	}
+	select {
+	case o.deleteQueue <- dj:
+		return nil
+	default:
+		dj.job.SetStatus(StatusFailed, "queue_full")
+		return fmt.Errorf("delete %w (%d)", ErrQueueFull, o.cfg.MaxQueueSize)
 	}
 }
 
@@ -112,12 +483,33 @@ func (o *Orchestrator) GetJob(id string) *Job {
 	return o.jobs.Get(id)
 }
 
+// JobExpired reports whether a job with this ID existed but was evicted
+// from the job store.
+func (o *Orchestrator) JobExpired(id string) bool {
+	return o.jobs.Expired(id)
+}
+
+// ListJobs returns a user's tracked jobs matching filters.
+func (o *Orchestrator) ListJobs(userID string, filters JobFilters) []*Job {
+	return o.jobs.ListByUser(userID, filters)
+}
+
+// InvalidateUserConfig drops the cached config for a user after it changes.
+func (o *Orchestrator) InvalidateUserConfig(userID string) {
+	o.userConfigs.Invalidate(userID)
+}
+
 // QueueDepth returns current queue depth.
 func (o *Orchestrator) QueueDepth() int {
 	return len(o.queue)
 }
 
+// JobsByStatus counts the jobs held in the job store by status.
+func (o *Orchestrator) JobsByStatus() map[JobStatus]int {
+	return o.jobs.CountByStatus()
+}
+
 // PathstoreClient returns the pathstore client for direct use by API handlers.
-func (o *Orchestrator) PathstoreClient() *pathstore.Client {
+func (o *Orchestrator) PathstoreClient() pathstore.Store {
 	return o.ps
 }
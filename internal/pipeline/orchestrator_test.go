@@ -0,0 +1,232 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/config"
+)
+
+func TestScaleDecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		depth    int
+		workers  int
+		idleFor  time.Duration
+		expected int
+	}{
+		{"backlog grows pool", 9, 4, 0, 1},
+		{"backlog at max stays", 20, 8, 0, 0},
+		{"moderate backlog stays", 8, 4, 0, 0},
+		{"idle long enough shrinks", 0, 6, scaleDownIdle, -1},
+		{"idle too short stays", 0, 6, scaleDownIdle - time.Second, 0},
+		{"idle at min stays", 0, 4, 2 * scaleDownIdle, 0},
+	}
+	for _, tt := range tests {
+		got := scaleDecision(tt.depth, tt.workers, 4, 8, tt.idleFor)
+		if got != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, got)
+		}
+	}
+}
+
+// newQueueTestOrchestrator returns an unstarted orchestrator with a
+// one-slot queue, so the second Submit overflows.
+func newQueueTestOrchestrator(behavior string) *Orchestrator {
+	cfg := config.Config{
+		MaxQueueSize:          1,
+		QueueOverflowBehavior: behavior,
+		QueueBlockTimeout:     20 * time.Millisecond,
+		JobTTL:                time.Hour,
+		MaxJobStoreSize:       100,
+	}
+	return NewOrchestrator(cfg, nil, nil, slog.New(slog.DiscardHandler))
+}
+
+func TestSubmit_RejectWhenFull(t *testing.T) {
+	o := newQueueTestOrchestrator("reject")
+	first := &Job{ID: "first", Status: StatusQueued}
+	second := &Job{ID: "second", Status: StatusQueued}
+
+	if err := o.Submit(context.Background(), first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := o.Submit(context.Background(), second); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull submitting to a full queue, got %v", err)
+	}
+	if snap := second.Snapshot(); snap.Status != StatusFailed || snap.Phase != "queue_full" {
+		t.Errorf("expected rejected job failed in queue_full, got %s/%s", snap.Status, snap.Phase)
+	}
+}
+
+func TestSubmit_BlockWaitsForRoom(t *testing.T) {
+	o := newQueueTestOrchestrator("block")
+	o.cfg.QueueBlockTimeout = time.Second
+	if err := o.Submit(context.Background(), &Job{ID: "first"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-o.queue
+	}()
+	if err := o.Submit(context.Background(), &Job{ID: "second"}); err != nil {
+		t.Errorf("expected blocked submit to succeed once room frees up, got %v", err)
+	}
+}
+
+func TestSubmit_BlockTimesOut(t *testing.T) {
+	o := newQueueTestOrchestrator("block")
+	if err := o.Submit(context.Background(), &Job{ID: "first"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	if err := o.Submit(context.Background(), &Job{ID: "second"}); err == nil {
+		t.Error("expected blocked submit to time out")
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected submit to block for the timeout, returned after %v", elapsed)
+	}
+}
+
+func TestSubmit_BlockedDuringStop(t *testing.T) {
+	o := newQueueTestOrchestrator("block")
+	o.cfg.QueueBlockTimeout = 5 * time.Second
+	if err := o.Submit(context.Background(), &Job{ID: "first"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		o.Stop()
+	}()
+	second := &Job{ID: "second", Status: StatusQueued}
+	if err := o.Submit(context.Background(), second); !errors.Is(err, ErrStopped) {
+		t.Errorf("expected ErrStopped for a submit blocked during Stop, got %v", err)
+	}
+	if snap := second.Snapshot(); snap.Status != StatusFailed || snap.Phase != "shutting_down" {
+		t.Errorf("expected job failed in shutting_down, got %s/%s", snap.Status, snap.Phase)
+	}
+}
+
+func TestSubmit_AfterStop(t *testing.T) {
+	for _, behavior := range []string{"reject", "block", "drop_oldest"} {
+		o := newQueueTestOrchestrator(behavior)
+		o.Stop()
+		if err := o.Submit(context.Background(), &Job{ID: "job"}); !errors.Is(err, ErrStopped) {
+			t.Errorf("%s: expected ErrStopped, got %v", behavior, err)
+		}
+		if err := o.SubmitDelete(&DeleteJob{ID: "delete"}); !errors.Is(err, ErrStopped) {
+			t.Errorf("%s: expected ErrStopped for delete, got %v", behavior, err)
+		}
+	}
+}
+
+func TestSubmit_DropOldest(t *testing.T) {
+	o := newQueueTestOrchestrator("drop_oldest")
+	first := &Job{ID: "first", Status: StatusQueued}
+	second := &Job{ID: "second", Status: StatusQueued}
+
+	if err := o.Submit(context.Background(), first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := o.Submit(context.Background(), second); err != nil {
+		t.Fatalf("expected drop_oldest to make room, got %v", err)
+	}
+	if snap := first.Snapshot(); snap.Status != StatusFailed || snap.Phase != "queue_overflow_evicted" {
+		t.Errorf("expected evicted job failed in queue_overflow_evicted, got %s/%s", snap.Status, snap.Phase)
+	}
+	if queued := <-o.queue; queued != second {
+		t.Errorf("expected the new job queued, got %s", queued.ID)
+	}
+}
+
+func TestSubmit_OverflowNotifiesCallbacks(t *testing.T) {
+	got := make(chan JobSnapshot, 2)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var snap JobSnapshot
+		json.NewDecoder(r.Body).Decode(&snap)
+		got <- snap
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		behavior string
+		failed   string // ID of the job the overflow fails
+		phase    string
+	}{
+		{"drop_oldest", "first", "queue_overflow_evicted"},
+		{"reject", "second", "queue_full"},
+	}
+	for _, tt := range tests {
+		o := newQueueTestOrchestrator(tt.behavior)
+		o.callbackClient = srv.Client()
+		o.callbackDelays = nil
+		o.Submit(context.Background(), &Job{ID: "first", Status: StatusQueued, CallbackURL: srv.URL})
+		o.Submit(context.Background(), &Job{ID: "second", Status: StatusQueued, CallbackURL: srv.URL})
+		o.wg.Wait()
+
+		select {
+		case snap := <-got:
+			if snap.ID != tt.failed || snap.Status != StatusFailed || snap.Phase != tt.phase {
+				t.Errorf("%s: expected callback for %s failed in %s, got %s %s/%s", tt.behavior, tt.failed, tt.phase, snap.ID, snap.Status, snap.Phase)
+			}
+		default:
+			t.Errorf("%s: expected a callback for the failed job", tt.behavior)
+		}
+		if len(got) != 0 {
+			t.Errorf("%s: expected one callback, got %d more", tt.behavior, len(got))
+		}
+	}
+}
+
+func TestSpawnWorker_IndexesNotReused(t *testing.T) {
+	o := newQueueTestOrchestrator("reject")
+	ctx, cancel := context.WithCancel(context.Background())
+	o.workerCtx = ctx
+
+	o.workerMu.Lock()
+	o.spawnWorkerLocked()
+	o.spawnWorkerLocked()
+	o.retireWorkerLocked()
+	o.spawnWorkerLocked()
+	o.workerMu.Unlock()
+
+	var got []int
+	for _, s := range o.WorkerStats() {
+		got = append(got, s.Index)
+	}
+	if len(got) != 2 || got[0] != 0 || got[1] != 2 {
+		t.Errorf("expected worker indexes [0 2] after retiring worker 1, got %v", got)
+	}
+	cancel()
+	o.wg.Wait()
+}
+
+func TestWorkerCounters_Track(t *testing.T) {
+	c := workerCounters{index: 3}
+	done := c.track(&Job{ID: "job-1"})
+	if got := c.snapshot().CurrentJobID; got != "job-1" {
+		t.Errorf("expected current job job-1, got %q", got)
+	}
+	done()
+	c.track(&Job{ID: "job-2"})()
+
+	s := c.snapshot()
+	if s.Index != 3 {
+		t.Errorf("expected index 3, got %d", s.Index)
+	}
+	if s.JobsProcessed != 2 {
+		t.Errorf("expected 2 jobs processed, got %d", s.JobsProcessed)
+	}
+	if s.CurrentJobID != "" {
+		t.Errorf("expected no current job, got %q", s.CurrentJobID)
+	}
+}
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// softDeletedPrefix indexes soft-deleted documents across users, so the
+// sweep can find those past their purge time without walking every user.
+const softDeletedPrefix = "memory/soft_deleted"
+
+func softDeletedPath(userID, docID string) string {
+	return fmt.Sprintf("%s/%s/%s", softDeletedPrefix, userID, docID)
+}
+
+// MarkSoftDeleted records that a document was soft-deleted and should be
+// purged by SweepSoftDeleted after purgeAfter.
+func MarkSoftDeleted(ctx context.Context, ps pathstore.Store, userID, docID string, purgeAfter time.Time) error {
+	return ps.PutNode(ctx, softDeletedPath(userID, docID), pathstore.NodeRequest{
+		Value: map[string]any{
+			"user_id":     userID,
+			"doc_id":      docID,
+			"purge_after": purgeAfter.UTC().Format(time.RFC3339),
+		},
+		MemoryType: "metacognitive",
+		Salience:   0.1,
+		Source:     "docgest:" + docID,
+	})
+}
+
+// UnmarkSoftDeleted removes a restored document from the sweep. A failure
+// only means the sweep later finds the document restored and skips it.
+func UnmarkSoftDeleted(ctx context.Context, ps pathstore.Store, userID, docID string) {
+	ps.DeleteNode(ctx, softDeletedPath(userID, docID), false)
+}
+
+// SweepSoftDeleted purges every soft-deleted document whose purge time is
+// at or before now and returns how many it purged. A document that fails
+// to purge stays indexed and is retried on the next sweep.
+func SweepSoftDeleted(ctx context.Context, ps pathstore.Store, log *slog.Logger, now time.Time) (int, error) {
+	entries, err := ps.ListAll(ctx, softDeletedPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("list soft-deleted documents: %w", err)
+	}
+	purged := 0
+	for _, e := range entries {
+		m, _ := e.Value.(map[string]any)
+		userID, _ := m["user_id"].(string)
+		docID, _ := m["doc_id"].(string)
+		purgeAfter, err := time.Parse(time.RFC3339, fmt.Sprint(m["purge_after"]))
+		if userID == "" || docID == "" || err != nil || purgeAfter.After(now) {
+			continue
+		}
+		meta, err := ps.GetNode(ctx, fmt.Sprintf("memory/users/%s/documents/%s/meta", userID, docID))
+		if err != nil {
+			log.Warn("soft-deleted document meta read failed", "user_id", userID, "doc_id", docID, "error", err)
+			continue
+		}
+		if meta != nil {
+			if metaMap, _ := meta.Value.(map[string]any); metaMap["deleted_at"] == nil {
+				// Restored, but its index entry outlived the restore.
+				UnmarkSoftDeleted(ctx, ps, userID, docID)
+				continue
+			}
+		}
+		// PurgeDocument also drops the index entry.
+		if _, err := PurgeDocument(ctx, ps, userID, docID); err != nil {
+			log.Warn("soft-deleted document purge failed", "user_id", userID, "doc_id", docID, "error", err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// phaseContext bounds one pipeline phase by d and logs a warning once 80%
+// of it has elapsed. A non-positive d leaves ctx without a deadline.
+func phaseContext(ctx context.Context, log *slog.Logger, phase string, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	warn := time.AfterFunc(d*8/10, func() {
+		log.Warn("phase nearing timeout", "phase", phase, "timeout", d)
+	})
+	return ctx, func() {
+		warn.Stop()
+		cancel()
+	}
+}
+
+// runPhase runs fn, which does not observe ctx, and returns ctx's error as
+// soon as ctx is done. fn is left to finish in the background in that case;
+// its result is discarded.
+func runPhase[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// phaseTimedOut reports whether phaseCtx hit its own deadline, as opposed to
+// the parent being cancelled on shutdown.
+func phaseTimedOut(parent, phaseCtx context.Context) bool {
+	return parent.Err() == nil && errors.Is(phaseCtx.Err(), context.DeadlineExceeded)
+}
+
+// phaseTimeoutReason is the job error recorded when a phase times out.
+func phaseTimeoutReason(phase string) string {
+	return "phase_timeout:" + phase
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestRunPhase_ReturnsResult(t *testing.T) {
+	v, err := runPhase(context.Background(), func() (int, error) { return 42, nil })
+	if err != nil || v != 42 {
+		t.Errorf("expected 42, nil; got %d, %v", v, err)
+	}
+}
+
+func TestRunPhase_Timeout(t *testing.T) {
+	parent := context.Background()
+	ctx, cancel := phaseContext(parent, slog.New(slog.NewTextHandler(io.Discard, nil)), "parsing", 20*time.Millisecond)
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+	_, err := runPhase(ctx, func() (int, error) {
+		<-release
+		return 0, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if !phaseTimedOut(parent, ctx) {
+		t.Error("expected phase to be reported as timed out")
+	}
+}
+
+func TestPhaseTimedOut_ParentCancelled(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel := phaseContext(parent, slog.New(slog.NewTextHandler(io.Discard, nil)), "storing", time.Hour)
+	defer cancel()
+
+	cancelParent()
+	if phaseTimedOut(parent, ctx) {
+		t.Error("expected shutdown not to count as a phase timeout")
+	}
+}
+
+func TestFailPhaseTimeout(t *testing.T) {
+	parent := context.Background()
+	ctx, cancel := context.WithTimeout(parent, time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	job := &Job{Status: StatusExtracting}
+	if !failPhaseTimeout(parent, ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), job, "extracting") {
+		t.Fatal("expected timeout to be handled")
+	}
+	snap := job.Snapshot()
+	if snap.Status != StatusFailed {
+		t.Errorf("expected status %q, got %q", StatusFailed, snap.Status)
+	}
+	if len(snap.Progress.Errors) != 1 || snap.Progress.Errors[0] != "phase_timeout:extracting" {
+		t.Errorf("expected phase_timeout:extracting error, got %v", snap.Progress.Errors)
+	}
+}
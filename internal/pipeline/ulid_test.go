@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGenerateULID_ConcurrentUniqueness(t *testing.T) {
+	const goroutines = 1000
+	const perGoroutine = 100
+
+	results := make([][]string, goroutines)
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			ids := make([]string, perGoroutine)
+			for i := range ids {
+				ids[i] = generateULID()
+			}
+			results[g] = ids
+		}(g)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, goroutines*perGoroutine)
+	for _, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("duplicate ULID %q", id)
+			}
+			seen[id] = true
+
+			if len(id) != 26 {
+				t.Fatalf("expected 26-char ULID, got %d chars: %q", len(id), id)
+			}
+			for _, c := range id {
+				if !strings.ContainsRune(crockford, c) {
+					t.Fatalf("ULID %q contains non-Crockford character %q", id, c)
+				}
+			}
+		}
+	}
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("expected %d ULIDs, got %d", goroutines*perGoroutine, len(seen))
+	}
+}
+
+func TestGenerateULID_TimestampPrefixIncreases(t *testing.T) {
+	prev := generateULID()
+	for range 20 {
+		time.Sleep(time.Millisecond)
+		next := generateULID()
+		if next[:10] <= prev[:10] {
+			t.Fatalf("expected timestamp prefix to increase: %q then %q", prev[:10], next[:10])
+		}
+		prev = next
+	}
+}
@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/chunker"
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// UserConfig holds per-user extraction parameters. Zero values leave the
+// service defaults in place.
+type UserConfig struct {
+	ChunkSize        int    `json:"chunk_size,omitempty"`
+	ChunkOverlap     int    `json:"chunk_overlap,omitempty"`
+	MaxFactsPerChunk int    `json:"max_facts_per_chunk,omitempty"`
+	LLMModel         string `json:"llm_model,omitempty"`
+
+	// MinTrustFloor is the lowest min_trust a stored fact may have.
+	MinTrustFloor int `json:"min_trust_floor,omitempty"`
+}
+
+// Merge returns c with every non-zero field of override applied on top.
+func (c UserConfig) Merge(override UserConfig) UserConfig {
+	if override.ChunkSize > 0 {
+		c.ChunkSize = override.ChunkSize
+	}
+	if override.ChunkOverlap > 0 {
+		c.ChunkOverlap = override.ChunkOverlap
+	}
+	if override.MaxFactsPerChunk > 0 {
+		c.MaxFactsPerChunk = override.MaxFactsPerChunk
+	}
+	if override.LLMModel != "" {
+		c.LLMModel = override.LLMModel
+	}
+	if override.MinTrustFloor > 0 {
+		c.MinTrustFloor = override.MinTrustFloor
+	}
+	return c
+}
+
+// ChunkConfig applies c's chunk size and overlap to base. Stored config and
+// request overrides are validated separately, so the merged overlap can
+// reach the merged size; when it does, it is cut to half the size so each
+// chunk still advances.
+func (c UserConfig) ChunkConfig(base chunker.Config) chunker.Config {
+	if c.ChunkSize > 0 {
+		base.ChunkSize = c.ChunkSize
+	}
+	if c.ChunkOverlap > 0 {
+		base.ChunkOverlap = c.ChunkOverlap
+	}
+	if base.ChunkSize > 0 && base.ChunkOverlap >= base.ChunkSize {
+		base.ChunkOverlap = base.ChunkSize / 2
+	}
+	return base
+}
+
+// UserConfigPath is the pathstore key holding a user's extraction config.
+func UserConfigPath(userID string) string {
+	return fmt.Sprintf("memory/users/%s/config", userID)
+}
+
+const (
+	userConfigTTL          = time.Minute
+	userConfigFetchTimeout = 2 * time.Second
+
+	// userConfigFailureTTL holds back refetches after a failed fetch so an
+	// unreachable pathstore doesn't cost every job the fetch timeout.
+	userConfigFailureTTL = 10 * time.Second
+)
+
+type userConfigEntry struct {
+	cfg       UserConfig
+	fetchedAt time.Time
+	ttl       time.Duration
+}
+
+// userConfigCache is a short-lived cache of user config nodes so each job
+// does not pay a pathstore round trip.
+type userConfigCache struct {
+	ps pathstore.Store
+
+	mu      sync.Mutex
+	entries map[string]userConfigEntry
+}
+
+func newUserConfigCache(ps pathstore.Store) *userConfigCache {
+	return &userConfigCache{
+		ps:      ps,
+		entries: make(map[string]userConfigEntry),
+	}
+}
+
+// Get returns the user's config, fetching it if the cached copy is stale.
+// On fetch failure the stale copy (or the zero config) is returned, and
+// cached for userConfigFailureTTL before the next attempt.
+func (c *userConfigCache) Get(ctx context.Context, userID string) (UserConfig, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < entry.ttl {
+		return entry.cfg, nil
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, userConfigFetchTimeout)
+	defer cancel()
+	cfg, err := FetchUserConfig(fetchCtx, c.ps, userID)
+	if err != nil {
+		c.mu.Lock()
+		c.entries[userID] = userConfigEntry{cfg: entry.cfg, fetchedAt: time.Now(), ttl: userConfigFailureTTL}
+		c.mu.Unlock()
+		return entry.cfg, err
+	}
+
+	c.mu.Lock()
+	c.entries[userID] = userConfigEntry{cfg: cfg, fetchedAt: time.Now(), ttl: userConfigTTL}
+	c.mu.Unlock()
+	return cfg, nil
+}
+
+// Invalidate drops a cached config so the next Get refetches it.
+func (c *userConfigCache) Invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, userID)
+}
+
+// FetchUserConfig reads and decodes a user's config node. A missing node
+// yields the zero config.
+func FetchUserConfig(ctx context.Context, ps pathstore.Store, userID string) (UserConfig, error) {
+	var cfg UserConfig
+	node, err := ps.GetNode(ctx, UserConfigPath(userID))
+	if err != nil || node == nil {
+		return cfg, err
+	}
+	raw, err := json.Marshal(node.Value)
+	if err != nil {
+		return cfg, fmt.Errorf("marshal user config: %w", err)
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("decode user config: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dgallion1/docgest/internal/chunker"
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+func TestUserConfig_MergeOverridesNonZero(t *testing.T) {
+	base := UserConfig{ChunkSize: 1000, ChunkOverlap: 100, MaxFactsPerChunk: 10, LLMModel: "model-a", MinTrustFloor: 3}
+	got := base.Merge(UserConfig{ChunkSize: 500, LLMModel: "model-b", MinTrustFloor: 7})
+
+	want := UserConfig{ChunkSize: 500, ChunkOverlap: 100, MaxFactsPerChunk: 10, LLMModel: "model-b", MinTrustFloor: 7}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestUserConfig_MergeZeroOverrideKeepsBase(t *testing.T) {
+	base := UserConfig{ChunkSize: 1000, LLMModel: "model-a"}
+	if got := base.Merge(UserConfig{}); got != base {
+		t.Errorf("expected %+v, got %+v", base, got)
+	}
+}
+
+func TestUserConfig_ChunkConfigClampsOverlap(t *testing.T) {
+	base := chunker.Config{ChunkSize: 1500, ChunkOverlap: 200}
+
+	// A stored overlap of 400 is valid alone but not with a per-request size of 300.
+	got := UserConfig{ChunkOverlap: 400}.Merge(UserConfig{ChunkSize: 300}).ChunkConfig(base)
+	if got.ChunkSize != 300 || got.ChunkOverlap != 150 {
+		t.Errorf("expected size 300 overlap 150, got size %d overlap %d", got.ChunkSize, got.ChunkOverlap)
+	}
+
+	got = UserConfig{ChunkSize: 1000, ChunkOverlap: 100}.ChunkConfig(base)
+	if got.ChunkSize != 1000 || got.ChunkOverlap != 100 {
+		t.Errorf("expected size 1000 overlap 100, got size %d overlap %d", got.ChunkSize, got.ChunkOverlap)
+	}
+	if got := (UserConfig{}).ChunkConfig(base); got != base {
+		t.Errorf("expected %+v, got %+v", base, got)
+	}
+}
+
+// countingConfigStore serves one user config node and counts reads.
+type countingConfigStore struct {
+	pathstore.Store
+	value map[string]any
+	err   error
+	gets  int
+}
+
+func (s *countingConfigStore) GetNode(ctx context.Context, key string) (*pathstore.NodeResponse, error) {
+	s.gets++
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.value == nil {
+		return nil, nil
+	}
+	return &pathstore.NodeResponse{Key: key, Value: s.value}, nil
+}
+
+func TestUserConfigCache_CachesAndInvalidates(t *testing.T) {
+	ps := &countingConfigStore{value: map[string]any{"chunk_size": 800.0, "llm_model": "model-a"}}
+	c := newUserConfigCache(ps)
+	ctx := context.Background()
+
+	for range 3 {
+		cfg, err := c.Get(ctx, "u1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.ChunkSize != 800 || cfg.LLMModel != "model-a" {
+			t.Errorf("expected chunk_size 800 and model-a, got %+v", cfg)
+		}
+	}
+	if ps.gets != 1 {
+		t.Errorf("expected 1 fetch, got %d", ps.gets)
+	}
+
+	ps.value["chunk_size"] = 600.0
+	c.Invalidate("u1")
+	if cfg, _ := c.Get(ctx, "u1"); cfg.ChunkSize != 600 {
+		t.Errorf("expected chunk_size 600 after invalidate, got %d", cfg.ChunkSize)
+	}
+	if ps.gets != 2 {
+		t.Errorf("expected 2 fetches, got %d", ps.gets)
+	}
+}
+
+func TestUserConfigCache_CachesFailures(t *testing.T) {
+	ps := &countingConfigStore{value: map[string]any{"chunk_size": 800.0}}
+	c := newUserConfigCache(ps)
+	ctx := context.Background()
+	c.Get(ctx, "u1")
+
+	// Expire the good copy, then fail the refetch.
+	c.entries["u1"] = userConfigEntry{cfg: c.entries["u1"].cfg, ttl: userConfigTTL}
+	ps.err = errors.New("pathstore down")
+	cfg, err := c.Get(ctx, "u1")
+	if err == nil {
+		t.Error("expected the fetch error to be returned")
+	}
+	if cfg.ChunkSize != 800 {
+		t.Errorf("expected the stale chunk_size 800, got %d", cfg.ChunkSize)
+	}
+
+	// The failure is cached: no refetch, and the stale copy is served.
+	cfg, err = c.Get(ctx, "u1")
+	if err != nil || cfg.ChunkSize != 800 {
+		t.Errorf("expected cached chunk_size 800 and no error, got %d %v", cfg.ChunkSize, err)
+	}
+	if ps.gets != 2 {
+		t.Errorf("expected 2 fetches, got %d", ps.gets)
+	}
+}
+
+func TestFetchUserConfig_MissingNode(t *testing.T) {
+	cfg, err := FetchUserConfig(context.Background(), &countingConfigStore{}, "u1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg != (UserConfig{}) {
+		t.Errorf("expected the zero config, got %+v", cfg)
+	}
+}
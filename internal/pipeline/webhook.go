@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// WebhookTTL is how long a webhook registration lasts without renewal.
+const WebhookTTL = 90 * 24 * time.Hour
+
+// SignatureHeader carries the HMAC of a callback body signed with a
+// registered webhook's secret.
+const SignatureHeader = "X-Docgest-Signature"
+
+// WebhookRegistration is a callback URL with the secret its deliveries are
+// signed with.
+type WebhookRegistration struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewWebhookRegistration creates a registration for url with a random ID
+// and secret, expiring WebhookTTL after now.
+func NewWebhookRegistration(url string, now time.Time) WebhookRegistration {
+	return WebhookRegistration{
+		ID:        randomHex(8),
+		URL:       url,
+		Secret:    randomHex(32),
+		CreatedAt: now,
+		ExpiresAt: now.Add(WebhookTTL),
+	}
+}
+
+// Expired reports whether the registration lapsed before now.
+func (w WebhookRegistration) Expired(now time.Time) bool {
+	return !now.Before(w.ExpiresAt)
+}
+
+// WebhookPath is the pathstore key holding a webhook registration.
+func WebhookPath(id string) string {
+	return "memory/webhooks/" + id
+}
+
+// StoreWebhook writes a registration to pathstore.
+func StoreWebhook(ctx context.Context, ps pathstore.Store, reg WebhookRegistration) error {
+	return ps.PutNode(ctx, WebhookPath(reg.ID), pathstore.NodeRequest{
+		Value:      reg,
+		MemoryType: "metacognitive",
+		Salience:   0.1,
+		Source:     "docgest:webhooks",
+	})
+}
+
+// FetchWebhook reads a registration. A missing node yields nil.
+func FetchWebhook(ctx context.Context, ps pathstore.Store, id string) (*WebhookRegistration, error) {
+	node, err := ps.GetNode(ctx, WebhookPath(id))
+	if err != nil || node == nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(node.Value)
+	if err != nil {
+		return nil, fmt.Errorf("marshal webhook: %w", err)
+	}
+	var reg WebhookRegistration
+	if err := json.Unmarshal(raw, &reg); err != nil {
+		return nil, fmt.Errorf("decode webhook: %w", err)
+	}
+	return &reg, nil
+}
+
+// SignPayload returns the SignatureHeader value for body:
+// "sha256=" followed by the hex HMAC-SHA256 of body under secret.
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is SignPayload(secret, body),
+// comparing in constant time.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignPayload(secret, body)), []byte(signature))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignPayload(t *testing.T) {
+	// HMAC-SHA256("secret", "hello"), as computed by openssl.
+	want := "sha256=88aab3ede8d3adf94d26ab90d3bafd4a2083070c3bcce9c014ee04a443847c0b"
+	if got := SignPayload("secret", []byte("hello")); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if !VerifySignature("secret", []byte("hello"), want) {
+		t.Error("expected matching signature to verify")
+	}
+	if VerifySignature("secret", []byte("hello!"), want) {
+		t.Error("expected signature over a different body to fail")
+	}
+	if VerifySignature("other", []byte("hello"), want) {
+		t.Error("expected signature under a different secret to fail")
+	}
+}
+
+func TestWebhookRegistration_Expiry(t *testing.T) {
+	now := time.Now()
+	reg := NewWebhookRegistration("https://93.184.216.34/hook", now)
+	if reg.ID == "" || len(reg.Secret) != 64 {
+		t.Errorf("expected random ID and 32-byte secret, got %q %q", reg.ID, reg.Secret)
+	}
+	if reg.Expired(now.Add(WebhookTTL - time.Second)) {
+		t.Error("expected registration to be live before its TTL")
+	}
+	if !reg.Expired(now.Add(WebhookTTL)) {
+		t.Error("expected registration to expire after its TTL")
+	}
+}
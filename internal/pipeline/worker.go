@@ -3,9 +3,14 @@ package pipeline
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dgallion1/docgest/internal/chunker"
@@ -17,21 +22,73 @@ import (
 
 // Worker processes a single document job.
 type Worker struct {
-	claude    *extract.ClaudeClient
-	pathstore *pathstore.Client
+	claude    extract.Extractor
+	pathstore pathstore.Store
 	log       *slog.Logger
 	chunkCfg  chunker.Config
 
+	// categories maps fact categories to storage path, type and salience.
+	categories extract.Categories
+
+	// prompts picks the extraction prompt version for each job.
+	prompts extract.PromptSet
+
+	// parserOpts configures format-specific parsing.
+	parserOpts parser.Options
+
+	// userConfigs supplies per-user parameter overrides; nil disables them.
+	userConfigs *userConfigCache
+
+	// createLinks enables same-entity and same-topic links between a
+	// document's facts.
+	createLinks bool
+
+	// normalizeSalience rescales salience per category before storage.
+	normalizeSalience bool
+
+	// onModelDeprecated, if set, is called when a job fails because the
+	// extraction model is no longer served.
+	onModelDeprecated func(model string)
+
+	// dedupThreshold drops near-duplicate facts within a document before
+	// storage; 0 disables.
+	dedupThreshold float64
+
+	// duplicateFactCheck stores each fact at a path derived from its text
+	// and document (see FactKey) and skips it if that path is already
+	// written, as left by two workers racing on it.
+	duplicateFactCheck bool
+
+	// entityPathSeparator joins the levels of a fact's EntityPath; empty
+	// means "/".
+	entityPathSeparator string
+
+	// sourceMultipliers scales stored salience by the job's source type;
+	// types missing from the map are left unscaled.
+	sourceMultipliers map[string]float64
+
 	maxConcurrentExtract int
 	maxConcurrentStore   int
+
+	// retryBudget caps extraction retries across all chunks of one job;
+	// zero leaves only the per-chunk MaxRetries limit.
+	retryBudget int
+
+	// Per-phase deadlines; extractTimeout applies to each chunk separately.
+	// Zero disables the deadline.
+	parseTimeout   time.Duration
+	chunkTimeout   time.Duration
+	extractTimeout time.Duration
+	storeTimeout   time.Duration
 }
 
-func NewWorker(claude *extract.ClaudeClient, ps *pathstore.Client, log *slog.Logger, chunkCfg chunker.Config, maxExtract, maxStore int) *Worker {
+func NewWorker(claude extract.Extractor, ps pathstore.Store, log *slog.Logger, chunkCfg chunker.Config, maxExtract, maxStore int) *Worker {
 	return &Worker{
 		claude:               claude,
 		pathstore:            ps,
 		log:                  log,
 		chunkCfg:             chunkCfg,
+		categories:           extract.DefaultCategories(),
 		maxConcurrentExtract: maxExtract,
 		maxConcurrentStore:   maxStore,
 	}
@@ -39,113 +96,273 @@ func NewWorker(claude *extract.ClaudeClient, ps *pathstore.Client, log *slog.Log
 
 // Process runs the full ingest pipeline for a job.
 func (w *Worker) Process(ctx context.Context, job *Job) {
-	log := w.log.With("job_id", job.ID, "doc_id", job.DocID, "user_id", job.UserID)
+	log := w.log.With("job_id", job.ID, "doc_id", job.DocID, "user_id", job.UserID, "request_id", job.RequestID)
+	ctx = pathstore.WithRequestID(ctx, job.RequestID)
+
+	params := w.jobParams(ctx, log, job)
+	chunkCfg := params.ChunkConfig(w.chunkCfg)
 
 	// Phase 1: Parse
 	job.SetStatus(StatusParsing, "parsing")
-	p, err := parser.ForFile(job.Filename)
+	p, err := parser.Select(job.Filename, job.ContentType, job.fileData, w.parserOpts)
 	if err != nil {
 		log.Error("unsupported format", "error", err)
-		job.AddError(err.Error())
+		job.AddError(fmt.Errorf("%w: %w", parser.ErrParseFailure, err))
 		job.SetStatus(StatusFailed, "parsing")
 		return
 	}
 
-	tree, err := p.Parse(bytes.NewReader(job.fileData), job.Filename)
+	parseCtx, cancelParse := phaseContext(ctx, log, "parsing", w.parseTimeout)
+	tree, err := runPhase(parseCtx, func() (*doctree.DocTree, error) {
+		return p.Parse(bytes.NewReader(job.fileData), job.Filename)
+	})
+	cancelParse()
 	if err != nil {
+		if failPhaseTimeout(ctx, parseCtx, log, job, "parsing") {
+			return
+		}
 		log.Error("parse failed", "error", err)
-		job.AddError(fmt.Sprintf("parse: %s", err))
+		job.AddError(fmt.Errorf("%w: %w", parser.ErrParseFailure, err))
 		job.SetStatus(StatusFailed, "parsing")
 		return
 	}
+	titleInferred := false
 	if job.Title != "" {
 		tree.Title = job.Title
+	} else if tree.Title == strings.TrimSuffix(job.Filename, filepath.Ext(job.Filename)) {
+		// A bare filename stem like "document_12345" says nothing.
+		if t := doctree.InferTitle(tree); t != tree.Title {
+			tree.Title = t
+			titleInferred = true
+		}
 	}
 
 	// Compute content hash from the parsed text.
 	parsedText := flattenTreeText(tree)
 	job.ContentHash = ContentHashHex([]byte(parsedText))
+	if job.UploadHash == "" {
+		job.UploadHash = ContentHashHex(job.fileData)
+	}
+	job.SetParsedStats(countTreeNodes(tree), len(parsedText))
+	docType := job.DocType
+	if docType == "" {
+		docType = extract.DetectDocType(job.Filename, parsedText)
+		job.SetDocType(docType)
+	}
 
-	// Phase 1.5: Dedup check
-	exists, existingDocID, err := w.checkDuplicate(ctx, job)
-	if err != nil {
-		log.Warn("dedup check failed, proceeding", "error", err)
-	} else if exists {
-		log.Info("duplicate document, skipping", "existing_doc_id", existingDocID)
-		job.SetStatus(StatusDupSkipped, "dedup")
+	if job.ParseOnly {
+		log.Info("parse-only job finished", "nodes", countTreeNodes(tree))
+		job.SetParsedTree(tree)
+		job.SetStatus(StatusCompleted, "done")
 		return
 	}
 
+	// Phase 1.5: Dedup check. A dry run writes no facts, so it may preview a
+	// document that was already ingested.
+	if !job.DryRun {
+		exists, existingDocID, err := w.checkDuplicate(ctx, job)
+		if err != nil {
+			log.Warn("dedup check failed, proceeding", "error", err)
+		} else if exists {
+			log.Info("duplicate document, skipping", "existing_doc_id", existingDocID)
+			job.SetStatus(StatusDupSkipped, "dedup")
+			return
+		}
+	}
+
 	// Phase 2: Chunk
 	job.SetStatus(StatusChunking, "chunking")
-	chunks := chunker.ChunkTree(tree, w.chunkCfg)
+	chunkingCtx, cancelChunking := phaseContext(ctx, log, "chunking", w.chunkTimeout)
+	chunks, err := runPhase(chunkingCtx, func() ([]doctree.Chunk, error) {
+		return chunker.ChunkTree(tree, chunkCfg), nil
+	})
+	cancelChunking()
+	if err != nil {
+		if !failPhaseTimeout(ctx, chunkingCtx, log, job, "chunking") {
+			job.AddError(fmt.Errorf("chunk: %w", err))
+			job.SetStatus(StatusFailed, "chunking")
+		}
+		return
+	}
 	job.SetTotalChunks(len(chunks))
 	log.Info("chunked document", "chunks", len(chunks))
 
 	if len(chunks) == 0 {
 		log.Warn("no chunks produced")
-		job.AddError("no extractable content")
+		job.AddError(errors.New("no extractable content"))
 		job.SetStatus(StatusFailed, "chunking")
 		return
 	}
 
+	if job.DryRun {
+		w.storePreview(ctx, log, job, chunks)
+		return
+	}
+
+	docPrefix := fmt.Sprintf("memory/users/%s/documents/%s", job.UserID, job.DocID)
+	chunkHashes := make([]string, len(chunks))
+	for i, c := range chunks {
+		chunkHashes[i] = chunkHash(c)
+	}
+
+	// An incremental ingest extracts only the chunks the stored version of
+	// the document did not have.
+	pending := chunks
+	var inc *incrementalState
+	if job.Incremental {
+		inc, err = w.loadIncremental(ctx, docPrefix, chunkHashes)
+		if err != nil {
+			log.Error("incremental state read failed", "error", err)
+			job.AddError(fmt.Errorf("incremental: %w", err))
+			job.SetStatus(StatusFailed, "incremental")
+			return
+		}
+		pending = inc.pending(chunks, chunkHashes)
+		job.SetTotalChunks(len(pending))
+		log.Info("incremental ingest", "chunks", len(chunks), "to_extract", len(pending),
+			"facts_kept", inc.retained, "facts_replaced", len(inc.stale))
+	}
+
 	// Phase 3: Extract facts from chunks with bounded concurrency.
 	job.SetStatus(StatusExtracting, "extracting")
+	// One prompt version per document so its facts are comparable.
+	extractPrompt := w.prompts.Pick()
+	log.Info("extracting facts", "prompt_version", extractPrompt.Version, "doc_type", docType)
 	type chunkResult struct {
-		facts []extract.Fact
-		err   error
-		idx   int
+		facts    []extract.Fact
+		err      error
+		idx      int
+		duration time.Duration
 	}
-	results := make(chan chunkResult, len(chunks))
+	results := make(chan chunkResult, len(pending))
 	sem := make(chan struct{}, w.maxConcurrentExtract)
-
-	for i, chunk := range chunks {
+	// Retries are shared across chunks so one bad document cannot multiply
+	// LLM calls by its chunk count.
+	var retriesUsed atomic.Int64
+	// Per-chunk fact yield for the meta's chunk_stats; only the collector
+	// below touches it.
+	chunkStats := make([]*ChunkFactStats, 0, len(pending))
+	statsByChunk := make(map[int]*ChunkFactStats, len(pending))
+
+	for _, chunk := range pending {
+		i := chunk.Index
+		stats := &ChunkFactStats{ChunkIndex: i, Breadcrumb: chunk.Breadcrumb}
+		chunkStats = append(chunkStats, stats)
+		statsByChunk[i] = stats
 		sem <- struct{}{}
 		go func(i int, chunk chunker.ChunkInput) {
 			defer func() { <-sem }()
-			prompt := extract.BuildChunkPrompt(tree.Title, chunk.Breadcrumb, chunk.Text)
+			job.SetChunkStatus(i, chunk.Breadcrumb, ChunkStarted)
+			start := time.Now()
+			extractCtx, cancel := phaseContext(ctx, log.With("chunk", i), "extracting", w.extractTimeout)
+			defer cancel()
+			chunkCtx := extract.WithAuditInfo(extractCtx, extract.AuditInfo{JobID: job.ID, DocID: job.DocID, ChunkIndex: i})
+			prompt := extractPrompt.BuildTyped(tree.Title, docType, chunk.Breadcrumb, chunk.Text)
 			var facts []extract.Fact
 			var result *extract.ExtractionResult
 			var lastErr error
 			for attempt := range MaxRetries {
-				result, lastErr = w.claude.ExtractFacts(ctx, prompt)
+				result, lastErr = w.claude.ExtractFactsWithModel(chunkCtx, prompt, params.LLMModel)
 				if lastErr == nil && result != nil {
 					facts = result.Facts
 				}
-				if lastErr == nil || !IsRetryable(lastErr) {
+				if lastErr == nil || !IsRetryable(lastErr) || attempt == MaxRetries-1 {
+					break
+				}
+				if w.retryBudget > 0 && retriesUsed.Add(1) > int64(w.retryBudget) {
+					log.Warn("retry budget exhausted", "chunk", i, "budget", w.retryBudget, "error", lastErr)
 					break
 				}
 				log.Warn("retryable extraction error", "chunk", i, "attempt", attempt, "error", lastErr)
 				select {
 				case <-time.After(Backoff(attempt)):
-				case <-ctx.Done():
-					results <- chunkResult{err: ctx.Err(), idx: i}
-					return
+				case <-extractCtx.Done():
+					lastErr = extractCtx.Err()
+				}
+				if extractCtx.Err() != nil {
+					break
 				}
 			}
-			results <- chunkResult{facts: facts, err: lastErr, idx: i}
+			if lastErr != nil && phaseTimedOut(ctx, extractCtx) {
+				lastErr = errors.New(phaseTimeoutReason("extracting"))
+			}
+			results <- chunkResult{facts: facts, err: lastErr, idx: i, duration: time.Since(start)}
 		}(i, chunker.ChunkInput{Text: chunk.Text, Breadcrumb: chunk.Breadcrumb})
 	}
 
-	// Collect extraction results.
+	// Collect extraction results. factChunks[i] is the chunk allFacts[i]
+	// came from.
+	minTrustFloor := job.MinTrustFloor
+	if minTrustFloor == 0 {
+		minTrustFloor = params.MinTrustFloor
+	}
 	var allFacts []extract.Fact
+	var factChunks []int
 	hadErrors := false
-	for range chunks {
+	var deprecated *extract.ModelDeprecatedError
+	failedChunks := make(map[int]bool)
+	for range pending {
 		r := <-results
 		job.IncrChunksProcessed()
+		stats := statsByChunk[r.idx]
+		stats.ExtractionDurationMs = r.duration.Milliseconds()
+		stats.FactsExtracted = len(r.facts)
 		if r.err != nil {
+			job.SetChunkStatus(r.idx, nil, ChunkFailed)
+			failedChunks[r.idx] = true
+			errors.As(r.err, &deprecated)
 			log.Error("extraction failed", "chunk", r.idx, "error", r.err)
-			job.AddError(fmt.Sprintf("chunk %d: %s", r.idx, r.err))
+			job.AddError(fmt.Errorf("chunk %d: %w: %w", r.idx, extract.ErrExtractionFailure, r.err))
 			hadErrors = true
 			continue
 		}
+		job.SetChunkStatus(r.idx, nil, ChunkCompleted)
+		kept := 0
 		for i := range r.facts {
-			if extract.ValidateFact(&r.facts[i]) {
-				allFacts = append(allFacts, r.facts[i])
+			if params.MaxFactsPerChunk > 0 && kept >= params.MaxFactsPerChunk {
+				break
+			}
+			ok, reasons := extract.ValidateFact(&r.facts[i], minTrustFloor)
+			if !ok {
+				log.Debug("fact rejected", "chunk", r.idx, "reasons", reasons)
+				job.AddRejections(reasons)
+				continue
+			}
+			allFacts = append(allFacts, r.facts[i])
+			factChunks = append(factChunks, r.idx)
+			kept++
+		}
+		stats.FactsValid = kept
+	}
+
+	if deprecated != nil {
+		log.Error("extraction model is not available; set ANTHROPIC_MODEL (or the user's llm_model config) to a supported model and resubmit the document",
+			"model", deprecated.Model, "error", deprecated.Message)
+		job.SetStatus(StatusFailed, "model_deprecated")
+		if w.onModelDeprecated != nil {
+			w.onModelDeprecated(deprecated.Model)
+		}
+		return
+	}
+
+	if w.dedupThreshold > 0 && len(allFacts) > 1 {
+		keep := extract.DedupFacts(allFacts, w.dedupThreshold)
+		if dropped := len(allFacts) - len(keep); dropped > 0 {
+			facts := make([]extract.Fact, len(keep))
+			chunksOf := make([]int, len(keep))
+			for i, k := range keep {
+				facts[i], chunksOf[i] = allFacts[k], factChunks[k]
 			}
+			allFacts, factChunks = facts, chunksOf
+			log.Info("near-duplicate facts dropped", "count", dropped)
+			job.AddRejections(slices.Repeat([]string{"near duplicate"}, dropped))
 		}
 	}
 
+	if w.normalizeSalience {
+		allFacts = extract.NormalizeSalience(allFacts)
+	}
+
 	job.AddFacts(len(allFacts), 0)
 	log.Info("extraction complete", "valid_facts", len(allFacts), "errors", hadErrors)
 
@@ -156,33 +373,49 @@ func (w *Worker) Process(ctx context.Context, job *Job) {
 
 	// Phase 4: Store facts in pathstore.
 	job.SetStatus(StatusStoring, "storing")
+	storeCtx, cancelStore := phaseContext(ctx, log, "storing", w.storeTimeout)
+	defer cancelStore()
+	// Everything below runs under the store deadline.
+	parentCtx := ctx
+	ctx = storeCtx
 	prefix := fmt.Sprintf("memory/users/%s", job.UserID)
-	docPrefix := fmt.Sprintf("memory/users/%s/documents/%s", job.UserID, job.DocID)
+	w.detectSupersedes(ctx, log, allFacts, prefix)
 	storedCount := 0
 
 	storeSem := make(chan struct{}, w.maxConcurrentStore)
 	type storeResult struct {
-		ok   bool
-		err  error
-		path string
+		ok           bool
+		duplicate    bool
+		err          error
+		path         string
+		manifestPath string
+		entity       string
+		idx          int
 	}
 	storeResults := make(chan storeResult, len(allFacts))
 
-	for _, fact := range allFacts {
+	for i, fact := range allFacts {
 		storeSem <- struct{}{}
-		go func(f extract.Fact) {
+		go func(i int, f extract.Fact) {
 			defer func() { <-storeSem }()
-			factPath, err := w.storeFact(ctx, f, prefix, job.DocID)
-			if err != nil {
-				storeResults <- storeResult{ok: false, err: err, path: factPath}
+			factPath, err := w.storeFact(ctx, f, prefix, job.DocID, job.SourceType)
+			duplicate := errors.Is(err, errDuplicateFact)
+			if duplicate {
+				// The manifest entry is still rewritten so the stored fact
+				// carries this version's chunk hash.
+				log.Info("fact already stored, skipping", "path", factPath)
+			} else if err != nil {
+				storeResults <- storeResult{ok: false, err: err, path: factPath, idx: i}
 				return
 			}
-			// Write manifest entry.
+			// Write manifest entry. The chunk hash lets a later incremental
+			// ingest keep the fact while its chunk is unchanged.
 			manifestPath := fmt.Sprintf("%s/facts/%s", docPrefix, extractULID(factPath))
 			manifestErr := w.pathstore.PutNode(ctx, manifestPath, pathstore.NodeRequest{
 				Value: map[string]any{
-					"path":     factPath,
-					"category": f.Category,
+					"path":       factPath,
+					"category":   f.Category,
+					"chunk_hash": chunkHashes[factChunks[i]],
 				},
 				MemoryType: "metacognitive",
 				Salience:   0.1,
@@ -190,76 +423,293 @@ func (w *Worker) Process(ctx context.Context, job *Job) {
 			})
 			if manifestErr != nil {
 				log.Warn("manifest write failed", "path", manifestPath, "error", manifestErr)
+				manifestPath = ""
 			}
-			storeResults <- storeResult{ok: true, path: factPath}
-		}(fact)
+			if duplicate {
+				storeResults <- storeResult{duplicate: true, path: factPath}
+				return
+			}
+			storeResults <- storeResult{ok: true, path: factPath, manifestPath: manifestPath, entity: extract.Slugify(f.Entity), idx: i}
+		}(i, fact)
 	}
 
+	storeFailed := false
+	// Track everything written so a failed document can be rolled back.
+	var storedPaths []string
+	var storedFacts []storedFact
+	entityPaths := make(map[string][]string)
+	// written holds every fact path this version now owns, so removing
+	// stale facts cannot delete one it rewrote or found already stored.
+	written := make(map[string]bool)
 	for range allFacts {
 		r := <-storeResults
+		if r.ok && written[r.path] {
+			// The same fact twice in one document: the duplicate check
+			// gave both copies one path.
+			r.ok, r.duplicate = false, true
+		}
+		if r.ok || r.duplicate {
+			written[r.path] = true
+		}
 		if r.ok {
 			storedCount++
-		} else {
+			storedPaths = append(storedPaths, r.path)
+			f := allFacts[r.idx]
+			storedFacts = append(storedFacts, storedFact{path: r.path, chunk: factChunks[r.idx], topics: f.Topics, salience: f.Salience})
+			statsByChunk[factChunks[r.idx]].FactsStored++
+			if r.entity != "" {
+				entityPaths[r.entity] = append(entityPaths[r.entity], r.path)
+			}
+			if r.manifestPath != "" {
+				storedPaths = append(storedPaths, r.manifestPath)
+			}
+		} else if !r.duplicate {
 			log.Error("store failed", "path", r.path, "error", r.err)
-			job.AddError(fmt.Sprintf("store %s: %s", r.path, r.err))
+			job.AddError(fmt.Errorf("store %s: %w: %w", r.path, pathstore.ErrStorageFailure, r.err))
+			failedChunks[factChunks[r.idx]] = true
 			hadErrors = true
+			storeFailed = true
 		}
 	}
 
+	if phaseTimedOut(parentCtx, storeCtx) {
+		w.rollback(ctx, log, storedPaths)
+		failPhaseTimeout(parentCtx, storeCtx, log, job, "storing")
+		return
+	}
+
 	job.AddFacts(0, storedCount)
 	log.Info("storage complete", "stored", storedCount, "total", len(allFacts))
 
+	// When nothing was stored there is no document to describe; undo any
+	// manifest entries and fail. A partial store still writes meta below,
+	// with facts_stored counting only the facts that made it.
+	if storeFailed && storedCount == 0 {
+		w.rollback(ctx, log, storedPaths)
+		job.SetStatus(StatusFailed, "storing")
+		return
+	}
+
+	// Record the chunks whose facts are now stored, so an incremental
+	// ingest of the next version can skip them. Chunks that failed
+	// extraction or lost a fact in storage are left out and will be
+	// retried.
+	var extracted []string
+	for i, h := range chunkHashes {
+		if !failedChunks[i] {
+			extracted = append(extracted, h)
+		}
+	}
+	factsInDoc := storedCount
+	if inc != nil {
+		factsInDoc += inc.retained
+		// The previous version's dedup index entry names a hash this
+		// document no longer has. It is found through the old meta, so
+		// remove it before the meta is replaced.
+		DeleteHashIndex(ctx, w.pathstore, job.UserID, job.DocID, docPrefix)
+	}
+
 	// Write document metadata.
 	metaErr := w.pathstore.PutNode(ctx, docPrefix+"/meta", pathstore.NodeRequest{
 		Value: map[string]any{
-			"filename":     job.Filename,
-			"title":        tree.Title,
-			"content_hash": job.ContentHash,
-			"facts_stored": storedCount,
-			"total_chunks": len(chunks),
-			"created_at":   job.CreatedAt.Format(time.RFC3339),
+			"filename":       job.Filename,
+			"title":          tree.Title,
+			"title_inferred": titleInferred,
+			"content_hash":   job.ContentHash,
+			"upload_hash":    job.UploadHash,
+			"facts_stored":   factsInDoc,
+			"total_chunks":   len(chunks),
+			"chunk_hashes":   extracted,
+			"prompt_version": extractPrompt.Version,
+			"doc_type":       docType,
+			"chunk_stats":    chunkStatsByBreadcrumb(chunkStats),
+			"created_at":     job.CreatedAt.Format(time.RFC3339),
 		},
 		MemoryType: "metacognitive",
 		Salience:   0.5,
 		Source:     "docgest:" + job.DocID,
 	})
 	if metaErr != nil {
-		log.Error("meta write failed", "error", metaErr)
-		job.AddError(fmt.Sprintf("meta: %s", metaErr))
+		// Without a meta node the stored facts are unreachable, so undo them.
+		log.Error("meta write failed, rolling back", "error", metaErr)
+		job.AddError(fmt.Errorf("meta: %w: %w", pathstore.ErrStorageFailure, metaErr))
+		w.rollback(ctx, log, storedPaths)
+		job.AddFacts(0, -storedCount)
+		if !failPhaseTimeout(parentCtx, storeCtx, log, job, "storing") {
+			job.SetStatus(StatusFailed, "storing")
+		}
+		return
 	}
 
-	// Write hash index for dedup.
-	hashPath := fmt.Sprintf("memory/users/%s/documents/by_hash/%s/%s", job.UserID, job.ContentHash, job.DocID)
-	hashErr := w.pathstore.PutNode(ctx, hashPath, pathstore.NodeRequest{
-		Value: map[string]any{
-			"filename":   job.Filename,
-			"created_at": job.CreatedAt.Format(time.RFC3339),
-		},
-		MemoryType: "metacognitive",
-		Salience:   0.1,
-		Source:     "docgest:" + job.DocID,
+	if inc != nil {
+		w.removeStaleFacts(ctx, log, inc.stale, written)
+	}
+
+	if w.createLinks {
+		linksCreated := w.linkTopicFacts(ctx, log, storedFacts)
+		linksCreated += w.linkEntityFacts(ctx, log, entityPaths)
+		job.AddLinks(linksCreated)
+	}
+
+	// Write hash index for dedup, under both the parsed-text hash and the
+	// upload hash.
+	hashErr := WriteHashIndex(ctx, w.pathstore, job.UserID, job.DocID, job.ContentHash, job.UploadHash, map[string]any{
+		"filename":   job.Filename,
+		"created_at": job.CreatedAt.Format(time.RFC3339),
 	})
 	if hashErr != nil {
 		log.Error("hash index write failed", "error", hashErr)
 	}
 
-	if hadErrors && storedCount > 0 {
+	if hadErrors {
 		job.SetStatus(StatusPartial, "done")
-	} else if hadErrors {
-		job.SetStatus(StatusFailed, "storing")
 	} else {
 		job.SetStatus(StatusCompleted, "done")
 	}
 }
 
+// previewChars is how much of each chunk a dry-run preview keeps, in runes.
+const previewChars = 200
+
+// storePreview writes the chunk count, breadcrumbs and the start of each
+// chunk to the document's dry_run_preview node and finishes the job without
+// extraction.
+func (w *Worker) storePreview(ctx context.Context, log *slog.Logger, job *Job, chunks []doctree.Chunk) {
+	previews := make([]map[string]any, len(chunks))
+	for i, c := range chunks {
+		text := []rune(c.Text)
+		if len(text) > previewChars {
+			text = text[:previewChars]
+		}
+		breadcrumb := c.Breadcrumb
+		if breadcrumb == nil {
+			breadcrumb = []string{}
+		}
+		previews[i] = map[string]any{
+			"index":      c.Index,
+			"breadcrumb": breadcrumb,
+			"preview":    string(text),
+		}
+	}
+
+	previewPath := fmt.Sprintf("memory/users/%s/documents/%s/dry_run_preview", job.UserID, job.DocID)
+	err := w.pathstore.PutNode(ctx, previewPath, pathstore.NodeRequest{
+		Value: map[string]any{
+			"filename":    job.Filename,
+			"chunk_count": len(chunks),
+			"chunks":      previews,
+			"created_at":  job.CreatedAt.Format(time.RFC3339),
+		},
+		MemoryType: "metacognitive",
+		Salience:   0.1,
+		Source:     "docgest:" + job.DocID,
+	})
+	if err != nil {
+		log.Error("preview write failed", "error", err)
+		job.AddError(fmt.Errorf("preview: %w: %w", pathstore.ErrStorageFailure, err))
+		job.SetStatus(StatusFailed, "storing")
+		return
+	}
+	log.Info("stored dry-run preview", "chunks", len(chunks))
+	job.SetPreviewPath(previewPath)
+	job.SetStatus(StatusCompleted, "done")
+}
+
+// failPhaseTimeout marks job failed with a phase_timeout reason if phaseCtx
+// ran past its deadline, and reports whether it did.
+func failPhaseTimeout(parent, phaseCtx context.Context, log *slog.Logger, job *Job, phase string) bool {
+	if !phaseTimedOut(parent, phaseCtx) {
+		return false
+	}
+	log.Error("phase timed out", "phase", phase)
+	job.AddError(errors.New(phaseTimeoutReason(phase)))
+	job.SetStatus(StatusFailed, phase)
+	return true
+}
+
 // storeFact writes a single fact to pathstore and returns the path used.
-func (w *Worker) storeFact(ctx context.Context, f extract.Fact, prefix, docID string) (string, error) {
-	info, ok := extract.CategoryMap[f.Category]
+func (w *Worker) storeFact(ctx context.Context, f extract.Fact, prefix, docID string, source SourceType) (string, error) {
+	info, ok := w.categories[f.Category]
 	if !ok {
 		return "", fmt.Errorf("unknown category: %s", f.Category)
 	}
 
-	entity := extract.Slugify(f.Entity)
+	dir, topics, err := factDir(w.categories, w.entityPathSeparator, f, prefix)
+	if err != nil {
+		return "", err
+	}
+	path := dir + "/" + generateULID()
+	if w.duplicateFactCheck {
+		path = dir + "/" + FactKey(docID, f.Text)
+		existing, err := w.pathstore.GetNode(ctx, path)
+		if err != nil {
+			return path, fmt.Errorf("duplicate check: %w", err)
+		}
+		if existing != nil {
+			return path, errDuplicateFact
+		}
+	}
+
+	salience := f.Salience
+	if salience == 0 {
+		salience = info.DefaultSal
+	}
+	if source == "" {
+		source = SourceDocument
+	}
+	if m, ok := w.sourceMultipliers[string(source)]; ok {
+		salience = min(1, salience*m)
+	}
+
+	value := map[string]any{
+		"text":      f.Text,
+		"entity":    f.Entity,
+		"topics":    topics,
+		"min_trust": f.MinTrust,
+		"source": map[string]any{
+			"type":   string(source),
+			"doc_id": docID,
+		},
+	}
+
+	err = w.pathstore.PutNode(ctx, path, pathstore.NodeRequest{
+		Value:      value,
+		MergeMode:  info.MergeMode,
+		MemoryType: info.MemoryType,
+		Salience:   salience,
+		Source:     "docgest:" + docID,
+		Supersedes: f.Supersedes,
+	})
+	return path, err
+}
+
+// errDuplicateFact reports that storeFact found the fact already stored
+// and wrote nothing.
+var errDuplicateFact = errors.New("fact already stored")
+
+// FactKey names a fact stored with the duplicate check on. The same text
+// from the same document always maps to the same key, so two workers
+// racing on a fact write one node rather than one each.
+func FactKey(docID, text string) string {
+	return ContentHashHex([]byte(docID + "\x00" + text))[:26]
+}
+
+// factDir returns the directory a fact is stored under (its path minus the
+// ULID) along with its slugified topics. A hierarchical EntityPath takes
+// precedence over Entity, its levels joined with sep.
+func factDir(cats extract.Categories, sep string, f extract.Fact, prefix string) (string, []string, error) {
+	info, ok := cats[f.Category]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown category: %s", f.Category)
+	}
+
+	if sep == "" {
+		sep = "/"
+	}
+	entity := extract.SlugifyEntityPath(f.EntityPath, sep)
+	if entity == "" {
+		entity = extract.Slugify(f.Entity)
+	}
 	if entity == "" {
 		entity = "general"
 	}
@@ -271,62 +721,223 @@ func (w *Worker) storeFact(ctx context.Context, f extract.Fact, prefix, docID st
 		}
 	}
 
-	ulid := generateULID()
-	var path string
 	switch f.Category {
 	case "entity_fact", "preference":
 		tmpl := strings.Replace(info.PathTemplate, "{entity}", entity, 1)
-		path = fmt.Sprintf("%s/%s/%s", prefix, tmpl, ulid)
+		return fmt.Sprintf("%s/%s", prefix, tmpl), topics, nil
 	case "topic_knowledge", "procedure":
 		topic := "general"
 		if len(topics) > 0 {
 			topic = topics[0]
 		}
 		tmpl := strings.Replace(info.PathTemplate, "{topic}", topic, 1)
-		path = fmt.Sprintf("%s/%s/%s", prefix, tmpl, ulid)
+		return fmt.Sprintf("%s/%s", prefix, tmpl), topics, nil
 	default:
-		return "", fmt.Errorf("unexpected category: %s", f.Category)
+		return "", nil, fmt.Errorf("unexpected category: %s", f.Category)
 	}
+}
 
-	salience := f.Salience
-	if salience == 0 {
-		salience = info.DefaultSal
+// supersedesLookupLimit caps how many existing facts one lookup returns.
+const supersedesLookupLimit = 50
+
+// detectSupersedes fills Supersedes for entity facts and preferences with
+// the paths of facts already stored for the same entity and category.
+// Each directory is queried once per job.
+func (w *Worker) detectSupersedes(ctx context.Context, log *slog.Logger, facts []extract.Fact, prefix string) {
+	existing := make(map[string][]string)
+	for i := range facts {
+		f := &facts[i]
+		if f.Category != "entity_fact" && f.Category != "preference" {
+			continue
+		}
+		dir, _, err := factDir(w.categories, w.entityPathSeparator, *f, prefix)
+		if err != nil {
+			continue
+		}
+		paths, ok := existing[dir]
+		if !ok {
+			children, err := w.pathstore.ListChildren(ctx, dir, supersedesLookupLimit)
+			if err != nil {
+				log.Warn("supersedes lookup failed", "dir", dir, "error", err)
+			}
+			for _, c := range children {
+				paths = append(paths, c.Key)
+			}
+			existing[dir] = paths
+		}
+		for _, p := range paths {
+			if !slices.Contains(f.Supersedes, p) {
+				f.Supersedes = append(f.Supersedes, p)
+			}
+		}
+	}
+}
+
+// jobParams merges the job's per-request overrides over the user's stored config.
+func (w *Worker) jobParams(ctx context.Context, log *slog.Logger, job *Job) UserConfig {
+	var params UserConfig
+	if w.userConfigs != nil {
+		cfg, err := w.userConfigs.Get(ctx, job.UserID)
+		if err != nil {
+			log.Warn("user config fetch failed, using defaults", "error", err)
+		}
+		params = cfg
 	}
+	return params.Merge(job.Overrides)
+}
 
-	value := map[string]any{
-		"text":      f.Text,
-		"entity":    f.Entity,
-		"topics":    topics,
-		"min_trust": f.MinTrust,
-		"source": map[string]any{
-			"type":   "document",
-			"doc_id": docID,
-		},
+// maxEntityLinks caps the links written per entity: every pair of up to 20
+// facts.
+const maxEntityLinks = 190
+
+// linkEntityFacts links each pair of facts that share an entity. Pairs are
+// taken from the first fact outward, so an entity that hits maxEntityLinks
+// still has its first fact linked to as many others as the cap allows.
+// Returns the number of links written.
+func (w *Worker) linkEntityFacts(ctx context.Context, log *slog.Logger, entityPaths map[string][]string) int {
+	sem := make(chan struct{}, w.maxConcurrentStore)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, failed := 0, 0
+
+	for entity, paths := range entityPaths {
+		paths = slices.Sorted(slices.Values(paths))
+		links := 0
+		for i := 0; i < len(paths) && links < maxEntityLinks; i++ {
+			for j := i + 1; j < len(paths) && links < maxEntityLinks; j++ {
+				links++
+				sem <- struct{}{}
+				wg.Add(1)
+				go func(from, to, entity string) {
+					defer func() { <-sem; wg.Done() }()
+					err := w.pathstore.PutLink(ctx, pathstore.LinkRequest{
+						From:          from,
+						To:            to,
+						Weight:        0.5,
+						Summary:       "same entity: " + entity,
+						Bidirectional: true,
+					})
+					mu.Lock()
+					defer mu.Unlock()
+					if err != nil {
+						log.Warn("link write failed", "from", from, "to", to, "error", err)
+						failed++
+						return
+					}
+					created++
+				}(paths[i], paths[j], entity)
+			}
+		}
 	}
+	wg.Wait()
+	if created+failed > 0 {
+		log.Info("entity links written", "created", created, "failed", failed)
+	}
+	return created
+}
 
-	err := w.pathstore.PutNode(ctx, path, pathstore.NodeRequest{
-		Value:      value,
-		MemoryType: info.MemoryType,
-		Salience:   salience,
-		Source:     "docgest:" + docID,
-	})
-	return path, err
+// storedFact is a fact written in the storage phase, kept for linking.
+type storedFact struct {
+	path     string
+	chunk    int
+	topics   []string
+	salience float64
 }
 
-// checkDuplicate checks if this content hash already exists for the user.
-func (w *Worker) checkDuplicate(ctx context.Context, job *Job) (bool, string, error) {
-	hashPrefix := fmt.Sprintf("memory/users/%s/documents/by_hash/%s", job.UserID, job.ContentHash)
-	children, err := w.pathstore.ListChildren(ctx, hashPrefix, 1)
-	if err != nil {
-		return false, "", err
+// topicLinkWeight scales the lower salience of two co-occurring facts.
+const topicLinkWeight = 0.5
+
+// linkTopicFacts links each pair of facts from the same chunk that share a
+// topic slug. Pairs are only formed within a chunk so a large document does
+// not produce a quadratic number of links. Returns the number written.
+func (w *Worker) linkTopicFacts(ctx context.Context, log *slog.Logger, facts []storedFact) int {
+	byChunk := make(map[int][]storedFact)
+	for _, f := range facts {
+		byChunk[f.chunk] = append(byChunk[f.chunk], f)
+	}
+
+	sem := make(chan struct{}, w.maxConcurrentStore)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, failed := 0, 0
+
+	for _, group := range byChunk {
+		for i := range group {
+			for j := i + 1; j < len(group); j++ {
+				topic := sharedTopic(group[i].topics, group[j].topics)
+				if topic == "" {
+					continue
+				}
+				sem <- struct{}{}
+				wg.Add(1)
+				go func(a, b storedFact, topic string) {
+					defer func() { <-sem; wg.Done() }()
+					err := w.pathstore.PutLink(ctx, pathstore.LinkRequest{
+						From:          a.path,
+						To:            b.path,
+						Weight:        min(a.salience, b.salience) * topicLinkWeight,
+						Summary:       "shared topic: " + topic,
+						Bidirectional: true,
+					})
+					mu.Lock()
+					defer mu.Unlock()
+					if err != nil {
+						log.Warn("link write failed", "from", a.path, "to", b.path, "error", err)
+						failed++
+						return
+					}
+					created++
+				}(group[i], group[j], topic)
+			}
+		}
+	}
+	wg.Wait()
+	if created+failed > 0 {
+		log.Info("topic links written", "created", created, "failed", failed)
+	}
+	return created
+}
+
+// sharedTopic returns the first topic slug of a that also appears in b, or
+// "" if they share none.
+func sharedTopic(a, b []string) string {
+	slugs := make(map[string]bool, len(b))
+	for _, t := range b {
+		if s := extract.Slugify(t); s != "" {
+			slugs[s] = true
+		}
 	}
-	if len(children) > 0 {
-		// Extract doc_id from the key path.
-		parts := strings.Split(children[0].Key, ".")
-		docID := parts[len(parts)-1]
-		return true, docID, nil
+	for _, t := range a {
+		if s := extract.Slugify(t); slugs[s] {
+			return s
+		}
 	}
-	return false, "", nil
+	return ""
+}
+
+// rollback deletes paths written during a failed storage phase. It runs
+// detached from ctx cancellation so shutdown does not leave orphaned facts.
+func (w *Worker) rollback(ctx context.Context, log *slog.Logger, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	failed := 0
+	for _, p := range paths {
+		if err := w.pathstore.DeleteNode(ctx, p, false); err != nil {
+			log.Warn("rollback delete failed", "path", p, "error", err)
+			failed++
+		}
+	}
+	log.Info("rolled back stored paths", "deleted", len(paths)-failed, "failed", failed)
+}
+
+// checkDuplicate checks if this content hash already exists for the user.
+func (w *Worker) checkDuplicate(ctx context.Context, job *Job) (bool, string, error) {
+	docID, err := LookupHashIndex(ctx, w.pathstore, job.UserID, job.ContentHash)
+	return docID != "", docID, err
 }
 
 // flattenTreeText extracts all text from a DocTree into a single string for hashing.
@@ -349,6 +960,20 @@ func flattenTreeText(tree *doctree.DocTree) string {
 	return sb.String()
 }
 
+// countTreeNodes returns the number of nodes below the tree root.
+func countTreeNodes(tree *doctree.DocTree) int {
+	n := 0
+	var walk func(nodes []*doctree.DocNode)
+	walk = func(nodes []*doctree.DocNode) {
+		for _, node := range nodes {
+			n++
+			walk(node.Children)
+		}
+	}
+	walk(tree.Children)
+	return n
+}
+
 // extractULID gets the last path segment (the ULID) from a full path.
 func extractULID(path string) string {
 	parts := strings.Split(path, "/")
@@ -0,0 +1,95 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/extract"
+	"github.com/dgallion1/docgest/internal/pipeline"
+	"github.com/dgallion1/docgest/internal/testutil"
+)
+
+// leakTestMarkdown has two sections, each long enough to be its own chunk.
+var leakTestMarkdown = "# Pets\n\n## Milo\n\n" +
+	strings.Repeat("Milo is a golden retriever who loves playing fetch at the park every morning. ", 10) +
+	"\n\n## Luna\n\n" +
+	strings.Repeat("Luna is a grey tabby cat who prefers to sleep on the sunny windowsill. ", 10)
+
+// blockingExtractor never answers; each call waits for its context.
+type blockingExtractor struct{}
+
+func (blockingExtractor) ExtractFactsWithModel(ctx context.Context, prompt, model string) (*extract.ExtractionResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// runJob starts an orchestrator, submits one markdown job, waits until the
+// job reaches a terminal status or wait elapses, then stops everything.
+func runJob(t *testing.T, ex extract.Extractor, wait time.Duration) pipeline.JobStatus {
+	t.Helper()
+	cfg := testutil.TestConfig()
+	orch := pipeline.NewOrchestrator(cfg, ex, testutil.NewMockPathstoreClient(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+	orch.Start(ctx)
+	defer func() {
+		cancel()
+		orch.Stop()
+	}()
+
+	now := time.Now()
+	job := &pipeline.Job{
+		ID:        "leak-test",
+		Type:      pipeline.JobTypeIngest,
+		DocID:     "doc1",
+		UserID:    "u1",
+		Status:    pipeline.StatusQueued,
+		Phase:     "queued",
+		Filename:  "pets.md",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	job.SetFileData([]byte(leakTestMarkdown))
+	if err := orch.Submit(ctx, job); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	for time.Now().Before(deadline) {
+		if st := job.Snapshot().Status; st.Terminal() {
+			return st
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return job.Snapshot().Status
+}
+
+func TestWorker_NoGoroutineLeak(t *testing.T) {
+	testutil.AssertNoGoroutineLeak(t, func() {
+		if st := runJob(t, testutil.NewMockExtractor(testutil.DefaultFacts...), 5*time.Second); st != pipeline.StatusCompleted {
+			t.Errorf("expected completed job, got %s", st)
+		}
+	})
+}
+
+func TestWorker_NoGoroutineLeakOnExtractionErrors(t *testing.T) {
+	ex := testutil.NewMockExtractor()
+	ex.SetError(errors.New("extractor down"))
+	testutil.AssertNoGoroutineLeak(t, func() {
+		if st := runJob(t, ex, 5*time.Second); st != pipeline.StatusFailed {
+			t.Errorf("expected failed job, got %s", st)
+		}
+	})
+}
+
+func TestWorker_NoGoroutineLeakOnShutdown(t *testing.T) {
+	// The job is still extracting when the orchestrator stops; cancellation
+	// must unblock every chunk goroutine.
+	testutil.AssertNoGoroutineLeak(t, func() {
+		runJob(t, blockingExtractor{}, 100*time.Millisecond)
+	})
+}
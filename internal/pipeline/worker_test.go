@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/dgallion1/docgest/internal/extract"
+)
+
+func TestFactDir(t *testing.T) {
+	tests := []struct {
+		fact extract.Fact
+		want string
+	}{
+		{extract.Fact{Category: "entity_fact", Entity: "Milo Dog"}, "memory/users/u1/entities/milo-dog/facts"},
+		{extract.Fact{Category: "preference", Entity: ""}, "memory/users/u1/entities/general/preferences"},
+		{extract.Fact{Category: "topic_knowledge", Topics: []string{"Go Lang", "x"}}, "memory/users/u1/topics/go-lang"},
+		{extract.Fact{Category: "procedure"}, "memory/users/u1/procedures/general"},
+		{extract.Fact{Category: "entity_fact", Entity: "alice", EntityPath: "Acme.Engineering.Alice"}, "memory/users/u1/entities/acme/engineering/alice/facts"},
+		{extract.Fact{Category: "preference", Entity: "alice", EntityPath: ".."}, "memory/users/u1/entities/alice/preferences"},
+	}
+	for _, tt := range tests {
+		got, _, err := factDir(extract.DefaultCategories(), "/", tt.fact, "memory/users/u1")
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.fact.Category, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.fact.Category, tt.want, got)
+		}
+	}
+
+	if _, _, err := factDir(extract.DefaultCategories(), "/", extract.Fact{Category: "bogus"}, "p"); err == nil {
+		t.Error("expected error for unknown category")
+	}
+}
+
+func TestFactDir_CustomTemplate(t *testing.T) {
+	cats, err := extract.DefaultCategories().WithPathTemplates(map[string]string{
+		"entity_fact": "kg/{entity}/attributes",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, _, err := factDir(cats, "/", extract.Fact{Category: "entity_fact", Entity: "Milo"}, "memory/users/u1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "memory/users/u1/kg/milo/attributes"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFactDir_EntityPathSeparator(t *testing.T) {
+	f := extract.Fact{Category: "entity_fact", EntityPath: "acme.engineering.alice"}
+	got, _, err := factDir(extract.DefaultCategories(), "--", f, "memory/users/u1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "memory/users/u1/entities/acme--engineering--alice/facts"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSharedTopic(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want string
+	}{
+		{[]string{"Go Lang", "tooling"}, []string{"go-lang"}, "go-lang"},
+		{[]string{"rust"}, []string{"go"}, ""},
+		{[]string{"", "go"}, []string{"", "Go"}, "go"},
+		{nil, []string{"go"}, ""},
+	}
+	for _, tt := range tests {
+		if got := sharedTopic(tt.a, tt.b); got != tt.want {
+			t.Errorf("sharedTopic(%v, %v): expected %q, got %q", tt.a, tt.b, tt.want, got)
+		}
+	}
+}
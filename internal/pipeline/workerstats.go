@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WorkerStats is a point-in-time view of one worker's load.
+type WorkerStats struct {
+	Index             int    `json:"index"`
+	JobsProcessed     int    `json:"jobs_processed"`
+	TotalProcessingMs int64  `json:"total_processing_ms"`
+	CurrentJobID      string `json:"current_job_id"`
+}
+
+// workerCounters holds one worker's live counters. The worker goroutine
+// writes them; stats readers load them without locking.
+type workerCounters struct {
+	index         int
+	jobsProcessed atomic.Int64
+	processingMs  atomic.Int64
+	currentJobID  atomic.Pointer[string]
+}
+
+// track records job as in flight until the returned func is called.
+func (c *workerCounters) track(job *Job) func() {
+	id := job.ID
+	c.currentJobID.Store(&id)
+	start := time.Now()
+	return func() {
+		c.processingMs.Add(time.Since(start).Milliseconds())
+		c.jobsProcessed.Add(1)
+		c.currentJobID.Store(nil)
+	}
+}
+
+func (c *workerCounters) snapshot() WorkerStats {
+	s := WorkerStats{
+		Index:             c.index,
+		JobsProcessed:     int(c.jobsProcessed.Load()),
+		TotalProcessingMs: c.processingMs.Load(),
+	}
+	if id := c.currentJobID.Load(); id != nil {
+		s.CurrentJobID = *id
+	}
+	return s
+}
+
+// WorkerStats returns per-worker counters for the live pool, oldest first.
+// Index is the worker's lifetime number, which matches its heartbeat node;
+// retired workers drop out of the list and their numbers are not reused.
+func (o *Orchestrator) WorkerStats() []WorkerStats {
+	o.workerMu.RLock()
+	defer o.workerMu.RUnlock()
+	stats := make([]WorkerStats, len(o.workerCounters))
+	for i, c := range o.workerCounters {
+		stats[i] = c.snapshot()
+	}
+	return stats
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// PathstoreBackend adapts a pathstore client to Backend. Values are stored
+// as plain nodes without memory type or salience.
+type PathstoreBackend struct {
+	client *pathstore.Client
+}
+
+var _ Backend = (*PathstoreBackend)(nil)
+
+// NewPathstoreBackend wraps client.
+func NewPathstoreBackend(client *pathstore.Client) *PathstoreBackend {
+	return &PathstoreBackend{client: client}
+}
+
+func (b *PathstoreBackend) Put(ctx context.Context, key string, value any) error {
+	return b.client.PutNode(ctx, key, pathstore.NodeRequest{Value: value})
+}
+
+func (b *PathstoreBackend) Get(ctx context.Context, key string) (any, error) {
+	node, err := b.client.GetNode(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, ErrNotFound
+	}
+	return node.Value, nil
+}
+
+func (b *PathstoreBackend) Delete(ctx context.Context, key string) error {
+	return b.client.DeleteNode(ctx, key, false)
+}
+
+// List scans below the path prefix ends in; pathstore lists by key path, so
+// a prefix that stops mid-segment is filtered client-side.
+func (b *PathstoreBackend) List(ctx context.Context, prefix string, limit int) ([]Item, error) {
+	dir := prefix
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i]
+	}
+	nodes, err := b.client.ListAll(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for _, n := range nodes {
+		key := strings.ReplaceAll(n.Key, ".", "/")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		items = append(items, Item{Key: key, Value: n.Value})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
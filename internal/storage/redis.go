@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisScanCount is the COUNT hint for each SCAN call.
+const redisScanCount = 500
+
+// redisIndexKey names, under the key prefix, the sorted set indexing every
+// stored key. All members share score 0, so ZRANGEBYLEX over a key prefix
+// lists it without scanning the keyspace.
+const redisIndexKey = "_keys"
+
+// RedisBackend stores JSON-encoded values in Redis under a key prefix, and
+// each key in an index sorted set for listing.
+type RedisBackend struct {
+	client *redis.Client
+	prefix string
+}
+
+var _ Backend = (*RedisBackend)(nil)
+
+// NewRedisBackend connects to the Redis server at url
+// (e.g. "redis://localhost:6379/0"). Every key is stored under keyPrefix.
+func NewRedisBackend(url, keyPrefix string) (*RedisBackend, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis url: %w", err)
+	}
+	return &RedisBackend{client: redis.NewClient(opts), prefix: keyPrefix}, nil
+}
+
+// Ping checks that the server is reachable.
+func (b *RedisBackend) Ping(ctx context.Context) error {
+	return b.client.Ping(ctx).Err()
+}
+
+func (b *RedisBackend) Put(ctx context.Context, key string, value any) error {
+	return b.put(ctx, key, value, redis.SetArgs{})
+}
+
+// PutExpiring stores value with a Redis expiry at at. The index entry
+// outlives the key and is removed by the next List that reaches it.
+func (b *RedisBackend) PutExpiring(ctx context.Context, key string, value any, at time.Time) error {
+	return b.put(ctx, key, value, redis.SetArgs{ExpireAt: at})
+}
+
+func (b *RedisBackend) put(ctx context.Context, key string, value any, args redis.SetArgs) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", key, err)
+	}
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetArgs(ctx, b.prefix+key, data, args)
+		pipe.ZAdd(ctx, b.prefix+redisIndexKey, redis.Z{Member: key})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis set %s: %w", key, err)
+	}
+	return nil
+}
+
+func (b *RedisBackend) Get(ctx context.Context, key string) (any, error) {
+	data, err := b.client.Get(ctx, b.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get %s: %w", key, err)
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", key, err)
+	}
+	return v, nil
+}
+
+func (b *RedisBackend) Delete(ctx context.Context, key string) error {
+	var del *redis.IntCmd
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		del = pipe.Del(ctx, b.prefix+key)
+		pipe.ZRem(ctx, b.prefix+redisIndexKey, key)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis del %s: %w", key, err)
+	}
+	if del.Val() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List reads keys under prefix from the index in lexical order. Index
+// entries whose key has expired are removed as they are found, and the
+// page is read again so limit still counts live keys.
+func (b *RedisBackend) List(ctx context.Context, prefix string, limit int) ([]Item, error) {
+	rng := &redis.ZRangeBy{Min: "-", Max: "+"}
+	if prefix != "" {
+		// No UTF-8 key contains 0xff, so it bounds every key with prefix.
+		rng = &redis.ZRangeBy{Min: "[" + prefix, Max: "(" + prefix + "\xff"}
+	}
+	if limit > 0 {
+		rng.Count = int64(limit)
+	}
+	for {
+		keys, err := b.client.ZRangeByLex(ctx, b.prefix+redisIndexKey, rng).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis list %s: %w", prefix, err)
+		}
+		if len(keys) == 0 {
+			return nil, nil
+		}
+		full := make([]string, len(keys))
+		for i, k := range keys {
+			full[i] = b.prefix + k
+		}
+		vals, err := b.client.MGet(ctx, full...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis mget %s: %w", prefix, err)
+		}
+
+		items := make([]Item, 0, len(keys))
+		var stale []any
+		for i, raw := range vals {
+			s, ok := raw.(string)
+			if !ok {
+				stale = append(stale, keys[i])
+				continue
+			}
+			var v any
+			if err := json.Unmarshal([]byte(s), &v); err != nil {
+				return nil, fmt.Errorf("decode %s: %w", keys[i], err)
+			}
+			items = append(items, Item{Key: keys[i], Value: v})
+		}
+		if len(stale) == 0 {
+			return items, nil
+		}
+		if err := b.client.ZRem(ctx, b.prefix+redisIndexKey, stale...).Err(); err != nil {
+			return nil, fmt.Errorf("redis list %s: %w", prefix, err)
+		}
+		if limit <= 0 || len(keys) < limit {
+			return items, nil
+		}
+	}
+}
+
+// Reindex adds every key stored under the prefix to the index, for data
+// written before the index existed. It scans the whole keyspace, so run it
+// once at startup rather than per request. It returns the number of keys
+// indexed.
+func (b *RedisBackend) Reindex(ctx context.Context) (int, error) {
+	n := 0
+	iter := b.client.Scan(ctx, 0, redisGlobEscape(b.prefix)+"*", redisScanCount).Iterator()
+	var batch []redis.Z
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := b.client.ZAdd(ctx, b.prefix+redisIndexKey, batch...).Err()
+		n += len(batch)
+		batch = batch[:0]
+		return err
+	}
+	for iter.Next(ctx) {
+		key := strings.TrimPrefix(iter.Val(), b.prefix)
+		if key == redisIndexKey {
+			continue
+		}
+		batch = append(batch, redis.Z{Member: key})
+		if len(batch) == redisScanCount {
+			if err := flush(); err != nil {
+				return n, fmt.Errorf("redis reindex: %w", err)
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return n, fmt.Errorf("redis reindex: %w", err)
+	}
+	if err := flush(); err != nil {
+		return n, fmt.Errorf("redis reindex: %w", err)
+	}
+	return n, nil
+}
+
+// Close releases the connection pool.
+func (b *RedisBackend) Close() error {
+	return b.client.Close()
+}
+
+// redisGlobEscape escapes SCAN MATCH metacharacters in s.
+func redisGlobEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,427 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+func TestRedisGlobEscape(t *testing.T) {
+	if got, want := redisGlobEscape(`docgest:a*b?[c]\`), `docgest:a\*b\?\[c\]\\`; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+// testRedisBackend connects to DOCGEST_TEST_REDIS_URL when set (e.g.
+// redis://localhost:6379/15) and to an in-process fakeRedis otherwise.
+func testRedisBackend(t *testing.T) *RedisBackend {
+	t.Helper()
+	url := os.Getenv("DOCGEST_TEST_REDIS_URL")
+	if url == "" {
+		url = "redis://" + newFakeRedis(t).addr
+	}
+	ctx := context.Background()
+	b, err := NewRedisBackend(url, "docgest-test:"+t.Name()+":")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	if err := b.Ping(ctx); err != nil {
+		t.Fatalf("redis unreachable: %v", err)
+	}
+	t.Cleanup(func() {
+		items, _ := b.List(ctx, "", 0)
+		for _, it := range items {
+			b.Delete(ctx, it.Key)
+		}
+		b.client.Del(ctx, b.prefix+redisIndexKey)
+	})
+	return b
+}
+
+func TestRedisBackend(t *testing.T) {
+	b := testRedisBackend(t)
+	ctx := context.Background()
+
+	for _, k := range []string{"d/facts/2", "d/facts/1", "e/x"} {
+		if err := b.Put(ctx, k, map[string]any{"key": k}); err != nil {
+			t.Fatalf("put %s: %v", k, err)
+		}
+	}
+	v, err := b.Get(ctx, "d/facts/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m, _ := v.(map[string]any); m["key"] != "d/facts/1" {
+		t.Errorf("unexpected value: %v", v)
+	}
+	if _, err := b.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	items, err := b.List(ctx, "d/", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 || items[0].Key != "d/facts/1" {
+		t.Errorf("expected first key d/facts/1, got %+v", items)
+	}
+	if items, _ := b.List(ctx, "d/", 0); len(items) != 2 {
+		t.Errorf("expected 2 keys under d/, got %+v", items)
+	}
+
+	if err := b.Delete(ctx, "d/facts/1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := b.Get(ctx, "d/facts/1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted key to be gone, got %v", err)
+	}
+	if err := b.Delete(ctx, "d/facts/1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting a missing key, got %v", err)
+	}
+	if items, _ := b.List(ctx, "d/", 0); len(items) != 1 || items[0].Key != "d/facts/2" {
+		t.Errorf("expected only d/facts/2 after delete, got %+v", items)
+	}
+}
+
+func TestRedisBackend_ExpiredKeysLeaveListings(t *testing.T) {
+	b := testRedisBackend(t)
+	ctx := context.Background()
+
+	if err := b.PutExpiring(ctx, "d/a", "old", time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, k := range []string{"d/b", "d/c"} {
+		b.Put(ctx, k, k)
+	}
+	if items, _ := b.List(ctx, "d/", 0); len(items) != 3 {
+		t.Fatalf("expected 3 keys before expiry, got %+v", items)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := b.Get(ctx, "d/a"); errors.Is(err, ErrNotFound) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected d/a to expire")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	items, err := b.List(ctx, "d/", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 2 || items[0].Key != "d/b" || items[1].Key != "d/c" {
+		t.Errorf("expected a full page of live keys, got %+v", items)
+	}
+}
+
+func TestRedisBackend_Reindex(t *testing.T) {
+	b := testRedisBackend(t)
+	ctx := context.Background()
+
+	// Keys written before the index existed.
+	for _, k := range []string{"d/a", "d/b"} {
+		if err := b.client.Set(ctx, b.prefix+k, `"v"`, 0).Err(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if items, _ := b.List(ctx, "d/", 0); len(items) != 0 {
+		t.Fatalf("expected unindexed keys to be unlisted, got %+v", items)
+	}
+	n, err := b.Reindex(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 keys indexed, got %d, %v", n, err)
+	}
+	if items, _ := b.List(ctx, "d/", 0); len(items) != 2 {
+		t.Errorf("expected 2 keys after reindex, got %+v", items)
+	}
+}
+
+func TestStore_OnRedisBackend(t *testing.T) {
+	b := testRedisBackend(t)
+	s := NewStore(b)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	s.PutNode(ctx, "doc/facts/1", pathstore.NodeRequest{Value: "live"})
+	s.PutNode(ctx, "doc/facts/2", pathstore.NodeRequest{Value: "archived", ExpiresAt: past})
+
+	if node, err := s.GetNode(ctx, "doc/facts/2"); err != nil || node != nil {
+		t.Errorf("expected expired node to read as missing, got %+v, %v", node, err)
+	}
+	if nodes, _ := s.ListAll(ctx, "doc"); len(nodes) != 1 {
+		t.Errorf("expected 1 live node, got %+v", nodes)
+	}
+	if err := s.DeleteNode(ctx, "doc/facts/9", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting a missing node, got %v", err)
+	}
+}
+
+// fakeRedis is a minimal in-process Redis speaking RESP2, enough for
+// RedisBackend: strings with EXAT expiry, DEL, MGET, lexically ranged sorted
+// sets, SCAN with a trailing-* MATCH and MULTI/EXEC.
+type fakeRedis struct {
+	addr string
+
+	mu      sync.Mutex
+	strs    map[string]string
+	expires map[string]time.Time
+	zsets   map[string]map[string]bool
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeRedis{
+		addr:    ln.Addr().String(),
+		strs:    make(map[string]string),
+		expires: make(map[string]time.Time),
+		zsets:   make(map[string]map[string]bool),
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readRESP(r)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "MULTI":
+			inMulti, queued = true, nil
+			reply = "+OK\r\n"
+		case cmd == "EXEC":
+			reply = fmt.Sprintf("*%d\r\n", len(queued))
+			for _, q := range queued {
+				reply += f.exec(q)
+			}
+			inMulti, queued = false, nil
+		case inMulti:
+			queued = append(queued, args)
+			reply = "+QUEUED\r\n"
+		default:
+			reply = f.exec(args)
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "SELECT", "CLIENT":
+		return "+OK\r\n"
+	case "SET":
+		f.strs[args[1]] = args[2]
+		delete(f.expires, args[1])
+		for i := 3; i+1 < len(args); i++ {
+			if strings.EqualFold(args[i], "EXAT") {
+				sec, _ := strconv.ParseInt(args[i+1], 10, 64)
+				f.expires[args[1]] = time.Unix(sec, 0)
+			}
+		}
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.getLocked(args[1])
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "MGET":
+		out := fmt.Sprintf("*%d\r\n", len(args)-1)
+		for _, k := range args[1:] {
+			if v, ok := f.getLocked(k); ok {
+				out += bulk(v)
+			} else {
+				out += "$-1\r\n"
+			}
+		}
+		return out
+	case "DEL":
+		n := 0
+		for _, k := range args[1:] {
+			if _, ok := f.getLocked(k); ok {
+				n++
+			}
+			delete(f.strs, k)
+			delete(f.zsets, k)
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "ZADD":
+		z := f.zsets[args[1]]
+		if z == nil {
+			z = make(map[string]bool)
+			f.zsets[args[1]] = z
+		}
+		n := 0
+		for i := 3; i < len(args); i += 2 {
+			if !z[args[i]] {
+				z[args[i]] = true
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "ZREM":
+		n := 0
+		for _, m := range args[2:] {
+			if f.zsets[args[1]][m] {
+				delete(f.zsets[args[1]], m)
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "ZRANGEBYLEX":
+		var members []string
+		for m := range f.zsets[args[1]] {
+			if lexAbove(m, args[2]) && lexBelow(m, args[3]) {
+				members = append(members, m)
+			}
+		}
+		sort.Strings(members)
+		if len(args) == 7 && strings.EqualFold(args[4], "LIMIT") {
+			off, _ := strconv.Atoi(args[5])
+			count, _ := strconv.Atoi(args[6])
+			members = members[min(off, len(members)):]
+			if count >= 0 && count < len(members) {
+				members = members[:count]
+			}
+		}
+		return array(members)
+	case "SCAN":
+		prefix := ""
+		for i := 2; i+1 < len(args); i++ {
+			if strings.EqualFold(args[i], "MATCH") {
+				prefix = strings.TrimSuffix(args[i+1], "*")
+				prefix = strings.NewReplacer(`\*`, "*", `\?`, "?", `\[`, "[", `\]`, "]", `\\`, `\`).Replace(prefix)
+			}
+		}
+		var keys []string
+		for k := range f.strs {
+			if _, ok := f.getLocked(k); ok && strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		for k := range f.zsets {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		return "*2\r\n" + bulk("0") + array(keys)
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+// getLocked returns a string value, dropping it once its expiry passes.
+// Caller must hold mu.
+func (f *fakeRedis) getLocked(key string) (string, bool) {
+	if at, ok := f.expires[key]; ok && !time.Now().Before(at) {
+		delete(f.strs, key)
+		delete(f.expires, key)
+	}
+	v, ok := f.strs[key]
+	return v, ok
+}
+
+func lexAbove(m, bound string) bool {
+	switch {
+	case bound == "-":
+		return true
+	case strings.HasPrefix(bound, "["):
+		return m >= bound[1:]
+	default:
+		return m > bound[1:]
+	}
+}
+
+func lexBelow(m, bound string) bool {
+	switch {
+	case bound == "+":
+		return true
+	case strings.HasPrefix(bound, "["):
+		return m <= bound[1:]
+	default:
+		return m < bound[1:]
+	}
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func array(items []string) string {
+	out := fmt.Sprintf("*%d\r\n", len(items))
+	for _, s := range items {
+		out += bulk(s)
+	}
+	return out
+}
+
+// readRESP reads one command sent as an array of bulk strings.
+func readRESP(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		hdr, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(hdr[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,41 @@
+// Package storage defines a minimal key-value Backend so deployments can
+// store facts somewhere other than pathstore. Store adapts any Backend to
+// the pathstore.Store interface the pipeline and HTTP handlers use.
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Backend.Get and Backend.Delete when a key does
+// not exist.
+var ErrNotFound = errors.New("storage: key not found")
+
+// Item is one key and its value from a prefix listing.
+type Item struct {
+	Key   string
+	Value any
+}
+
+// Backend is a key-value store with prefix listing. Keys use slash-separated
+// paths such as "memory/users/u1/topics/go/01ABC".
+type Backend interface {
+	Put(ctx context.Context, key string, value any) error
+	// Get returns ErrNotFound if key does not exist.
+	Get(ctx context.Context, key string) (any, error)
+	// Delete returns ErrNotFound if key does not exist.
+	Delete(ctx context.Context, key string) error
+	// List returns items whose key starts with prefix in lexical key order,
+	// at most limit of them when limit is positive.
+	List(ctx context.Context, prefix string, limit int) ([]Item, error)
+}
+
+// ExpiringBackend is a Backend that can drop a key at a given time. Store
+// writes nodes with an expiry through it so they free their space; on other
+// backends expired nodes stay stored but Store no longer returns them.
+type ExpiringBackend interface {
+	Backend
+	PutExpiring(ctx context.Context, key string, value any, at time.Time) error
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// linkPrefix is where Store keeps links, outside any document or user path.
+const linkPrefix = "_links/"
+
+// Store implements pathstore.Store on top of a Backend so the pipeline and
+// HTTP handlers can run without a pathstore server. Keys are stored in
+// slash form and reported in the dotted form pathstore returns. Merge modes
+// are not applied: every PutNode replaces the node. A node whose ExpiresAt
+// has passed reads as missing.
+type Store struct {
+	backend Backend
+}
+
+var _ pathstore.Store = (*Store)(nil)
+
+// NewStore wraps b.
+func NewStore(b Backend) *Store {
+	return &Store{backend: b}
+}
+
+// nodeRecord is what Store writes to the backend for each node.
+type nodeRecord struct {
+	Value      any      `json:"value"`
+	MemoryType string   `json:"memory_type,omitempty"`
+	Salience   float64  `json:"salience,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	Supersedes []string `json:"supersedes,omitempty"`
+}
+
+// expired reports whether rec carries an ExpiresAt at or before now.
+func (rec nodeRecord) expired(now time.Time) bool {
+	if rec.ExpiresAt == "" {
+		return false
+	}
+	at, err := time.Parse(time.RFC3339, rec.ExpiresAt)
+	return err == nil && !now.Before(at)
+}
+
+// decodeRecord converts a value read back from the backend into a record.
+// Backends that decode JSON return generic maps, so round-trip through JSON.
+func decodeRecord(v any) (nodeRecord, error) {
+	if rec, ok := v.(nodeRecord); ok {
+		return rec, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nodeRecord{}, err
+	}
+	var rec nodeRecord
+	err = json.Unmarshal(data, &rec)
+	return rec, err
+}
+
+func normalizeKey(key string) string {
+	return strings.Trim(strings.ReplaceAll(key, ".", "/"), "/")
+}
+
+func dottedKey(key string) string {
+	return strings.ReplaceAll(key, "/", ".")
+}
+
+func (s *Store) PutNode(ctx context.Context, key string, req pathstore.NodeRequest) error {
+	rec := nodeRecord{
+		Value:      req.Value,
+		MemoryType: req.MemoryType,
+		Salience:   req.Salience,
+		Source:     req.Source,
+		ExpiresAt:  req.ExpiresAt,
+		Supersedes: req.Supersedes,
+	}
+	if eb, ok := s.backend.(ExpiringBackend); ok && req.ExpiresAt != "" {
+		if at, err := time.Parse(time.RFC3339, req.ExpiresAt); err == nil {
+			return eb.PutExpiring(ctx, normalizeKey(key), rec, at)
+		}
+	}
+	return s.backend.Put(ctx, normalizeKey(key), rec)
+}
+
+func (s *Store) GetNode(ctx context.Context, key string) (*pathstore.NodeResponse, error) {
+	k := normalizeKey(key)
+	v, err := s.backend.Get(ctx, k)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec, err := decodeRecord(v)
+	if err != nil {
+		return nil, fmt.Errorf("decode node %s: %w", key, err)
+	}
+	if rec.expired(time.Now()) {
+		return nil, nil
+	}
+	return &pathstore.NodeResponse{
+		Key:        dottedKey(k),
+		Value:      rec.Value,
+		MemoryType: rec.MemoryType,
+		Salience:   rec.Salience,
+	}, nil
+}
+
+// DeleteNode returns an error wrapping ErrNotFound when there is nothing to
+// delete: no node at key, and with recursive no node below it either.
+func (s *Store) DeleteNode(ctx context.Context, key string, recursive bool) error {
+	k := normalizeKey(key)
+	err := s.backend.Delete(ctx, k)
+	if err != nil && !(recursive && errors.Is(err, ErrNotFound)) {
+		return fmt.Errorf("delete node %s: %w", key, err)
+	}
+	if !recursive {
+		return nil
+	}
+	children, listErr := s.backend.List(ctx, k+"/", 0)
+	if listErr != nil {
+		return listErr
+	}
+	if err != nil && len(children) == 0 {
+		return fmt.Errorf("delete node %s: %w", key, err)
+	}
+	for _, c := range children {
+		if err := s.backend.Delete(ctx, c.Key); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListChildren(ctx context.Context, key string, limit int) ([]pathstore.ListChildrenResponse, error) {
+	nodes, _, err := s.ListChildrenWithCursor(ctx, key, limit, "")
+	return nodes, err
+}
+
+// ListChildrenWithCursor returns descendants of key in lexical order,
+// leaving out expired nodes. The cursor is the decimal offset of the next
+// page.
+func (s *Store) ListChildrenWithCursor(ctx context.Context, key string, limit int, cursor string) ([]pathstore.ListChildrenResponse, string, error) {
+	offset, _ := strconv.Atoi(cursor)
+	fetch := 0
+	if limit > 0 {
+		fetch = offset + limit + 1 // one extra to tell whether a next page exists
+	}
+	out, err := s.listLive(ctx, normalizeKey(key)+"/", fetch)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if offset > len(out) {
+		offset = len(out)
+	}
+	out = out[offset:]
+	next := ""
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+		next = strconv.Itoa(offset + limit)
+	}
+	return out, next, nil
+}
+
+// listLive lists up to limit unexpired nodes under prefix. Expired nodes
+// count against the backend's limit, so when any are dropped from a full
+// page the listing is repeated without one.
+func (s *Store) listLive(ctx context.Context, prefix string, limit int) ([]pathstore.ListChildrenResponse, error) {
+	items, err := s.backend.List(ctx, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]pathstore.ListChildrenResponse, 0, len(items))
+	for _, it := range items {
+		rec, err := decodeRecord(it.Value)
+		if err != nil {
+			return nil, fmt.Errorf("decode node %s: %w", it.Key, err)
+		}
+		if rec.expired(now) {
+			continue
+		}
+		out = append(out, pathstore.ListChildrenResponse{Key: dottedKey(it.Key), Value: rec.Value})
+	}
+	if limit > 0 && len(items) == limit && len(out) < len(items) {
+		all, err := s.listLive(ctx, prefix, 0)
+		if err != nil {
+			return nil, err
+		}
+		out = all[:min(limit, len(all))]
+	}
+	return out, nil
+}
+
+func (s *Store) ListAll(ctx context.Context, key string) ([]pathstore.ListChildrenResponse, error) {
+	return s.ListChildren(ctx, key, 0)
+}
+
+// PutLink stores the link under a key derived from both endpoints, so
+// writing the same edge twice updates it.
+func (s *Store) PutLink(ctx context.Context, req pathstore.LinkRequest) error {
+	key := linkPrefix + url.PathEscape(normalizeKey(req.From)) + "/" + url.PathEscape(normalizeKey(req.To))
+	return s.backend.Put(ctx, key, req)
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// memBackend is an in-memory Backend that stores JSON like RedisBackend.
+type memBackend struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{data: make(map[string][]byte)}
+}
+
+func (m *memBackend) Put(ctx context.Context, key string, value any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = b
+	return nil
+}
+
+func (m *memBackend) Get(ctx context.Context, key string) (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var v any
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+func (m *memBackend) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.data[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memBackend) List(ctx context.Context, prefix string, limit int) ([]Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for k := range m.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	var items []Item
+	for _, k := range keys {
+		var v any
+		json.Unmarshal(m.data[k], &v)
+		items = append(items, Item{Key: k, Value: v})
+	}
+	return items, nil
+}
+
+func TestStore_PutGetNode(t *testing.T) {
+	s := NewStore(newMemBackend())
+	ctx := context.Background()
+
+	err := s.PutNode(ctx, "memory/users/u1/topics/go/01A", pathstore.NodeRequest{
+		Value:      map[string]any{"text": "Go has channels."},
+		MemoryType: "semantic",
+		Salience:   0.6,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	node, err := s.GetNode(ctx, "memory.users.u1.topics.go.01A")
+	if err != nil || node == nil {
+		t.Fatalf("expected node, got %v, %v", node, err)
+	}
+	if node.Key != "memory.users.u1.topics.go.01A" {
+		t.Errorf("expected dotted key, got %q", node.Key)
+	}
+	if node.MemoryType != "semantic" || node.Salience != 0.6 {
+		t.Errorf("expected semantic/0.6, got %s/%v", node.MemoryType, node.Salience)
+	}
+	if v, _ := node.Value.(map[string]any); v["text"] != "Go has channels." {
+		t.Errorf("unexpected value: %v", node.Value)
+	}
+
+	missing, err := s.GetNode(ctx, "memory/users/u1/nope")
+	if err != nil || missing != nil {
+		t.Errorf("expected nil node for missing key, got %v, %v", missing, err)
+	}
+}
+
+func TestStore_ListChildrenWithCursor(t *testing.T) {
+	s := NewStore(newMemBackend())
+	ctx := context.Background()
+	for _, k := range []string{"d/facts/3", "d/facts/1", "d/facts/2", "d/meta", "other/x"} {
+		s.PutNode(ctx, k, pathstore.NodeRequest{Value: k})
+	}
+
+	page, next, err := s.ListChildrenWithCursor(ctx, "d/facts", 2, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page) != 2 || page[0].Key != "d.facts.1" || page[1].Key != "d.facts.2" || next != "2" {
+		t.Fatalf("unexpected first page %+v, next %q", page, next)
+	}
+	page, next, _ = s.ListChildrenWithCursor(ctx, "d/facts", 2, next)
+	if len(page) != 1 || page[0].Value != "d/facts/3" || next != "" {
+		t.Errorf("unexpected last page %+v, next %q", page, next)
+	}
+
+	all, _ := s.ListAll(ctx, "d")
+	if len(all) != 4 {
+		t.Errorf("expected 4 descendants of d, got %d", len(all))
+	}
+}
+
+func TestStore_DeleteNodeRecursive(t *testing.T) {
+	s := NewStore(newMemBackend())
+	ctx := context.Background()
+	for _, k := range []string{"d", "d/facts/1", "d/meta", "dx/keep"} {
+		s.PutNode(ctx, k, pathstore.NodeRequest{Value: k})
+	}
+
+	if err := s.DeleteNode(ctx, "d", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, k := range []string{"d", "d/facts/1", "d/meta"} {
+		if n, _ := s.GetNode(ctx, k); n != nil {
+			t.Errorf("expected %s deleted", k)
+		}
+	}
+	if n, _ := s.GetNode(ctx, "dx/keep"); n == nil {
+		t.Error("expected sibling with shared prefix to survive")
+	}
+}
+
+func TestStore_DeleteNodeMissing(t *testing.T) {
+	s := NewStore(newMemBackend())
+	ctx := context.Background()
+	s.PutNode(ctx, "d/facts/1", pathstore.NodeRequest{Value: "f"})
+
+	if err := s.DeleteNode(ctx, "d/facts/2", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing node, got %v", err)
+	}
+	// d itself was never written, but it has descendants to delete.
+	if err := s.DeleteNode(ctx, "d", true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := s.DeleteNode(ctx, "d", true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound once nothing is left, got %v", err)
+	}
+}
+
+func TestStore_HidesExpiredNodes(t *testing.T) {
+	s := NewStore(newMemBackend())
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	s.PutNode(ctx, "d/a", pathstore.NodeRequest{Value: "a", ExpiresAt: past})
+	s.PutNode(ctx, "d/b", pathstore.NodeRequest{Value: "b", ExpiresAt: future})
+	s.PutNode(ctx, "d/c", pathstore.NodeRequest{Value: "c"})
+
+	if n, _ := s.GetNode(ctx, "d/a"); n != nil {
+		t.Errorf("expected expired node to read as missing, got %+v", n)
+	}
+	if n, _ := s.GetNode(ctx, "d/b"); n == nil {
+		t.Error("expected unexpired node to be readable")
+	}
+	page, next, err := s.ListChildrenWithCursor(ctx, "d", 2, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page) != 2 || page[0].Key != "d.b" || page[1].Key != "d.c" || next != "" {
+		t.Errorf("expected live nodes d.b and d.c on one page, got %+v next %q", page, next)
+	}
+}
+
+func TestStore_PutLink(t *testing.T) {
+	b := newMemBackend()
+	s := NewStore(b)
+	ctx := context.Background()
+	link := pathstore.LinkRequest{From: "a/b", To: "c/d", Weight: 0.3, Summary: "shared topic: go"}
+	s.PutLink(ctx, link)
+	s.PutLink(ctx, link)
+
+	items, _ := b.List(ctx, linkPrefix, 0)
+	if len(items) != 1 {
+		t.Fatalf("expected rewriting a link to update it, got %d items", len(items))
+	}
+	if v, _ := items[0].Value.(map[string]any); v["summary"] != "shared topic: go" {
+		t.Errorf("unexpected link value: %v", items[0].Value)
+	}
+}
@@ -0,0 +1,34 @@
+package testutil
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// goroutineSettleTimeout is how long AssertNoGoroutineLeak waits for
+// goroutines started by fn to exit.
+const goroutineSettleTimeout = 5 * time.Second
+
+// AssertNoGoroutineLeak runs fn and fails t if the goroutine count has not
+// returned to its starting level within five seconds of fn returning. fn
+// should stop everything it starts, e.g. by calling Orchestrator.Stop.
+// Tests using it must not run in parallel, since other tests' goroutines
+// would be counted too.
+func AssertNoGoroutineLeak(t *testing.T, fn func()) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	fn()
+
+	deadline := time.Now().Add(goroutineSettleTimeout)
+	after := runtime.NumGoroutine()
+	for after > before && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		after = runtime.NumGoroutine()
+	}
+	if after > before {
+		buf := make([]byte, 1<<20)
+		buf = buf[:runtime.Stack(buf, true)]
+		t.Errorf("goroutine leak: %d goroutines before, %d after\n%s", before, after, buf)
+	}
+}
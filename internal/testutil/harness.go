@@ -0,0 +1,252 @@
+// Package testutil provides an end-to-end test harness that runs the real
+// HTTP API and pipeline against in-memory pathstore and extractor fakes.
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/api"
+	"github.com/dgallion1/docgest/internal/config"
+	"github.com/dgallion1/docgest/internal/extract"
+	"github.com/dgallion1/docgest/internal/pipeline"
+)
+
+// APIKey is the docgest API key the harness server accepts.
+const APIKey = "test-api-key"
+
+// Harness is a running docgest server backed by mocks.
+type Harness struct {
+	t *testing.T
+
+	Server    *httptest.Server
+	Client    *http.Client // Sends APIKey on every request.
+	Pathstore *MockPathstoreClient
+	Extractor *MockExtractor
+	// Claude backs the LLM stats and audit endpoints only; it never calls
+	// the API, since extraction goes through Extractor.
+	Claude   *extract.ClaudeClient
+	LogLevel *slog.LevelVar // Adjusted by POST /api/admin/log-level.
+
+	mu               sync.Mutex
+	deprecatedModels []string
+}
+
+// DefaultFacts is what the harness extractor returns for each chunk.
+var DefaultFacts = []extract.Fact{
+	{Text: "Milo is a golden retriever.", Category: "entity_fact", Entity: "Milo", Salience: 0.7},
+	{Text: "Go channels synchronize goroutines.", Category: "topic_knowledge", Topics: []string{"go"}, Salience: 0.5},
+}
+
+// TestConfig returns a small, deterministic config for harness servers.
+func TestConfig() config.Config {
+	return config.Config{
+		DocgestAPIKey:        APIKey,
+		WorkerCount:          1,
+		MinWorkerCount:       1,
+		MaxWorkerCount:       1,
+		MaxQueueSize:         16,
+		MaxConcurrentExtract: 2,
+		MaxConcurrentStore:   4,
+		MaxUploadBytes:       1 << 20,
+		DefaultChunkSize:     1000,
+		DefaultChunkOverlap:  100,
+		JobTTL:               time.Hour,
+		MaxJobStoreSize:      1000,
+		SoftDeleteTTL:        time.Hour,
+	}
+}
+
+// NewTestHarness starts a server on a random port. It is torn down via
+// t.Cleanup.
+func NewTestHarness(t *testing.T) *Harness {
+	t.Helper()
+	return NewTestHarnessWithConfig(t, TestConfig())
+}
+
+// NewTestHarnessWithConfig is NewTestHarness with a caller-supplied config,
+// usually TestConfig with a few fields changed.
+func NewTestHarnessWithConfig(t *testing.T, cfg config.Config) *Harness {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ps := NewMockPathstoreClient()
+	ex := NewMockExtractor(DefaultFacts...)
+
+	h := &Harness{
+		t:         t,
+		Client:    &http.Client{Transport: authTransport{key: APIKey}, Timeout: 10 * time.Second},
+		Pathstore: ps,
+		Extractor: ex,
+		Claude:    extract.NewClaudeClient("test-anthropic-key", "test-model"),
+		LogLevel:  new(slog.LevelVar),
+	}
+
+	orch := pipeline.NewOrchestrator(cfg, ex, ps, log)
+	orch.SetOnModelDeprecated(h.recordDeprecatedModel)
+	ctx, cancel := context.WithCancel(context.Background())
+	orch.Start(ctx)
+
+	srv := api.NewServer(orch, h.Claude, log, cfg)
+	srv.SetLogLevel(h.LogLevel)
+	h.Server = httptest.NewServer(srv)
+	t.Cleanup(func() {
+		h.Server.Close()
+		cancel()
+		orch.Stop()
+	})
+	return h
+}
+
+func (h *Harness) recordDeprecatedModel(model string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.deprecatedModels = append(h.deprecatedModels, model)
+}
+
+// DeprecatedModels lists the models reported to the orchestrator's
+// model-deprecated hook, one entry per failed job.
+func (h *Harness) DeprecatedModels() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.deprecatedModels...)
+}
+
+type authTransport struct {
+	key string
+}
+
+func (a authTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+a.key)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// File is one upload in a multipart request.
+type File struct {
+	Name string
+	Data []byte
+
+	// ContentType is sent as the part's Content-Type; empty uses the
+	// multipart default, application/octet-stream.
+	ContentType string
+}
+
+// Do sends a request and decodes the JSON response body into a map.
+func (h *Harness) Do(req *http.Request) (int, map[string]any) {
+	h.t.Helper()
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && err != io.EOF {
+		h.t.Fatalf("%s %s: decode response: %v", req.Method, req.URL.Path, err)
+	}
+	return resp.StatusCode, body
+}
+
+// Get issues a GET against the harness server.
+func (h *Harness) Get(path string) (int, map[string]any) {
+	h.t.Helper()
+	req, err := http.NewRequest(http.MethodGet, h.Server.URL+path, nil)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return h.Do(req)
+}
+
+// Delete issues a DELETE against the harness server.
+func (h *Harness) Delete(path string) (int, map[string]any) {
+	h.t.Helper()
+	req, err := http.NewRequest(http.MethodDelete, h.Server.URL+path, nil)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return h.Do(req)
+}
+
+// PostFiles sends a multipart form with fields and files under fileField.
+func (h *Harness) PostFiles(path string, fields map[string]string, fileField string, files ...File) (int, map[string]any) {
+	h.t.Helper()
+	return h.Do(h.FilesRequest(path, fields, fileField, files...))
+}
+
+// FilesRequest builds the POST PostFiles sends, for tests that need the
+// raw response.
+func (h *Harness) FilesRequest(path string, fields map[string]string, fileField string, files ...File) *http.Request {
+	h.t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	for _, f := range files {
+		fw, err := createFilePart(mw, fileField, f)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		fw.Write(f.Data)
+	}
+	mw.Close()
+
+	req, err := http.NewRequest(http.MethodPost, h.Server.URL+path, &buf)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// createFilePart starts a file part, using f.ContentType when set.
+func createFilePart(mw *multipart.Writer, field string, f File) (io.Writer, error) {
+	if f.ContentType == "" {
+		return mw.CreateFormFile(field, f.Name)
+	}
+	hdr := make(textproto.MIMEHeader)
+	hdr.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": field, "filename": f.Name}))
+	hdr.Set("Content-Type", f.ContentType)
+	return mw.CreatePart(hdr)
+}
+
+// Ingest uploads one file for userID and returns the job ID.
+func (h *Harness) Ingest(userID string, f File) string {
+	h.t.Helper()
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": userID}, "file", f)
+	if code != http.StatusAccepted {
+		h.t.Fatalf("ingest %s: expected 202, got %d: %v", f.Name, code, body)
+	}
+	jobID, _ := body["job_id"].(string)
+	return jobID
+}
+
+// WaitForJob polls the status endpoint until the job is terminal and
+// returns the final status body.
+func (h *Harness) WaitForJob(jobID string) map[string]any {
+	h.t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		code, body := h.Get("/api/ingest/" + jobID + "/status")
+		if code != http.StatusOK {
+			h.t.Fatalf("status %s: expected 200, got %d", jobID, code)
+		}
+		if status, _ := body["status"].(string); pipeline.JobStatus(status).Terminal() {
+			return body
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.t.Fatalf("job %s did not finish", jobID)
+	return nil
+}
@@ -0,0 +1,1514 @@
+package testutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgallion1/docgest/internal/api"
+	"github.com/dgallion1/docgest/internal/extract"
+	"github.com/dgallion1/docgest/internal/pathstore"
+	"github.com/dgallion1/docgest/internal/pipeline"
+)
+
+// sampleMarkdown has two sections, each long enough to form its own chunk.
+var sampleMarkdown = "# Pets\n\n" +
+	strings.Repeat("Milo is a golden retriever who lives with the Smith family and loves to play fetch in the park. ", 8) +
+	"\n\n## Programming\n\n" +
+	strings.Repeat("Go channels are used to synchronize goroutines and pass values between them safely. ", 8) +
+	"\n"
+
+func TestHarness_IngestAndPoll(t *testing.T) {
+	h := NewTestHarness(t)
+
+	jobID := h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	status := h.WaitForJob(jobID)
+
+	if status["status"] != string(pipeline.StatusCompleted) {
+		t.Fatalf("expected status %q, got %v (%v)", pipeline.StatusCompleted, status["status"], status["progress"])
+	}
+	if h.Extractor.Calls() == 0 {
+		t.Error("expected the extractor to be called")
+	}
+	if status["percent_complete"] != 100.0 {
+		t.Errorf("expected 100 percent complete, got %v", status["percent_complete"])
+	}
+	if desc, _ := status["description"].(string); desc == "" {
+		t.Error("expected a status description")
+	}
+	docID, _ := status["doc_id"].(string)
+	if len(h.Pathstore.Keys("memory/users/u1/documents/"+docID+"/meta")) != 1 {
+		t.Error("expected document meta to be written")
+	}
+	if len(h.Pathstore.Keys("memory/users/u1/entities/milo/facts")) == 0 {
+		t.Error("expected entity facts to be stored")
+	}
+
+	code, body := h.Get("/api/documents?user_id=u1")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 listing documents, got %d", code)
+	}
+	if docs, _ := body["documents"].([]any); len(docs) != 1 {
+		t.Errorf("expected 1 document listed, got %d", len(docs))
+	}
+}
+
+func TestHarness_BatchMixedFiles(t *testing.T) {
+	h := NewTestHarness(t)
+
+	code, body := h.PostFiles("/api/ingest/batch", map[string]string{"user_id": "u1"}, "files",
+		File{Name: "a.md", Data: []byte(sampleMarkdown)},
+		File{Name: "b.txt", Data: []byte(strings.ReplaceAll(sampleMarkdown, "Milo", "Rex"))},
+		File{Name: "c.exe", Data: []byte("MZ")},
+	)
+	if code != http.StatusAccepted && code != http.StatusOK {
+		t.Fatalf("expected batch to be accepted, got %d: %v", code, body)
+	}
+
+	results, _ := body["jobs"].([]any)
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	queued := 0
+	for _, r := range results {
+		res := r.(map[string]any)
+		if jobID, ok := res["job_id"].(string); ok {
+			queued++
+			if st := h.WaitForJob(jobID)["status"]; st != string(pipeline.StatusCompleted) {
+				t.Errorf("%v: expected completed, got %v", res["filename"], st)
+			}
+			continue
+		}
+		if res["filename"] != "c.exe" {
+			t.Errorf("expected only c.exe to be rejected, got error for %v", res["filename"])
+		}
+	}
+	if queued != 2 {
+		t.Errorf("expected 2 queued jobs, got %d", queued)
+	}
+}
+
+func TestHarness_DeleteAfterIngest(t *testing.T) {
+	h := NewTestHarness(t)
+
+	jobID := h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	docID, _ := h.WaitForJob(jobID)["doc_id"].(string)
+
+	// A fact the manifest never recorded must still be cleaned up.
+	h.Pathstore.PutNode(context.Background(), "memory/users/u1/entities/orphan/facts/01X", pathstore.NodeRequest{
+		Value: map[string]any{"text": "orphan", "source": map[string]any{"type": "document", "doc_id": docID}},
+	})
+
+	code, body := h.Delete(fmt.Sprintf("/api/documents/%s?user_id=u1", docID))
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202 queueing delete, got %d: %v", code, body)
+	}
+	deleteJobID, _ := body["deletion_job_id"].(string)
+	if body["status"] != string(pipeline.StatusQueued) || deleteJobID == "" {
+		t.Fatalf("expected queued deletion job, got %v", body)
+	}
+
+	status := h.WaitForJob(deleteJobID)
+	if status["status"] != string(pipeline.StatusCompleted) || status["type"] != string(pipeline.JobTypeDelete) {
+		t.Fatalf("expected completed delete job, got %v", status)
+	}
+	result, _ := status["progress"].(map[string]any)["delete"].(map[string]any)
+	if n, _ := result["facts_deleted"].(float64); n == 0 {
+		t.Error("expected facts to be deleted")
+	}
+	if n, _ := result["orphans_deleted"].(float64); n != 1 {
+		t.Errorf("expected 1 orphan deleted, got %v", result["orphans_deleted"])
+	}
+	if keys := h.Pathstore.Keys("memory/users/u1/documents"); len(keys) != 0 {
+		t.Errorf("expected no document nodes after delete, got %v", keys)
+	}
+	if keys := h.Pathstore.Keys("memory/users/u1/entities"); len(keys) != 0 {
+		t.Errorf("expected no fact nodes after delete, got %v", keys)
+	}
+}
+
+func TestHarness_ContentHashHeaders(t *testing.T) {
+	h := NewTestHarness(t)
+	ingest := func() (http.Header, string) {
+		t.Helper()
+		req := h.FilesRequest("/api/ingest", map[string]string{"user_id": "u1"}, "file", File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+		resp, err := h.Client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d", resp.StatusCode)
+		}
+		var body struct {
+			JobID string `json:"job_id"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.Header, body.JobID
+	}
+
+	want := pipeline.ContentHashHex([]byte(sampleMarkdown))
+	first, jobID := ingest()
+	if got := first.Get(api.ContentHashHeader); got != want {
+		t.Errorf("expected content hash %s, got %q", want, got)
+	}
+	if got := first.Get(api.ExistingDocIDHeader); got != "" {
+		t.Errorf("expected no existing doc on first upload, got %q", got)
+	}
+	h.WaitForJob(jobID)
+
+	second, _ := ingest()
+	if got := second.Get(api.ContentHashHeader); got != want {
+		t.Errorf("expected content hash %s, got %q", want, got)
+	}
+	if got := second.Get(api.ExistingDocIDHeader); got != want[:16] {
+		t.Errorf("expected existing doc %s, got %q", want[:16], got)
+	}
+}
+
+// factKeys returns the user's stored fact keys, leaving out document
+// meta, manifests and archives.
+func factKeys(h *Harness, userID string) []string {
+	var keys []string
+	for _, k := range h.Pathstore.Keys("memory/users/" + userID) {
+		if !strings.HasPrefix(k, "memory/users/"+userID+"/documents/") {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func TestHarness_SoftDeleteAndRestore(t *testing.T) {
+	h := NewTestHarness(t)
+	docID := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))["doc_id"].(string)
+	facts := factKeys(h, "u1")
+	if len(facts) == 0 {
+		t.Fatal("expected stored facts")
+	}
+
+	code, body := h.Delete("/api/documents/" + docID + "?user_id=u1&soft=true")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 from soft delete, got %d %v", code, body)
+	}
+	if n, _ := body["facts_archived"].(float64); int(n) != len(facts) {
+		t.Errorf("expected %d facts archived, got %v", len(facts), body["facts_archived"])
+	}
+	if keys := factKeys(h, "u1"); len(keys) != 0 {
+		t.Errorf("expected no active facts after soft delete, got %v", keys)
+	}
+	_, list := h.Get("/api/documents?user_id=u1")
+	if docs, _ := list["documents"].([]any); len(docs) != 0 {
+		t.Errorf("expected the deleted document to be hidden, got %v", docs)
+	}
+
+	code, body = h.Get("/api/documents/" + docID + "/restore?user_id=u1")
+	if code != http.StatusOK || body["status"] != "restored" {
+		t.Fatalf("expected restored, got %d %v", code, body)
+	}
+	if got := factKeys(h, "u1"); !slices.Equal(got, facts) {
+		t.Errorf("expected facts %v restored, got %v", facts, got)
+	}
+	if keys := h.Pathstore.Keys("memory/users/u1/documents/" + docID + "/deleted_facts"); len(keys) != 0 {
+		t.Errorf("expected the archive to be emptied, got %v", keys)
+	}
+	if keys := h.Pathstore.Keys("memory/soft_deleted"); len(keys) != 0 {
+		t.Errorf("expected the document out of the sweep index, got %v", keys)
+	}
+	if code, _ := h.Get("/api/documents/" + docID + "/restore?user_id=u1"); code != http.StatusConflict {
+		t.Errorf("expected 409 restoring a live document, got %d", code)
+	}
+}
+
+func TestHarness_RestorePartialFailure(t *testing.T) {
+	h := NewTestHarness(t)
+	docID := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))["doc_id"].(string)
+	facts := factKeys(h, "u1")
+	if code, body := h.Delete("/api/documents/" + docID + "?user_id=u1&soft=true"); code != http.StatusOK {
+		t.Fatalf("expected 200 from soft delete, got %d %v", code, body)
+	}
+
+	h.Pathstore.FailPuts(facts[0], errors.New("disk full"))
+	code, body := h.Get("/api/documents/" + docID + "/restore?user_id=u1")
+	if code != http.StatusOK || body["status"] != "partial" || body["failed"] != 1.0 {
+		t.Fatalf("expected partial restore with 1 failure, got %d %v", code, body)
+	}
+	meta, _ := h.Pathstore.GetNode(context.Background(), "memory/users/u1/documents/"+docID+"/meta")
+	if value, _ := meta.Value.(map[string]any); value["deleted_at"] == nil {
+		t.Error("expected the document to stay deleted after a partial restore")
+	}
+
+	// A retry restores what is left.
+	h.Pathstore.FailPuts(facts[0], nil)
+	code, body = h.Get("/api/documents/" + docID + "/restore?user_id=u1")
+	if code != http.StatusOK || body["status"] != "restored" || body["facts_restored"] != 1.0 {
+		t.Fatalf("expected the remaining fact restored, got %d %v", code, body)
+	}
+	if got := factKeys(h, "u1"); !slices.Equal(got, facts) {
+		t.Errorf("expected facts %v restored, got %v", facts, got)
+	}
+}
+
+func TestHarness_RestoreAfterTTL(t *testing.T) {
+	h := NewTestHarness(t)
+	ctx := context.Background()
+	docID := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))["doc_id"].(string)
+	if code, body := h.Delete("/api/documents/" + docID + "?user_id=u1&soft=true"); code != http.StatusOK {
+		t.Fatalf("expected 200 from soft delete, got %d %v", code, body)
+	}
+
+	// Backdate the delete past the harness's one-hour TTL.
+	metaPath := "memory/users/u1/documents/" + docID + "/meta"
+	meta, _ := h.Pathstore.GetNode(ctx, metaPath)
+	value, _ := meta.Value.(map[string]any)
+	value["deleted_at"] = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	h.Pathstore.PutNode(ctx, metaPath, pathstore.NodeRequest{Value: value})
+
+	code, body := h.Get("/api/documents/" + docID + "/restore?user_id=u1")
+	if code != http.StatusGone || body["code"] != api.ErrCodeGone {
+		t.Fatalf("expected 410, got %d %v", code, body)
+	}
+	if keys := h.Pathstore.Keys("memory/users/u1/documents/" + docID); len(keys) != 0 {
+		t.Errorf("expected the document purged, got %v", keys)
+	}
+	if keys := h.Pathstore.Keys("memory/soft_deleted"); len(keys) != 0 {
+		t.Errorf("expected the document out of the sweep index, got %v", keys)
+	}
+}
+
+func TestHarness_SweepSoftDeleted(t *testing.T) {
+	h := NewTestHarness(t)
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ingest := func(name, content string) string {
+		t.Helper()
+		docID := h.WaitForJob(h.Ingest("u1", File{Name: name, Data: []byte(content)}))["doc_id"].(string)
+		if code, body := h.Delete("/api/documents/" + docID + "?user_id=u1&soft=true"); code != http.StatusOK {
+			t.Fatalf("expected 200 from soft delete, got %d %v", code, body)
+		}
+		return docID
+	}
+	deleted := ingest("a.md", sampleMarkdown)
+	restored := ingest("b.md", strings.ReplaceAll(sampleMarkdown, "Milo", "Rex"))
+	if code, body := h.Get("/api/documents/" + restored + "/restore?user_id=u1"); code != http.StatusOK {
+		t.Fatalf("expected 200 from restore, got %d %v", code, body)
+	}
+
+	if n, err := pipeline.SweepSoftDeleted(ctx, h.Pathstore, log, time.Now()); err != nil || n != 0 {
+		t.Fatalf("expected nothing purged before the TTL, got %d %v", n, err)
+	}
+	n, err := pipeline.SweepSoftDeleted(ctx, h.Pathstore, log, time.Now().Add(2*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 document purged, got %d %v", n, err)
+	}
+	if keys := h.Pathstore.Keys("memory/users/u1/documents/" + deleted); len(keys) != 0 {
+		t.Errorf("expected %s purged, got %v", deleted, keys)
+	}
+	if keys := h.Pathstore.Keys("memory/users/u1/documents/" + restored + "/meta"); len(keys) != 1 {
+		t.Errorf("expected restored document %s kept", restored)
+	}
+	if keys := h.Pathstore.Keys("memory/soft_deleted"); len(keys) != 0 {
+		t.Errorf("expected an empty sweep index, got %v", keys)
+	}
+}
+
+func TestHarness_RestoreRebuildsHashIndex(t *testing.T) {
+	h := NewTestHarness(t)
+	docID := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))["doc_id"].(string)
+	before := h.Pathstore.Keys("memory/users/u1/documents/by_hash")
+	if len(before) != 2 {
+		t.Fatalf("expected content and upload hash entries, got %v", before)
+	}
+
+	if code, body := h.Delete("/api/documents/" + docID + "?user_id=u1&soft=true"); code != http.StatusOK {
+		t.Fatalf("expected 200 from soft delete, got %d %v", code, body)
+	}
+	if keys := h.Pathstore.Keys("memory/users/u1/documents/by_hash"); len(keys) != 0 {
+		t.Errorf("expected no hash entries after soft delete, got %v", keys)
+	}
+	if code, body := h.Get("/api/documents/" + docID + "/restore?user_id=u1"); code != http.StatusOK {
+		t.Fatalf("expected 200 from restore, got %d %v", code, body)
+	}
+	after := h.Pathstore.Keys("memory/users/u1/documents/by_hash")
+	if len(after) != 2 || !slices.Contains(after, before[0]) || !slices.Contains(after, before[1]) {
+		t.Errorf("expected hash entries %v restored, got %v", before, after)
+	}
+
+	// Re-uploading the same bytes names the restored document.
+	req := h.FilesRequest("/api/ingest", map[string]string{"user_id": "u1"}, "file", File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get(api.ExistingDocIDHeader); got != docID {
+		t.Errorf("expected existing doc %s, got %q", docID, got)
+	}
+}
+
+func TestHarness_DuplicateDetection(t *testing.T) {
+	h := NewTestHarness(t)
+
+	first := h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if st := h.WaitForJob(first)["status"]; st != string(pipeline.StatusCompleted) {
+		t.Fatalf("expected first ingest to complete, got %v", st)
+	}
+	calls := h.Extractor.Calls()
+
+	second := h.Ingest("u1", File{Name: "copy.md", Data: []byte(sampleMarkdown)})
+	if st := h.WaitForJob(second)["status"]; st != string(pipeline.StatusDupSkipped) {
+		t.Errorf("expected duplicate to be skipped, got %v", st)
+	}
+	if h.Extractor.Calls() != calls {
+		t.Error("expected no extraction for a duplicate document")
+	}
+
+	// A different user does not share the dedup index.
+	other := h.Ingest("u2", File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if st := h.WaitForJob(other)["status"]; st != string(pipeline.StatusCompleted) {
+		t.Errorf("expected other user's ingest to complete, got %v", st)
+	}
+}
+
+func TestHarness_DocumentPaths(t *testing.T) {
+	h := NewTestHarness(t)
+
+	jobID := h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	docID, _ := h.WaitForJob(jobID)["doc_id"].(string)
+
+	code, body := h.Get(fmt.Sprintf("/api/documents/%s/paths?user_id=u1", docID))
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", code, body)
+	}
+	paths, _ := body["paths"].([]any)
+	if len(paths) == 0 {
+		t.Fatal("expected manifest paths")
+	}
+	if _, ok := body["missing"]; ok {
+		t.Error("expected no missing list without validate")
+	}
+
+	gone := paths[0].(string)
+	h.Pathstore.DeleteNode(context.Background(), gone, false)
+
+	_, body = h.Get(fmt.Sprintf("/api/documents/%s/paths?user_id=u1&validate=true", docID))
+	missing, _ := body["missing"].([]any)
+	if len(missing) != 1 || missing[0] != gone {
+		t.Errorf("expected missing [%s], got %v", gone, body["missing"])
+	}
+}
+
+func TestHarness_RejectsOversizedUpload(t *testing.T) {
+	h := NewTestHarness(t)
+	big := File{Name: "big.txt", Data: bytes.Repeat([]byte("x"), 3<<20)}
+
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1"}, "file", big)
+	if code != http.StatusRequestEntityTooLarge || body["code"] != api.ErrCodeFileTooLarge {
+		t.Errorf("expected 413 file_too_large from Content-Length, got %d %v", code, body)
+	}
+
+	// Without a Content-Length the body limit still applies while reading.
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("user_id", "u1")
+	fw, _ := mw.CreateFormFile("file", big.Name)
+	fw.Write(big.Data)
+	mw.Close()
+	req, _ := http.NewRequest(http.MethodPost, h.Server.URL+"/api/ingest", io.NopCloser(&buf))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	code, body = h.Do(req)
+	if code != http.StatusRequestEntityTooLarge || body["code"] != api.ErrCodeFileTooLarge {
+		t.Errorf("expected 413 file_too_large for chunked upload, got %d %v", code, body)
+	}
+}
+
+func TestHarness_PerExtensionUploadLimit(t *testing.T) {
+	cfg := TestConfig()
+	cfg.MaxUploadBytesByExtension = map[string]int64{".csv": 2048}
+	h := NewTestHarnessWithConfig(t, cfg)
+
+	csv := "name,role\n" + strings.Repeat("alice,engineer\n", 300)
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1"}, "file",
+		File{Name: "people.csv", Data: []byte(csv)})
+	if code != http.StatusRequestEntityTooLarge || body["code"] != api.ErrCodeFileTooLarge {
+		t.Errorf("expected 413 file_too_large for csv over its limit, got %d %v", code, body)
+	}
+
+	code, body = h.PostFiles("/api/ingest/batch", map[string]string{"user_id": "u1"}, "files",
+		File{Name: "people.csv", Data: []byte(csv)})
+	jobs, _ := body["jobs"].([]any)
+	if len(jobs) != 1 {
+		t.Fatalf("expected 1 batch result, got %d %v", code, body)
+	}
+	if res, _ := jobs[0].(map[string]any); res["code"] != api.ErrCodeFileTooLarge {
+		t.Errorf("expected batch csv over its limit to be rejected, got %d %v", code, body)
+	}
+
+	code, _ = h.PostFiles("/api/ingest", map[string]string{"user_id": "u1"}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusAccepted {
+		t.Errorf("expected markdown to use the global limit, got %d", code)
+	}
+}
+
+func TestHarness_CallbackURLValidation(t *testing.T) {
+	h := NewTestHarness(t)
+
+	for _, cb := range []string{"http://93.184.216.34/hook", "https://10.0.0.1/hook", "https://127.0.0.1/hook"} {
+		code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "callback_url": cb}, "file",
+			File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+		if code != http.StatusBadRequest || body["code"] != api.ErrCodeInvalidRequest {
+			t.Errorf("expected 400 invalid_request for callback_url %s, got %d %v", cb, code, body)
+		}
+	}
+}
+
+func TestHarness_ListDocumentsByTag(t *testing.T) {
+	h := NewTestHarness(t)
+	var docIDs []string
+	for _, pet := range []string{"Milo", "Rex", "Luna"} {
+		content := strings.ReplaceAll(sampleMarkdown, "Milo", pet)
+		docIDs = append(docIDs, h.WaitForJob(h.Ingest("u1", File{Name: pet + ".md", Data: []byte(content)}))["doc_id"].(string))
+	}
+	tag := func(docID string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, h.Server.URL+"/api/documents/"+docID+"/tags",
+			strings.NewReader(`{"user_id":"u1","tags":["project:alpha"]}`))
+		if code, body := h.Do(req); code != http.StatusOK {
+			t.Fatalf("expected 200 tagging %s, got %d %v", docID, code, body)
+		}
+	}
+	tag(docIDs[0])
+	tag(docIDs[2])
+
+	// Each page of one holds a match, although every document has many
+	// more nodes than its meta.
+	var listed []string
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > 2 {
+			t.Fatalf("expected 2 pages, got more (listed %v)", listed)
+		}
+		code, body := h.Get("/api/documents?user_id=u1&tag=project:alpha&limit=1&cursor=" + cursor)
+		if code != http.StatusOK {
+			t.Fatalf("expected 200, got %d %v", code, body)
+		}
+		docs, _ := body["documents"].([]any)
+		if len(docs) != 1 {
+			t.Fatalf("expected 1 document on page %d, got %v", page, docs)
+		}
+		key, _ := docs[0].(map[string]any)["key"].(string)
+		listed = append(listed, key)
+		if cursor, _ = body["next_cursor"].(string); cursor == "" {
+			break
+		}
+	}
+	for _, docID := range []string{docIDs[0], docIDs[2]} {
+		if !slices.ContainsFunc(listed, func(k string) bool { return strings.Contains(k, docID) }) {
+			t.Errorf("expected %s listed, got %v", docID, listed)
+		}
+	}
+
+	if code, _ := h.Delete("/api/documents/" + docIDs[0] + "/tags/project:alpha?user_id=u1"); code != http.StatusOK {
+		t.Fatalf("expected 200 removing tag, got %d", code)
+	}
+	_, body := h.Get("/api/documents?user_id=u1&tag=project:alpha")
+	if docs, _ := body["documents"].([]any); len(docs) != 1 {
+		t.Errorf("expected 1 tagged document after removal, got %v", docs)
+	}
+}
+
+func TestHarness_Webhooks(t *testing.T) {
+	h := NewTestHarness(t)
+
+	send := func(method, path, body string, header map[string]string) (int, map[string]any) {
+		req, _ := http.NewRequest(method, h.Server.URL+path, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		return h.Do(req)
+	}
+
+	code, reg := send(http.MethodPost, "/api/webhooks", `{"url": "https://93.184.216.34/hooks/docgest"}`, nil)
+	id, _ := reg["id"].(string)
+	secret, _ := reg["secret"].(string)
+	if code != http.StatusCreated || id == "" || secret == "" {
+		t.Fatalf("expected webhook registered, got %d %v", code, reg)
+	}
+	if len(h.Pathstore.Keys("memory/webhooks/"+id)) != 1 {
+		t.Error("expected registration stored under memory/webhooks")
+	}
+
+	payload := `{"job_id":"j1","status":"completed"}`
+	sig := pipeline.SignPayload(secret, []byte(payload))
+	_, body := send(http.MethodPost, "/api/webhooks/verify?webhook_id="+id, payload, map[string]string{pipeline.SignatureHeader: sig})
+	if body["valid"] != true {
+		t.Errorf("expected valid signature, got %v", body)
+	}
+	_, body = send(http.MethodPost, "/api/webhooks/verify?webhook_id="+id, payload+" ", map[string]string{pipeline.SignatureHeader: sig})
+	if body["valid"] != false {
+		t.Errorf("expected tampered body to fail verification, got %v", body)
+	}
+
+	code, body = send(http.MethodPut, "/api/webhooks/"+id+"/renew", "", nil)
+	if code != http.StatusOK || body["expires_at"] == nil {
+		t.Errorf("expected renewal, got %d %v", code, body)
+	}
+
+	expired := pipeline.NewWebhookRegistration("https://93.184.216.34/old", time.Now().Add(-pipeline.WebhookTTL-time.Hour))
+	pipeline.StoreWebhook(context.Background(), h.Pathstore, expired)
+	if code, _ := send(http.MethodPut, "/api/webhooks/"+expired.ID+"/renew", "", nil); code != http.StatusGone {
+		t.Errorf("expected expired registration to be gone, got %d", code)
+	}
+	if code, _ := send(http.MethodPost, "/api/webhooks/verify?webhook_id=missing", payload, nil); code != http.StatusNotFound {
+		t.Errorf("expected unknown webhook to be not found, got %d", code)
+	}
+
+	code, _ = h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "webhook_id": id}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusAccepted {
+		t.Errorf("expected ingest with a registered webhook to be accepted, got %d", code)
+	}
+	code, _ = h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "webhook_id": expired.ID}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusGone {
+		t.Errorf("expected ingest with an expired webhook to be rejected, got %d", code)
+	}
+}
+
+func TestHarness_SetLogLevel(t *testing.T) {
+	h := NewTestHarness(t)
+
+	post := func(body string) (int, map[string]any) {
+		req, _ := http.NewRequest(http.MethodPost, h.Server.URL+"/api/admin/log-level", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return h.Do(req)
+	}
+
+	code, body := post(`{"level": "debug"}`)
+	if code != http.StatusOK || body["level"] != "DEBUG" || body["previous"] != "INFO" {
+		t.Errorf("expected level changed from INFO to DEBUG, got %d %v", code, body)
+	}
+	if got := h.LogLevel.Level(); got != slog.LevelDebug {
+		t.Errorf("expected level var set to debug, got %v", got)
+	}
+
+	code, body = post(`{"level": "loud"}`)
+	if code != http.StatusBadRequest || body["code"] != api.ErrCodeInvalidRequest {
+		t.Errorf("expected 400 for unknown level, got %d %v", code, body)
+	}
+	if got := h.LogLevel.Level(); got != slog.LevelDebug {
+		t.Errorf("expected level unchanged after bad request, got %v", got)
+	}
+}
+
+func TestHarness_BatchIngestTarArchive(t *testing.T) {
+	h := NewTestHarness(t)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range map[string]string{
+		"docs/notes.md":   sampleMarkdown,
+		"docs/people.csv": "name,role\n" + strings.Repeat("alice,engineer\n", 50),
+		"docs/logo.png":   "png",
+	} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))})
+		tw.Write([]byte(data))
+	}
+	tw.Close()
+	gz.Close()
+
+	code, body := h.PostFiles("/api/ingest/batch", map[string]string{"user_id": "u1"}, "files",
+		File{Name: "docs.tar.gz", Data: buf.Bytes()})
+	jobs, _ := body["jobs"].([]any)
+	if code != http.StatusAccepted || len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs from the archive's supported files, got %d %v", code, body)
+	}
+	for _, j := range jobs {
+		res, _ := j.(map[string]any)
+		if res["archive"] != "docs.tar.gz" {
+			t.Errorf("expected result to name its archive, got %v", res)
+		}
+		jobID, _ := res["job_id"].(string)
+		if st := h.WaitForJob(jobID)["status"]; st != string(pipeline.StatusCompleted) {
+			t.Errorf("expected %v to complete, got %v", res["filename"], st)
+		}
+	}
+
+	var evil bytes.Buffer
+	gz = gzip.NewWriter(&evil)
+	tw = tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "../escape.md", Mode: 0o644, Size: 3})
+	tw.Write([]byte("# x"))
+	tw.Close()
+	gz.Close()
+	_, body = h.PostFiles("/api/ingest/batch", map[string]string{"user_id": "u1"}, "files",
+		File{Name: "evil.tgz", Data: evil.Bytes()})
+	jobs, _ = body["jobs"].([]any)
+	if len(jobs) != 1 {
+		t.Fatalf("expected 1 result for the rejected archive, got %v", body)
+	}
+	if res, _ := jobs[0].(map[string]any); res["code"] != api.ErrCodeInvalidRequest {
+		t.Errorf("expected archive with path traversal rejected, got %v", res)
+	}
+}
+
+func TestHarness_IngestMarkdownExtension(t *testing.T) {
+	h := NewTestHarness(t)
+
+	jobID := h.Ingest("u1", File{Name: "notes.markdown", Data: []byte(sampleMarkdown)})
+	if st := h.WaitForJob(jobID)["status"]; st != string(pipeline.StatusCompleted) {
+		t.Errorf("expected .markdown upload to complete, got %v", st)
+	}
+}
+
+func TestHarness_RetryBudget(t *testing.T) {
+	cfg := TestConfig()
+	cfg.RetryBudgetPerJob = 1
+	h := NewTestHarnessWithConfig(t, cfg)
+	h.Extractor.SetError(&extract.RetryableError{StatusCode: http.StatusTooManyRequests, Message: "rate limited"})
+
+	jobID := h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	status := h.WaitForJob(jobID)
+	if status["status"] != string(pipeline.StatusFailed) {
+		t.Fatalf("expected failed job, got %v", status["status"])
+	}
+
+	// Each chunk gets one attempt; the budget allows a single retry in total.
+	chunks, _ := status["progress"].(map[string]any)["total_chunks"].(float64)
+	if want := int(chunks) + 1; h.Extractor.Calls() != want {
+		t.Errorf("expected %d extraction calls for %v chunks, got %d", want, chunks, h.Extractor.Calls())
+	}
+}
+
+func TestHarness_SourceTypeScalesSalience(t *testing.T) {
+	cfg := TestConfig()
+	cfg.SourceTypeMultiplier = map[string]float64{"document": 1.0, "note": 0.5}
+	h := NewTestHarnessWithConfig(t, cfg)
+
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "source_type": "note"}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %v", code, body)
+	}
+	h.WaitForJob(body["job_id"].(string))
+
+	keys := h.Pathstore.Keys("memory/users/u1/entities/milo/facts")
+	if len(keys) == 0 {
+		t.Fatal("expected stored entity facts")
+	}
+	node, _ := h.Pathstore.GetNode(context.Background(), keys[0])
+	if want := 0.35; math.Abs(node.Salience-want) > 1e-9 {
+		t.Errorf("expected salience %v, got %v", want, node.Salience)
+	}
+	value, _ := node.Value.(map[string]any)
+	source, _ := value["source"].(map[string]any)
+	if source["type"] != "note" {
+		t.Errorf("expected source.type note, got %v", source["type"])
+	}
+
+	code, body = h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "source_type": "tweet"}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusBadRequest || body["code"] != api.ErrCodeInvalidRequest {
+		t.Errorf("expected 400 invalid_request for unknown source_type, got %d %v", code, body)
+	}
+}
+
+var topicLinkFacts = []extract.Fact{
+	{Text: "Go channels synchronize goroutines.", Category: "topic_knowledge", Topics: []string{"Go"}, Salience: 0.8},
+	{Text: "Run go vet before every commit.", Category: "procedure", Topics: []string{"go", "tooling"}, Salience: 0.6},
+	{Text: "Rust has no garbage collector.", Category: "topic_knowledge", Topics: []string{"rust"}, Salience: 0.5},
+}
+
+func TestHarness_TopicLinks(t *testing.T) {
+	cfg := TestConfig()
+	cfg.CreateCrossFactLinks = true
+	h := NewTestHarnessWithConfig(t, cfg)
+	h.Extractor.SetFacts(topicLinkFacts...)
+
+	status := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+	progress, _ := status["progress"].(map[string]any)
+	chunks, _ := progress["total_chunks"].(float64)
+
+	// One go/go pair per chunk; pairs never span chunks.
+	links := h.Pathstore.Links()
+	if len(links) != int(chunks) {
+		t.Fatalf("expected %v links, got %d: %+v", chunks, len(links), links)
+	}
+	for _, l := range links {
+		if l.Summary != "shared topic: go" {
+			t.Errorf("expected summary %q, got %q", "shared topic: go", l.Summary)
+		}
+		if math.Abs(l.Weight-0.3) > 1e-9 {
+			t.Errorf("expected weight 0.3, got %v", l.Weight)
+		}
+	}
+	if got, _ := progress["links_created"].(float64); int(got) != len(links) {
+		t.Errorf("expected links_created %d, got %v", len(links), got)
+	}
+}
+
+func TestHarness_EntityLinks(t *testing.T) {
+	tests := []struct {
+		name          string
+		factsPerChunk int
+		want          int
+	}{
+		{"all pairs", 3, 15}, // 6 facts: 6*5/2 pairs
+		{"capped", 12, 190},  // 24 facts: 276 pairs, capped
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := TestConfig()
+			cfg.CreateCrossFactLinks = true
+			h := NewTestHarnessWithConfig(t, cfg)
+			var facts []extract.Fact
+			for i := range tt.factsPerChunk {
+				facts = append(facts, extract.Fact{Text: fmt.Sprintf("Milo knows trick number %d.", i), Category: "entity_fact", Entity: "milo", Salience: 0.8})
+			}
+			h.Extractor.SetFacts(facts...)
+
+			status := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+			if chunks, _ := status["progress"].(map[string]any)["total_chunks"].(float64); chunks != 2 {
+				t.Fatalf("expected 2 chunks, got %v", chunks)
+			}
+			links := h.Pathstore.Links()
+			if len(links) != tt.want {
+				t.Fatalf("expected %d links, got %d", tt.want, len(links))
+			}
+			seen := make(map[[2]string]bool)
+			for _, l := range links {
+				if l.Summary != "same entity: milo" || !l.Bidirectional {
+					t.Errorf("expected a bidirectional same-entity link, got %+v", l)
+				}
+				pair := [2]string{min(l.From, l.To), max(l.From, l.To)}
+				if seen[pair] || l.From == l.To {
+					t.Errorf("expected distinct pairs, got %v twice", pair)
+				}
+				seen[pair] = true
+			}
+		})
+	}
+}
+
+func TestHarness_TopicLinksDisabled(t *testing.T) {
+	h := NewTestHarness(t)
+	h.Extractor.SetFacts(topicLinkFacts...)
+
+	status := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+	if links := h.Pathstore.Links(); len(links) != 0 {
+		t.Errorf("expected no links with CREATE_CROSS_FACT_LINKS off, got %+v", links)
+	}
+	progress, _ := status["progress"].(map[string]any)
+	if got, _ := progress["links_created"].(float64); got != 0 {
+		t.Errorf("expected links_created 0, got %v", got)
+	}
+}
+
+func TestHarness_IngestByContentType(t *testing.T) {
+	h := NewTestHarness(t)
+
+	jobID := h.Ingest("u1", File{Name: "notes.bin", Data: []byte(sampleMarkdown), ContentType: "text/markdown"})
+	if st := h.WaitForJob(jobID)["status"]; st != string(pipeline.StatusCompleted) {
+		t.Errorf("expected upload with markdown content type to complete, got %v", st)
+	}
+
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1"}, "file",
+		File{Name: "notes.bin", Data: []byte("plain prose with no recognizable signature")})
+	if code != http.StatusBadRequest || body["code"] != api.ErrCodeUnsupportedType {
+		t.Errorf("expected 400 unsupported_type for unrecognized content, got %d %v", code, body)
+	}
+}
+
+func TestHarness_IngestDetectsContent(t *testing.T) {
+	h := NewTestHarness(t)
+
+	jobID := h.Ingest("u1", File{Name: "attachment", Data: []byte(sampleMarkdown)})
+	if st := h.WaitForJob(jobID)["status"]; st != string(pipeline.StatusCompleted) {
+		t.Errorf("expected markdown without extension or content type to complete, got %v", st)
+	}
+	if n := h.Extractor.Calls(); n != 2 {
+		t.Errorf("expected 2 extractor calls for sniffed markdown, got %d", n)
+	}
+}
+
+func TestHarness_InfersTitleFromContent(t *testing.T) {
+	h := NewTestHarness(t)
+
+	status := h.WaitForJob(h.Ingest("u1", File{Name: "document_12345.md", Data: []byte(sampleMarkdown)}))
+	docID, _ := status["doc_id"].(string)
+	meta, _ := h.Pathstore.GetNode(context.Background(), "memory/users/u1/documents/"+docID+"/meta")
+	if meta == nil {
+		t.Fatal("expected document meta")
+	}
+	value, _ := meta.Value.(map[string]any)
+	if value["title"] != "Pets" || value["title_inferred"] != true {
+		t.Errorf("expected inferred title Pets, got %v (inferred %v)", value["title"], value["title_inferred"])
+	}
+}
+
+func TestHarness_IdempotentIngest(t *testing.T) {
+	h := NewTestHarness(t)
+	file := File{Name: "notes.md", Data: []byte(sampleMarkdown)}
+
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "idempotency_key": "retry-1"}, "file", file)
+	if code != http.StatusAccepted || body["job_id"] != "retry-1" {
+		t.Fatalf("expected 202 with job_id retry-1, got %d %v", code, body)
+	}
+	h.WaitForJob("retry-1")
+
+	code, body = h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "job_id": "retry-1"}, "file", file)
+	if code != http.StatusOK || body["status"] != string(pipeline.StatusCompleted) {
+		t.Errorf("expected 200 with the existing completed job, got %d %v", code, body)
+	}
+	if calls := h.Extractor.Calls(); calls != 2 {
+		t.Errorf("expected the document to be processed once (2 chunks), got %d extractor calls", calls)
+	}
+
+	code, body = h.PostFiles("/api/ingest", map[string]string{"user_id": "u2", "job_id": "retry-1"}, "file", file)
+	if code != http.StatusConflict || body["code"] != api.ErrCodeConflict {
+		t.Errorf("expected 409 for another user's job_id, got %d %v", code, body)
+	}
+}
+
+func TestHarness_IdempotentIngestConcurrentRetries(t *testing.T) {
+	h := NewTestHarness(t)
+	file := File{Name: "notes.md", Data: []byte(sampleMarkdown)}
+
+	const n = 8
+	codes := make(chan int, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, _ := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "job_id": "race-1"}, "file", file)
+			codes <- code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	accepted := 0
+	for code := range codes {
+		if code == http.StatusAccepted {
+			accepted++
+		} else if code != http.StatusOK {
+			t.Errorf("expected 202 or 200, got %d", code)
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("expected exactly one retry to queue the job, got %d", accepted)
+	}
+	h.WaitForJob("race-1")
+	if calls := h.Extractor.Calls(); calls != 2 {
+		t.Errorf("expected the document to be processed once (2 chunks), got %d extractor calls", calls)
+	}
+}
+
+func TestHarness_IdempotentIngestRetriesFailedJob(t *testing.T) {
+	h := NewTestHarness(t)
+	file := File{Name: "notes.md", Data: []byte(sampleMarkdown)}
+
+	h.Extractor.SetError(errors.New("extractor down"))
+	code, _ := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "job_id": "retry-2"}, "file", file)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	if st := h.WaitForJob("retry-2")["status"]; st != string(pipeline.StatusFailed) {
+		t.Fatalf("expected failed job, got %v", st)
+	}
+
+	h.Extractor.SetError(nil)
+	code, _ = h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "job_id": "retry-2"}, "file", file)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected a failed job_id to be resubmitted with 202, got %d", code)
+	}
+	if st := h.WaitForJob("retry-2")["status"]; st != string(pipeline.StatusCompleted) {
+		t.Errorf("expected resubmitted job to complete, got %v", st)
+	}
+}
+
+func TestHarness_DropsNearDuplicateFacts(t *testing.T) {
+	cfg := TestConfig()
+	cfg.FactDedupThreshold = 0.8
+	h := NewTestHarnessWithConfig(t, cfg)
+	// Every chunk yields the same two facts, so all but one copy of each
+	// are near duplicates.
+	h.Extractor.SetFacts(
+		extract.Fact{Text: "Milo the cat prefers tuna over chicken.", Category: "entity_fact", Entity: "Milo", Salience: 0.8},
+		extract.Fact{Text: "Rust has no garbage collector.", Category: "topic_knowledge", Topics: []string{"rust"}, Salience: 0.5},
+	)
+
+	status := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+	progress, _ := status["progress"].(map[string]any)
+	if got, _ := progress["facts_stored"].(float64); got != 2 {
+		t.Errorf("expected 2 facts stored, got %v", got)
+	}
+	chunks, _ := progress["total_chunks"].(float64)
+	reasons, _ := progress["rejection_reasons"].(map[string]any)
+	if got, _ := reasons["near duplicate"].(float64); got != 2*(chunks-1) {
+		t.Errorf("expected %v near duplicates, got %v", 2*(chunks-1), got)
+	}
+}
+
+func TestHarness_DocType(t *testing.T) {
+	h := NewTestHarness(t)
+
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "doc_type": "legal"}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %v", code, body)
+	}
+	status := h.WaitForJob(body["job_id"].(string))
+	docID, _ := status["doc_id"].(string)
+	meta, _ := h.Pathstore.GetNode(context.Background(), "memory/users/u1/documents/"+docID+"/meta")
+	if meta == nil {
+		t.Fatal("expected document meta")
+	}
+	if value, _ := meta.Value.(map[string]any); value["doc_type"] != "legal" {
+		t.Errorf("expected doc_type legal in meta, got %v", value["doc_type"])
+	}
+
+	code, body = h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "doc_type": "novel"}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusBadRequest || body["code"] != api.ErrCodeInvalidRequest {
+		t.Errorf("expected 400 for unknown doc_type, got %d %v", code, body)
+	}
+}
+
+func TestHarness_MinTrustFloor(t *testing.T) {
+	h := NewTestHarness(t)
+	h.Extractor.SetFacts(
+		extract.Fact{Text: "Milo the cat prefers tuna over chicken.", Category: "entity_fact", Entity: "Milo", Salience: 0.8},
+		extract.Fact{Text: "Milo the cat was adopted in 2019.", Category: "entity_fact", Entity: "Milo", Salience: 0.8, MinTrust: 9},
+	)
+
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "min_trust_floor": "7"}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %v", code, body)
+	}
+	h.WaitForJob(body["job_id"].(string))
+
+	keys := h.Pathstore.Keys("memory/users/u1/entities/milo/facts")
+	if len(keys) == 0 {
+		t.Fatal("expected stored facts")
+	}
+	for _, k := range keys {
+		node, _ := h.Pathstore.GetNode(context.Background(), k)
+		value, _ := node.Value.(map[string]any)
+		want := 7
+		if strings.Contains(value["text"].(string), "adopted") {
+			want = 9
+		}
+		if value["min_trust"] != want {
+			t.Errorf("expected min_trust %d for %q, got %v", want, value["text"], value["min_trust"])
+		}
+	}
+
+	code, body = h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "min_trust_floor": "11"}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusBadRequest || body["code"] != api.ErrCodeInvalidRequest {
+		t.Errorf("expected 400 for min_trust_floor above 10, got %d %v", code, body)
+	}
+}
+
+func TestHarness_ModelDeprecated(t *testing.T) {
+	h := NewTestHarness(t)
+	h.Extractor.SetError(&extract.ModelDeprecatedError{Model: "claude-old", Message: "model not available"})
+
+	status := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+	if status["status"] != string(pipeline.StatusFailed) || status["phase"] != "model_deprecated" {
+		t.Errorf("expected failed/model_deprecated, got %v/%v", status["status"], status["phase"])
+	}
+	if got := h.DeprecatedModels(); len(got) != 1 || got[0] != "claude-old" {
+		t.Errorf("expected one deprecation hook call for claude-old, got %v", got)
+	}
+}
+
+func TestHarness_ParsedStatsInProgress(t *testing.T) {
+	h := NewTestHarness(t)
+
+	status := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+	progress, _ := status["progress"].(map[string]any)
+	if n, _ := progress["parsed_node_count"].(float64); n != 2 {
+		t.Errorf("expected 2 parsed nodes, got %v", progress["parsed_node_count"])
+	}
+	if n, _ := progress["parsed_text_bytes"].(float64); n < 1000 || n > float64(len(sampleMarkdown)) {
+		t.Errorf("expected parsed_text_bytes close to the document size, got %v", progress["parsed_text_bytes"])
+	}
+}
+
+func TestHarness_ChunkProgress(t *testing.T) {
+	h := NewTestHarness(t)
+
+	status := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+	progress, _ := status["progress"].(map[string]any)
+	chunks, _ := progress["chunks"].([]any)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunk entries, got %v", progress["chunks"])
+	}
+	for _, c := range chunks {
+		entry, _ := c.(map[string]any)
+		if entry["status"] != string(pipeline.ChunkCompleted) {
+			t.Errorf("expected chunk completed, got %v", entry)
+		}
+		if _, ok := entry["breadcrumb"].([]any); !ok {
+			t.Errorf("expected chunk breadcrumb, got %v", entry)
+		}
+	}
+}
+
+func TestHarness_ChunkStatsInMeta(t *testing.T) {
+	h := NewTestHarness(t)
+
+	status := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+	docID, _ := status["doc_id"].(string)
+	meta, _ := h.Pathstore.GetNode(context.Background(), "memory/users/u1/documents/"+docID+"/meta")
+	if meta == nil {
+		t.Fatal("expected document meta")
+	}
+	value, _ := meta.Value.(map[string]any)
+	stats, ok := value["chunk_stats"].(map[string]pipeline.ChunkFactStats)
+	if !ok || len(stats) == 0 {
+		t.Fatalf("expected chunk_stats in meta, got %v", value["chunk_stats"])
+	}
+	extracted, stored := 0, 0
+	for key, s := range stats {
+		if key != strings.Join(s.Breadcrumb, " > ") {
+			t.Errorf("expected key to be the joined breadcrumb, got %q for %v", key, s.Breadcrumb)
+		}
+		extracted += s.FactsExtracted
+		stored += s.FactsStored
+	}
+	if extracted != 2*len(DefaultFacts) {
+		t.Errorf("expected %d facts extracted across chunks, got %d", 2*len(DefaultFacts), extracted)
+	}
+	progress, _ := status["progress"].(map[string]any)
+	if want, _ := progress["facts_stored"].(float64); stored != int(want) {
+		t.Errorf("expected chunk stats to account for %v stored facts, got %d", want, stored)
+	}
+}
+
+func TestHarness_DuplicateFactCheck(t *testing.T) {
+	cfg := TestConfig()
+	cfg.DuplicateFactCheck = true
+	h := NewTestHarnessWithConfig(t, cfg)
+	h.Extractor.SetFacts(
+		extract.Fact{Text: "Milo is a golden retriever.", Category: "entity_fact", Entity: "milo", Salience: 0.8},
+	)
+
+	// A racing worker already stored the fact for this document.
+	docID := pipeline.ContentHashHex([]byte(sampleMarkdown))[:16]
+	dir := "memory/users/u1/entities/milo/facts"
+	h.Pathstore.PutNode(context.Background(), dir+"/"+pipeline.FactKey(docID, "Milo is a golden retriever"), pathstore.NodeRequest{
+		Value: map[string]any{
+			"text":   "Milo is a golden retriever",
+			"source": map[string]any{"type": "document", "doc_id": docID},
+		},
+	})
+
+	status := h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+	if status["status"] != string(pipeline.StatusCompleted) {
+		t.Fatalf("expected completed, got %v", status["status"])
+	}
+	progress, _ := status["progress"].(map[string]any)
+	if progress["facts_stored"] != 0.0 {
+		t.Errorf("expected the duplicate to be skipped, got facts_stored %v", progress["facts_stored"])
+	}
+	if keys := h.Pathstore.Keys(dir + "/"); len(keys) != 1 {
+		t.Errorf("expected only the existing fact under %s, got %v", dir, keys)
+	}
+}
+
+func TestHarness_QueueStats(t *testing.T) {
+	h := NewTestHarness(t)
+
+	jobID := h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	h.WaitForJob(jobID)
+
+	code, body := h.Get("/api/stats/queue")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if body["queue_depth"] != 0.0 {
+		t.Errorf("expected queue_depth 0, got %v", body["queue_depth"])
+	}
+	if n, _ := body["worker_count"].(float64); n < 1 {
+		t.Errorf("expected at least 1 worker, got %v", body["worker_count"])
+	}
+	if body["jobs_active"] != 0.0 {
+		t.Errorf("expected jobs_active 0, got %v", body["jobs_active"])
+	}
+	byStatus, _ := body["jobs_by_status"].(map[string]any)
+	if byStatus["completed"] != 1.0 {
+		t.Errorf("expected 1 completed job, got %v", byStatus)
+	}
+}
+
+func TestHarness_WorkerStats(t *testing.T) {
+	h := NewTestHarness(t)
+
+	for _, name := range []string{"a.md", "b.md"} {
+		h.WaitForJob(h.Ingest("u1", File{Name: name, Data: []byte(sampleMarkdown + name)}))
+	}
+
+	// A job reports completed just before its worker's counters update.
+	var workers []any
+	total := 0.0
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		code, body := h.Get("/api/stats/workers")
+		if code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		workers, _ = body["workers"].([]any)
+		total = 0
+		for _, raw := range workers {
+			ws, _ := raw.(map[string]any)
+			n, _ := ws["jobs_processed"].(float64)
+			total += n
+		}
+		if total == 2 {
+			break
+		}
+	}
+	if len(workers) < 1 {
+		t.Fatalf("expected at least 1 worker, got %v", workers)
+	}
+	if total != 2 {
+		t.Errorf("expected 2 jobs processed across workers, got %v", total)
+	}
+	for i, raw := range workers {
+		ws, _ := raw.(map[string]any)
+		if ws["index"] != float64(i) {
+			t.Errorf("expected index %d, got %v", i, ws["index"])
+		}
+		if ws["current_job_id"] != "" {
+			t.Errorf("expected idle worker, got current job %v", ws["current_job_id"])
+		}
+	}
+}
+
+func TestHarness_ParseOnly(t *testing.T) {
+	h := NewTestHarness(t)
+
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "parse_only": "true"}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %v", code, body)
+	}
+	status := h.WaitForJob(body["job_id"].(string))
+	if status["status"] != string(pipeline.StatusCompleted) {
+		t.Fatalf("expected completed, got %v", status["status"])
+	}
+	if status["parse_only"] != true {
+		t.Errorf("expected parse_only true, got %v", status["parse_only"])
+	}
+	if h.Extractor.Calls() != 0 {
+		t.Errorf("expected no extraction calls, got %d", h.Extractor.Calls())
+	}
+	if keys := h.Pathstore.Keys("memory/users/u1"); len(keys) != 0 {
+		t.Errorf("expected no pathstore writes, got %v", keys)
+	}
+	progress, _ := status["progress"].(map[string]any)
+	if progress["total_chunks"] != 0.0 {
+		t.Errorf("expected no chunks, got %v", progress["total_chunks"])
+	}
+
+	tree, _ := status["parsed_tree"].(map[string]any)
+	sections, _ := tree["Children"].([]any)
+	if len(sections) != 1 {
+		t.Fatalf("expected 1 top-level section in parsed_tree, got %v", tree)
+	}
+	pets, _ := sections[0].(map[string]any)
+	subsections, _ := pets["Children"].([]any)
+	if pets["Title"] != "Pets" || len(subsections) != 1 {
+		t.Fatalf("expected Pets section with 1 subsection, got %v", pets)
+	}
+	if sub, _ := subsections[0].(map[string]any); sub["Title"] != "Programming" {
+		t.Errorf("expected Programming subsection, got %v", sub["Title"])
+	}
+}
+
+func TestHarness_DryRun(t *testing.T) {
+	h := NewTestHarness(t)
+
+	code, body := h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "dry_run": "true"}, "file",
+		File{Name: "notes.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %v", code, body)
+	}
+	status := h.WaitForJob(body["job_id"].(string))
+	if status["status"] != string(pipeline.StatusCompleted) {
+		t.Fatalf("expected completed, got %v", status["status"])
+	}
+	if h.Extractor.Calls() != 0 {
+		t.Errorf("expected no extraction calls, got %d", h.Extractor.Calls())
+	}
+	docID, _ := status["doc_id"].(string)
+	wantPath := "memory/users/u1/documents/" + docID + "/dry_run_preview"
+	if status["preview_path"] != wantPath {
+		t.Errorf("expected preview_path %s, got %v", wantPath, status["preview_path"])
+	}
+	if len(h.Pathstore.Keys("memory/users/u1/documents/"+docID+"/meta")) != 0 {
+		t.Error("expected no document meta for a dry run")
+	}
+
+	code, body = h.Get("/api/documents/" + docID + "/preview?user_id=u1")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %v", code, body)
+	}
+	preview, _ := body["preview"].(map[string]any)
+	if preview["chunk_count"] != 2.0 {
+		t.Errorf("expected 2 chunks, got %v", preview["chunk_count"])
+	}
+	chunks, _ := preview["chunks"].([]any)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunk previews, got %d", len(chunks))
+	}
+	first, _ := chunks[0].(map[string]any)
+	if text, _ := first["preview"].(string); text == "" || len([]rune(text)) > 200 {
+		t.Errorf("expected a preview of at most 200 characters, got %q", text)
+	}
+
+	// A real ingest of the same document is not skipped as a duplicate.
+	status = h.WaitForJob(h.Ingest("u1", File{Name: "notes.md", Data: []byte(sampleMarkdown)}))
+	if status["status"] != string(pipeline.StatusCompleted) {
+		t.Errorf("expected ingest after dry run to complete, got %v", status["status"])
+	}
+
+	code, _ = h.Get("/api/documents/missing/preview?user_id=u1")
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for a document without a preview, got %d", code)
+	}
+}
+
+func TestHarness_IncrementalIngest(t *testing.T) {
+	h := NewTestHarness(t)
+	ctx := context.Background()
+	fields := map[string]string{"user_id": "u1", "doc_id": "wiki", "incremental": "true"}
+	docPrefix := "memory/users/u1/documents/wiki"
+
+	ingest := func(content string) map[string]any {
+		t.Helper()
+		code, body := h.PostFiles("/api/ingest", fields, "file", File{Name: "wiki.md", Data: []byte(content)})
+		if code != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d %v", code, body)
+		}
+		status := h.WaitForJob(body["job_id"].(string))
+		if status["status"] != string(pipeline.StatusCompleted) {
+			t.Fatalf("expected completed, got %v (%v)", status["status"], status["progress"])
+		}
+		return status
+	}
+	// manifest maps each manifest key to its chunk hash.
+	manifest := func() map[string]string {
+		out := make(map[string]string)
+		for _, k := range h.Pathstore.Keys(docPrefix + "/facts") {
+			node, _ := h.Pathstore.GetNode(ctx, k)
+			value, _ := node.Value.(map[string]any)
+			out[k], _ = value["chunk_hash"].(string)
+		}
+		return out
+	}
+
+	// Nothing is stored yet, so the first version is ingested in full.
+	ingest(sampleMarkdown)
+	if h.Extractor.Calls() != 2 {
+		t.Fatalf("expected 2 extraction calls, got %d", h.Extractor.Calls())
+	}
+	before := manifest()
+	if len(before) == 0 {
+		t.Fatal("expected manifest entries")
+	}
+	for k, hash := range before {
+		if hash == "" {
+			t.Errorf("expected a chunk hash on manifest entry %s", k)
+		}
+	}
+
+	updated := strings.ReplaceAll(sampleMarkdown, "Go channels", "Buffered channels")
+	ingest(updated)
+	if h.Extractor.Calls() != 3 {
+		t.Errorf("expected only the changed chunk to be extracted, got %d calls in total", h.Extractor.Calls())
+	}
+
+	after := manifest()
+	if len(after) != len(before) {
+		t.Errorf("expected %d manifest entries, got %d", len(before), len(after))
+	}
+	kept, replaced := 0, 0
+	for k := range before {
+		if _, ok := after[k]; ok {
+			kept++
+		} else {
+			replaced++
+			if node, _ := h.Pathstore.GetNode(ctx, k); node != nil {
+				t.Errorf("expected stale manifest entry %s to be removed", k)
+			}
+		}
+	}
+	if kept == 0 || replaced == 0 {
+		t.Errorf("expected some facts kept and some replaced, got %d kept and %d replaced", kept, replaced)
+	}
+
+	meta, _ := h.Pathstore.GetNode(ctx, docPrefix+"/meta")
+	value, _ := meta.Value.(map[string]any)
+	if value["facts_stored"] != len(after) {
+		t.Errorf("expected facts_stored %d, got %v", len(after), value["facts_stored"])
+	}
+	// Only the current version is indexed, by parsed-text and upload hash.
+	hashes := h.Pathstore.Keys("memory/users/u1/documents/by_hash")
+	wantHashes := []string{
+		fmt.Sprintf("memory/users/u1/documents/by_hash/%s/wiki", value["content_hash"]),
+		fmt.Sprintf("memory/users/u1/documents/by_hash/%s/wiki", value["upload_hash"]),
+	}
+	if len(hashes) != 2 || !slices.Contains(hashes, wantHashes[0]) || !slices.Contains(hashes, wantHashes[1]) {
+		t.Errorf("expected dedup index entries %v, got %v", wantHashes, hashes)
+	}
+}
+
+func TestHarness_IncrementalDuplicateFactCheck(t *testing.T) {
+	cfg := TestConfig()
+	cfg.DuplicateFactCheck = true
+	h := NewTestHarnessWithConfig(t, cfg)
+	ctx := context.Background()
+	h.Extractor.SetFacts(
+		extract.Fact{Text: "Milo is a golden retriever", Category: "entity_fact", Entity: "milo", Salience: 0.8},
+	)
+	fields := map[string]string{"user_id": "u1", "doc_id": "wiki", "incremental": "true"}
+	factPath := "memory/users/u1/entities/milo/facts/" + pipeline.FactKey("wiki", "Milo is a golden retriever")
+	manifestPath := "memory/users/u1/documents/wiki/facts/" + pipeline.FactKey("wiki", "Milo is a golden retriever")
+
+	ingest := func(content string) map[string]any {
+		t.Helper()
+		code, body := h.PostFiles("/api/ingest", fields, "file", File{Name: "wiki.md", Data: []byte(content)})
+		if code != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d %v", code, body)
+		}
+		status := h.WaitForJob(body["job_id"].(string))
+		if status["status"] != string(pipeline.StatusCompleted) {
+			t.Fatalf("expected completed, got %v (%v)", status["status"], status["progress"])
+		}
+		return status
+	}
+
+	// Both chunks yield the same fact, which is stored once.
+	status := ingest(sampleMarkdown)
+	progress, _ := status["progress"].(map[string]any)
+	if progress["facts_stored"] != 1.0 {
+		t.Errorf("expected 1 fact stored, got %v", progress["facts_stored"])
+	}
+	if keys := h.Pathstore.Keys("memory/users/u1/entities/milo/facts/"); len(keys) != 1 || keys[0] != factPath {
+		t.Fatalf("expected only %s, got %v", factPath, keys)
+	}
+
+	// Every chunk changes, so the fact's manifest entry is stale, yet the
+	// new version extracts the same fact again: it must survive.
+	updated := strings.ReplaceAll(sampleMarkdown, "Milo", "Rex")
+	updated = strings.ReplaceAll(updated, "Go channels", "Buffered channels")
+	ingest(updated)
+	if h.Extractor.Calls() != 4 {
+		t.Errorf("expected both chunks to be extracted again, got %d calls in total", h.Extractor.Calls())
+	}
+	if node, _ := h.Pathstore.GetNode(ctx, factPath); node == nil {
+		t.Errorf("expected %s to be kept", factPath)
+	}
+	node, _ := h.Pathstore.GetNode(ctx, manifestPath)
+	if node == nil {
+		t.Fatalf("expected manifest entry %s to be kept", manifestPath)
+	}
+	value, _ := node.Value.(map[string]any)
+	if value["path"] != factPath {
+		t.Errorf("expected manifest entry to point at %s, got %v", factPath, value["path"])
+	}
+}
+
+func TestHarness_ExpiredJobStatus(t *testing.T) {
+	cfg := TestConfig()
+	cfg.MaxJobStoreSize = 1
+	h := NewTestHarnessWithConfig(t, cfg)
+
+	first := h.Ingest("u1", File{Name: "a.md", Data: []byte(sampleMarkdown)})
+	h.WaitForJob(first)
+	h.WaitForJob(h.Ingest("u1", File{Name: "b.md", Data: []byte(strings.ReplaceAll(sampleMarkdown, "Milo", "Rex"))}))
+
+	code, body := h.Get("/api/ingest/" + first + "/status")
+	if code != http.StatusGone || body["code"] != api.ErrCodeJobExpired {
+		t.Errorf("expected 410 %s for an evicted job, got %d %v", api.ErrCodeJobExpired, code, body)
+	}
+	code, body = h.Get("/api/ingest/never-existed/status")
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown job, got %d %v", code, body)
+	}
+}
+
+func TestHarness_UserConfig(t *testing.T) {
+	h := NewTestHarness(t)
+
+	put := func(body string) (int, map[string]any) {
+		req, _ := http.NewRequest(http.MethodPut, h.Server.URL+"/api/users/u1/config", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return h.Do(req)
+	}
+
+	code, body := put(`{"chunk_size": 80, "chunk_overlap": 100}`)
+	if code != http.StatusBadRequest || body["code"] != api.ErrCodeInvalidRequest {
+		t.Errorf("expected 400 for overlap above chunk_size, got %d %v", code, body)
+	}
+	if code, body := put(`{"chunk_overlap": 400, "llm_model": "model-a"}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %v", code, body)
+	}
+	code, body = h.Get("/api/users/u1/config")
+	if code != http.StatusOK || body["chunk_overlap"] != 400.0 || body["llm_model"] != "model-a" {
+		t.Errorf("expected stored overlap 400 and model-a, got %d %v", code, body)
+	}
+
+	// A per-request chunk_size below the stored overlap is accepted and
+	// the stored config is merged under it.
+	code, body = h.PostFiles("/api/ingest", map[string]string{"user_id": "u1", "chunk_size": "120"}, "file",
+		File{Name: "pets.md", Data: []byte(sampleMarkdown)})
+	if code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %v", code, body)
+	}
+	status := h.WaitForJob(body["job_id"].(string))
+	if status["status"] != string(pipeline.StatusCompleted) {
+		t.Errorf("expected completed, got %v", status)
+	}
+	if calls := h.Extractor.Calls(); calls <= 2 {
+		t.Errorf("expected more than the default 2 chunks at chunk_size 120, got %d extractions", calls)
+	}
+}
+
+func TestHarness_LLMStats(t *testing.T) {
+	h := NewTestHarness(t)
+
+	code, body := h.Get("/api/stats/llm")
+	if code != http.StatusOK || body["model"] != "test-model" {
+		t.Errorf("expected 200 with model test-model, got %d %v", code, body)
+	}
+	if _, ok := body["stats"].(map[string]any); !ok {
+		t.Errorf("expected a stats object, got %v", body["stats"])
+	}
+
+	h.Claude.Stats = nil
+	code, body = h.Get("/api/stats/llm")
+	if code != http.StatusServiceUnavailable || body["code"] != api.ErrCodeUnavailable {
+		t.Errorf("expected 503 unavailable without stats, got %d %v", code, body)
+	}
+}
@@ -0,0 +1,215 @@
+package testutil
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dgallion1/docgest/internal/extract"
+	"github.com/dgallion1/docgest/internal/pathstore"
+)
+
+// MockPathstoreClient is an in-memory pathstore.Store. Keys are stored in
+// slash form and reported in the dotted form real pathstore returns.
+type MockPathstoreClient struct {
+	mu    sync.Mutex
+	nodes map[string]pathstore.NodeRequest
+	links []pathstore.LinkRequest
+
+	// putErrs fails writes to keys under each prefix.
+	putErrs map[string]error
+}
+
+var _ pathstore.Store = (*MockPathstoreClient)(nil)
+
+// NewMockPathstoreClient returns an empty store.
+func NewMockPathstoreClient() *MockPathstoreClient {
+	return &MockPathstoreClient{nodes: make(map[string]pathstore.NodeRequest)}
+}
+
+func normalizeKey(key string) string {
+	return strings.Trim(strings.ReplaceAll(key, ".", "/"), "/")
+}
+
+func dottedKey(key string) string {
+	return strings.ReplaceAll(key, "/", ".")
+}
+
+func (m *MockPathstoreClient) PutNode(ctx context.Context, key string, req pathstore.NodeRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := normalizeKey(key)
+	for prefix, err := range m.putErrs {
+		if k == prefix || strings.HasPrefix(k, prefix+"/") {
+			return err
+		}
+	}
+	m.nodes[k] = req
+	return nil
+}
+
+// FailPuts makes every later write to a key under prefix (slash form) fail
+// with err; a nil err lets writes there succeed again.
+func (m *MockPathstoreClient) FailPuts(prefix string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.putErrs == nil {
+		m.putErrs = make(map[string]error)
+	}
+	if err == nil {
+		delete(m.putErrs, normalizeKey(prefix))
+		return
+	}
+	m.putErrs[normalizeKey(prefix)] = err
+}
+
+func (m *MockPathstoreClient) GetNode(ctx context.Context, key string) (*pathstore.NodeResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := normalizeKey(key)
+	req, ok := m.nodes[k]
+	if !ok {
+		return nil, nil
+	}
+	return &pathstore.NodeResponse{
+		Key:        dottedKey(k),
+		Value:      req.Value,
+		MemoryType: req.MemoryType,
+		Salience:   req.Salience,
+	}, nil
+}
+
+func (m *MockPathstoreClient) DeleteNode(ctx context.Context, key string, recursive bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := normalizeKey(key)
+	delete(m.nodes, k)
+	if recursive {
+		for child := range m.nodes {
+			if strings.HasPrefix(child, k+"/") {
+				delete(m.nodes, child)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MockPathstoreClient) ListChildren(ctx context.Context, key string, limit int) ([]pathstore.ListChildrenResponse, error) {
+	nodes, _, err := m.ListChildrenWithCursor(ctx, key, limit, "")
+	return nodes, err
+}
+
+// ListChildrenWithCursor returns descendants of key in lexical order. The
+// cursor is the decimal offset of the next page.
+func (m *MockPathstoreClient) ListChildrenWithCursor(ctx context.Context, key string, limit int, cursor string) ([]pathstore.ListChildrenResponse, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefix := normalizeKey(key) + "/"
+	var keys []string
+	for k := range m.nodes {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	offset, _ := strconv.Atoi(cursor)
+	if offset > len(keys) {
+		offset = len(keys)
+	}
+	keys = keys[offset:]
+	next := ""
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+		next = strconv.Itoa(offset + limit)
+	}
+
+	out := make([]pathstore.ListChildrenResponse, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, pathstore.ListChildrenResponse{Key: dottedKey(k), Value: m.nodes[k].Value})
+	}
+	return out, next, nil
+}
+
+func (m *MockPathstoreClient) ListAll(ctx context.Context, key string) ([]pathstore.ListChildrenResponse, error) {
+	return m.ListChildren(ctx, key, 0)
+}
+
+func (m *MockPathstoreClient) PutLink(ctx context.Context, req pathstore.LinkRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.links = append(m.links, req)
+	return nil
+}
+
+// Keys returns every stored key under prefix (slash form), sorted.
+func (m *MockPathstoreClient) Keys(prefix string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := normalizeKey(prefix)
+	var keys []string
+	for k := range m.nodes {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Links returns a copy of every link written.
+func (m *MockPathstoreClient) Links() []pathstore.LinkRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]pathstore.LinkRequest(nil), m.links...)
+}
+
+// MockExtractor returns a fixed set of facts for every prompt.
+type MockExtractor struct {
+	mu    sync.Mutex
+	facts []extract.Fact
+	err   error
+	calls int
+}
+
+var _ extract.Extractor = (*MockExtractor)(nil)
+
+// NewMockExtractor returns an extractor that yields facts for each chunk.
+func NewMockExtractor(facts ...extract.Fact) *MockExtractor {
+	return &MockExtractor{facts: facts}
+}
+
+func (m *MockExtractor) ExtractFactsWithModel(ctx context.Context, prompt, model string) (*extract.ExtractionResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	facts := make([]extract.Fact, len(m.facts))
+	copy(facts, m.facts)
+	return &extract.ExtractionResult{Facts: facts}, nil
+}
+
+// SetFacts replaces the facts returned for each later chunk.
+func (m *MockExtractor) SetFacts(facts ...extract.Fact) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.facts = facts
+}
+
+// SetError makes every later call fail with err; nil restores success.
+func (m *MockExtractor) SetError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Calls reports how many extraction requests were made.
+func (m *MockExtractor) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}